@@ -59,6 +59,9 @@ func main() {
 	log.Println("Shutting down...")
 
 	// Остановка бота
-	cupBot.Stop()
+	if err := cupBot.Stop(); err != nil {
+		log.Printf("Bot stopped with error: %v", err)
+		return
+	}
 	log.Println("Bot stopped successfully")
 }
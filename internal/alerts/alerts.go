@@ -0,0 +1,290 @@
+// Package alerts evaluates the threshold rules of alerts.rules against
+// samples of the host metrics and reports when an alert fires or resolves.
+package alerts
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/system"
+)
+
+// States of a rule
+const (
+	StateOK      = "ok"
+	StatePending = "pending" // past the threshold, not for long enough yet
+	StateFiring  = "firing"
+)
+
+// Transition is an alert that fired or resolved
+type Transition struct {
+	Rule   config.AlertRule
+	Firing bool // false when the alert resolved
+	Value  float64
+	Since  time.Time // when the metric crossed the threshold
+	At     time.Time
+}
+
+// Sampler returns the current metrics of the host
+type Sampler func() (*system.SystemInfo, error)
+
+// Handler is called for every transition
+type Handler func(t Transition)
+
+// Service samples the host every events.polling_interval seconds and
+// evaluates the alert rules
+type Service struct {
+	config  *config.Config
+	db      *database.DB
+	sample  Sampler
+	handler Handler
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+
+	states map[string]*database.AlertState
+}
+
+// NewService creates the alerts service; handler is called from the
+// sampling goroutine
+func NewService(cfg *config.Config, db *database.DB, sample Sampler, handler Handler) *Service {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Service{
+		config:  cfg,
+		db:      db,
+		sample:  sample,
+		handler: handler,
+		ctx:     ctx,
+		cancel:  cancel,
+		states:  make(map[string]*database.AlertState),
+	}
+}
+
+// Start loads the saved states and starts sampling
+func (s *Service) Start() error {
+	if !s.config.Alerts.Enabled || len(s.config.Alerts.Rules) == 0 {
+		log.Println("Alerts are disabled")
+		return nil
+	}
+	if err := s.loadStates(); err != nil {
+		return err
+	}
+
+	log.Printf("Starting alerts with %d rules...", len(s.config.Alerts.Rules))
+	s.wg.Add(1)
+	go s.run()
+	return nil
+}
+
+// Stop stops sampling and waits for the current evaluation
+func (s *Service) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loadStates restores the states of the configured rules and forgets the
+// states of removed ones
+func (s *Service) loadStates() error {
+	saved, err := s.db.GetAlertStates()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, state := range saved {
+		if s.rule(state.Rule) == nil {
+			if err := s.db.DeleteAlertState(state.Rule); err != nil {
+				log.Printf("Warning: Failed to delete the state of alert %s: %v", state.Rule, err)
+			}
+			continue
+		}
+		s.states[state.Rule] = state
+	}
+	return nil
+}
+
+func (s *Service) rule(name string) *config.AlertRule {
+	for i := range s.config.Alerts.Rules {
+		if s.config.Alerts.Rules[i].Name == name {
+			return &s.config.Alerts.Rules[i]
+		}
+	}
+	return nil
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(s.config.Events.PollingInterval) * time.Second)
+	defer ticker.Stop()
+
+	s.check()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+// check samples the host once and evaluates the rules
+func (s *Service) check() {
+	info, err := s.sample()
+	if err != nil {
+		log.Printf("Warning: Failed to sample metrics for alerts: %v", err)
+		return
+	}
+	for _, t := range s.evaluate(time.Now(), info) {
+		if s.handler != nil {
+			s.handler(t)
+		}
+	}
+}
+
+// evaluate moves every rule along ok → pending → firing → ok and returns
+// the alerts that fired or resolved
+func (s *Service) evaluate(now time.Time, info *system.SystemInfo) []Transition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transitions []Transition
+	for _, rule := range s.config.Alerts.Rules {
+		value, ok := metricValue(&rule, info)
+		if !ok {
+			continue // the metric is missing from this sample
+		}
+
+		state := s.states[rule.Name]
+		if state == nil {
+			state = &database.AlertState{Rule: rule.Name, State: StateOK, Since: now}
+			s.states[rule.Name] = state
+		}
+		before := state.State
+		state.Value = value
+
+		switch state.State {
+		case StateFiring:
+			if !past(rule.Operator, value, rule.ClearLevel()) {
+				transitions = append(transitions, Transition{Rule: rule, Value: value, Since: state.Since, At: now})
+				state.State, state.Since = StateOK, now
+			}
+		case StatePending:
+			if !past(rule.Operator, value, rule.Threshold) {
+				state.State, state.Since = StateOK, now
+			}
+		default:
+			if past(rule.Operator, value, rule.Threshold) {
+				state.State, state.Since = StatePending, now
+			}
+		}
+		if state.State == StatePending && now.Sub(state.Since) >= time.Duration(rule.Duration)*time.Second {
+			state.State = StateFiring
+			transitions = append(transitions, Transition{Rule: rule, Firing: true, Value: value, Since: state.Since, At: now})
+		}
+
+		if state.State != before {
+			if err := s.db.SaveAlertState(state); err != nil {
+				log.Printf("Warning: Failed to save the state of alert %s: %v", rule.Name, err)
+			}
+		}
+	}
+	return transitions
+}
+
+// States returns the current states by rule name
+func (s *Service) States() map[string]database.AlertState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make(map[string]database.AlertState, len(s.states))
+	for name, state := range s.states {
+		states[name] = *state
+	}
+	return states
+}
+
+// past reports whether value is past level in the direction of operator
+func past(operator string, value, level float64) bool {
+	if operator == "<" {
+		return value < level
+	}
+	return value > level
+}
+
+// metricValue extracts the metric of a rule from a sample
+func metricValue(rule *config.AlertRule, info *system.SystemInfo) (float64, bool) {
+	switch rule.Metric {
+	case config.AlertMetricCPU:
+		if len(info.CPUInfo.Usage) == 0 {
+			return 0, false
+		}
+		var total float64
+		for _, usage := range info.CPUInfo.Usage {
+			total += usage
+		}
+		return total / float64(len(info.CPUInfo.Usage)), true
+	case config.AlertMetricMemory:
+		return info.MemoryInfo.UsedPercent, true
+	case config.AlertMetricDiskUsed, config.AlertMetricDiskFree:
+		for _, disk := range info.DiskInfo {
+			if sameDisk(disk.Mountpoint, rule.Target) || sameDisk(disk.Device, rule.Target) {
+				if rule.Metric == config.AlertMetricDiskFree {
+					return 100 - disk.UsedPercent, true
+				}
+				return disk.UsedPercent, true
+			}
+		}
+	case config.AlertMetricNetRecv, config.AlertMetricNetSent:
+		return networkRate(rule, info)
+	}
+	return 0, false
+}
+
+// sameDisk compares disks so that "C:" matches "C:\" and "/data/" matches
+// "/data"
+func sameDisk(name, target string) bool {
+	if name == "" {
+		return false
+	}
+	trim := func(s string) string {
+		if trimmed := strings.TrimRight(s, `\/`); trimmed != "" {
+			return trimmed
+		}
+		return s
+	}
+	return strings.EqualFold(trim(name), trim(target))
+}
+
+// networkRate returns bytes per second of the target interface, or of all
+// of them when the rule has no target, as measured by the snapshot. The
+// first snapshot has no rates.
+func networkRate(rule *config.AlertRule, info *system.SystemInfo) (float64, bool) {
+	if info.RateInterval <= 0 {
+		return 0, false
+	}
+
+	var rate float64
+	found := false
+	for _, iface := range info.NetworkInfo {
+		if rule.Target != "" && iface.Name != rule.Target {
+			continue
+		}
+		if rule.Metric == config.AlertMetricNetSent {
+			rate += iface.SentRate
+		} else {
+			rate += iface.RecvRate
+		}
+		found = true
+	}
+	return rate, found
+}
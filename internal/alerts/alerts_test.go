@@ -0,0 +1,150 @@
+package alerts
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/system"
+)
+
+func setupTestService(t *testing.T, db *database.DB, rules ...config.AlertRule) *Service {
+	t.Helper()
+	if db == nil {
+		db = setupTestDB(t)
+	}
+	cfg := &config.Config{Alerts: config.AlertsConfig{Enabled: true, Rules: rules}}
+	cfg.Events.PollingInterval = 30
+	s := NewService(cfg, db, nil, nil)
+	if err := s.loadStates(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func setupTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func diskSample(freePercent float64) *system.SystemInfo {
+	return &system.SystemInfo{
+		CPUInfo:    system.CPUInfo{Usage: []float64{10, 30}},
+		MemoryInfo: system.MemoryInfo{UsedPercent: 50},
+		DiskInfo:   []system.DiskInfo{{Device: "C:", Mountpoint: `C:\`, UsedPercent: 100 - freePercent}},
+	}
+}
+
+func float(v float64) *float64 { return &v }
+
+func TestEvaluateDurationAndHysteresis(t *testing.T) {
+	rule := config.AlertRule{Name: "disk-c", Metric: config.AlertMetricDiskFree, Target: "C:", Operator: "<", Threshold: 10, Clear: float(15), Duration: 300}
+	s := setupTestService(t, nil, rule)
+	start := time.Now()
+
+	steps := []struct {
+		after      time.Duration
+		free       float64
+		state      string
+		transition string // fired, resolved or none
+	}{
+		{0, 20, StateOK, ""},
+		{time.Minute, 8, StatePending, ""},
+		{3 * time.Minute, 12, StateOK, ""}, // back above the threshold before 5 minutes
+		{4 * time.Minute, 9, StatePending, ""},
+		{8 * time.Minute, 7, StatePending, ""},
+		{9 * time.Minute, 8, StateFiring, "fired"},
+		{10 * time.Minute, 12, StateFiring, ""}, // above the threshold, not above clear
+		{11 * time.Minute, 16, StateOK, "resolved"},
+	}
+
+	for i, step := range steps {
+		transitions := s.evaluate(start.Add(step.after), diskSample(step.free))
+		if state := s.States()["disk-c"].State; state != step.state {
+			t.Errorf("Step %d: expected %s, got %s", i, step.state, state)
+		}
+		if step.transition == "" {
+			if len(transitions) != 0 {
+				t.Errorf("Step %d: expected no transition, got %+v", i, transitions)
+			}
+			continue
+		}
+		if len(transitions) != 1 || transitions[0].Firing != (step.transition == "fired") || transitions[0].Value != step.free {
+			t.Errorf("Step %d: expected the alert %s at %v, got %+v", i, step.transition, step.free, transitions)
+		}
+	}
+}
+
+func TestEvaluateWithoutDuration(t *testing.T) {
+	s := setupTestService(t, nil,
+		config.AlertRule{Name: "memory", Metric: config.AlertMetricMemory, Operator: ">", Threshold: 90},
+		config.AlertRule{Name: "cpu", Metric: config.AlertMetricCPU, Operator: ">", Threshold: 15},
+		config.AlertRule{Name: "disk-d", Metric: config.AlertMetricDiskUsed, Target: "D:", Operator: ">", Threshold: 1},
+	)
+
+	info := diskSample(50)
+	info.MemoryInfo.UsedPercent = 95
+	transitions := s.evaluate(time.Now(), info)
+	if len(transitions) != 2 || transitions[0].Rule.Name != "memory" || transitions[1].Rule.Name != "cpu" || transitions[1].Value != 20 {
+		t.Errorf("Expected memory and cpu to fire at once, got %+v", transitions)
+	}
+	if _, ok := s.States()["disk-d"]; ok {
+		t.Error("A rule on a missing disk should not get a state")
+	}
+}
+
+func TestEvaluateNetworkRate(t *testing.T) {
+	s := setupTestService(t, nil,
+		config.AlertRule{Name: "eth0-in", Metric: config.AlertMetricNetRecv, Target: "eth0", Operator: ">", Threshold: 1000},
+		config.AlertRule{Name: "all-out", Metric: config.AlertMetricNetSent, Operator: ">", Threshold: 1000},
+	)
+	start := time.Now()
+	sample := func(interval time.Duration, recv, sent float64) *system.SystemInfo {
+		return &system.SystemInfo{RateInterval: interval, NetworkInfo: []system.NetworkInfo{
+			{Name: "eth0", RecvRate: recv, SentRate: sent},
+			{Name: "eth1", SentRate: sent},
+		}}
+	}
+
+	if transitions := s.evaluate(start, sample(0, 0, 0)); len(transitions) != 0 {
+		t.Errorf("The first sample has no rate, got %+v", transitions)
+	}
+	transitions := s.evaluate(start.Add(10*time.Second), sample(10*time.Second, 2000, 600))
+	if len(transitions) != 2 || transitions[0].Value != 2000 || transitions[1].Value != 1200 {
+		t.Errorf("Expected 2000 B/s received on eth0 and 1200 B/s sent in total, got %+v", transitions)
+	}
+}
+
+func TestStatesSurviveRestart(t *testing.T) {
+	db := setupTestDB(t)
+	rule := config.AlertRule{Name: "memory", Metric: config.AlertMetricMemory, Operator: ">", Threshold: 90}
+	info := diskSample(50)
+	info.MemoryInfo.UsedPercent = 95
+
+	s := setupTestService(t, db, rule)
+	if transitions := s.evaluate(time.Now(), info); len(transitions) != 1 {
+		t.Fatalf("Expected the alert to fire, got %+v", transitions)
+	}
+
+	restarted := setupTestService(t, db, rule)
+	if transitions := restarted.evaluate(time.Now(), info); len(transitions) != 0 {
+		t.Errorf("A firing alert should not fire again after a restart, got %+v", transitions)
+	}
+	info.MemoryInfo.UsedPercent = 40
+	if transitions := restarted.evaluate(time.Now(), info); len(transitions) != 1 || transitions[0].Firing {
+		t.Errorf("Expected the alert to resolve, got %+v", transitions)
+	}
+
+	// States of rules removed from the configuration are forgotten
+	setupTestService(t, db)
+	if states, _ := db.GetAlertStates(); len(states) != 0 {
+		t.Errorf("Expected no states left, got %+v", states)
+	}
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -16,12 +18,27 @@ type Middleware struct {
 	db     *database.DB
 }
 
-// NewMiddleware создает новый экземпляр middleware
+// NewMiddleware создает новый экземпляр middleware.
+// Администраторы из конфигурации заносятся в базу данных при первом запуске;
+// дальше роли и блокировки хранятся только в базе данных.
 func NewMiddleware(cfg *config.Config, db *database.DB) *Middleware {
-	return &Middleware{
+	m := &Middleware{
 		config: cfg,
 		db:     db,
 	}
+	m.seedAdmins()
+	return m
+}
+
+// seedAdmins creates database rows for the configured admins that are not
+// known yet. Existing rows are never modified, so a demoted or banned admin
+// stays that way even if still listed in the config.
+func (m *Middleware) seedAdmins() {
+	for _, id := range m.config.Users.AdminUserIDs {
+		if err := m.db.EnsureUser(id, true); err != nil {
+			log.Printf("Failed to seed admin user %d: %v", id, err)
+		}
+	}
 }
 
 // AuthorizeUser проверяет права пользователя на выполнение команд
@@ -44,35 +61,46 @@ func (m *Middleware) AuthorizeUser(update tgbotapi.Update) (bool, *database.User
 		return false, nil
 	}
 
-	// Проверяем, разрешен ли пользователь в конфигурации
-	if !m.config.IsAllowed(user.ID) {
-		log.Printf("Unauthorized access attempt from user %d (%s)", user.ID, user.UserName)
-		return false, nil
-	}
-
-	// Создаем или обновляем пользователя в базе данных
-	dbUser := &database.User{
-		ID:        user.ID,
-		Username:  user.UserName,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		IsAdmin:   m.config.IsAdmin(user.ID),
-		IsActive:  true,
-		UpdatedAt: time.Now(),
-	}
+	dbUser, err := m.db.GetUser(user.ID)
+	switch {
+	case err == nil:
+		// Пользователь уже известен: база данных определяет доступ и роль
+		if !dbUser.IsActive {
+			log.Printf("Blocked user %d (%s) attempted access", user.ID, user.UserName)
+			return false, nil
+		}
 
-	// Проверяем, существует ли пользователь в БД
-	existingUser, err := m.db.GetUser(user.ID)
-	if err != nil {
-		// Пользователь не найден, создаем нового
-		dbUser.CreatedAt = time.Now()
-	} else {
-		// Пользователь существует, сохраняем дату создания
-		dbUser.CreatedAt = existingUser.CreatedAt
-	}
+		dbUser.Username = user.UserName
+		dbUser.FirstName = user.FirstName
+		dbUser.LastName = user.LastName
+		dbUser.LanguageCode = user.LanguageCode
+		if err := m.db.UpdateUserProfile(dbUser); err != nil {
+			log.Printf("Failed to update user %d: %v", user.ID, err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		// Новый пользователь: списки из конфигурации задают только начальный доступ
+		if !m.config.IsAllowed(user.ID) {
+			log.Printf("Unauthorized access attempt from user %d (%s)", user.ID, user.UserName)
+			return false, nil
+		}
 
-	if err := m.db.CreateOrUpdateUser(dbUser); err != nil {
-		log.Printf("Failed to create/update user %d: %v", user.ID, err)
+		dbUser = &database.User{
+			ID:           user.ID,
+			Username:     user.UserName,
+			FirstName:    user.FirstName,
+			LastName:     user.LastName,
+			IsAdmin:      m.config.IsAdmin(user.ID),
+			IsActive:     true,
+			LanguageCode: user.LanguageCode,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+		if err := m.db.CreateOrUpdateUser(dbUser); err != nil {
+			log.Printf("Failed to create user %d: %v", user.ID, err)
+		}
+	default:
+		log.Printf("Failed to load user %d: %v", user.ID, err)
+		return false, nil
 	}
 
 	// Обновляем сессию пользователя
@@ -90,9 +118,23 @@ func (m *Middleware) AuthorizeUser(update tgbotapi.Update) (bool, *database.User
 	return true, dbUser
 }
 
-// RequireAdmin проверяет, является ли пользователь администратором
+// RequireAdmin проверяет, является ли пользователь активным администратором
 func (m *Middleware) RequireAdmin(userID int64) bool {
-	return m.config.IsAdmin(userID)
+	user, err := m.db.GetUser(userID)
+	if err != nil {
+		return false
+	}
+	return user.IsAdmin && user.IsActive
+}
+
+// IsAllowed проверяет, может ли пользователь пользоваться ботом.
+// Для известных пользователей решает база данных, для новых — конфигурация.
+func (m *Middleware) IsAllowed(userID int64) bool {
+	user, err := m.db.GetUser(userID)
+	if err != nil {
+		return errors.Is(err, sql.ErrNoRows) && m.config.IsAllowed(userID)
+	}
+	return user.IsActive
 }
 
 // LogCommand записывает выполненную команду в историю
@@ -111,6 +153,25 @@ func (m *Middleware) LogCommand(userID int64, command string, args string, succe
 	}
 }
 
+// LogExecution записывает в историю запуск команды хоста с кодом выхода
+// и длительностью
+func (m *Middleware) LogExecution(userID int64, command string, args string, exitCode int, duration time.Duration, success bool, response string) {
+	history := &database.CommandHistory{
+		UserID:     userID,
+		Command:    command,
+		Arguments:  args,
+		Success:    success,
+		Response:   response,
+		ExecutedAt: time.Now(),
+		ExitCode:   &exitCode,
+		Duration:   duration,
+	}
+
+	if err := m.db.AddCommandHistory(history); err != nil {
+		log.Printf("Failed to log execution for user %d: %v", userID, err)
+	}
+}
+
 // GetUserHistory возвращает историю команд пользователя
 func (m *Middleware) GetUserHistory(userID int64, limit int) ([]*database.CommandHistory, error) {
 	return m.db.GetCommandHistory(userID, limit)
@@ -173,6 +234,13 @@ func (m *Middleware) SetUserAdmin(adminID int64, userID int64, isAdmin bool) err
 		}
 	}
 
+	// The user may not have written to the bot yet
+	if isAdmin {
+		if err := m.db.EnsureUser(userID, false); err != nil {
+			return err
+		}
+	}
+
 	return m.db.SetUserAdmin(userID, isAdmin)
 }
 
@@ -182,6 +250,12 @@ func (m *Middleware) SetUserActive(adminID int64, userID int64, isActive bool) e
 		return fmt.Errorf("access denied: admin privileges required")
 	}
 
+	// Create the row first so that a ban sticks even for users who have
+	// never written to the bot
+	if err := m.db.EnsureUser(userID, false); err != nil {
+		return err
+	}
+
 	// Prevent deactivating the last admin
 	if !isActive {
 		user, err := m.db.GetUser(userID)
@@ -801,3 +801,134 @@ func teardownTestDB(t *testing.T, db *database.DB) {
 	db.Close()
 	// Note: The actual file cleanup is handled by the test framework
 }
+
+func TestAuthorizeUser_StoresLanguageCode(t *testing.T) {
+	cfg := createTestConfig()
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+	middleware := NewMiddleware(cfg, db)
+
+	from := &tgbotapi.User{ID: 987654321, UserName: "regular", LanguageCode: "en"}
+	update := tgbotapi.Update{
+		Message: &tgbotapi.Message{From: from, Chat: &tgbotapi.Chat{ID: 987654321}},
+	}
+
+	if _, user := middleware.AuthorizeUser(update); user == nil || user.LanguageCode != "en" {
+		t.Fatalf("Expected language code en for a new user, got %+v", user)
+	}
+
+	// The client language follows the latest update
+	from.LanguageCode = "ru"
+	middleware.AuthorizeUser(update)
+
+	user, err := db.GetUser(987654321)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if user.LanguageCode != "ru" {
+		t.Errorf("Expected stored language code ru, got %q", user.LanguageCode)
+	}
+}
+
+func TestAuthorizeUser_BannedUserStaysBanned(t *testing.T) {
+	cfg := createTestConfig()
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+	middleware := NewMiddleware(cfg, db)
+
+	update := tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			From: &tgbotapi.User{ID: 987654321, UserName: "regular"},
+			Chat: &tgbotapi.Chat{ID: 987654321},
+		},
+	}
+
+	if authorized, _ := middleware.AuthorizeUser(update); !authorized {
+		t.Fatal("Expected allowed user to be authorized before ban")
+	}
+
+	if err := middleware.SetUserActive(123456789, 987654321, false); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+
+	// The user is still listed in allowed_users, but the ban must win
+	if authorized, _ := middleware.AuthorizeUser(update); authorized {
+		t.Error("Expected banned user to be rejected")
+	}
+	if middleware.IsAllowed(987654321) {
+		t.Error("Expected IsAllowed to be false for banned user")
+	}
+
+	user, err := db.GetUser(987654321)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if user.IsActive {
+		t.Error("Expected ban to survive authorization attempts")
+	}
+}
+
+func TestAuthorizeUser_PromotedAdmin(t *testing.T) {
+	cfg := createTestConfig()
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+	middleware := NewMiddleware(cfg, db)
+
+	if err := middleware.SetUserAdmin(123456789, 987654321, true); err != nil {
+		t.Fatalf("Failed to promote user: %v", err)
+	}
+
+	if !middleware.RequireAdmin(987654321) {
+		t.Error("Expected promoted user to pass admin check")
+	}
+
+	update := tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			From: &tgbotapi.User{ID: 987654321, UserName: "regular", FirstName: "Regular"},
+			Chat: &tgbotapi.Chat{ID: 987654321},
+		},
+	}
+
+	authorized, dbUser := middleware.AuthorizeUser(update)
+	if !authorized {
+		t.Fatal("Expected promoted user to be authorized")
+	}
+	if !dbUser.IsAdmin {
+		t.Error("Expected promoted user to keep admin role after authorization")
+	}
+	if dbUser.FirstName != "Regular" {
+		t.Errorf("Expected profile to be refreshed, got first name %q", dbUser.FirstName)
+	}
+}
+
+func TestAuthorizeUser_DemotedConfigAdmin(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Users.AdminUserIDs = []int64{123456789, 555555555}
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+	middleware := NewMiddleware(cfg, db)
+
+	if err := middleware.SetUserAdmin(123456789, 555555555, false); err != nil {
+		t.Fatalf("Failed to demote admin: %v", err)
+	}
+
+	// Re-creating the middleware (bot restart) must not restore the role
+	middleware = NewMiddleware(cfg, db)
+	if middleware.RequireAdmin(555555555) {
+		t.Error("Expected demoted config admin to stay demoted")
+	}
+}
+
+func TestIsAllowed_UnknownUser(t *testing.T) {
+	cfg := createTestConfig()
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+	middleware := NewMiddleware(cfg, db)
+
+	if !middleware.IsAllowed(987654321) {
+		t.Error("Expected configured user to be allowed before first contact")
+	}
+	if middleware.IsAllowed(111111111) {
+		t.Error("Expected unknown user to be rejected")
+	}
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/alerts"
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/system"
+)
+
+// notifyUsers sends a message to every user in their language, in the chat
+// they last used the bot from. Users unknown to the bot get the default
+// language in their private chat, deactivated users get nothing. An empty
+// text skips the user.
+func (b *Bot) notifyUsers(userIDs []int64, message func(userID int64, user *database.User) (string, *tgbotapi.InlineKeyboardMarkup)) {
+	for _, userID := range userIDs {
+		user, err := b.db.GetUser(userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Warning: Failed to get user %d for a notification: %v", userID, err)
+			continue
+		}
+		if user != nil && !user.IsActive {
+			continue
+		}
+
+		chatID := userID // the private chat with a user has their ID
+		if session, err := b.db.GetUserSession(userID); err == nil && session.ChatID != 0 {
+			chatID = session.ChatID
+		}
+		text, keyboard := message(userID, user)
+		if text == "" {
+			continue
+		}
+		if _, err := b.sendText(chatID, text, keyboard); err != nil {
+			log.Printf("Warning: Failed to notify user %d: %v", userID, err)
+		}
+	}
+}
+
+// sendAlert notifies the recipients of a rule that its alert fired or
+// resolved
+func (b *Bot) sendAlert(t alerts.Transition) {
+	recipients := b.config.AlertRecipients(&t.Rule)
+	if len(recipients) == 0 {
+		log.Printf("Alert %s changed but has no recipients", t.Rule.Name)
+		return
+	}
+	b.notifyUsers(recipients, func(userID int64, user *database.User) (string, *tgbotapi.InlineKeyboardMarkup) {
+		return b.alertText(user, t), nil
+	})
+}
+
+// alertText describes a transition of an alert
+func (b *Bot) alertText(user *database.User, t alerts.Transition) string {
+	metric := b.alertMetricName(user, &t.Rule)
+	value := alertValue(&t.Rule, t.Value)
+	if t.Firing {
+		threshold := alertValue(&t.Rule, t.Rule.Threshold)
+		return b.t(user, "alerts.fired", t.Rule.Name, metric, value, t.Rule.Operator, threshold,
+			formatDuration(b.lang(user), t.At.Sub(t.Since)))
+	}
+	return b.t(user, "alerts.resolved", t.Rule.Name, metric, value,
+		formatDuration(b.lang(user), t.At.Sub(t.Since)))
+}
+
+// alertMetricName names the metric of a rule with its target
+func (b *Bot) alertMetricName(user *database.User, rule *config.AlertRule) string {
+	switch rule.Metric {
+	case config.AlertMetricDiskUsed, config.AlertMetricDiskFree:
+		return b.t(user, "alerts.metric."+rule.Metric, rule.Target)
+	case config.AlertMetricNetRecv, config.AlertMetricNetSent:
+		target := rule.Target
+		if target == "" {
+			target = b.t(user, "alerts.all_interfaces")
+		}
+		return b.t(user, "alerts.metric."+rule.Metric, target)
+	}
+	return b.t(user, "alerts.metric."+rule.Metric)
+}
+
+// alertValue formats a value of the metric of a rule
+func alertValue(rule *config.AlertRule, value float64) string {
+	if rule.Metric == config.AlertMetricNetRecv || rule.Metric == config.AlertMetricNetSent {
+		return system.FormatBytes(uint64(value)) + "/s"
+	}
+	return fmt.Sprintf("%.1f%%", value)
+}
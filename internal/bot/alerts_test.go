@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/alerts"
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+)
+
+func TestSendAlert(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t)
+	user.Language = "ru"
+	if err := bot.db.CreateOrUpdateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	// The admin last wrote from a group
+	if err := bot.db.UpdateUserSession(&database.UserSession{UserID: admin.ID, ChatID: -100, LastSeen: time.Now(), IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	banned := &database.User{ID: 555, Username: "banned"}
+	if err := bot.db.CreateOrUpdateUser(banned); err != nil {
+		t.Fatal(err)
+	}
+	bot.config.Alerts.NotifyUsers = []int64{admin.ID, user.ID, banned.ID, 777}
+
+	clear := 15.0
+	rule := config.AlertRule{Name: "disk-c", Metric: config.AlertMetricDiskFree, Target: "C:", Operator: "<", Threshold: 10, Clear: &clear, Duration: 300}
+	since := time.Now().Add(-6 * time.Minute)
+	bot.sendAlert(alerts.Transition{Rule: rule, Firing: true, Value: 8.25, Since: since, At: time.Now()})
+
+	sent := fake.Sent()
+	if len(sent) != 3 {
+		t.Fatalf("Expected the admin, the user and the unknown recipient notified, got %+v", sent)
+	}
+	if sent[0].ChatID != -100 || !containsString(sent[0].Text, "Free space on C: is 8.2% (&lt; 10.0%) for 6m") {
+		t.Errorf("Unexpected alert for the admin in chat %d: %s", sent[0].ChatID, sent[0].Text)
+	}
+	if sent[1].ChatID != user.ID || !containsString(sent[1].Text, "Свободно на C:") {
+		t.Errorf("Expected the alert in Russian in the private chat, got %d: %s", sent[1].ChatID, sent[1].Text)
+	}
+	if sent[2].ChatID != 777 {
+		t.Errorf("Expected an unknown recipient notified in their private chat, got %d", sent[2].ChatID)
+	}
+
+	fake.Reset()
+	rule.NotifyUsers = []int64{user.ID}
+	bot.sendAlert(alerts.Transition{Rule: rule, Value: 16, Since: since, At: time.Now()})
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].ChatID != user.ID || !containsString(sent[0].Text, "снято") {
+		t.Errorf("Expected only the recipients of the rule notified, got %+v", sent)
+	}
+}
@@ -1,26 +1,37 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/cupbot/cupbot/internal/alerts"
 	"github.com/cupbot/cupbot/internal/auth"
+	"github.com/cupbot/cupbot/internal/callbacks"
 	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/conversation"
 	"github.com/cupbot/cupbot/internal/database"
 	"github.com/cupbot/cupbot/internal/events"
+	"github.com/cupbot/cupbot/internal/executor"
+	"github.com/cupbot/cupbot/internal/exporter"
 	"github.com/cupbot/cupbot/internal/filemanager"
+	"github.com/cupbot/cupbot/internal/i18n"
+	"github.com/cupbot/cupbot/internal/metrics"
 	"github.com/cupbot/cupbot/internal/power"
 	"github.com/cupbot/cupbot/internal/screenshot"
+	"github.com/cupbot/cupbot/internal/services"
 	"github.com/cupbot/cupbot/internal/system"
+	"github.com/cupbot/cupbot/internal/telegram"
+	"github.com/cupbot/cupbot/internal/telegram/render"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Bot представляет Telegram бота
 type Bot struct {
-	api               *tgbotapi.BotAPI
+	api               telegram.Client
 	config            *config.Config
 	db                *database.DB
 	authMw            *auth.Middleware
@@ -28,17 +39,41 @@ type Bot struct {
 	fileManager       *filemanager.Service
 	screenshotService *screenshot.Service
 	eventsService     *events.Service
+	notifications     *events.Throttle // throttles notifications, not the event log
+	alerts            *alerts.Service
+	metrics           *metrics.Recorder
+	exporter          *exporter.Server
+	stats             *botStats
+	notifyLimits      notifyLimiter
+	held              *heldDelivery // nil until Start
 	powerService      *power.Service
-}
-
-// New создает новый экземпляр бота
+	executor          *executor.Service
+	services          services.Manager
+	commands          *commandRegistry
+	callbackStore     *callbacks.Store
+	confirmStore      *callbacks.Store
+	conversations     *conversation.Store
+	dispatcher        *updateDispatcher
+	dashboards        *dashboardManager
+	shells            *shellManager
+	webhook           *webhookServer // nil in polling mode
+}
+
+// New создает новый экземпляр бота, подключенный к Bot API
 func New(cfg *config.Config, db *database.DB) (*Bot, error) {
-	api, err := tgbotapi.NewBotAPI(cfg.Bot.Token)
+	api, err := telegram.NewClient(cfg.Bot.Token, cfg.Bot.APIEndpoint, cfg.Bot.Debug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot API: %w", err)
 	}
 
-	api.Debug = cfg.Bot.Debug
+	return NewWithClient(cfg, db, api), nil
+}
+
+// NewWithClient создает бота поверх готового клиента Bot API
+func NewWithClient(cfg *config.Config, db *database.DB, api telegram.Client) *Bot {
+	callbackStore := callbacks.NewStore(db, callbacks.DefaultTTL)
+	fileManager := filemanager.NewService(cfg)
+	fileManager.SetCallbackStore(callbackStore)
 
 	bot := &Bot{
 		api:               api,
@@ -46,22 +81,82 @@ func New(cfg *config.Config, db *database.DB) (*Bot, error) {
 		db:                db,
 		authMw:            auth.NewMiddleware(cfg, db),
 		systemService:     system.NewService(),
-		fileManager:       filemanager.NewService(cfg),
+		fileManager:       fileManager,
+		callbackStore:     callbackStore,
+		confirmStore:      callbacks.NewStore(db, confirmTimeout(cfg)),
+		conversations:     conversation.NewStore(db, time.Duration(cfg.Bot.DialogTimeout)*time.Second),
 		screenshotService: screenshot.NewService(cfg),
 		eventsService:     events.NewService(cfg),
 		powerService:      power.NewService(cfg),
+		executor:          executor.NewService(cfg),
+		services:          services.New(),
+		commands:          newCommandRegistry(),
+		stats:             newBotStats(),
 	}
 
-	log.Printf("Authorized on account %s", api.Self.UserName)
-	return bot, nil
+	bot.eventsService.AddHandler(bot.stats.observeEvent)
+	bot.eventsService.AddHandler(bot.handleSystemEvent)
+	bot.notifications = events.NewThrottle(cfg, bot.sendEvent)
+	bot.exporter = bot.newExporter()
+	bot.alerts = alerts.NewService(cfg, db, bot.systemService.GetSystemInfo, bot.sendAlert)
+	bot.metrics = metrics.NewRecorder(cfg, db, bot.systemService.GetSystemInfo)
+	bot.dispatcher = newUpdateDispatcher(cfg.Bot.Workers, cfg.Bot.QueueSize, bot.handleUpdate)
+	bot.dashboards = newDashboardManager(time.Duration(cfg.Bot.Dashboard.Interval)*time.Second, cfg.Bot.Dashboard.MaxPerChat)
+	bot.dashboards.render = bot.dashboardText
+	bot.shells = newShellManager(time.Duration(cfg.Shell.IdleTimeout)*time.Second, time.Second)
+	if cfg.Bot.Mode == config.ModeWebhook {
+		bot.webhook = newWebhookServer(cfg.Bot.Webhook, bot.dispatcher.submit)
+	}
+
+	log.Printf("Authorized on account %s", api.Self().UserName)
+	return bot
 }
 
 // Start запускает бота
 func (b *Bot) Start() error {
+	// Expired tokens of both stores share a table
+	b.callbackStore.Start(callbacks.CleanupInterval)
+	if err := b.conversations.Cleanup(); err != nil {
+		log.Printf("Warning: Failed to clean up expired dialogs: %v", err)
+	}
+	if err := b.db.EndStaleShellSessions(); err != nil {
+		log.Printf("Warning: Failed to close shell sessions of the previous run: %v", err)
+	}
+
+	b.systemService.Start(time.Duration(b.config.System.SampleInterval) * time.Second)
+
 	// Start events monitoring
+	b.notifications.Start()
 	if err := b.eventsService.Start(); err != nil {
 		log.Printf("Warning: Failed to start events service: %v", err)
 	}
+	b.startHeldDelivery()
+	if err := b.alerts.Start(); err != nil {
+		log.Printf("Warning: Failed to start alerts: %v", err)
+	}
+	if err := b.metrics.Start(); err != nil {
+		log.Printf("Warning: Failed to start the metrics history: %v", err)
+	}
+	if b.exporter != nil {
+		if err := b.exporter.Start(); err != nil {
+			log.Printf("Warning: Failed to start the metrics listener: %v", err)
+		}
+	}
+
+	b.publishCommands()
+
+	if b.webhook != nil {
+		return b.receiveWebhook()
+	}
+	return b.receivePolling()
+}
+
+// receivePolling получает обновления через long polling до вызова Stop
+func (b *Bot) receivePolling() error {
+	// getUpdates не работает, пока установлен webhook
+	if err := b.api.DeleteWebhook(); err != nil {
+		log.Printf("Warning: Failed to delete webhook: %v", err)
+	}
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
@@ -71,21 +166,63 @@ func (b *Bot) Start() error {
 	log.Println("Bot started. Waiting for messages...")
 
 	for update := range updates {
-		go b.handleUpdate(update)
+		b.dispatcher.submit(update)
 	}
 
 	return nil
 }
 
-// Stop останавливает бота
-func (b *Bot) Stop() {
-	b.api.StopReceivingUpdates()
+// Stop останавливает бота и ждет завершения обработчиков, но не дольше
+// shutdown_timeout
+func (b *Bot) Stop() error {
+	if b.webhook != nil {
+		b.stopWebhook()
+	} else {
+		b.api.StopReceivingUpdates()
+	}
+	err := b.dispatcher.stop(b.ShutdownTimeout())
+	// Handlers are done, no dashboard or shell can start anymore
+	dashboardsErr := b.dashboards.stop(b.ShutdownTimeout())
+	shellsErr := b.shells.stop(b.ShutdownTimeout())
+	// Returns once the shutdown event is recorded and published, so that
+	// the notifications flush it
 	b.eventsService.Stop()
+	b.notifications.Stop()
+	b.stopHeldDelivery()
+	b.alerts.Stop()
+	b.metrics.Stop()
+	b.systemService.Stop()
+	b.callbackStore.Stop()
+	if b.exporter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), b.ShutdownTimeout())
+		if err := b.exporter.Stop(ctx); err != nil {
+			log.Printf("Warning: Failed to stop the metrics listener: %v", err)
+		}
+		cancel()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to drain update handlers: %w", err)
+	}
+	if dashboardsErr != nil {
+		return fmt.Errorf("failed to stop dashboards: %w", dashboardsErr)
+	}
+	if shellsErr != nil {
+		return fmt.Errorf("failed to stop shell sessions: %w", shellsErr)
+	}
 	log.Println("Bot stopped")
+	return nil
+}
+
+// ShutdownTimeout возвращает максимальное время работы Stop
+func (b *Bot) ShutdownTimeout() time.Duration {
+	return time.Duration(b.config.Bot.ShutdownTimeout) * time.Second
 }
 
 // handleUpdate обрабатывает входящие обновления
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	start := time.Now()
+	defer func() { b.stats.observeUpdate(update, time.Since(start)) }()
+
 	// Авторизация пользователя
 	authorized, user := b.authMw.AuthorizeUser(update)
 	if !authorized {
@@ -102,7 +239,14 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 
 // handleMessage обрабатывает текстовые сообщения
 func (b *Bot) handleMessage(message *tgbotapi.Message, user *database.User) {
+	// В открытую shell-сессию идет все, кроме ее собственных команд, в том
+	// числе пути вроде /bin/ls
+	if !isShellCommand(message) && b.handleShellInput(message, user) {
+		return
+	}
+	// Обычный текст отвечает на текущий диалог
 	if !message.IsCommand() {
+		b.handleDialogInput(message, user)
 		return
 	}
 
@@ -113,204 +257,68 @@ func (b *Bot) handleMessage(message *tgbotapi.Message, user *database.User) {
 
 	var response string
 	var success bool
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+	var logged bool
 
-	switch command {
-	case "start":
-		response, success = b.handleStart(message, user)
-	case "help", "menu":
-		response, success = b.handleHelp(message, user)
-	case "status":
-		response, success = b.handleStatusInternal(user)
-	case "uptime":
-		response, success = b.handleUptimeInternal(user)
-	case "history":
-		response, success = b.handleHistoryInternal(user, args)
-	case "users":
-		response, success = b.handleUsersInternal(user)
-	case "stats":
-		response, success = b.handleStatsInternal(user)
-	case "cleanup":
-		response, success = b.handleCleanup(message, user, args)
-	case "addadmin":
-		response, success = b.handleAddAdmin(message, user, args)
-	case "removeadmin":
-		response, success = b.handleRemoveAdmin(message, user, args)
-	case "banuser":
-		response, success = b.handleBanUser(message, user, args)
-	case "unbanuser":
-		response, success = b.handleUnbanUser(message, user, args)
-	case "deleteuser":
-		response, success = b.handleDeleteUser(message, user, args)
-	case "files":
-		response, success = b.handleFiles(message, user, args)
-	case "screenshot":
-		response, success = b.handleScreenshot(message, user, args)
+	cmd := b.commands.command(command)
+	switch {
+	case cmd == nil:
+		response = b.t(user, "error.unknown_command", command)
+		keyboard = menuKeyboard(b, user)
+	case !cmd.Role.allows(user):
+		response = b.t(user, "error.admin_required")
+		keyboard = menuKeyboard(b, user)
+	case b.config.RequiresConfirmation(commandAction(cmd.Name)):
+		response, success, keyboard = b.stageCommand(message, user, cmd.Name, args)
 	default:
-		response = fmt.Sprintf("Неизвестная команда: %s\nИспользуйте /help для просмотра доступных команд", command)
-		success = false
+		response, success = cmd.Handler(b, message, user, args)
+		keyboard = resolveKeyboard(b, user, cmd.Keyboard)
+		logged = cmd.LogsHistory
 	}
 
 	// Отправляем ответ
 	if response != "" {
-		msg := tgbotapi.NewMessage(message.Chat.ID, response)
-		msg.ParseMode = tgbotapi.ModeMarkdown
-
-		// Добавляем кнопку меню после каждого ответа (кроме start)
-		if command != "start" {
-			msg.ReplyMarkup = b.getMenuKeyboard()
-		}
-
-		// Для команд help и menu показываем полную клавиатуру
-		if command == "help" || command == "menu" {
-			msg.ReplyMarkup = b.getMainKeyboard(user.IsAdmin)
-		}
-
-		if _, err := b.api.Send(msg); err != nil {
+		if _, err := b.sendText(message.Chat.ID, response, keyboard); err != nil {
 			log.Printf("Failed to send message: %v", err)
 		}
 	}
 
 	// Записываем в историю
-	b.authMw.LogCommand(user.ID, command, args, success, response)
+	if !logged {
+		b.authMw.LogCommand(user.ID, command, args, success, response)
+	}
 }
 
 // handleCallbackQuery обрабатывает callback запросы
 func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery, user *database.User) {
 	// Отвечаем на callback
 	callbackResponse := tgbotapi.NewCallback(callback.ID, "")
-	b.api.Request(callbackResponse)
+	b.api.AnswerCallback(callbackResponse)
 
 	var response string
 	var success bool
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+	var panel bool
 
-	// Обрабатываем каллбэк данные
+	cb := b.commands.callback(callback.Data)
 	switch {
-	// Basic commands
-	case callback.Data == "status":
-		response, success = b.handleStatusCallback(user)
-	case callback.Data == "uptime":
-		response, success = b.handleUptimeCallback(user)
-	case callback.Data == "history":
-		response, success = b.handleHistoryCallback(user)
-	case callback.Data == "users":
-		response, success = b.handleUsersCallback(user)
-	case callback.Data == "stats":
-		response, success = b.handleStatsCallback(user)
-	case callback.Data == "files":
-		response, success = b.handleFilesCallback(user)
-	case callback.Data == "screenshot":
-		response, success = b.handleScreenshotCallback(user)
-	case callback.Data == "events":
-		response, success = b.handleEventsCallback(user)
-
-	// Menu navigation
-	case callback.Data == "admin_menu":
-		response, success = b.handleAdminMenuCallback(user)
-	case callback.Data == "main_menu":
-		response, success = b.handleMainMenuCallback(user)
-	case callback.Data == "menu":
-		response, success = b.handleMenuCallback(user)
-
-	// Power management
-	case callback.Data == "power_menu":
-		response, success = b.handlePowerMenuCallback(user)
-	case callback.Data == "shutdown_now":
-		response, success = b.handleShutdownNowCallback(user)
-	case callback.Data == "shutdown_1min":
-		response, success = b.handleShutdownDelayCallback(user, 1*time.Minute, false)
-	case callback.Data == "shutdown_5min":
-		response, success = b.handleShutdownDelayCallback(user, 5*time.Minute, false)
-	case callback.Data == "shutdown_10min":
-		response, success = b.handleShutdownDelayCallback(user, 10*time.Minute, false)
-	case callback.Data == "shutdown_30min":
-		response, success = b.handleShutdownDelayCallback(user, 30*time.Minute, false)
-	case callback.Data == "reboot_now":
-		response, success = b.handleRebootNowCallback(user)
-	case callback.Data == "reboot_1min":
-		response, success = b.handleRebootDelayCallback(user, 1*time.Minute, false)
-	case callback.Data == "reboot_5min":
-		response, success = b.handleRebootDelayCallback(user, 5*time.Minute, false)
-	case callback.Data == "reboot_10min":
-		response, success = b.handleRebootDelayCallback(user, 10*time.Minute, false)
-	case callback.Data == "reboot_30min":
-		response, success = b.handleRebootDelayCallback(user, 30*time.Minute, false)
-	case callback.Data == "force_shutdown":
-		response, success = b.handleShutdownDelayCallback(user, 0, true)
-	case callback.Data == "force_reboot":
-		response, success = b.handleRebootDelayCallback(user, 0, true)
-	case callback.Data == "cancel_power":
-		response, success = b.handleCancelPowerCallback(user)
-	case callback.Data == "power_status":
-		response, success = b.handlePowerStatusCallback(user)
-
-	// User management
-	case callback.Data == "user_menu":
-		response, success = b.handleUserMenuCallback(user)
-	case callback.Data == "add_admin_menu":
-		response, success = b.handleAddAdminMenuCallback(user)
-	case callback.Data == "remove_admin_menu":
-		response, success = b.handleRemoveAdminMenuCallback(user)
-	case callback.Data == "ban_user_menu":
-		response, success = b.handleBanUserMenuCallback(user)
-	case callback.Data == "unban_user_menu":
-		response, success = b.handleUnbanUserMenuCallback(user)
-	case callback.Data == "delete_user_menu":
-		response, success = b.handleDeleteUserMenuCallback(user)
-	case callback.Data == "list_users":
-		response, success = b.handleListUsersCallback(user)
-
-	// Enhanced services
-	case callback.Data == "file_manager_admin":
-		response, success = b.handleFileManagerAdminCallback(user)
-	case callback.Data == "screenshot_admin":
-		response, success = b.handleScreenshotAdminCallback(user)
-	case callback.Data == "system_tools":
-		response, success = b.handleSystemToolsCallback(user)
-
-	// File manager interactive navigation
-	case strings.HasPrefix(callback.Data, "fm_"):
-		response, success = b.handleFileManagerCallback(callback, user)
-
+	case cb == nil:
+		response = b.t(user, "error.unknown_action")
+		keyboard = menuKeyboard(b, user)
+	case !cb.Role.allows(user):
+		response = b.t(user, "error.admin_required")
+		keyboard = menuKeyboard(b, user)
+	case cb.Confirm || b.config.RequiresConfirmation(callback.Data):
+		response, success, keyboard = b.stageCallback(callback, user)
 	default:
-		response = "Неизвестная команда"
-		success = false
+		response, success = cb.Handler(b, callback, user)
+		keyboard = resolveKeyboard(b, user, cb.Keyboard)
+		panel = cb.Panel
 	}
 
 	// Отправляем ответ
 	if response != "" {
-		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, response)
-		msg.ParseMode = tgbotapi.ModeMarkdown
-
-		// Добавляем кнопку меню после каждого callback ответа
-		if !isMenuCallback(callback.Data) && !isPowerCallback(callback.Data) && !isUserManagementCallback(callback.Data) && !isFileManagerCallback(callback.Data) {
-			msg.ReplyMarkup = b.getMenuKeyboard()
-		}
-
-		// Для меню показываем соответствующую клавиатуру
-		switch callback.Data {
-		case "main_menu", "menu":
-			msg.ReplyMarkup = b.getMainKeyboard(user.IsAdmin)
-		case "admin_menu":
-			msg.ReplyMarkup = b.getAdminKeyboard()
-		case "power_menu":
-			msg.ReplyMarkup = b.getPowerMenuKeyboard()
-		case "user_menu":
-			msg.ReplyMarkup = b.getUserManagementKeyboard()
-		case "file_manager_admin":
-			msg.ReplyMarkup = b.getFileManagerKeyboard()
-		case "system_tools":
-			msg.ReplyMarkup = b.getSystemToolsKeyboard()
-		case "files":
-			// Show drive selection keyboard for files callback
-			drives := b.fileManager.GetAvailableDrives()
-			if len(drives) > 0 {
-				msg.ReplyMarkup = b.generateEnhancedDriveSelectionKeyboard(drives)
-			}
-		}
-
-		if _, err := b.api.Send(msg); err != nil {
-			log.Printf("Failed to send callback response: %v", err)
-		}
+		b.replyToCallback(callback, user, panel, response, keyboard)
 	}
 
 	// Записываем в историю
@@ -322,84 +330,59 @@ func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery, user *databa
 // sendUnauthorizedMessage отправляет сообщение о недостатке прав
 func (b *Bot) sendUnauthorizedMessage(update tgbotapi.Update) {
 	var chatID int64
+	var from *tgbotapi.User
 	if update.Message != nil {
 		chatID = update.Message.Chat.ID
+		from = update.Message.From
 	} else if update.CallbackQuery != nil {
 		chatID = update.CallbackQuery.Message.Chat.ID
+		from = update.CallbackQuery.From
 	} else {
 		return
 	}
 
-	msg := tgbotapi.NewMessage(chatID, "❌ У вас нет прав для использования этого бота.")
-	b.api.Send(msg)
+	// Неизвестного пользователя нет в базе, язык берется из клиента Telegram
+	lang := b.defaultLanguage()
+	if from != nil {
+		if matched := i18n.Match(from.LanguageCode); matched != "" {
+			lang = matched
+		}
+	}
+
+	if _, err := b.sendText(chatID, i18n.T(lang, "error.unauthorized"), nil); err != nil {
+		log.Printf("Failed to send message: %v", err)
+	}
 }
 
 // handleStart обрабатывает команду /start
 func (b *Bot) handleStart(message *tgbotapi.Message, user *database.User) (string, bool) {
-	welcome := fmt.Sprintf(`🤖 *Добро пожаловать в CupBot!*
-
-Привет, %s! Этот бот позволяет удаленно управлять компьютером.
-
-📊 *Основные возможности:*
-• Просмотр статуса системы
-• Мониторинг времени работы
-• Просмотр истории команд`, user.FirstName)
+	welcome := b.t(user, "start.welcome", user.FirstName)
 
 	if user.IsAdmin {
-		welcome += `
-
-🔑 *Вы — администратор!*
-• Управление пользователями
-• Просмотр статистики
-• Очистка данных`
+		welcome += "\n\n" + b.t(user, "start.admin")
 	}
 
-	welcome += `
-
-📱 *Используйте кнопки ниже для управления:*`
+	welcome += "\n\n" + b.t(user, "start.footer")
 
 	// Отправляем сообщение с клавиатурой
-	msg := tgbotapi.NewMessage(message.Chat.ID, welcome)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	msg.ReplyMarkup = b.getMainKeyboard(user.IsAdmin)
-	b.api.Send(msg)
+	keyboard := b.getMainKeyboard(b.lang(user), user.IsAdmin)
+	if _, err := b.sendText(message.Chat.ID, welcome, &keyboard); err != nil {
+		log.Printf("Failed to send message: %v", err)
+	}
 
 	return "", true // Пустой ответ, так как мы уже отправили сообщение
 }
 
 // handleHelp обрабатывает команду /help
 func (b *Bot) handleHelp(message *tgbotapi.Message, user *database.User) (string, bool) {
-	help := `📖 *Справка по командам*
-
-*Основные команды:*
-/start - Начать работу с ботом
-/help - Показать эту справку
-/status - Полный статус системы
-/uptime - Время работы системы
-/history [N] - История команд (по умолчанию 10)
-/files [путь] - Файловый менеджер
-/screenshot - Создать скриншот рабочего стола`
+	lang := b.lang(user)
+	help := i18n.T(lang, "help.title") + "\n\n" + i18n.T(lang, "help.user_commands") + "\n" + b.commands.helpSection(RoleUser, lang)
 
 	if user.IsAdmin {
-		help += `
-
-*Команды администратора:*
-/users - Список всех пользователей
-/stats - Статистика использования бота
-/cleanup [дни] - Очистка истории старше N дней (по умолчанию 30)
-/addadmin [ID] - Назначить администратора
-/removeadmin [ID] - Убрать права администратора
-/banuser [ID] - Заблокировать пользователя
-/unbanuser [ID] - Разблокировать пользователя
-/deleteuser [ID] - Удалить пользователя`
+		help += "\n\n" + i18n.T(lang, "help.admin_commands") + "\n" + b.commands.helpSection(RoleAdmin, lang)
 	}
 
-	help += `
-
-*Информация:*
-• Все команды записываются в историю
-• Только авторизованные пользователи могут использовать бота
-• Администраторы имеют расширенный доступ`
+	help += "\n\n" + i18n.T(lang, "help.info")
 
 	return help, true
 }
@@ -408,48 +391,52 @@ func (b *Bot) handleHelp(message *tgbotapi.Message, user *database.User) (string
 func (b *Bot) handleStatus(message *tgbotapi.Message, user *database.User) (string, bool) {
 	sysInfo, err := b.systemService.GetSystemInfo()
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка получения информации о системе: %v", err), false
+		return b.t(user, "status.error", err), false
 	}
 
-	response := "💻 *Статус системы*\n\n"
+	lang := b.lang(user)
+	response := i18n.T(lang, "status.title") + "\n\n"
 
 	// Основная информация
-	response += fmt.Sprintf("🖥️ *Хост:* %s\n", sysInfo.Hostname)
-	response += fmt.Sprintf("🔧 *ОС:* %s %s\n", sysInfo.OS, sysInfo.Platform)
-	response += fmt.Sprintf("⏰ *Время работы:* %s\n", formatDuration(sysInfo.Uptime))
-	response += fmt.Sprintf("🔄 *Процессов:* %d\n\n", sysInfo.ProcessCount)
+	response += i18n.T(lang, "status.host", sysInfo.Hostname) + "\n"
+	response += i18n.T(lang, "status.os", sysInfo.OS, sysInfo.Platform) + "\n"
+	response += i18n.T(lang, "status.uptime", formatDuration(lang, sysInfo.Uptime)) + "\n"
+	response += i18n.T(lang, "status.processes", sysInfo.ProcessCount) + "\n\n"
 
 	// Информация о CPU
-	response += "🧠 *Процессор:*\n"
-	response += fmt.Sprintf("   • Модель: %s\n", sysInfo.CPUInfo.ModelName)
-	response += fmt.Sprintf("   • Ядер: %d\n", sysInfo.CPUInfo.Cores)
+	response += i18n.T(lang, "status.cpu") + "\n"
+	response += i18n.T(lang, "status.cpu_model", sysInfo.CPUInfo.ModelName) + "\n"
+	response += i18n.T(lang, "status.cpu_cores", sysInfo.CPUInfo.Cores) + "\n"
 	if len(sysInfo.CPUInfo.Usage) > 0 {
 		avgUsage := 0.0
 		for _, usage := range sysInfo.CPUInfo.Usage {
 			avgUsage += usage
 		}
 		avgUsage /= float64(len(sysInfo.CPUInfo.Usage))
-		response += fmt.Sprintf("   • Загрузка: %.1f%%\n", avgUsage)
+		response += i18n.T(lang, "status.cpu_usage", avgUsage) + "\n"
+	}
+	if len(sysInfo.Load) == 3 {
+		response += i18n.T(lang, "status.cpu_load", sysInfo.Load[0], sysInfo.Load[1], sysInfo.Load[2]) + "\n"
 	}
 	if sysInfo.CPUInfo.Temperature > 0 {
-		response += fmt.Sprintf("   • Температура: %.1f°C\n", sysInfo.CPUInfo.Temperature)
+		response += i18n.T(lang, "status.cpu_temperature", sysInfo.CPUInfo.Temperature) + "\n"
 	}
 	response += "\n"
 
 	// Информация о памяти
-	response += "🧮 *Память:*\n"
-	response += fmt.Sprintf("   • Всего: %s\n", system.FormatBytes(sysInfo.MemoryInfo.Total))
-	response += fmt.Sprintf("   • Используется: %s (%.1f%%)\n",
-		system.FormatBytes(sysInfo.MemoryInfo.Used), sysInfo.MemoryInfo.UsedPercent)
-	response += fmt.Sprintf("   • Доступно: %s\n\n", system.FormatBytes(sysInfo.MemoryInfo.Available))
+	response += i18n.T(lang, "status.memory") + "\n"
+	response += i18n.T(lang, "status.memory_total", system.FormatBytes(sysInfo.MemoryInfo.Total)) + "\n"
+	response += i18n.T(lang, "status.memory_used",
+		system.FormatBytes(sysInfo.MemoryInfo.Used), sysInfo.MemoryInfo.UsedPercent) + "\n"
+	response += i18n.T(lang, "status.memory_available", system.FormatBytes(sysInfo.MemoryInfo.Available)) + "\n\n"
 
 	// Информация о дисках
-	response += "💾 *Диски:*\n"
+	response += i18n.T(lang, "status.disks") + "\n"
 	for _, disk := range sysInfo.DiskInfo {
 		if disk.Total > 0 {
-			response += fmt.Sprintf("   • %s (%s)\n", disk.Device, disk.Fstype)
-			response += fmt.Sprintf("     Всего: %s | Свободно: %s (%.1f%%)\n",
-				system.FormatBytes(disk.Total), system.FormatBytes(disk.Free), 100-disk.UsedPercent)
+			response += "   • " + render.Escape(fmt.Sprintf("%s (%s)", disk.Device, disk.Fstype)) + "\n"
+			response += i18n.T(lang, "status.disk_space",
+				system.FormatBytes(disk.Total), system.FormatBytes(disk.Free), 100-disk.UsedPercent) + "\n"
 		}
 	}
 
@@ -462,16 +449,23 @@ func (b *Bot) handleStatus(message *tgbotapi.Message, user *database.User) (stri
 	}
 
 	if activeInterfaces > 0 {
-		response += "\n🌐 *Сеть (активные интерфейсы):*\n"
+		response += "\n" + i18n.T(lang, "status.network") + "\n"
 		for _, net := range sysInfo.NetworkInfo {
 			if net.BytesSent > 0 || net.BytesRecv > 0 {
-				response += fmt.Sprintf("   • %s\n", net.Name)
-				response += fmt.Sprintf("     Отправлено: %s | Получено: %s\n",
-					system.FormatBytes(net.BytesSent), system.FormatBytes(net.BytesRecv))
+				response += "   • " + render.Escape(net.Name) + "\n"
+				response += i18n.T(lang, "status.network_traffic",
+					system.FormatBytes(net.BytesSent), system.FormatBytes(net.BytesRecv)) + "\n"
+				if sysInfo.RateInterval > 0 {
+					response += i18n.T(lang, "status.network_rate",
+						system.FormatBytes(uint64(net.SentRate)), system.FormatBytes(uint64(net.RecvRate))) + "\n"
+				}
 			}
 		}
 	}
 
+	response += "\n" + i18n.T(lang, "status.collected",
+		sysInfo.CollectedAt.Format("15:04:05"), int(time.Since(sysInfo.CollectedAt).Seconds()))
+
 	return response, true
 }
 func (b *Bot) handleUptime(message *tgbotapi.Message, user *database.User) (string, bool) {
@@ -481,10 +475,10 @@ func (b *Bot) handleUptime(message *tgbotapi.Message, user *database.User) (stri
 func (b *Bot) handleUptimeInternal(user *database.User) (string, bool) {
 	uptime, err := b.systemService.GetUptime()
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка получения времени работы: %v", err), false
+		return b.t(user, "uptime.error", err), false
 	}
 
-	return fmt.Sprintf("⏰ *Время работы системы:* %s", formatDuration(uptime)), true
+	return b.t(user, "uptime.response", formatDuration(b.lang(user), uptime)), true
 }
 
 // handleHistory обрабатывает команду /history
@@ -498,21 +492,25 @@ func (b *Bot) handleHistory(message *tgbotapi.Message, user *database.User, args
 
 	history, err := b.authMw.GetUserHistory(user.ID, limit)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка получения истории: %v", err), false
+		return b.t(user, "history.error", err), false
 	}
 
 	if len(history) == 0 {
-		return "📝 История команд пуста", true
+		return b.t(user, "history.empty"), true
 	}
 
-	response := fmt.Sprintf("📝 *История команд* (последние %d):\n\n", len(history))
+	response := b.t(user, "history.title", len(history)) + "\n\n"
 	for i, cmd := range history {
 		status := "✅"
 		if !cmd.Success {
 			status = "❌"
 		}
-		response += fmt.Sprintf("%d. %s `/%s %s`\n   _Время: %s_\n\n",
-			i+1, status, cmd.Command, cmd.Arguments, cmd.ExecutedAt.Format("02.01.2006 15:04:05"))
+		response += b.t(user, "history.entry",
+			i+1, status, cmd.Command, cmd.Arguments, cmd.ExecutedAt.Format("02.01.2006 15:04:05")) + "\n"
+		if cmd.ExitCode != nil {
+			response += b.t(user, "history.exit", *cmd.ExitCode, cmd.Duration) + "\n"
+		}
+		response += "\n"
 	}
 
 	return response, true
@@ -521,31 +519,31 @@ func (b *Bot) handleHistory(message *tgbotapi.Message, user *database.User, args
 // handleUsers обрабатывает команду /users (только админы)
 func (b *Bot) handleUsers(message *tgbotapi.Message, user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	users, err := b.authMw.GetAllUsers(user.ID)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка получения списка пользователей: %v", err), false
+		return b.t(user, "users.error", err), false
 	}
 
 	if len(users) == 0 {
-		return "👥 Список пользователей пуст", true
+		return b.t(user, "users.empty"), true
 	}
 
-	response := "👥 *Список пользователей:*\n\n"
+	response := b.t(user, "users.title") + "\n\n"
 	for i, u := range users {
 		status := "🟢"
 		if !u.IsActive {
 			status = "🔴"
 		}
-		role := "Пользователь"
+		role := b.t(user, "users.role_user")
 		if u.IsAdmin {
-			role = "Администратор"
+			role = b.t(user, "users.role_admin")
 		}
-		response += fmt.Sprintf("%d. %s *%s %s* (@%s)\n   ID: %d | %s\n   Создан: %s\n\n",
+		response += b.t(user, "users.entry",
 			i+1, status, u.FirstName, u.LastName, u.Username, u.ID, role,
-			u.CreatedAt.Format("02.01.2006 15:04"))
+			u.CreatedAt.Format("02.01.2006 15:04")) + "\n\n"
 	}
 
 	return response, true
@@ -554,26 +552,27 @@ func (b *Bot) handleUsers(message *tgbotapi.Message, user *database.User) (strin
 // handleStats обрабатывает команду /stats (только админы)
 func (b *Bot) handleStats(message *tgbotapi.Message, user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	stats, err := b.authMw.GetStats(user.ID)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка получения статистики: %v", err), false
+		return b.t(user, "stats.error", err), false
 	}
 
-	response := "📊 *Статистика использования:*\n\n"
-	response += fmt.Sprintf("👥 Всего пользователей: %v\n", stats["total_users"])
-	response += fmt.Sprintf("🟢 Активных пользователей: %v\n", stats["active_users"])
-	response += fmt.Sprintf("📝 Всего команд: %v\n", stats["total_commands"])
-	response += fmt.Sprintf("✅ Успешных команд: %v\n", stats["successful_commands"])
-	response += fmt.Sprintf("🕐 Команд за 24 часа: %v\n", stats["recent_commands"])
+	lang := b.lang(user)
+	response := i18n.T(lang, "stats.title") + "\n\n"
+	response += i18n.T(lang, "stats.total_users", stats["total_users"]) + "\n"
+	response += i18n.T(lang, "stats.active_users", stats["active_users"]) + "\n"
+	response += i18n.T(lang, "stats.total_commands", stats["total_commands"]) + "\n"
+	response += i18n.T(lang, "stats.successful_commands", stats["successful_commands"]) + "\n"
+	response += i18n.T(lang, "stats.recent_commands", stats["recent_commands"]) + "\n"
 
 	// Добавляем процент успешности
 	if total := stats["total_commands"].(int); total > 0 {
 		successful := stats["successful_commands"].(int)
 		successRate := float64(successful) * 100 / float64(total)
-		response += fmt.Sprintf("📈 Процент успешности: %.1f%%", successRate)
+		response += i18n.T(lang, "stats.success_rate", successRate)
 	}
 
 	return response, true
@@ -582,7 +581,7 @@ func (b *Bot) handleStats(message *tgbotapi.Message, user *database.User) (strin
 // handleCleanup обрабатывает команду /cleanup (только админы)
 func (b *Bot) handleCleanup(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	days := 30
@@ -594,27 +593,38 @@ func (b *Bot) handleCleanup(message *tgbotapi.Message, user *database.User, args
 
 	err := b.authMw.CleanupOldData(user.ID, days)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка очистки данных: %v", err), false
+		return b.t(user, "cleanup.error", err), false
+	}
+
+	if b.callbackStore != nil {
+		if err := b.callbackStore.Cleanup(); err != nil {
+			log.Printf("Failed to clean up callback tokens: %v", err)
+		}
+	}
+	if b.conversations != nil {
+		if err := b.conversations.Cleanup(); err != nil {
+			log.Printf("Failed to clean up expired dialogs: %v", err)
+		}
 	}
 
-	return fmt.Sprintf("🧹 Очистка завершена. Удалены записи старше %d дней.", days), true
+	return b.t(user, "cleanup.done", days), true
 }
 
 // Вспомогательные функции
 
-// formatDuration форматирует продолжительность в читаемый вид
-func formatDuration(d time.Duration) string {
+// formatDuration форматирует продолжительность в читаемый вид на языке lang
+func formatDuration(lang string, d time.Duration) string {
 	days := int(d.Hours()) / 24
 	hours := int(d.Hours()) % 24
 	minutes := int(d.Minutes()) % 60
 
 	if days > 0 {
-		return fmt.Sprintf("%d дн. %d ч. %d мин.", days, hours, minutes)
+		return i18n.T(lang, "duration.days", days, hours, minutes)
 	}
 	if hours > 0 {
-		return fmt.Sprintf("%d ч. %d мин.", hours, minutes)
+		return i18n.T(lang, "duration.hours", hours, minutes)
 	}
-	return fmt.Sprintf("%d мин.", minutes)
+	return i18n.T(lang, "duration.minutes", minutes)
 }
 
 // parseLimit парсит строку в число
@@ -627,128 +637,128 @@ func parseLimit(s string) (int, error) {
 // handleAddAdmin обрабатывает команду /addadmin (только админы)
 func (b *Bot) handleAddAdmin(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	if args == "" {
-		return "❌ Необходимо указать ID пользователя. Пример: /addadmin 123456789", false
+		return b.t(user, "admin.user_id_required", "addadmin"), false
 	}
 
 	userID, err := parseUserID(args)
 	if err != nil {
-		return "❌ Неверный ID пользователя", false
+		return b.t(user, "admin.invalid_user_id"), false
 	}
 
 	err = b.authMw.SetUserAdmin(user.ID, userID, true)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 
-	return fmt.Sprintf("✅ Пользователь %d назначен администратором", userID), true
+	return b.t(user, "admin.promoted", userID), true
 }
 
 // handleRemoveAdmin обрабатывает команду /removeadmin (только админы)
 func (b *Bot) handleRemoveAdmin(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	if args == "" {
-		return "❌ Необходимо указать ID пользователя. Пример: /removeadmin 123456789", false
+		return b.t(user, "admin.user_id_required", "removeadmin"), false
 	}
 
 	userID, err := parseUserID(args)
 	if err != nil {
-		return "❌ Неверный ID пользователя", false
+		return b.t(user, "admin.invalid_user_id"), false
 	}
 
 	if userID == user.ID {
-		return "❌ Нельзя убрать права администратора у себя", false
+		return b.t(user, "admin.cannot_demote_self"), false
 	}
 
 	err = b.authMw.SetUserAdmin(user.ID, userID, false)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 
-	return fmt.Sprintf("✅ Права администратора у пользователя %d убраны", userID), true
+	return b.t(user, "admin.demoted", userID), true
 }
 
 // handleBanUser обрабатывает команду /banuser (только админы)
 func (b *Bot) handleBanUser(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	if args == "" {
-		return "❌ Необходимо указать ID пользователя. Пример: /banuser 123456789", false
+		return b.t(user, "admin.user_id_required", "banuser"), false
 	}
 
 	userID, err := parseUserID(args)
 	if err != nil {
-		return "❌ Неверный ID пользователя", false
+		return b.t(user, "admin.invalid_user_id"), false
 	}
 
 	if userID == user.ID {
-		return "❌ Нельзя заблокировать себя", false
+		return b.t(user, "admin.cannot_ban_self"), false
 	}
 
 	err = b.authMw.SetUserActive(user.ID, userID, false)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 
-	return fmt.Sprintf("✅ Пользователь %d заблокирован", userID), true
+	return b.t(user, "admin.banned", userID), true
 }
 
 // handleUnbanUser обрабатывает команду /unbanuser (только админы)
 func (b *Bot) handleUnbanUser(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	if args == "" {
-		return "❌ Необходимо указать ID пользователя. Пример: /unbanuser 123456789", false
+		return b.t(user, "admin.user_id_required", "unbanuser"), false
 	}
 
 	userID, err := parseUserID(args)
 	if err != nil {
-		return "❌ Неверный ID пользователя", false
+		return b.t(user, "admin.invalid_user_id"), false
 	}
 
 	err = b.authMw.SetUserActive(user.ID, userID, true)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 
-	return fmt.Sprintf("✅ Пользователь %d разблокирован", userID), true
+	return b.t(user, "admin.unbanned", userID), true
 }
 
 // handleDeleteUser обрабатывает команду /deleteuser (только админы)
 func (b *Bot) handleDeleteUser(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Доступ запрещен. Требуются права администратора.", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	if args == "" {
-		return "❌ Необходимо указать ID пользователя. Пример: /deleteuser 123456789", false
+		return b.t(user, "admin.user_id_required", "deleteuser"), false
 	}
 
 	userID, err := parseUserID(args)
 	if err != nil {
-		return "❌ Неверный ID пользователя", false
+		return b.t(user, "admin.invalid_user_id"), false
 	}
 
 	if userID == user.ID {
-		return "❌ Нельзя удалить себя", false
+		return b.t(user, "admin.cannot_delete_self"), false
 	}
 
 	err = b.authMw.DeleteUser(user.ID, userID)
 	if err != nil {
-		return fmt.Sprintf("❌ Ошибка: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 
-	return fmt.Sprintf("✅ Пользователь %d удален", userID), true
+	return b.t(user, "admin.deleted", userID), true
 }
 
 // parseUserID парсит ID пользователя из строки
@@ -759,33 +769,33 @@ func parseUserID(s string) (int64, error) {
 }
 
 // getMainKeyboard returns the main keyboard
-func (b *Bot) getMainKeyboard(isAdmin bool) tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getMainKeyboard(lang string, isAdmin bool) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
 
 	// Basic buttons
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("💻 System Status", "status"),
-		tgbotapi.NewInlineKeyboardButtonData("⏰ Uptime", "uptime"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.status"), "status"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.uptime"), "uptime"),
 	})
 
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("📝 Command History", "history"),
-		tgbotapi.NewInlineKeyboardButtonData("📁 File Manager", "files"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.history"), "history"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.files"), "files"),
 	})
 
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("📸 Screenshot", "screenshot"),
-		tgbotapi.NewInlineKeyboardButtonData("🔔 Events", "events"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.screenshot"), "screenshot"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.events"), "events"),
 	})
 
 	// Admin buttons
 	if isAdmin {
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("👥 Users", "users"),
-			tgbotapi.NewInlineKeyboardButtonData("📊 Statistics", "stats"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.users"), "users"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.stats"), "stats"),
 		})
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("🔑 Admin Menu", "admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.admin_menu"), "admin_menu"),
 		})
 	}
 
@@ -815,13 +825,13 @@ func (b *Bot) handleStatsCallback(user *database.User) (string, bool) {
 
 func (b *Bot) handleAdminMenuCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied", false
+		return b.t(user, "error.admin_required"), false
 	}
-	return "🔑 *Admin Menu*\n\nSelect an action:", true
+	return b.t(user, "menu.admin"), true
 }
 
 func (b *Bot) handleMainMenuCallback(user *database.User) (string, bool) {
-	return fmt.Sprintf("🏠 *Main Menu*\n\nHello, %s! Choose an action:", user.FirstName), true
+	return b.t(user, "menu.main", user.FirstName), true
 }
 
 // Missing internal handler methods
@@ -842,32 +852,32 @@ func (b *Bot) handleStatsInternal(user *database.User) (string, bool) {
 }
 
 // getAdminKeyboard returns admin-specific keyboard
-func (b *Bot) getAdminKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getAdminKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	rows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🔌 Power Management", "power_menu"),
-			tgbotapi.NewInlineKeyboardButtonData("👥 User Management", "user_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.power_menu"), "power_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.user_menu"), "user_menu"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("📁 File Manager+", "file_manager_admin"),
-			tgbotapi.NewInlineKeyboardButtonData("📸 Screenshot+", "screenshot_admin"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.file_manager_admin"), "file_manager_admin"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.screenshot_admin"), "screenshot_admin"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("💻 System Monitoring", "status"),
-			tgbotapi.NewInlineKeyboardButtonData("🔧 System Tools", "system_tools"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.monitoring"), "status"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.system_tools"), "system_tools"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🏠 Main Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.main_menu"), "main_menu"),
 		},
 	}
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 // getMenuKeyboard returns simple menu button
-func (b *Bot) getMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getMenuKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	rows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("📜 Menu", "menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.menu"), "menu"),
 		},
 	}
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
@@ -879,34 +889,32 @@ func (b *Bot) handleFiles(message *tgbotapi.Message, user *database.User, args s
 	// If args provided, still support legacy command format
 	if args != "" {
 		// Legacy direct path browsing
-		response, err := b.fileManager.GetDirectoryNavigationResponse(args, 1)
+		response, err := b.fileManager.GetDirectoryNavigationResponse(b.lang(user), args, 1)
 		if err != nil {
-			return fmt.Sprintf("❌ Error: %v", err), false
+			return b.t(user, "error.generic", err), false
 		}
 		
 		// Send response with interactive keyboard
 		paginatedResult, err := b.fileManager.ListDirectoryPaginated(args, 1, 15)
 		if err != nil {
-			return fmt.Sprintf("❌ Error listing directory: %v", err), false
+			return b.t(user, "files.list_error", err), false
 		}
 		
-		keyboard := b.generateEnhancedDirectoryKeyboard(response.Context, paginatedResult)
-		msg := tgbotapi.NewMessage(message.Chat.ID, response.Content)
-		msg.ParseMode = tgbotapi.ModeMarkdown
-		msg.ReplyMarkup = keyboard
-		b.api.Send(msg)
+		keyboard := b.generateEnhancedDirectoryKeyboard(b.lang(user), user.ID, response.Context, paginatedResult)
+		if _, err := b.sendText(message.Chat.ID, response.Content, &keyboard); err != nil {
+			log.Printf("Failed to send message: %v", err)
+		}
 		
 		return "", true // Empty response since we sent the message
 	}
 	
 	// No args - show interactive drive selection
-	response := b.fileManager.GetDriveSelectionResponse()
-	keyboard := b.generateEnhancedDriveSelectionKeyboard(b.fileManager.GetAvailableDrives())
+	response := b.fileManager.GetDriveSelectionResponse(b.lang(user))
+	keyboard := b.generateEnhancedDriveSelectionKeyboard(b.lang(user), b.fileManager.GetAvailableDrives())
 	
-	msg := tgbotapi.NewMessage(message.Chat.ID, response.Content)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	msg.ReplyMarkup = keyboard
-	b.api.Send(msg)
+	if _, err := b.sendText(message.Chat.ID, response.Content, &keyboard); err != nil {
+		log.Printf("Failed to send message: %v", err)
+	}
 	
 	return "", true // Empty response since we sent the message
 }
@@ -914,175 +922,132 @@ func (b *Bot) handleFiles(message *tgbotapi.Message, user *database.User, args s
 func (b *Bot) handleScreenshot(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
 	filename, err := b.screenshotService.TakeScreenshot()
 	if err != nil {
-		return fmt.Sprintf("❌ Error taking screenshot: %v", err), false
+		return b.t(user, "screenshot.error", err), false
 	}
 
 	// Send screenshot as photo
 	photo := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FilePath(filename))
-	photo.Caption = fmt.Sprintf("📸 Desktop Screenshot\nTaken at: %s", time.Now().Format("2006-01-02 15:04:05"))
+	photo.Caption = b.t(user, "screenshot.caption", time.Now().Format("2006-01-02 15:04:05"))
 
-	if _, err := b.api.Send(photo); err != nil {
-		return fmt.Sprintf("❌ Error sending screenshot: %v", err), false
+	if _, err := b.sendPhoto(photo); err != nil {
+		return b.t(user, "screenshot.send_error", err), false
 	}
 
-	return "📸 Screenshot taken and sent!", true
+	return b.t(user, "screenshot.sent"), true
 }
 
 // Callback handlers for new services
 func (b *Bot) handleFilesCallback(user *database.User) (string, bool) {
 	drives := b.fileManager.GetAvailableDrives()
 	if len(drives) == 0 {
-		return "❌ No drives available in configuration", false
+		return b.t(user, "files.no_drives"), false
 	}
 
-	response := "📁 *File Manager*\n\nAvailable drives:\n"
+	response := b.t(user, "files.title") + "\n\n"
 	for _, drive := range drives {
-		response += fmt.Sprintf("• %s\n", drive)
+		response += "• " + render.Escape(drive) + "\n"
 	}
-	response += "\n💡 *Click on a drive below to start browsing:*"
+	response += "\n" + b.t(user, "files.pick_drive")
 	return response, true
 }
 
 func (b *Bot) handleScreenshotCallback(user *database.User) (string, bool) {
-	return "📸 *Screenshot Service*\n\nUse `/screenshot` command to take a desktop screenshot.", true
-}
-
-func (b *Bot) handleEventsCallback(user *database.User) (string, bool) {
-	// For now, just return that the service is enabled
-	status := "running"
-	if !b.config.Events.Enabled {
-		status = "disabled"
-	}
-
-	return fmt.Sprintf("🔔 *System Events Monitor*\n\nStatus: %s\n\nMonitoring system events and sending notifications.", status), true
+	return b.t(user, "screenshot.info"), true
 }
 
 func (b *Bot) handleMenuCallback(user *database.User) (string, bool) {
-	return fmt.Sprintf("📜 *Menu*\n\nHello, %s! Choose an action:", user.FirstName), true
+	return b.t(user, "menu.menu", user.FirstName), true
 }
 
-// Helper functions for callback type checking
-func isMenuCallback(data string) bool {
-	menuCallbacks := []string{"main_menu", "admin_menu", "menu", "power_menu", "user_menu", "file_manager_admin", "system_tools"}
-	for _, callback := range menuCallbacks {
-		if data == callback {
-			return true
-		}
-	}
-	return false
-}
-
-func isPowerCallback(data string) bool {
-	powerCallbacks := []string{"power_menu", "shutdown_now", "shutdown_1min", "shutdown_5min", "shutdown_10min", "shutdown_30min",
-		"reboot_now", "reboot_1min", "reboot_5min", "reboot_10min", "reboot_30min", "force_shutdown", "force_reboot", "cancel_power", "power_status"}
-	for _, callback := range powerCallbacks {
-		if data == callback {
-			return true
-		}
-	}
-	return false
-}
-
-func isUserManagementCallback(data string) bool {
-	userCallbacks := []string{"user_menu", "add_admin_menu", "remove_admin_menu", "ban_user_menu", "unban_user_menu", "delete_user_menu", "list_users"}
-	for _, callback := range userCallbacks {
-		if data == callback {
-			return true
-		}
-	}
-	return false
-}
-
-func isFileManagerCallback(data string) bool {
-	return strings.HasPrefix(data, "fm_") || data == "files" || data == "file_manager_admin"
-}
-
-func (b *Bot) getPowerMenuKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getPowerMenuKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	rows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🔴 Shutdown Now", "shutdown_now"),
-			tgbotapi.NewInlineKeyboardButtonData("🔄 Reboot Now", "reboot_now"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.shutdown_now"), "shutdown_now"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.reboot_now"), "reboot_now"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("⏱️ Shutdown in 1min", "shutdown_1min"),
-			tgbotapi.NewInlineKeyboardButtonData("⏱️ Reboot in 1min", "reboot_1min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.shutdown_1min"), "shutdown_1min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.reboot_1min"), "reboot_1min"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("⏰ Shutdown in 5min", "shutdown_5min"),
-			tgbotapi.NewInlineKeyboardButtonData("⏰ Reboot in 5min", "reboot_5min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.shutdown_5min"), "shutdown_5min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.reboot_5min"), "reboot_5min"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🕒 Shutdown in 10min", "shutdown_10min"),
-			tgbotapi.NewInlineKeyboardButtonData("🕒 Reboot in 10min", "reboot_10min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.shutdown_10min"), "shutdown_10min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.reboot_10min"), "reboot_10min"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🕥 Shutdown in 30min", "shutdown_30min"),
-			tgbotapi.NewInlineKeyboardButtonData("🕥 Reboot in 30min", "reboot_30min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.shutdown_30min"), "shutdown_30min"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.reboot_30min"), "reboot_30min"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("⚠️ Force Shutdown", "force_shutdown"),
-			tgbotapi.NewInlineKeyboardButtonData("⚠️ Force Reboot", "force_reboot"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.force_shutdown"), "force_shutdown"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.force_reboot"), "force_reboot"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("❌ Cancel Operation", "cancel_power"),
-			tgbotapi.NewInlineKeyboardButtonData("ℹ️ Power Status", "power_status"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.cancel_power"), "cancel_power"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.power_status"), "power_status"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Admin Menu", "admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_admin"), "admin_menu"),
 		},
 	}
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
-func (b *Bot) getUserManagementKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getUserManagementKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	rows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("👥 List All Users", "list_users"),
-			tgbotapi.NewInlineKeyboardButtonData("📊 User Statistics", "stats"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.list_users"), "list_users"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.user_stats"), "stats"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("➕ Add Administrator", "add_admin_menu"),
-			tgbotapi.NewInlineKeyboardButtonData("➖ Remove Administrator", "remove_admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.add_admin"), "add_admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.remove_admin"), "remove_admin_menu"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🚫 Ban User", "ban_user_menu"),
-			tgbotapi.NewInlineKeyboardButtonData("✅ Unban User", "unban_user_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.ban_user"), "ban_user_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.unban_user"), "unban_user_menu"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🗑️ Delete User", "delete_user_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.delete_user"), "delete_user_menu"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Admin Menu", "admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_admin"), "admin_menu"),
 		},
 	}
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 // Update the existing getFileManagerKeyboard to use new interactive interface
-func (b *Bot) getFileManagerKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getFileManagerKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	rows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("📁 Browse Files", "fm_drives"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.browse_files"), "fm_drives"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Admin Menu", "admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_admin"), "admin_menu"),
 		},
 	}
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
-func (b *Bot) getSystemToolsKeyboard() tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) getSystemToolsKeyboard(lang string) tgbotapi.InlineKeyboardMarkup {
 	rows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("💻 System Status", "status"),
-			tgbotapi.NewInlineKeyboardButtonData("⏰ Uptime", "uptime"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.status"), "status"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.uptime"), "uptime"),
+		},
+		{
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.history"), "history"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.system_events"), "events"),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("📝 Command History", "history"),
-			tgbotapi.NewInlineKeyboardButtonData("🔔 System Events", "events"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.services"), servicesData),
 		},
 		{
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Admin Menu", "admin_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_admin"), "admin_menu"),
 		},
 	}
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
@@ -1091,134 +1056,130 @@ func (b *Bot) getSystemToolsKeyboard() tgbotapi.InlineKeyboardMarkup {
 // Power Management Callback Handlers
 func (b *Bot) handlePowerMenuCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	// Check current power status
-	response := "🔌 *Power Management*\n\n"
+	response := b.t(user, "power.title") + "\n\n"
 
 	if op := b.powerService.GetScheduledOperation(); op != nil {
 		timeLeft := time.Until(op.ScheduledAt)
-		response += fmt.Sprintf("⚠️ *Active Operation:* %s\n", op.Type)
-		response += fmt.Sprintf("⏰ *Time Remaining:* %v\n\n", timeLeft.Round(time.Second))
+		response += b.t(user, "power.active_operation", op.Type) + "\n"
+		response += b.t(user, "power.time_remaining", timeLeft.Round(time.Second)) + "\n\n"
 	}
 
-	response += "Choose a power operation:"
+	response += b.t(user, "power.choose")
 	return response, true
 }
 
 func (b *Bot) handleShutdownNowCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	err := b.powerService.ScheduleShutdown(user.ID, 0, false)
 	if err != nil {
-		return fmt.Sprintf("❌ Error initiating shutdown: %v", err), false
+		return b.t(user, "power.shutdown_error", err), false
 	}
 
-	return "🔴 *Immediate shutdown initiated*\n\nThe system will shut down now.", true
+	return b.t(user, "power.shutdown_now"), true
 }
 
 func (b *Bot) handleRebootNowCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	err := b.powerService.ScheduleReboot(user.ID, 0, false)
 	if err != nil {
-		return fmt.Sprintf("❌ Error initiating reboot: %v", err), false
+		return b.t(user, "power.reboot_error", err), false
 	}
 
-	return "🔄 *Immediate reboot initiated*\n\nThe system will restart now.", true
+	return b.t(user, "power.reboot_now"), true
 }
 
 func (b *Bot) handleShutdownDelayCallback(user *database.User, delay time.Duration, force bool) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	err := b.powerService.ScheduleShutdown(user.ID, delay, force)
 	if err != nil {
-		return fmt.Sprintf("❌ Error scheduling shutdown: %v", err), false
+		return b.t(user, "power.schedule_shutdown_error", err), false
 	}
 
 	if delay == 0 {
 		if force {
-			return "⚠️ *Force shutdown initiated*\n\nThe system will shut down immediately, closing all applications.", true
+			return b.t(user, "power.force_shutdown_now"), true
 		}
-		return "🔴 *Immediate shutdown initiated*\n\nThe system will shut down now.", true
+		return b.t(user, "power.shutdown_now"), true
 	}
 
-	actionType := "Shutdown"
 	if force {
-		actionType = "Force shutdown"
+		return b.t(user, "power.force_shutdown_scheduled", delay), true
 	}
-
-	return fmt.Sprintf("⏰ *%s scheduled*\n\nThe system will shut down in %v.", actionType, delay), true
+	return b.t(user, "power.shutdown_scheduled", delay), true
 }
 
 func (b *Bot) handleRebootDelayCallback(user *database.User, delay time.Duration, force bool) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	err := b.powerService.ScheduleReboot(user.ID, delay, force)
 	if err != nil {
-		return fmt.Sprintf("❌ Error scheduling reboot: %v", err), false
+		return b.t(user, "power.schedule_reboot_error", err), false
 	}
 
 	if delay == 0 {
 		if force {
-			return "⚠️ *Force reboot initiated*\n\nThe system will restart immediately, closing all applications.", true
+			return b.t(user, "power.force_reboot_now"), true
 		}
-		return "🔄 *Immediate reboot initiated*\n\nThe system will restart now.", true
+		return b.t(user, "power.reboot_now"), true
 	}
 
-	actionType := "Reboot"
 	if force {
-		actionType = "Force reboot"
+		return b.t(user, "power.force_reboot_scheduled", delay), true
 	}
-
-	return fmt.Sprintf("⏰ *%s scheduled*\n\nThe system will restart in %v.", actionType, delay), true
+	return b.t(user, "power.reboot_scheduled", delay), true
 }
 
 func (b *Bot) handleCancelPowerCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	err := b.powerService.CancelScheduledOperation()
 	if err != nil {
-		return fmt.Sprintf("❌ Error canceling operation: %v", err), false
+		return b.t(user, "power.cancel_error", err), false
 	}
 
-	return "✅ *Power operation canceled*\n\nAny scheduled shutdown or reboot has been canceled.", true
+	return b.t(user, "power.canceled"), true
 }
 
 func (b *Bot) handlePowerStatusCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	status := b.powerService.GetPowerStatus()
-	response := "ℹ️ *Power Management Status*\n\n"
+	response := b.t(user, "power.status_title") + "\n\n"
 
 	if op := b.powerService.GetScheduledOperation(); op != nil {
 		timeLeft := time.Until(op.ScheduledAt)
-		response += fmt.Sprintf("⚠️ *Active Operation:* %s\n", op.Type)
-		response += fmt.Sprintf("👤 *Initiated by:* User %d\n", op.UserID)
-		response += fmt.Sprintf("⏰ *Scheduled for:* %s\n", op.ScheduledAt.Format("15:04:05"))
-		response += fmt.Sprintf("⏱️ *Time remaining:* %v\n", timeLeft.Round(time.Second))
+		response += b.t(user, "power.active_operation", op.Type) + "\n"
+		response += b.t(user, "power.initiated_by", op.UserID) + "\n"
+		response += b.t(user, "power.scheduled_for", op.ScheduledAt.Format("15:04:05")) + "\n"
+		response += b.t(user, "power.time_remaining", timeLeft.Round(time.Second)) + "\n"
 	} else {
-		response += "✅ No active power operations\n"
+		response += b.t(user, "power.no_operations") + "\n"
 	}
 
 	// Add platform-specific information
 	if supported, exists := status["supported"]; exists && !supported.(bool) {
-		response += "\n⚠️ Platform: Non-Windows (limited support)"
+		response += "\n" + b.t(user, "power.platform_limited")
 	} else {
-		response += "\n🟢 Platform: Windows (full support)"
+		response += "\n" + b.t(user, "power.platform_full")
 	}
 
 	return response, true
@@ -1227,50 +1188,10 @@ func (b *Bot) handlePowerStatusCallback(user *database.User) (string, bool) {
 // User Management Callback Handlers
 func (b *Bot) handleUserMenuCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
-	}
-
-	return "👥 *User Management*\n\nSelect a user management action:", true
-}
-
-func (b *Bot) handleAddAdminMenuCallback(user *database.User) (string, bool) {
-	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
-	}
-
-	return "➕ *Add Administrator*\n\nTo promote a user to administrator, use the command:\n`/addadmin [User_ID]`\n\nExample: `/addadmin 123456789`", true
-}
-
-func (b *Bot) handleRemoveAdminMenuCallback(user *database.User) (string, bool) {
-	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
-	}
-
-	return "➖ *Remove Administrator*\n\nTo remove admin privileges from a user, use the command:\n`/removeadmin [User_ID]`\n\nExample: `/removeadmin 123456789`", true
-}
-
-func (b *Bot) handleBanUserMenuCallback(user *database.User) (string, bool) {
-	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
-	}
-
-	return "🚫 *Ban User*\n\nTo ban a user from using the bot, use the command:\n`/banuser [User_ID]`\n\nExample: `/banuser 123456789`", true
-}
-
-func (b *Bot) handleUnbanUserMenuCallback(user *database.User) (string, bool) {
-	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
-	}
-
-	return "✅ *Unban User*\n\nTo unban a user and restore access, use the command:\n`/unbanuser [User_ID]`\n\nExample: `/unbanuser 123456789`", true
-}
-
-func (b *Bot) handleDeleteUserMenuCallback(user *database.User) (string, bool) {
-	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
-	return "🗑️ *Delete User*\n\nTo permanently delete a user from the database, use the command:\n`/deleteuser [User_ID]`\n\nExample: `/deleteuser 123456789`\n\n⚠️ *Warning:* This action cannot be undone!", true
+	return b.t(user, "menu.users"), true
 }
 
 func (b *Bot) handleListUsersCallback(user *database.User) (string, bool) {
@@ -1280,65 +1201,55 @@ func (b *Bot) handleListUsersCallback(user *database.User) (string, bool) {
 // Enhanced Service Callback Handlers
 func (b *Bot) handleFileManagerAdminCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
-	return "📁 *Enhanced File Manager*\n\nAdmin file management features:\n\n• Browse all accessible drives\n• Upload and download files\n• View file details and permissions\n\nUse the buttons below or `/files` command to start browsing.", true
+	return b.t(user, "menu.file_manager_admin"), true
 }
 
 func (b *Bot) handleScreenshotAdminCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
 	// Check if running as service
-	response := "📸 *Enhanced Screenshot Service*\n\n"
+	response := b.t(user, "screenshot.admin_title") + "\n\n"
 
 	// Try to take a screenshot to test functionality
 	_, err := b.screenshotService.TakeScreenshot()
 	if err != nil {
 		if strings.Contains(err.Error(), "service") {
-			response += "⚠️ *Service Mode Detected*\n\n"
-			response += "Screenshots are not available when running as a Windows Service.\n\n"
-			response += "📝 *Alternative:* Run CupBot in interactive mode to enable screenshots.\n\n"
-			response += "🔧 *How to run interactively:*\n"
-			response += "1. Stop the Windows service\n"
-			response += "2. Run `cupbot.exe` directly from command line\n"
-			response += "3. Screenshot functionality will be available"
+			response += b.t(user, "screenshot.service_mode")
 			return response, false
 		}
-		response += fmt.Sprintf("❌ Error testing screenshot: %v\n\n", err)
+		response += b.t(user, "screenshot.test_error", err) + "\n\n"
 	} else {
-		response += "✅ Screenshot functionality is available\n\n"
+		response += b.t(user, "screenshot.available") + "\n\n"
 	}
 
-	response += "Admin screenshot features:\n\n"
-	response += "• Capture full desktop\n"
-	response += "• Configurable quality and format\n"
-	response += "• Automatic timestamping\n\n"
-	response += "Use `/screenshot` command to capture the desktop."
+	response += b.t(user, "screenshot.admin_features")
 
 	return response, true
 }
 
 func (b *Bot) handleSystemToolsCallback(user *database.User) (string, bool) {
 	if !user.IsAdmin {
-		return "❌ Access denied: Admin privileges required", false
+		return b.t(user, "error.admin_required"), false
 	}
 
-	return "🛠️ *System Tools*\n\nCollection of system monitoring and diagnostic tools:", true
+	return b.t(user, "menu.system_tools"), true
 }
 
 // File Manager Keyboard Generation Methods
 
 // generateEnhancedDriveSelectionKeyboard creates enhanced keyboard for drive selection
-func (b *Bot) generateEnhancedDriveSelectionKeyboard(drives []string) tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) generateEnhancedDriveSelectionKeyboard(lang string, drives []string) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
 	
 	if len(drives) == 0 {
 		// Add back to menu button only
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_menu"), "main_menu"),
 		})
 		return tgbotapi.NewInlineKeyboardMarkup(rows...)
 	}
@@ -1364,19 +1275,19 @@ func (b *Bot) generateEnhancedDriveSelectionKeyboard(drives []string) tgbotapi.I
 	
 	// Add back to menu button
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_menu"), "main_menu"),
 	})
 	
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 // generateEnhancedDirectoryKeyboard creates enhanced keyboard for directory navigation
-func (b *Bot) generateEnhancedDirectoryKeyboard(context *filemanager.NavigationContext, result *filemanager.PaginatedDirectoryResult) tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) generateEnhancedDirectoryKeyboard(lang string, userID int64, context *filemanager.NavigationContext, result *filemanager.PaginatedDirectoryResult) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
 	
 	// Add breadcrumb row for deeper paths (clickable breadcrumb navigation)
 	if len(context.Breadcrumbs) > 2 {
-		breadcrumbRow := b.generateBreadcrumbRow(context)
+		breadcrumbRow := b.generateBreadcrumbRow(userID, context)
 		if len(breadcrumbRow) > 0 {
 			rows = append(rows, breadcrumbRow)
 		}
@@ -1384,33 +1295,33 @@ func (b *Bot) generateEnhancedDirectoryKeyboard(context *filemanager.NavigationC
 	
 	// Add file/directory rows (1 per row for touch-friendly interface)
 	for _, file := range result.Files {
-		rows = append(rows, b.generateFileRow(file))
+		rows = append(rows, b.generateFileRow(userID, file))
 	}
 	
 	// Add pagination row if needed
 	if result.TotalPages > 1 {
-		paginationRow := b.generatePaginationRow(context, result)
+		paginationRow := b.generatePaginationRow(lang, userID, context, result)
 		if len(paginationRow) > 0 {
 			rows = append(rows, paginationRow)
 		}
 	}
 	
 	// Add navigation controls row
-	navRow := b.generateNavigationControlsRow(context)
+	navRow := b.generateNavigationControlsRow(lang, userID, context)
 	if len(navRow) > 0 {
 		rows = append(rows, navRow)
 	}
 	
 	// Add menu return row
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_menu"), "main_menu"),
 	})
 	
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
 }
 
 // generateBreadcrumbRow creates clickable breadcrumb navigation
-func (b *Bot) generateBreadcrumbRow(context *filemanager.NavigationContext) []tgbotapi.InlineKeyboardButton {
+func (b *Bot) generateBreadcrumbRow(userID int64, context *filemanager.NavigationContext) []tgbotapi.InlineKeyboardButton {
 	var buttons []tgbotapi.InlineKeyboardButton
 	
 	// Limit breadcrumbs to avoid telegram callback data limits
@@ -1424,28 +1335,28 @@ func (b *Bot) generateBreadcrumbRow(context *filemanager.NavigationContext) []tg
 	
 	for i := startIdx; i < len(context.Breadcrumbs); i++ {
 		item := context.Breadcrumbs[i]
-		encodedPath := b.fileManager.EncodePathForCallback(item.Path)
 		label := item.Name
 		if len(label) > 8 {
 			label = label[:8] + "…"
 		}
-		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, "fm_breadcrumb_"+encodedPath))
+		callbackData := b.fileCallbackData(userID, filemanager.ActionBreadcrumb, item.Path, 0)
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, callbackData))
 	}
 	
 	return buttons
 }
 
 // generateFileRow creates a button row for a file or directory
-func (b *Bot) generateFileRow(file filemanager.FileInfo) []tgbotapi.InlineKeyboardButton {
+func (b *Bot) generateFileRow(userID int64, file filemanager.FileInfo) []tgbotapi.InlineKeyboardButton {
 	var icon string
-	var callbackPrefix string
+	var action string
 	
 	if file.IsDir {
 		icon = "📁"
-		callbackPrefix = "fm_dir_"
+		action = filemanager.ActionDir
 	} else {
 		icon = "📄"
-		callbackPrefix = "fm_file_"
+		action = filemanager.ActionFile
 	}
 	
 	// Truncate long file names for better display
@@ -1460,253 +1371,86 @@ func (b *Bot) generateFileRow(file filemanager.FileInfo) []tgbotapi.InlineKeyboa
 		label = fmt.Sprintf("%s (%s)", label, sizeStr)
 	}
 	
-	encodedPath := b.fileManager.EncodePathForCallback(file.Path)
 	labelWithIcon := fmt.Sprintf("%s %s", icon, label)
 	
 	return []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData(labelWithIcon, callbackPrefix+encodedPath),
+		tgbotapi.NewInlineKeyboardButtonData(labelWithIcon, b.fileCallbackData(userID, action, file.Path, 0)),
 	}
 }
 
 // generatePaginationRow creates pagination controls
-func (b *Bot) generatePaginationRow(context *filemanager.NavigationContext, result *filemanager.PaginatedDirectoryResult) []tgbotapi.InlineKeyboardButton {
+func (b *Bot) generatePaginationRow(lang string, userID int64, context *filemanager.NavigationContext, result *filemanager.PaginatedDirectoryResult) []tgbotapi.InlineKeyboardButton {
 	var buttons []tgbotapi.InlineKeyboardButton
-	encodedPath := b.fileManager.EncodePathForCallback(context.CurrentPath)
 	
 	// Previous page button
 	if result.HasPrev {
-		prevCallback := fmt.Sprintf("fm_page_%s_%d", encodedPath, result.CurrentPage-1)
-		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("◀️ Prev", prevCallback))
+		prevCallback := b.fileCallbackData(userID, filemanager.ActionPage, context.CurrentPath, result.CurrentPage-1)
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.prev"), prevCallback))
 	}
 	
 	// Page info button (non-clickable info)
-	pageInfo := fmt.Sprintf("Page %d/%d", result.CurrentPage, result.TotalPages)
+	pageInfo := i18n.T(lang, "button.page", result.CurrentPage, result.TotalPages)
 	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(pageInfo, "fm_page_info"))
 	
 	// Next page button
 	if result.HasNext {
-		nextCallback := fmt.Sprintf("fm_page_%s_%d", encodedPath, result.CurrentPage+1)
-		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("Next ▶️", nextCallback))
+		nextCallback := b.fileCallbackData(userID, filemanager.ActionPage, context.CurrentPath, result.CurrentPage+1)
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.next"), nextCallback))
 	}
 	
 	return buttons
 }
 
 // generateNavigationControlsRow creates navigation control buttons
-func (b *Bot) generateNavigationControlsRow(context *filemanager.NavigationContext) []tgbotapi.InlineKeyboardButton {
+func (b *Bot) generateNavigationControlsRow(lang string, userID int64, context *filemanager.NavigationContext) []tgbotapi.InlineKeyboardButton {
 	var buttons []tgbotapi.InlineKeyboardButton
 	
 	// Up button (if can navigate up)
 	if context.CanNavigateUp {
-		encodedParent := b.fileManager.EncodePathForCallback(context.ParentPath)
-		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("⬆️ Up", "fm_parent_"+encodedParent))
+		upCallback := b.fileCallbackData(userID, filemanager.ActionParent, context.CurrentPath, 0)
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.up"), upCallback))
 	}
 	
 	// Drives button (always available)
-	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("🏠 Drives", "fm_drives"))
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.drives"), "fm_drives"))
 	
 	// Refresh button
-	encodedCurrent := b.fileManager.EncodePathForCallback(context.CurrentPath)
-	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("🔄 Refresh", "fm_dir_"+encodedCurrent))
+	refreshCallback := b.fileCallbackData(userID, filemanager.ActionPage, context.CurrentPath, context.CurrentPage)
+	buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.refresh"), refreshCallback))
 	
 	return buttons
 }
 
 // generateEnhancedFileDetailsKeyboard creates enhanced keyboard for file details
-func (b *Bot) generateEnhancedFileDetailsKeyboard(filePath string) tgbotapi.InlineKeyboardMarkup {
+func (b *Bot) generateEnhancedFileDetailsKeyboard(lang string, userID int64, filePath string) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
 	
 	// Add download button if download is enabled
 	if b.config.IsActionAllowed("download") {
-		encodedPath := b.fileManager.EncodePathForCallback(filePath)
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData("⬇️ Download", "fm_download_"+encodedPath),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.download"), b.fileCallbackData(userID, filemanager.ActionDownload, filePath, 0)),
 		})
 	}
 	
 	// Add properties/info button
-	encodedPath := b.fileManager.EncodePathForCallback(filePath)
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("ℹ️ Properties", "fm_file_"+encodedPath),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.properties"), b.fileCallbackData(userID, filemanager.ActionFile, filePath, 0)),
 	})
 	
 	// Add navigation buttons
 	parentPath := b.fileManager.GetParentDirectory(filePath)
-	encodedParent := b.fileManager.EncodePathForCallback(parentPath)
 	
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Directory", "fm_dir_"+encodedParent),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_directory"), b.fileCallbackData(userID, filemanager.ActionDir, parentPath, 0)),
 	})
 	
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🏠 Drives", "fm_drives"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.drives"), "fm_drives"),
 	})
 	
 	// Add back to menu button
 	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
-	})
-	
-	return tgbotapi.NewInlineKeyboardMarkup(rows...)
-}
-
-// generateDirectoryResponse creates the response text for directory listing
-func (b *Bot) generateDirectoryResponse(navContext *filemanager.NavigationContext, files []filemanager.FileInfo) string {
-	response := fmt.Sprintf("📁 *Current Directory*\n`%s`\n\n", navContext.CurrentPath)
-	
-	// Add breadcrumb
-	if len(navContext.Breadcrumbs) > 0 {
-		breadcrumb := "📍 **Path:** "
-		for i, item := range navContext.Breadcrumbs {
-			if i > 0 {
-				breadcrumb += " > "
-			}
-			breadcrumb += item.Name
-		}
-		response += breadcrumb + "\n\n"
-	}
-	
-	// Add directory/file counts
-	dirCount := 0
-	fileCount := 0
-	for _, file := range files {
-		if file.IsDir {
-			dirCount++
-		} else {
-			fileCount++
-		}
-	}
-	
-	response += fmt.Sprintf("📊 **Contents:** %d folders, %d files\n\n", dirCount, fileCount)
-	
-	if len(files) == 0 {
-		response += "📭 *This directory is empty*\n\n"
-	} else {
-		response += "💡 *Click on any item below to navigate:*\n"
-		if len(files) > 20 {
-			response += "⚠️ *Showing first 20 items*\n"
-		}
-	}
-	
-	return response
-}
-
-// generatePaginatedDirectoryResponse creates the response text for paginated directory listing
-func (b *Bot) generatePaginatedDirectoryResponse(navContext *filemanager.NavigationContext, result *filemanager.PaginatedDirectoryResult) string {
-	response := fmt.Sprintf("📁 *Current Directory*\n`%s`\n\n", navContext.CurrentPath)
-	
-	// Add breadcrumb
-	if len(navContext.Breadcrumbs) > 0 {
-		breadcrumb := "📍 **Path:** "
-		for i, item := range navContext.Breadcrumbs {
-			if i > 0 {
-				breadcrumb += " > "
-			}
-			breadcrumb += item.Name
-		}
-		response += breadcrumb + "\n\n"
-	}
-	
-	// Add directory/file counts with pagination info
-	dirCount := 0
-	fileCount := 0
-	for _, file := range result.Files {
-		if file.IsDir {
-			dirCount++
-		} else {
-			fileCount++
-		}
-	}
-	
-	response += fmt.Sprintf("📊 **Contents:** %d total items\n", result.TotalFiles)
-	if result.TotalPages > 1 {
-		response += fmt.Sprintf("📄 **Page %d of %d** (%d folders, %d files shown)\n\n", 
-			result.CurrentPage, result.TotalPages, dirCount, fileCount)
-	} else {
-		response += fmt.Sprintf("📄 **%d folders, %d files**\n\n", dirCount, fileCount)
-	}
-	
-	if len(result.Files) == 0 {
-		if result.TotalFiles == 0 {
-			response += "📭 *This directory is empty*\n\n"
-		} else {
-			response += "📭 *No items on this page*\n\n"
-		}
-	} else {
-		response += "💡 *Click on any item below to navigate:*\n"
-	}
-	
-	return response
-}
-
-// generatePaginatedDirectoryKeyboard creates keyboard for paginated directory navigation
-func (b *Bot) generatePaginatedDirectoryKeyboard(navContext *filemanager.NavigationContext, result *filemanager.PaginatedDirectoryResult) tgbotapi.InlineKeyboardMarkup {
-	var rows [][]tgbotapi.InlineKeyboardButton
-	
-	// Add file/directory buttons (1 per row for readability)
-	for _, file := range result.Files {
-		encodedPath := b.fileManager.EncodePathForCallback(file.Path)
-		
-		var icon, callbackPrefix string
-		if file.IsDir {
-			icon = "📁"
-			callbackPrefix = "fm_dir_"
-		} else {
-			icon = "📄"
-			callbackPrefix = "fm_file_"
-		}
-		
-		buttonText := fmt.Sprintf("%s %s", icon, file.Name)
-		if len(buttonText) > 30 {
-			buttonText = buttonText[:27] + "..."
-		}
-		
-		rows = append(rows, []tgbotapi.InlineKeyboardButton{
-			tgbotapi.NewInlineKeyboardButtonData(buttonText, callbackPrefix+encodedPath),
-		})
-	}
-	
-	// Add pagination buttons if needed
-	if result.TotalPages > 1 {
-		var paginationRow []tgbotapi.InlineKeyboardButton
-		encodedCurrentPath := b.fileManager.EncodePathForCallback(navContext.CurrentPath)
-		
-		if result.HasPrev {
-			paginationRow = append(paginationRow, tgbotapi.NewInlineKeyboardButtonData(
-				"◀️ Prev", fmt.Sprintf("fm_page_%s_%d", encodedCurrentPath, result.CurrentPage-1)))
-		}
-		
-		// Page indicator (non-clickable info)
-		paginationRow = append(paginationRow, tgbotapi.NewInlineKeyboardButtonData(
-			fmt.Sprintf("%d/%d", result.CurrentPage, result.TotalPages), "fm_page_info"))
-		
-		if result.HasNext {
-			paginationRow = append(paginationRow, tgbotapi.NewInlineKeyboardButtonData(
-				"Next ▶️", fmt.Sprintf("fm_page_%s_%d", encodedCurrentPath, result.CurrentPage+1)))
-		}
-		
-		rows = append(rows, paginationRow)
-	}
-	
-	// Add navigation buttons
-	var navRow []tgbotapi.InlineKeyboardButton
-	
-	if navContext.CanNavigateUp {
-		encodedParent := b.fileManager.EncodePathForCallback(navContext.ParentPath)
-		navRow = append(navRow, tgbotapi.NewInlineKeyboardButtonData(
-			"⬆️ Parent", "fm_parent_"+encodedParent))
-	}
-	
-	navRow = append(navRow, tgbotapi.NewInlineKeyboardButtonData(
-		"🏠 Drives", "fm_drives"))
-	
-	if len(navRow) > 0 {
-		rows = append(rows, navRow)
-	}
-	
-	// Add back to menu button
-	rows = append(rows, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Back to Menu", "main_menu"),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(lang, "button.back_menu"), "main_menu"),
 	})
 	
 	return tgbotapi.NewInlineKeyboardMarkup(rows...)
@@ -1714,59 +1458,18 @@ func (b *Bot) generatePaginatedDirectoryKeyboard(navContext *filemanager.Navigat
 
 // File Manager Interactive Navigation Handlers
 
-// handleFileManagerCallback processes all file manager navigation callbacks
-func (b *Bot) handleFileManagerCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
-	callbackData := callback.Data
-	
-	// Parse callback type
-	switch {
-	case callbackData == "fm_drives":
-		return b.handleFileDrivesCallback(callback, user)
-	case strings.HasPrefix(callbackData, "fm_drive_"):
-		drive := strings.TrimPrefix(callbackData, "fm_drive_")
-		return b.handleFileDriveCallback(callback, user, drive)
-	case strings.HasPrefix(callbackData, "fm_dir_"):
-		encodedPath := strings.TrimPrefix(callbackData, "fm_dir_")
-		return b.handleFileDirectoryCallback(callback, user, encodedPath)
-	case strings.HasPrefix(callbackData, "fm_file_"):
-		encodedPath := strings.TrimPrefix(callbackData, "fm_file_")
-		return b.handleFileDetailsCallback(callback, user, encodedPath)
-	case strings.HasPrefix(callbackData, "fm_parent_"):
-		encodedPath := strings.TrimPrefix(callbackData, "fm_parent_")
-		return b.handleFileParentCallback(callback, user, encodedPath)
-	case strings.HasPrefix(callbackData, "fm_download_"):
-		encodedPath := strings.TrimPrefix(callbackData, "fm_download_")
-		return b.handleFileDownloadCallback(callback, user, encodedPath)
-	case strings.HasPrefix(callbackData, "fm_page_"):
-		parts := strings.Split(strings.TrimPrefix(callbackData, "fm_page_"), "_")
-		if len(parts) >= 2 {
-			encodedPath := parts[0]
-			page := parts[1]
-			return b.handleFilePaginationCallback(callback, user, encodedPath, page)
-		}
-	case strings.HasPrefix(callbackData, "fm_breadcrumb_"):
-		encodedPath := strings.TrimPrefix(callbackData, "fm_breadcrumb_")
-		return b.handleFileBreadcrumbCallback(callback, user, encodedPath)
-	case callbackData == "fm_page_info":
-		// Non-functional page info button, just acknowledge
-		return "", true
-	}
-	
-	return "❌ Unknown file manager command", false
-}
-
 // handleFileDrivesCallback shows available drives with enhanced interface
 func (b *Bot) handleFileDrivesCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
-	response := b.fileManager.GetDriveSelectionResponse()
+	response := b.fileManager.GetDriveSelectionResponse(b.lang(user))
 	
 	// Generate enhanced drive selection keyboard
 	drives := b.fileManager.GetAvailableDrives()
-	keyboard := b.generateEnhancedDriveSelectionKeyboard(drives)
+	keyboard := b.generateEnhancedDriveSelectionKeyboard(b.lang(user), drives)
 	
 	// Update the message with keyboard
 	if err := b.updateCallbackMessage(callback, response.Content, keyboard); err != nil {
 		log.Printf("Failed to update message: %v", err)
-		return "❌ Error updating interface", false
+		return b.t(user, "error.update_interface"), false
 	}
 	
 	return "", true // Empty response since we updated the message
@@ -1779,44 +1482,46 @@ func (b *Bot) handleFileDriveCallback(callback *tgbotapi.CallbackQuery, user *da
 }
 
 // handleFileDirectoryCallback navigates to a directory
-func (b *Bot) handleFileDirectoryCallback(callback *tgbotapi.CallbackQuery, user *database.User, encodedPath string) (string, bool) {
-	path, err := b.fileManager.DecodePathFromCallback(encodedPath)
-	if err != nil {
-		return fmt.Sprintf("❌ Invalid path: %v", err), false
+func (b *Bot) handleFileDirectoryCallback(callback *tgbotapi.CallbackQuery, user *database.User, token string) (string, bool) {
+	payload, errResponse := b.resolveFileCallback(user, filemanager.ActionDir, token)
+	if payload == nil {
+		return errResponse, false
 	}
 	
-	return b.navigateToDirectory(callback, user, path)
+	return b.navigateToDirectory(callback, user, payload.Path)
 }
 
 // handleFileDetailsCallback shows enhanced file details
-func (b *Bot) handleFileDetailsCallback(callback *tgbotapi.CallbackQuery, user *database.User, encodedPath string) (string, bool) {
-	path, err := b.fileManager.DecodePathFromCallback(encodedPath)
-	if err != nil {
-		return fmt.Sprintf("❌ Invalid path: %v", err), false
+func (b *Bot) handleFileDetailsCallback(callback *tgbotapi.CallbackQuery, user *database.User, token string) (string, bool) {
+	payload, errResponse := b.resolveFileCallback(user, filemanager.ActionFile, token)
+	if payload == nil {
+		return errResponse, false
 	}
+	path := payload.Path
 	
-	response, err := b.fileManager.GetFileDetailsResponse(path)
+	response, err := b.fileManager.GetFileDetailsResponse(b.lang(user), path)
 	if err != nil {
-		return fmt.Sprintf("❌ Error: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 	
-	keyboard := b.generateEnhancedFileDetailsKeyboard(path)
+	keyboard := b.generateEnhancedFileDetailsKeyboard(b.lang(user), user.ID, path)
 	
 	// Update the message with keyboard
 	if err := b.updateCallbackMessage(callback, response.Content, keyboard); err != nil {
 		log.Printf("Failed to update message: %v", err)
-		return "❌ Error updating interface", false
+		return b.t(user, "error.update_interface"), false
 	}
 	
 	return "", true
 }
 
 // handleFileParentCallback navigates to parent directory
-func (b *Bot) handleFileParentCallback(callback *tgbotapi.CallbackQuery, user *database.User, encodedPath string) (string, bool) {
-	path, err := b.fileManager.DecodePathFromCallback(encodedPath)
-	if err != nil {
-		return fmt.Sprintf("❌ Invalid path: %v", err), false
+func (b *Bot) handleFileParentCallback(callback *tgbotapi.CallbackQuery, user *database.User, token string) (string, bool) {
+	payload, errResponse := b.resolveFileCallback(user, filemanager.ActionParent, token)
+	if payload == nil {
+		return errResponse, false
 	}
+	path := payload.Path
 	
 	parentPath := b.fileManager.GetParentDirectory(path)
 	if parentPath == path {
@@ -1828,75 +1533,97 @@ func (b *Bot) handleFileParentCallback(callback *tgbotapi.CallbackQuery, user *d
 }
 
 // handleFileDownloadCallback initiates file download
-func (b *Bot) handleFileDownloadCallback(callback *tgbotapi.CallbackQuery, user *database.User, encodedPath string) (string, bool) {
-	path, err := b.fileManager.DecodePathFromCallback(encodedPath)
-	if err != nil {
-		return fmt.Sprintf("❌ Invalid path: %v", err), false
+func (b *Bot) handleFileDownloadCallback(callback *tgbotapi.CallbackQuery, user *database.User, token string) (string, bool) {
+	payload, errResponse := b.resolveFileCallback(user, filemanager.ActionDownload, token)
+	if payload == nil {
+		return errResponse, false
 	}
 	
-	downloadPath, err := b.fileManager.DownloadFile(path)
+	downloadPath, err := b.fileManager.DownloadFile(payload.Path)
 	if err != nil {
-		return fmt.Sprintf("❌ Download failed: %v", err), false
+		return b.t(user, "files.download_error", err), false
 	}
 	
 	// Send file to user
 	doc := tgbotapi.NewDocument(callback.Message.Chat.ID, tgbotapi.FilePath(downloadPath))
-	if _, err := b.api.Send(doc); err != nil {
-		return fmt.Sprintf("❌ Failed to send file: %v", err), false
+	if _, err := b.sendDocument(doc); err != nil {
+		return b.t(user, "files.send_error", err), false
 	}
 	
-	return "✅ File downloaded and sent!", true
+	return b.t(user, "files.sent"), true
 }
 
 // handleFileBreadcrumbCallback handles breadcrumb navigation
-func (b *Bot) handleFileBreadcrumbCallback(callback *tgbotapi.CallbackQuery, user *database.User, encodedPath string) (string, bool) {
-	if encodedPath == "root" {
+func (b *Bot) handleFileBreadcrumbCallback(callback *tgbotapi.CallbackQuery, user *database.User, token string) (string, bool) {
+	if token == "root" {
 		// Navigate to drives selection
 		return b.handleFileDrivesCallback(callback, user)
 	}
 	
-	path, err := b.fileManager.DecodePathFromCallback(encodedPath)
-	if err != nil {
-		return fmt.Sprintf("❌ Invalid path: %v", err), false
+	payload, errResponse := b.resolveFileCallback(user, filemanager.ActionBreadcrumb, token)
+	if payload == nil {
+		return errResponse, false
 	}
 	
-	return b.navigateToDirectory(callback, user, path)
+	return b.navigateToDirectory(callback, user, payload.Path)
 }
 
 // handleFilePaginationCallback handles directory pagination
-func (b *Bot) handleFilePaginationCallback(callback *tgbotapi.CallbackQuery, user *database.User, encodedPath, pageStr string) (string, bool) {
-	path, err := b.fileManager.DecodePathFromCallback(encodedPath)
-	if err != nil {
-		return fmt.Sprintf("❌ Invalid path: %v", err), false
+func (b *Bot) handleFilePaginationCallback(callback *tgbotapi.CallbackQuery, user *database.User, token string) (string, bool) {
+	payload, errResponse := b.resolveFileCallback(user, filemanager.ActionPage, token)
+	if payload == nil {
+		return errResponse, false
 	}
 	
-	page, err := strconv.Atoi(pageStr)
+	return b.navigateToDirectoryPaginated(callback, user, payload.Path, payload.Page)
+}
+
+// fileCallbackData builds "fm_<action>_<token>" callback data owned by the user
+func (b *Bot) fileCallbackData(userID int64, action, path string, page int) string {
+	token, err := b.fileManager.EncodePathForCallback(userID, action, path, page)
 	if err != nil {
-		return "❌ Invalid page number", false
+		log.Printf("Failed to issue callback token for user %d: %v", userID, err)
+		return "fm_expired"
+	}
+	return "fm_" + action + "_" + token
+}
+
+// resolveFileCallback resolves a file manager token. On failure it returns
+// nil and the response to show to the user.
+func (b *Bot) resolveFileCallback(user *database.User, action, token string) (*callbacks.Payload, string) {
+	payload, err := b.fileManager.DecodePathFromCallback(user.ID, action, token)
+	switch {
+	case err == nil:
+		return payload, ""
+	case errors.Is(err, callbacks.ErrNotFound), errors.Is(err, callbacks.ErrExpired):
+		return nil, b.t(user, "files.expired")
+	case errors.Is(err, callbacks.ErrForeignUser):
+		log.Printf("User %d tried to use a callback token of another user", user.ID)
+		return nil, b.t(user, "files.foreign")
+	default:
+		return nil, b.t(user, "files.invalid_path", err)
 	}
-	
-	return b.navigateToDirectoryPaginated(callback, user, path, page)
 }
 
 // navigateToDirectoryPaginated handles enhanced paginated directory navigation
 func (b *Bot) navigateToDirectoryPaginated(callback *tgbotapi.CallbackQuery, user *database.User, path string, page int) (string, bool) {
-	response, err := b.fileManager.GetDirectoryNavigationResponse(path, page)
+	response, err := b.fileManager.GetDirectoryNavigationResponse(b.lang(user), path, page)
 	if err != nil {
-		return fmt.Sprintf("❌ Error: %v", err), false
+		return b.t(user, "error.generic", err), false
 	}
 	
 	// Get paginated result for keyboard generation
 	paginatedResult, err := b.fileManager.ListDirectoryPaginated(path, page, 15)
 	if err != nil {
-		return fmt.Sprintf("❌ Error listing directory: %v", err), false
+		return b.t(user, "files.list_error", err), false
 	}
 	
-	keyboard := b.generateEnhancedDirectoryKeyboard(response.Context, paginatedResult)
+	keyboard := b.generateEnhancedDirectoryKeyboard(b.lang(user), user.ID, response.Context, paginatedResult)
 	
 	// Update the message with keyboard
 	if err := b.updateCallbackMessage(callback, response.Content, keyboard); err != nil {
 		log.Printf("Failed to update message: %v", err)
-		return "❌ Error updating interface", false
+		return b.t(user, "error.update_interface"), false
 	}
 	
 	return "", true
@@ -1910,10 +1637,11 @@ func (b *Bot) navigateToDirectory(callback *tgbotapi.CallbackQuery, user *databa
 
 // updateCallbackMessage updates the callback message with new content and keyboard
 func (b *Bot) updateCallbackMessage(callback *tgbotapi.CallbackQuery, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
-	edit.ParseMode = tgbotapi.ModeMarkdown
-	edit.ReplyMarkup = &keyboard
-	
-	_, err := b.api.Send(edit)
-	return err
+	return b.editText(callback.Message.Chat.ID, callback.Message.MessageID, text, &keyboard)
+}
+
+// editCallbackMessage replaces the message a button was pressed on; a nil
+// keyboard removes the buttons
+func (b *Bot) editCallbackMessage(callback *tgbotapi.CallbackQuery, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	return b.editText(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
 }
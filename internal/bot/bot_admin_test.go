@@ -7,6 +7,7 @@ import (
 	"github.com/cupbot/cupbot/internal/config"
 	"github.com/cupbot/cupbot/internal/database"
 	"github.com/cupbot/cupbot/internal/filemanager"
+	"github.com/cupbot/cupbot/internal/i18n"
 	"github.com/cupbot/cupbot/internal/power"
 	"github.com/cupbot/cupbot/internal/screenshot"
 	"github.com/cupbot/cupbot/internal/system"
@@ -18,33 +19,35 @@ func TestAdminMenuAccess(t *testing.T) {
 
 	// Test admin user access
 	adminUser := &database.User{
-		ID:       123,
-		Username: "admin",
-		IsAdmin:  true,
-		IsActive: true,
+		ID:           123,
+		Username:     "admin",
+		LanguageCode: "en",
+		IsAdmin:      true,
+		IsActive:     true,
 	}
 
 	response, success := bot.handleAdminMenuCallback(adminUser)
 	if !success {
 		t.Error("Admin user should have access to admin menu")
 	}
-	if response != "🔑 *Admin Menu*\n\nSelect an action:" {
+	if response != "🔑 <b>Admin Menu</b>\n\nSelect an action:" {
 		t.Errorf("Unexpected admin menu response: %s", response)
 	}
 
 	// Test regular user access
 	regularUser := &database.User{
-		ID:       456,
-		Username: "user",
-		IsAdmin:  false,
-		IsActive: true,
+		ID:           456,
+		Username:     "user",
+		LanguageCode: "en",
+		IsAdmin:      false,
+		IsActive:     true,
 	}
 
 	response, success = bot.handleAdminMenuCallback(regularUser)
 	if success {
 		t.Error("Regular user should not have access to admin menu")
 	}
-	if response != "❌ Access denied" {
+	if response != i18n.T(i18n.English, "error.admin_required") {
 		t.Errorf("Unexpected access denied response: %s", response)
 	}
 }
@@ -53,10 +56,11 @@ func TestAdminMenuAccess(t *testing.T) {
 func TestPowerManagementCallbacks(t *testing.T) {
 	bot := createTestBot(t)
 	adminUser := &database.User{
-		ID:       123,
-		Username: "admin",
-		IsAdmin:  true,
-		IsActive: true,
+		ID:           123,
+		Username:     "admin",
+		LanguageCode: "en",
+		IsAdmin:      true,
+		IsActive:     true,
 	}
 
 	tests := []struct {
@@ -68,13 +72,13 @@ func TestPowerManagementCallbacks(t *testing.T) {
 		{
 			name:     "Power Menu",
 			handler:  bot.handlePowerMenuCallback,
-			expected: "🔌 *Power Management*",
+			expected: "🔌 <b>Power Management</b>",
 			success:  true,
 		},
 		{
 			name:     "Power Status",
 			handler:  bot.handlePowerStatusCallback,
-			expected: "ℹ️ *Power Management Status*",
+			expected: "ℹ️ <b>Power Management Status</b>",
 			success:  true,
 		},
 	}
@@ -98,10 +102,11 @@ func TestPowerManagementCallbacks(t *testing.T) {
 func TestPowerOperations(t *testing.T) {
 	bot := createTestBot(t)
 	adminUser := &database.User{
-		ID:       123,
-		Username: "admin",
-		IsAdmin:  true,
-		IsActive: true,
+		ID:           123,
+		Username:     "admin",
+		LanguageCode: "en",
+		IsAdmin:      true,
+		IsActive:     true,
 	}
 
 	// Test shutdown with delay
@@ -134,17 +139,19 @@ func TestPowerOperations(t *testing.T) {
 func TestUserManagementCallbacks(t *testing.T) {
 	bot := createTestBot(t)
 	adminUser := &database.User{
-		ID:       123,
-		Username: "admin",
-		IsAdmin:  true,
-		IsActive: true,
+		ID:           123,
+		Username:     "admin",
+		LanguageCode: "en",
+		IsAdmin:      true,
+		IsActive:     true,
 	}
 
 	regularUser := &database.User{
-		ID:       456,
-		Username: "user",
-		IsAdmin:  false,
-		IsActive: true,
+		ID:           456,
+		Username:     "user",
+		LanguageCode: "en",
+		IsAdmin:      false,
+		IsActive:     true,
 	}
 
 	tests := []struct {
@@ -165,36 +172,6 @@ func TestUserManagementCallbacks(t *testing.T) {
 			user:      regularUser,
 			expectErr: true,
 		},
-		{
-			name:      "Add Admin Menu - Admin",
-			handler:   bot.handleAddAdminMenuCallback,
-			user:      adminUser,
-			expectErr: false,
-		},
-		{
-			name:      "Remove Admin Menu - Admin",
-			handler:   bot.handleRemoveAdminMenuCallback,
-			user:      adminUser,
-			expectErr: false,
-		},
-		{
-			name:      "Ban User Menu - Admin",
-			handler:   bot.handleBanUserMenuCallback,
-			user:      adminUser,
-			expectErr: false,
-		},
-		{
-			name:      "Unban User Menu - Admin",
-			handler:   bot.handleUnbanUserMenuCallback,
-			user:      adminUser,
-			expectErr: false,
-		},
-		{
-			name:      "Delete User Menu - Admin",
-			handler:   bot.handleDeleteUserMenuCallback,
-			user:      adminUser,
-			expectErr: false,
-		},
 	}
 
 	for _, tt := range tests {
@@ -220,10 +197,11 @@ func TestUserManagementCallbacks(t *testing.T) {
 func TestEnhancedServiceCallbacks(t *testing.T) {
 	bot := createTestBot(t)
 	adminUser := &database.User{
-		ID:       123,
-		Username: "admin",
-		IsAdmin:  true,
-		IsActive: true,
+		ID:           123,
+		Username:     "admin",
+		LanguageCode: "en",
+		IsAdmin:      true,
+		IsActive:     true,
 	}
 
 	// Test file manager admin callback
@@ -255,40 +233,43 @@ func TestEnhancedServiceCallbacks(t *testing.T) {
 	}
 }
 
-// TestMenuNavigationHelpers tests the helper functions for menu navigation
-func TestMenuNavigationHelpers(t *testing.T) {
+// TestMenuNavigationKeyboards tests which keyboard each callback declares
+func TestMenuNavigationKeyboards(t *testing.T) {
+	bot := setupTestBot(t)
+	defer teardownTestBot(t, bot)
+	user := &database.User{ID: 123, LanguageCode: "en", IsAdmin: true, IsActive: true}
+
 	testCases := []struct {
-		callback string
-		isMenu   bool
-		isPower  bool
-		isUser   bool
+		callback    string
+		hasKeyboard bool
 	}{
-		{"main_menu", true, false, false},
-		{"admin_menu", true, false, false},
-		{"power_menu", true, true, false},
-		{"user_menu", true, false, true},
-		{"shutdown_now", false, true, false},
-		{"reboot_1min", false, true, false},
-		{"add_admin_menu", false, false, true},
-		{"status", false, false, false},
-		{"unknown_callback", false, false, false},
+		{"main_menu", true},
+		{"admin_menu", true},
+		{"power_menu", true},
+		{"user_menu", true},
+		{"shutdown_now", false},
+		{"reboot_1min", false},
+		{"add_admin_menu", true}, // user picker of the dialog
+		{"status", true},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.callback, func(t *testing.T) {
-			if isMenuCallback(tc.callback) != tc.isMenu {
-				t.Errorf("isMenuCallback(%s) = %v, expected %v", tc.callback, isMenuCallback(tc.callback), tc.isMenu)
-			}
-
-			if isPowerCallback(tc.callback) != tc.isPower {
-				t.Errorf("isPowerCallback(%s) = %v, expected %v", tc.callback, isPowerCallback(tc.callback), tc.isPower)
+			cb := bot.commands.callback(tc.callback)
+			if cb == nil {
+				t.Fatalf("callback %s is not registered", tc.callback)
 			}
 
-			if isUserManagementCallback(tc.callback) != tc.isUser {
-				t.Errorf("isUserManagementCallback(%s) = %v, expected %v", tc.callback, isUserManagementCallback(tc.callback), tc.isUser)
+			keyboard := resolveKeyboard(bot, user, cb.Keyboard)
+			if (keyboard != nil) != tc.hasKeyboard {
+				t.Errorf("callback %s: keyboard attached = %v, expected %v", tc.callback, keyboard != nil, tc.hasKeyboard)
 			}
 		})
 	}
+
+	if bot.commands.callback("unknown_callback") != nil {
+		t.Error("unknown callback should not be registered")
+	}
 }
 
 // TestKeyboardGeneration tests that keyboards are generated correctly
@@ -296,29 +277,29 @@ func TestKeyboardGeneration(t *testing.T) {
 	bot := createTestBot(t)
 
 	// Test main keyboard for regular user
-	keyboard := bot.getMainKeyboard(false)
+	keyboard := bot.getMainKeyboard(i18n.English, false)
 	if len(keyboard.InlineKeyboard) == 0 {
 		t.Error("Main keyboard should have buttons")
 	}
 
 	// Test main keyboard for admin (should have more buttons)
-	adminKeyboard := bot.getMainKeyboard(true)
+	adminKeyboard := bot.getMainKeyboard(i18n.English, true)
 	if len(adminKeyboard.InlineKeyboard) <= len(keyboard.InlineKeyboard) {
 		t.Error("Admin keyboard should have more buttons than regular keyboard")
 	}
 
 	// Test admin-specific keyboards
-	adminOnlyKeyboard := bot.getAdminKeyboard()
+	adminOnlyKeyboard := bot.getAdminKeyboard(i18n.English)
 	if len(adminOnlyKeyboard.InlineKeyboard) == 0 {
 		t.Error("Admin keyboard should have buttons")
 	}
 
-	powerKeyboard := bot.getPowerMenuKeyboard()
+	powerKeyboard := bot.getPowerMenuKeyboard(i18n.English)
 	if len(powerKeyboard.InlineKeyboard) == 0 {
 		t.Error("Power keyboard should have buttons")
 	}
 
-	userManagementKeyboard := bot.getUserManagementKeyboard()
+	userManagementKeyboard := bot.getUserManagementKeyboard(i18n.English)
 	if len(userManagementKeyboard.InlineKeyboard) == 0 {
 		t.Error("User management keyboard should have buttons")
 	}
@@ -348,6 +329,7 @@ func createTestBot(t *testing.T) *Bot {
 		screenshotService: screenshot.NewService(cfg),  // Initialize screenshot service
 		systemService:     system.NewService(),         // Fixed: no arguments
 		fileManager:       filemanager.NewService(cfg), // Fixed: correct field name
+		commands:          newCommandRegistry(),
 		// Other services would be initialized here
 	}
 
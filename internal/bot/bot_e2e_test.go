@@ -0,0 +1,266 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	testAdmin = tgbotapi.User{ID: 123456789, UserName: "admin", FirstName: "Admin"}
+	testUser  = tgbotapi.User{ID: 987654321, UserName: "user", FirstName: "User"}
+)
+
+// startFakeBot runs a bot against an in-process fake Bot API
+func startFakeBot(t *testing.T) (*Bot, *telegramtest.Fake) {
+	cfg := createTestConfig()
+	cfg.Bot.Workers = 4
+	cfg.Bot.QueueSize = 100
+	cfg.Bot.ShutdownTimeout = 5
+
+	db := setupTestDB(t)
+	fake := telegramtest.NewFake()
+	bot := NewWithClient(cfg, db, fake)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := bot.Start(); err != nil {
+			t.Errorf("Start failed: %v", err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		if err := bot.Stop(); err != nil {
+			t.Errorf("Stop failed: %v", err)
+		}
+		<-done
+		db.Close()
+	})
+
+	return bot, fake
+}
+
+// newFakeBot builds a bot on the fake Bot API with an admin and a regular
+// user without starting it. Options set up the feature under test.
+func newFakeBot(t *testing.T, options ...func(*testing.T, *Bot)) (*Bot, *telegramtest.Fake, *database.User, *database.User) {
+	bot := setupTestBot(t)
+	t.Cleanup(func() { teardownTestBot(t, bot) })
+	admin, user := createTestUsers(t, bot)
+	for _, option := range options {
+		option(t, bot)
+	}
+	return bot, bot.api.(*telegramtest.Fake), admin, user
+}
+
+// createTestUsers stores an admin and a regular user
+func createTestUsers(t *testing.T, bot *Bot) (*database.User, *database.User) {
+	admin := &database.User{ID: 123456789, Username: "admin", FirstName: "Admin", LanguageCode: "en", IsAdmin: true, IsActive: true}
+	user := &database.User{ID: 987654321, Username: "user", FirstName: "User", LanguageCode: "en", IsActive: true}
+	for _, u := range []*database.User{admin, user} {
+		if err := bot.db.CreateOrUpdateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return admin, user
+}
+
+// waitForReply waits for a message or edit in the chat containing text
+func waitForReply(t *testing.T, fake *telegramtest.Fake, chatID int64, text string) telegramtest.Sent {
+	t.Helper()
+	sent, ok := fake.WaitFor(2*time.Second, func(s telegramtest.Sent) bool {
+		return s.ChatID == chatID &&
+			(s.Method == telegramtest.MethodSendMessage || s.Method == telegramtest.MethodEditMessage) &&
+			strings.Contains(s.Text, text)
+	})
+	if !ok {
+		var texts []string
+		for _, s := range fake.Sent() {
+			texts = append(texts, s.Method+": "+s.Text)
+		}
+		t.Fatalf("No reply containing %q in chat %d, sent:\n%s", text, chatID, strings.Join(texts, "\n"))
+	}
+	return sent
+}
+
+func TestE2EStartPublishesCommands(t *testing.T) {
+	_, fake := startFakeBot(t)
+
+	fake.InjectMessage(testAdmin, "/start")
+	waitForReply(t, fake, testAdmin.ID, "Добро пожаловать")
+
+	userCommands, ok := fake.Commands(0)
+	if !ok || len(userCommands) == 0 {
+		t.Fatal("Expected default command list to be published")
+	}
+	adminCommands, ok := fake.Commands(testAdmin.ID)
+	if !ok || len(adminCommands) <= len(userCommands) {
+		t.Errorf("Expected admin command list in the admin chat, got %d commands", len(adminCommands))
+	}
+}
+
+func TestE2EFileBrowsing(t *testing.T) {
+	_, fake := startFakeBot(t)
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "readme.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.InjectMessage(testAdmin, "/files "+root)
+	listing := waitForReply(t, fake, testAdmin.ID, "Текущая папка")
+
+	data, ok := listing.Button("docs")
+	if !ok {
+		t.Fatal("Directory listing has no button for docs")
+	}
+	if len(data) > 64 {
+		t.Errorf("Callback data exceeds 64 bytes: %q", data)
+	}
+
+	// Opening the directory edits the listing in place
+	fake.InjectCallback(testAdmin, listing, data)
+	opened := waitForReply(t, fake, testAdmin.ID, "папок: 0, файлов: 1")
+	if opened.Method != telegramtest.MethodEditMessage || opened.MessageID != listing.MessageID {
+		t.Errorf("Expected the listing message to be edited, got %s of message %d", opened.Method, opened.MessageID)
+	}
+	if _, ok := opened.Button("readme.txt"); !ok {
+		t.Error("Opened directory has no button for readme.txt")
+	}
+
+	// Another user in the same chat can't replay the admin's button
+	fake.InjectCallback(testUser, listing, data)
+	waitForReply(t, fake, testAdmin.ID, "принадлежит другому пользователю")
+}
+
+func TestE2EPowerScheduling(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Would schedule a real shutdown on Windows")
+	}
+
+	_, fake := startFakeBot(t)
+
+	fake.InjectMessage(testAdmin, "/help")
+	help := waitForReply(t, fake, testAdmin.ID, "Команды администратора")
+
+	fake.InjectCallback(testAdmin, help, "power_menu")
+	menu := waitForReply(t, fake, testAdmin.ID, "Управление питанием")
+
+	data, ok := menu.Button("Выключить через 5 мин")
+	if !ok {
+		t.Fatal("Power menu has no 5 minute shutdown button")
+	}
+	fake.InjectCallback(testAdmin, menu, data)
+	waitForReply(t, fake, testAdmin.ID, "only supported on Windows")
+
+	// Regular users can't reach power management
+	fake.InjectMessage(testUser, "/help")
+	userHelp := waitForReply(t, fake, testUser.ID, "Основные команды")
+	fake.InjectCallback(testUser, userHelp, "power_menu")
+	waitForReply(t, fake, testUser.ID, "Доступ запрещен")
+}
+
+func TestE2EBanUser(t *testing.T) {
+	_, fake := startFakeBot(t)
+
+	fake.InjectMessage(testUser, "/uptime")
+	waitForReply(t, fake, testUser.ID, "")
+
+	fake.InjectMessage(testAdmin, "/banuser 987654321")
+	waitForReply(t, fake, testAdmin.ID, "987654321")
+
+	fake.Reset()
+	fake.InjectMessage(testUser, "/uptime")
+	waitForReply(t, fake, testUser.ID, "нет прав")
+
+	fake.InjectMessage(testAdmin, "/unbanuser 987654321")
+	waitForReply(t, fake, testAdmin.ID, "987654321")
+
+	fake.Reset()
+	fake.InjectMessage(testUser, "/banuser 123456789")
+	waitForReply(t, fake, testUser.ID, "Доступ запрещен")
+}
+
+func TestE2EBanUserDialog(t *testing.T) {
+	_, fake := startFakeBot(t)
+
+	fake.InjectMessage(testUser, "/uptime")
+	waitForReply(t, fake, testUser.ID, "")
+
+	fake.InjectMessage(testAdmin, "/help")
+	help := waitForReply(t, fake, testAdmin.ID, "Команды администратора")
+
+	// The menu asks for the user instead of explaining the command
+	fake.InjectCallback(testAdmin, help, "ban_user_menu")
+	prompt := waitForReply(t, fake, testAdmin.ID, "Отправьте ID")
+	if _, ok := prompt.Button("@user"); !ok {
+		t.Fatal("Prompt has no button for the known user")
+	}
+
+	fake.InjectMessage(testAdmin, "987654321")
+	waitForReply(t, fake, testAdmin.ID, "заблокирован")
+
+	fake.Reset()
+	fake.InjectMessage(testUser, "/uptime")
+	waitForReply(t, fake, testUser.ID, "нет прав")
+
+	// Picking from the list edits the prompt into the result
+	fake.InjectCallback(testAdmin, help, "unban_user_menu")
+	prompt = waitForReply(t, fake, testAdmin.ID, "Отправьте ID")
+	data, ok := prompt.Button("@user")
+	if !ok {
+		t.Fatal("Unban prompt has no button for the banned user")
+	}
+	fake.InjectCallback(testAdmin, prompt, data)
+	result := waitForReply(t, fake, testAdmin.ID, "разблокирован")
+	if result.Method != telegramtest.MethodEditMessage || result.MessageID != prompt.MessageID {
+		t.Errorf("Expected the prompt to be edited, got %s of message %d", result.Method, result.MessageID)
+	}
+}
+
+func TestE2EShutdownEventRecorded(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Bot.Workers = 4
+	cfg.Bot.QueueSize = 100
+	cfg.Bot.ShutdownTimeout = 5
+
+	db := setupTestDB(t)
+	defer db.Close()
+	fake := telegramtest.NewFake()
+	bot := NewWithClient(cfg, db, fake)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := bot.Start(); err != nil {
+			t.Errorf("Start failed: %v", err)
+		}
+	}()
+	fake.InjectMessage(testAdmin, "/start")
+	waitForReply(t, fake, testAdmin.ID, "Добро пожаловать")
+
+	if err := bot.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	<-done
+
+	// Right after Stop the database may be closed, as main does
+	recorded, err := db.GetEvents(database.EventFilter{Types: []string{string(events.EventShutdown)}}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 {
+		t.Errorf("Expected the shutdown event recorded by the time Stop returned, got %d", len(recorded))
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cupbot/cupbot/internal/database"
 	"github.com/cupbot/cupbot/internal/filemanager"
+	"github.com/cupbot/cupbot/internal/i18n"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -16,7 +17,7 @@ func TestEnhancedFileManagerKeyboardGeneration(t *testing.T) {
 
 	// Test enhanced drive selection keyboard
 	drives := []string{"C:", "D:"}
-	keyboard := bot.generateEnhancedDriveSelectionKeyboard(drives)
+	keyboard := bot.generateEnhancedDriveSelectionKeyboard(i18n.English, drives)
 
 	if len(keyboard.InlineKeyboard) == 0 {
 		t.Error("Enhanced drive selection keyboard should have buttons")
@@ -35,7 +36,7 @@ func TestEnhancedFileManagerKeyboardGeneration_NoDrives(t *testing.T) {
 
 	// Test with no drives
 	drives := []string{}
-	keyboard := bot.generateEnhancedDriveSelectionKeyboard(drives)
+	keyboard := bot.generateEnhancedDriveSelectionKeyboard(i18n.English, drives)
 
 	if len(keyboard.InlineKeyboard) == 0 {
 		t.Error("Enhanced drive selection keyboard should have at least back button")
@@ -81,7 +82,7 @@ func TestEnhancedDirectoryKeyboard(t *testing.T) {
 		t.Fatalf("ListDirectoryPaginated failed: %v", err)
 	}
 
-	keyboard := bot.generateEnhancedDirectoryKeyboard(context, result)
+	keyboard := bot.generateEnhancedDirectoryKeyboard(i18n.English, 123456789, context, result)
 
 	if len(keyboard.InlineKeyboard) == 0 {
 		t.Error("Enhanced directory keyboard should have buttons")
@@ -111,7 +112,7 @@ func TestFileRowGeneration(t *testing.T) {
 		Size:  0,
 	}
 
-	dirRow := bot.generateFileRow(dirInfo)
+	dirRow := bot.generateFileRow(123456789, dirInfo)
 	if len(dirRow) != 1 {
 		t.Errorf("Expected 1 button in directory row, got %d", len(dirRow))
 	}
@@ -132,7 +133,7 @@ func TestFileRowGeneration(t *testing.T) {
 		Size:  1024,
 	}
 
-	fileRow := bot.generateFileRow(fileInfo)
+	fileRow := bot.generateFileRow(123456789, fileInfo)
 	if len(fileRow) != 1 {
 		t.Errorf("Expected 1 button in file row, got %d", len(fileRow))
 	}
@@ -166,7 +167,7 @@ func TestBreadcrumbRowGeneration(t *testing.T) {
 		},
 	}
 
-	breadcrumbRow := bot.generateBreadcrumbRow(context)
+	breadcrumbRow := bot.generateBreadcrumbRow(123456789, context)
 
 	// Should have breadcrumb buttons (limited to avoid telegram limits)
 	if len(breadcrumbRow) == 0 {
@@ -202,7 +203,7 @@ func TestPaginationRowGeneration(t *testing.T) {
 		HasPrev:     true,
 	}
 
-	paginationRow := bot.generatePaginationRow(context, result)
+	paginationRow := bot.generatePaginationRow(i18n.English, 123456789, context, result)
 
 	// Should have prev, info, and next buttons
 	expectedButtons := 3
@@ -236,7 +237,7 @@ func TestNavigationControlsRowGeneration(t *testing.T) {
 		CanNavigateUp: true,
 	}
 
-	navRow := bot.generateNavigationControlsRow(context)
+	navRow := bot.generateNavigationControlsRow(i18n.English, 123456789, context)
 
 	// Should have Up, Drives, and Refresh buttons
 	expectedMinButtons := 3
@@ -251,7 +252,7 @@ func TestNavigationControlsRowGeneration(t *testing.T) {
 		CanNavigateUp: false,
 	}
 
-	navRowRoot := bot.generateNavigationControlsRow(contextRoot)
+	navRowRoot := bot.generateNavigationControlsRow(i18n.English, 123456789, contextRoot)
 
 	// Should have Drives and Refresh buttons (no Up button)
 	expectedButtonsRoot := 2
@@ -265,7 +266,7 @@ func TestEnhancedFileDetailsKeyboard(t *testing.T) {
 	defer teardownTestBot(t, bot)
 
 	testFilePath := "/test/file.txt"
-	keyboard := bot.generateEnhancedFileDetailsKeyboard(testFilePath)
+	keyboard := bot.generateEnhancedFileDetailsKeyboard(i18n.English, 123456789, testFilePath)
 
 	if len(keyboard.InlineKeyboard) == 0 {
 		t.Error("Enhanced file details keyboard should have buttons")
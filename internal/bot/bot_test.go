@@ -6,9 +6,15 @@ import (
 	"time"
 
 	"github.com/cupbot/cupbot/internal/auth"
+	"github.com/cupbot/cupbot/internal/callbacks"
 	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/conversation"
 	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+	"github.com/cupbot/cupbot/internal/filemanager"
+	"github.com/cupbot/cupbot/internal/i18n"
 	"github.com/cupbot/cupbot/internal/system"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -129,6 +135,24 @@ func TestHandleUptime(t *testing.T) {
 	}
 }
 
+func TestHandleStatusShowsSnapshotAge(t *testing.T) {
+	bot := setupTestBot(t)
+	defer teardownTestBot(t, bot)
+	bot.systemService.Start(time.Hour)
+	defer bot.systemService.Stop()
+
+	user := &database.User{ID: 123456789, Username: "testuser", IsActive: true, Language: "en"}
+	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 987654321}}
+
+	response, success := bot.handleStatus(message, user)
+	if !success {
+		t.Fatalf("Expected handleStatus to succeed, got %s", response)
+	}
+	if !contains(response, "Data as of") {
+		t.Errorf("Expected the age of the snapshot in the status, got:\n%s", response)
+	}
+}
+
 func TestHandleHistory(t *testing.T) {
 	bot := setupTestBot(t)
 	defer teardownTestBot(t, bot)
@@ -671,19 +695,23 @@ func TestHandleDeleteUser(t *testing.T) {
 
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
+		lang     string
 		input    time.Duration
 		expected string
 	}{
-		{time.Minute * 30, "30 мин."},
-		{time.Hour*2 + time.Minute*15, "2 ч. 15 мин."},
-		{time.Hour*24*3 + time.Hour*5 + time.Minute*30, "3 дн. 5 ч. 30 мин."},
-		{time.Hour * 25, "1 дн. 1 ч. 0 мин."},
+		{i18n.Russian, time.Minute * 30, "30 мин."},
+		{i18n.Russian, time.Hour*2 + time.Minute*15, "2 ч. 15 мин."},
+		{i18n.Russian, time.Hour*24*3 + time.Hour*5 + time.Minute*30, "3 дн. 5 ч. 30 мин."},
+		{i18n.Russian, time.Hour * 25, "1 дн. 1 ч. 0 мин."},
+		{i18n.English, time.Minute * 30, "30m"},
+		{i18n.English, time.Hour*2 + time.Minute*15, "2h 15m"},
+		{i18n.English, time.Hour*24*3 + time.Hour*5 + time.Minute*30, "3d 5h 30m"},
 	}
 
 	for _, test := range tests {
-		result := formatDuration(test.input)
+		result := formatDuration(test.lang, test.input)
 		if result != test.expected {
-			t.Errorf("formatDuration(%v): expected %s, got %s", test.input, test.expected, result)
+			t.Errorf("formatDuration(%s, %v): expected %s, got %s", test.lang, test.input, test.expected, result)
 		}
 	}
 }
@@ -755,13 +783,13 @@ func TestGetMainKeyboard(t *testing.T) {
 	defer teardownTestBot(t, bot)
 
 	// Test regular user keyboard
-	regularKeyboard := bot.getMainKeyboard(false)
+	regularKeyboard := bot.getMainKeyboard(i18n.English, false)
 	if len(regularKeyboard.InlineKeyboard) == 0 {
 		t.Error("Expected keyboard to have buttons")
 	}
 
 	// Test admin keyboard
-	adminKeyboard := bot.getMainKeyboard(true)
+	adminKeyboard := bot.getMainKeyboard(i18n.English, true)
 	if len(adminKeyboard.InlineKeyboard) == 0 {
 		t.Error("Expected admin keyboard to have buttons")
 	}
@@ -777,14 +805,25 @@ func setupTestBot(t *testing.T) *Bot {
 	cfg := createTestConfig()
 	db := setupTestDB(t)
 
+	callbackStore := callbacks.NewStore(db, callbacks.DefaultTTL)
+	fileManager := filemanager.NewService(cfg)
+	fileManager.SetCallbackStore(callbackStore)
+
 	// Create bot struct without API initialization
 	bot := &Bot{
-		api:           nil, // No real API for testing
+		api:           telegramtest.NewFake(), // No real API for testing
 		config:        cfg,
 		db:            db,
 		authMw:        auth.NewMiddleware(cfg, db),
 		systemService: system.NewService(),
-	}
+		fileManager:   fileManager,
+		commands:      newCommandRegistry(),
+		callbackStore: callbackStore,
+		confirmStore:  callbacks.NewStore(db, defaultConfirmTimeout),
+		conversations: conversation.NewStore(db, conversation.DefaultTimeout),
+		shells:        newShellManager(time.Minute, time.Second),
+	}
+	bot.notifications = events.NewThrottle(cfg, bot.sendEvent)
 
 	return bot
 }
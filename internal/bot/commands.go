@@ -0,0 +1,528 @@
+package bot
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newCommandRegistry declares every command and callback of the bot.
+// Adding a feature means adding its registration here.
+func newCommandRegistry() *commandRegistry {
+	r := newRegistry()
+
+	// Основные команды
+	r.addCommand(&Command{
+		Name:        "start",
+		Description: "cmd.start",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleStart(m, u)
+		},
+		Keyboard: noKeyboard,
+	})
+	r.addCommand(&Command{
+		Name:        "help",
+		Aliases:     []string{"menu"},
+		Description: "cmd.help",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleHelp(m, u)
+		},
+		Keyboard: mainKeyboard,
+	})
+	r.addCommand(&Command{
+		Name:        "status",
+		Description: "cmd.status",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleStatusInternal(u)
+		},
+		Keyboard: statusKeyboard,
+	})
+	r.addCommand(&Command{
+		Name:        "dashboard",
+		Description: "cmd.dashboard",
+		Handler:     (*Bot).handleDashboard,
+	})
+	r.addCommand(&Command{
+		Name:        "graph",
+		Usage:       "usage.graph",
+		Description: "cmd.graph",
+		Handler:     (*Bot).handleGraph,
+	})
+	r.addCommand(&Command{
+		Name:        "events",
+		Usage:       "usage.events",
+		Description: "cmd.events",
+		Handler:     (*Bot).handleEvents,
+	})
+	r.addCommand(&Command{
+		Name:        "subscribe",
+		Usage:       "usage.subscribe",
+		Description: "cmd.subscribe",
+		Handler:     (*Bot).handleSubscribe,
+		Keyboard:    subscriptionKeyboard,
+	})
+	r.addCommand(&Command{
+		Name:        "unsubscribe",
+		Usage:       "usage.unsubscribe",
+		Description: "cmd.unsubscribe",
+		Handler:     (*Bot).handleUnsubscribe,
+		Keyboard:    subscriptionKeyboard,
+	})
+	r.addCommand(&Command{
+		Name:        "uptime",
+		Description: "cmd.uptime",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleUptimeInternal(u)
+		},
+	})
+	r.addCommand(&Command{
+		Name:        "history",
+		Usage:       "[N]",
+		Description: "cmd.history",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleHistoryInternal(u, args)
+		},
+	})
+	r.addCommand(&Command{
+		Name:        "cancel",
+		Description: "cmd.cancel",
+		Handler:     (*Bot).handleCancel,
+	})
+	r.addCommand(&Command{
+		Name:        "files",
+		Usage:       "usage.path",
+		Description: "cmd.files",
+		Handler:     (*Bot).handleFiles,
+	})
+	r.addCommand(&Command{
+		Name:        "screenshot",
+		Description: "cmd.screenshot",
+		Handler:     (*Bot).handleScreenshot,
+	})
+	r.addCommand(&Command{
+		Name:        "language",
+		Usage:       "[ru|en|auto]",
+		Description: "cmd.language",
+		Handler:     (*Bot).handleLanguage,
+		Keyboard:    languageKeyboard,
+	})
+	r.addCommand(&Command{
+		Name:        "style",
+		Usage:       "[panel|log]",
+		Description: "cmd.style",
+		Handler:     (*Bot).handleStyle,
+		Keyboard:    styleKeyboard,
+	})
+
+	// Команды администратора
+	r.addCommand(&Command{
+		Name:        "users",
+		Role:        RoleAdmin,
+		Description: "cmd.users",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleUsersInternal(u)
+		},
+	})
+	r.addCommand(&Command{
+		Name:        "stats",
+		Role:        RoleAdmin,
+		Description: "cmd.stats",
+		Handler: func(b *Bot, m *tgbotapi.Message, u *database.User, args string) (string, bool) {
+			return b.handleStatsInternal(u)
+		},
+	})
+	r.addCommand(&Command{
+		Name:        "cleanup",
+		Role:        RoleAdmin,
+		Usage:       "usage.days",
+		Description: "cmd.cleanup",
+		Handler:     (*Bot).handleCleanup,
+	})
+	r.addCommand(&Command{
+		Name:        "exec",
+		Role:        RoleAdmin,
+		Usage:       "usage.exec",
+		Description: "cmd.exec",
+		Handler:     (*Bot).handleExec,
+		LogsHistory: true,
+	})
+	r.addCommand(&Command{
+		Name:        "shell",
+		Role:        RoleAdmin,
+		Description: "cmd.shell",
+		Handler:     (*Bot).handleShell,
+		LogsHistory: true,
+	})
+	r.addCommand(&Command{
+		Name:        "shell_kill",
+		Role:        RoleAdmin,
+		Description: "cmd.shell_kill",
+		Handler:     (*Bot).handleShellKill,
+	})
+	r.addCommand(&Command{
+		Name:        "ps",
+		Role:        RoleAdmin,
+		Usage:       "usage.ps",
+		Description: "cmd.ps",
+		Handler:     (*Bot).handlePs,
+	})
+	r.addCommand(&Command{
+		Name:        "services",
+		Role:        RoleAdmin,
+		Usage:       "usage.services",
+		Description: "cmd.services",
+		Handler:     (*Bot).handleServices,
+	})
+	r.addCommand(&Command{
+		Name:        "service",
+		Role:        RoleAdmin,
+		Usage:       "usage.service",
+		Description: "cmd.service",
+		Handler:     (*Bot).handleService,
+	})
+	r.addCommand(&Command{
+		Name:        "addadmin",
+		Role:        RoleAdmin,
+		Usage:       "[ID]",
+		Description: "cmd.addadmin",
+		Handler:     withCommandsRefresh((*Bot).handleAddAdmin),
+	})
+	r.addCommand(&Command{
+		Name:        "removeadmin",
+		Role:        RoleAdmin,
+		Usage:       "[ID]",
+		Description: "cmd.removeadmin",
+		Handler:     withCommandsRefresh((*Bot).handleRemoveAdmin),
+	})
+	r.addCommand(&Command{
+		Name:        "banuser",
+		Role:        RoleAdmin,
+		Usage:       "[ID]",
+		Description: "cmd.banuser",
+		Handler:     (*Bot).handleBanUser,
+	})
+	r.addCommand(&Command{
+		Name:        "unbanuser",
+		Role:        RoleAdmin,
+		Usage:       "[ID]",
+		Description: "cmd.unbanuser",
+		Handler:     (*Bot).handleUnbanUser,
+	})
+	r.addCommand(&Command{
+		Name:        "deleteuser",
+		Role:        RoleAdmin,
+		Usage:       "[ID]",
+		Description: "cmd.deleteuser",
+		Handler:     (*Bot).handleDeleteUser,
+	})
+
+	// Basic callbacks
+	r.addCallback(&Callback{Data: "status", Handler: userCallback((*Bot).handleStatusCallback), Keyboard: statusKeyboard, Panel: true})
+	r.addCallback(&Callback{Data: "uptime", Handler: userCallback((*Bot).handleUptimeCallback), Panel: true})
+	r.addCallback(&Callback{Data: "history", Handler: userCallback((*Bot).handleHistoryCallback), Panel: true})
+	r.addCallback(&Callback{Data: "users", Role: RoleAdmin, Handler: userCallback((*Bot).handleUsersCallback), Panel: true})
+	r.addCallback(&Callback{Data: "stats", Role: RoleAdmin, Handler: userCallback((*Bot).handleStatsCallback), Panel: true})
+	r.addCallback(&Callback{Data: "screenshot", Handler: userCallback((*Bot).handleScreenshotCallback)})
+	r.addCallback(&Callback{Data: "events", Handler: userCallback((*Bot).handleEventsCallback), Keyboard: eventsKeyboard, Panel: true})
+	r.addCallback(&Callback{Prefix: eventMutePrefix, Handler: (*Bot).handleEventMuteCallback, Keyboard: eventsKeyboard})
+	r.addCallback(&Callback{Prefix: eventUnmutePrefix, Handler: (*Bot).handleEventUnmuteCallback, Keyboard: eventsKeyboard, Panel: true})
+	r.addCallback(&Callback{Prefix: eventInfoPrefix, Handler: (*Bot).handleEventInfoCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: eventDigestPrefix, Handler: (*Bot).handleEventDigestCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: eventLogData, Handler: (*Bot).handleEventLogCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: eventsPagePrefix, Handler: (*Bot).handleEventsPageCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: eventShowPrefix, Handler: (*Bot).handleEventShowCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: eventAckPrefix, Role: RoleAdmin, Handler: (*Bot).handleEventAckCallback})
+	r.addCallback(&Callback{Data: subscriptionData, Handler: userCallback((*Bot).handleSubscriptionCallback), Keyboard: subscriptionKeyboard, Panel: true})
+	for _, data := range []string{subscriptionOnData, subscriptionOffData, subscriptionQuietOffData} {
+		r.addCallback(&Callback{Data: data, Handler: (*Bot).handleSubscriptionChangeCallback, Keyboard: subscriptionKeyboard, Panel: true})
+	}
+	for _, prefix := range []string{subscriptionTypePrefix, subscriptionLevelPrefix, subscriptionQuietPrefix} {
+		r.addCallback(&Callback{Prefix: prefix, Handler: (*Bot).handleSubscriptionChangeCallback, Keyboard: subscriptionKeyboard, Panel: true})
+	}
+	r.addCallback(&Callback{
+		Data:     "files",
+		Handler:  userCallback((*Bot).handleFilesCallback),
+		Keyboard: driveSelectionKeyboard,
+	})
+
+	// Pinned dashboards
+	for _, data := range []string{dashboardPauseData, dashboardResumeData, dashboardStopData} {
+		r.addCallback(&Callback{Data: data, Handler: (*Bot).handleDashboardCallback})
+	}
+
+	// Shell sessions
+	for _, data := range []string{shellInterruptData, shellKillData} {
+		r.addCallback(&Callback{Data: data, Role: RoleAdmin, Handler: (*Bot).handleShellCallback})
+	}
+
+	// Process manager; signals are always confirmed
+	r.addCallback(&Callback{Prefix: psPagePrefix, Role: RoleAdmin, Handler: (*Bot).handlePsPageCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: psInfoPrefix, Role: RoleAdmin, Handler: (*Bot).handlePsInfoCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: psTerminatePrefix, Role: RoleAdmin, Handler: (*Bot).handlePsSignalCallback, Confirm: true})
+	r.addCallback(&Callback{Prefix: psKillPrefix, Role: RoleAdmin, Handler: (*Bot).handlePsSignalCallback, Confirm: true})
+
+	// Service manager; services.allowed is checked on every action
+	r.addCallback(&Callback{Data: servicesData, Role: RoleAdmin, Handler: (*Bot).handleServicesCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: servicesPagePrefix, Role: RoleAdmin, Handler: (*Bot).handleServicesPageCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: serviceInfoPrefix, Role: RoleAdmin, Handler: (*Bot).handleServiceInfoCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: serviceDoPrefix, Role: RoleAdmin, Handler: (*Bot).handleServiceActionCallback, Keyboard: noKeyboard})
+
+	// Menu navigation
+	r.addCallback(&Callback{Data: "main_menu", Handler: userCallback((*Bot).handleMainMenuCallback), Keyboard: mainKeyboard, Panel: true})
+	r.addCallback(&Callback{Data: "menu", Handler: userCallback((*Bot).handleMenuCallback), Keyboard: mainKeyboard, Panel: true})
+	r.addCallback(&Callback{
+		Data:     "admin_menu",
+		Role:     RoleAdmin,
+		Handler:  userCallback((*Bot).handleAdminMenuCallback),
+		Keyboard: staticKeyboard((*Bot).getAdminKeyboard),
+		Panel:    true,
+	})
+
+	// Power management
+	r.addCallback(&Callback{
+		Data:     "power_menu",
+		Role:     RoleAdmin,
+		Handler:  userCallback((*Bot).handlePowerMenuCallback),
+		Keyboard: staticKeyboard((*Bot).getPowerMenuKeyboard),
+		Panel:    true,
+	})
+	r.addCallback(&Callback{Data: "shutdown_now", Role: RoleAdmin, Handler: userCallback((*Bot).handleShutdownNowCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: "reboot_now", Role: RoleAdmin, Handler: userCallback((*Bot).handleRebootNowCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: "force_shutdown", Role: RoleAdmin, Handler: shutdownCallback(0, true), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: "force_reboot", Role: RoleAdmin, Handler: rebootCallback(0, true), Keyboard: noKeyboard})
+	for _, delay := range []struct {
+		suffix string
+		delay  time.Duration
+	}{
+		{"1min", 1 * time.Minute},
+		{"5min", 5 * time.Minute},
+		{"10min", 10 * time.Minute},
+		{"30min", 30 * time.Minute},
+	} {
+		r.addCallback(&Callback{Data: "shutdown_" + delay.suffix, Role: RoleAdmin, Handler: shutdownCallback(delay.delay, false), Keyboard: noKeyboard})
+		r.addCallback(&Callback{Data: "reboot_" + delay.suffix, Role: RoleAdmin, Handler: rebootCallback(delay.delay, false), Keyboard: noKeyboard})
+	}
+	r.addCallback(&Callback{Data: "cancel_power", Role: RoleAdmin, Handler: userCallback((*Bot).handleCancelPowerCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: "power_status", Role: RoleAdmin, Handler: userCallback((*Bot).handlePowerStatusCallback), Keyboard: noKeyboard})
+
+	// User management
+	r.addCallback(&Callback{
+		Data:     "user_menu",
+		Role:     RoleAdmin,
+		Handler:  userCallback((*Bot).handleUserMenuCallback),
+		Keyboard: staticKeyboard((*Bot).getUserManagementKeyboard),
+		Panel:    true,
+	})
+	for _, menu := range []struct {
+		data   string
+		dialog string
+	}{
+		{"add_admin_menu", "addadmin"},
+		{"remove_admin_menu", "removeadmin"},
+		{"ban_user_menu", "banuser"},
+		{"unban_user_menu", "unbanuser"},
+		{"delete_user_menu", "deleteuser"},
+	} {
+		r.addCallback(&Callback{Data: menu.data, Role: RoleAdmin, Handler: dialogCallback(menu.dialog), Keyboard: dialogKeyboard(menu.dialog)})
+	}
+	r.addCallback(&Callback{Data: "list_users", Role: RoleAdmin, Handler: userCallback((*Bot).handleListUsersCallback), Keyboard: noKeyboard})
+
+	// Enhanced services
+	r.addCallback(&Callback{
+		Data:     "file_manager_admin",
+		Role:     RoleAdmin,
+		Handler:  userCallback((*Bot).handleFileManagerAdminCallback),
+		Keyboard: staticKeyboard((*Bot).getFileManagerKeyboard),
+		Panel:    true,
+	})
+	r.addCallback(&Callback{Data: "screenshot_admin", Role: RoleAdmin, Handler: userCallback((*Bot).handleScreenshotAdminCallback)})
+	r.addCallback(&Callback{
+		Data:     "system_tools",
+		Role:     RoleAdmin,
+		Handler:  userCallback((*Bot).handleSystemToolsCallback),
+		Keyboard: staticKeyboard((*Bot).getSystemToolsKeyboard),
+		Panel:    true,
+	})
+
+	// File manager interactive navigation
+	r.addCallback(&Callback{Data: "fm_drives", Handler: (*Bot).handleFileDrivesCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: "fm_page_info", Handler: ignoreCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_drive_", Handler: fileCallback("fm_drive_", (*Bot).handleFileDriveCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_dir_", Handler: fileCallback("fm_dir_", (*Bot).handleFileDirectoryCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_file_", Handler: fileCallback("fm_file_", (*Bot).handleFileDetailsCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_parent_", Handler: fileCallback("fm_parent_", (*Bot).handleFileParentCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_download_", Handler: fileCallback("fm_download_", (*Bot).handleFileDownloadCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_breadcrumb_", Handler: fileCallback("fm_breadcrumb_", (*Bot).handleFileBreadcrumbCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_page_", Handler: fileCallback("fm_page_", (*Bot).handleFilePaginationCallback), Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: "fm_expired", Handler: expiredFileCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: "fm_", Handler: unknownFileCallback, Keyboard: noKeyboard})
+
+	// Language of the replies
+	r.addCallback(&Callback{Prefix: languagePrefix, Handler: (*Bot).handleLanguageCallback, Keyboard: noKeyboard})
+
+	// Menu style: one panel edited in place or a log of messages
+	r.addCallback(&Callback{Prefix: stylePrefix, Handler: (*Bot).handleStyleCallback, Keyboard: noKeyboard})
+
+	// Confirmation of actions listed in bot.confirm.actions
+	r.addCallback(&Callback{Prefix: confirmOKPrefix, Handler: (*Bot).handleConfirmCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Prefix: confirmNoPrefix, Handler: (*Bot).handleConfirmCancelCallback, Keyboard: noKeyboard})
+
+	// Interactive prompts; the dialog re-checks its role on every answer
+	r.addCallback(&Callback{Prefix: dialogPickPrefix, Handler: (*Bot).handleDialogPickCallback, Keyboard: noKeyboard})
+	r.addCallback(&Callback{Data: dialogCancelData, Handler: (*Bot).handleDialogCancelCallback, Keyboard: noKeyboard})
+	r.addDialog(userDialog("addadmin",
+		"dialog.addadmin",
+		func(target, user *database.User) bool { return target.IsActive && !target.IsAdmin },
+		withCommandsRefresh((*Bot).handleAddAdmin)))
+	r.addDialog(userDialog("removeadmin",
+		"dialog.removeadmin",
+		func(target, user *database.User) bool { return target.IsAdmin && target.ID != user.ID },
+		withCommandsRefresh((*Bot).handleRemoveAdmin)))
+	r.addDialog(userDialog("banuser",
+		"dialog.banuser",
+		func(target, user *database.User) bool { return target.IsActive && target.ID != user.ID },
+		(*Bot).handleBanUser))
+	r.addDialog(userDialog("unbanuser",
+		"dialog.unbanuser",
+		func(target, user *database.User) bool { return !target.IsActive },
+		(*Bot).handleUnbanUser))
+	r.addDialog(userDialog("deleteuser",
+		"dialog.deleteuser",
+		func(target, user *database.User) bool { return target.ID != user.ID },
+		(*Bot).handleDeleteUser))
+
+	return r
+}
+
+// userCallback adapts handlers that only need the user
+func userCallback(handler func(b *Bot, user *database.User) (string, bool)) callbackFunc {
+	return func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+		return handler(b, user)
+	}
+}
+
+// fileCallback passes the callback data without its prefix to a file manager handler
+func fileCallback(prefix string, handler func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User, value string) (string, bool)) callbackFunc {
+	return func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+		return handler(b, callback, user, strings.TrimPrefix(callback.Data, prefix))
+	}
+}
+
+// dialogCallback starts a dialog in the chat the button was pressed in
+func dialogCallback(name string) callbackFunc {
+	return func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+		return b.startDialog(callback.Message.Chat.ID, user, name)
+	}
+}
+
+// dialogKeyboard shows the options of the first step of a dialog
+func dialogKeyboard(name string) keyboardFunc {
+	return func(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+		dialog := b.commands.dialog(name)
+		if dialog == nil || !dialog.Role.allows(user) {
+			return menuKeyboard(b, user)
+		}
+		return dialog.keyboard(b, user, 0)
+	}
+}
+
+func expiredFileCallback(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	return b.t(user, "files.expired"), false
+}
+
+func unknownFileCallback(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	return b.t(user, "files.unknown"), false
+}
+
+// ignoreCallback acknowledges informational buttons that have no action
+func ignoreCallback(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	return "", true
+}
+
+func shutdownCallback(delay time.Duration, force bool) callbackFunc {
+	return func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+		return b.handleShutdownDelayCallback(user, delay, force)
+	}
+}
+
+func rebootCallback(delay time.Duration, force bool) callbackFunc {
+	return func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+		return b.handleRebootDelayCallback(user, delay, force)
+	}
+}
+
+// withCommandsRefresh republishes the command list of the target user after
+// a successful role change, so the Telegram menu matches the new role
+func withCommandsRefresh(handler commandFunc) commandFunc {
+	return func(b *Bot, message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+		response, success := handler(b, message, user, args)
+		if success {
+			if userID, err := parseUserID(args); err == nil {
+				b.publishUserCommands(userID)
+			}
+		}
+		return response, success
+	}
+}
+
+func driveSelectionKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	drives := b.fileManager.GetAvailableDrives()
+	if len(drives) == 0 {
+		return nil
+	}
+	kb := b.generateEnhancedDriveSelectionKeyboard(b.lang(user), drives)
+	return &kb
+}
+
+// publishCommands registers the command lists shown in the Telegram menu:
+// user commands by default, translated for every client language with a
+// catalog, and the full list in every admin's private chat
+func (b *Bot) publishCommands() {
+	defaultScope := tgbotapi.NewSetMyCommandsWithScope(tgbotapi.NewBotCommandScopeDefault(), b.commands.botCommands(RoleUser, b.defaultLanguage())...)
+	if err := b.api.SetCommands(defaultScope); err != nil {
+		log.Printf("Failed to set bot commands: %v", err)
+	}
+	for _, lang := range i18n.Languages() {
+		scope := tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeDefault(), lang, b.commands.botCommands(RoleUser, lang)...)
+		if err := b.api.SetCommands(scope); err != nil {
+			log.Printf("Failed to set %s bot commands: %v", lang, err)
+		}
+	}
+
+	users, err := b.db.GetAllUsers()
+	if err != nil {
+		log.Printf("Failed to load users for bot commands: %v", err)
+		return
+	}
+	for _, u := range users {
+		if u.IsAdmin && u.IsActive {
+			b.publishUserCommands(u.ID)
+		}
+	}
+}
+
+// publishUserCommands sets or clears the admin command list in a user's
+// private chat (for private chats the chat ID equals the user ID). The list
+// is in the language of the user.
+func (b *Bot) publishUserCommands(userID int64) {
+	scope := tgbotapi.NewBotCommandScopeChat(userID)
+
+	var err error
+	if b.authMw.RequireAdmin(userID) {
+		lang := b.defaultLanguage()
+		if user, err := b.db.GetUser(userID); err == nil {
+			lang = b.lang(user)
+		}
+		err = b.api.SetCommands(tgbotapi.NewSetMyCommandsWithScope(scope, b.commands.botCommands(RoleAdmin, lang)...))
+	} else {
+		err = b.api.DeleteCommands(tgbotapi.NewDeleteMyCommandsWithScope(scope))
+	}
+
+	if err != nil {
+		log.Printf("Failed to update bot commands for user %d: %v", userID, err)
+	}
+}
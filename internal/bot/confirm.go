@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/callbacks"
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// confirmAction is the callback token action of staged actions
+	confirmAction = "confirm"
+	// confirmOKPrefix and confirmNoPrefix start the data of the Confirm and
+	// Cancel buttons, followed by the token of the staged action
+	confirmOKPrefix = "cf_ok_"
+	confirmNoPrefix = "cf_no_"
+	// defaultConfirmTimeout applies when bot.confirm.timeout is not set
+	defaultConfirmTimeout = time.Minute
+)
+
+// commandAction names a command in bot.confirm.actions
+func commandAction(name string) string {
+	return "/" + name
+}
+
+// confirmTimeout returns how long a staged action waits for confirmation
+func confirmTimeout(cfg *config.Config) time.Duration {
+	if cfg.Bot.Confirm.Timeout <= 0 {
+		return defaultConfirmTimeout
+	}
+	return time.Duration(cfg.Bot.Confirm.Timeout) * time.Second
+}
+
+// formatTimeout shows a timeout like formatDuration, or in seconds when it
+// isn't a whole number of minutes
+func formatTimeout(lang string, d time.Duration) string {
+	if d%time.Minute == 0 {
+		return formatDuration(lang, d)
+	}
+	return i18n.T(lang, "duration.seconds", int(d.Seconds()))
+}
+
+// stagedAction is the payload of a confirmation. A command keeps the
+// message that ran it, so that its handler sees the same sender, chat and
+// arguments once it is confirmed.
+type stagedAction struct {
+	Action  string            `json:"action"`
+	Args    string            `json:"args,omitempty"`
+	Message *tgbotapi.Message `json:"message,omitempty"`
+}
+
+// stageAction keeps an action until its user confirms it and returns the
+// Confirm / Cancel prompt. Commands are staged with their arguments and
+// message.
+func (b *Bot) stageAction(user *database.User, staged stagedAction, label render.HTML) (string, bool, *tgbotapi.InlineKeyboardMarkup) {
+	data, err := json.Marshal(staged)
+	if err != nil {
+		return b.t(user, "error.generic", err), false, menuKeyboard(b, user)
+	}
+	token, err := b.confirmStore.Issue(user.ID, confirmAction, string(data), 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false, menuKeyboard(b, user)
+	}
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.confirm"), confirmOKPrefix+token),
+		tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.cancel"), confirmNoPrefix+token),
+	))
+	response := b.t(user, "confirm.prompt", label, formatTimeout(b.lang(user), confirmTimeout(b.config)))
+	return response, true, &kb
+}
+
+// stageCommand stages a command listed in bot.confirm.actions along with
+// the message that ran it
+func (b *Bot) stageCommand(message *tgbotapi.Message, user *database.User, name, args string) (string, bool, *tgbotapi.InlineKeyboardMarkup) {
+	label := "/" + name
+	if args != "" {
+		label += " " + args
+	}
+	// Only what handlers read is kept, not the messages it replies to
+	kept := &tgbotapi.Message{
+		MessageID: message.MessageID,
+		From:      message.From,
+		Date:      message.Date,
+		Chat:      message.Chat,
+		Text:      message.Text,
+		Entities:  message.Entities,
+	}
+	return b.stageAction(user, stagedAction{Action: commandAction(name), Args: args, Message: kept}, render.Code(label))
+}
+
+// stageCallback stages a button listed in bot.confirm.actions, naming it
+// after the pressed button
+func (b *Bot) stageCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool, *tgbotapi.InlineKeyboardMarkup) {
+	label := render.Code(callback.Data)
+	if markup := callback.Message.ReplyMarkup; markup != nil {
+		for _, row := range markup.InlineKeyboard {
+			for _, button := range row {
+				if button.CallbackData != nil && *button.CallbackData == callback.Data {
+					label = render.HTML("<b>" + render.Escape(button.Text) + "</b>")
+				}
+			}
+		}
+	}
+	return b.stageAction(user, stagedAction{Action: callback.Data}, label)
+}
+
+// resolveConfirmation loads the staged action behind a Confirm or Cancel
+// button and makes the button single-use. On failure it returns the reply.
+func (b *Bot) resolveConfirmation(user *database.User, token string) (*stagedAction, string) {
+	payload, err := b.confirmStore.Resolve(token, user.ID)
+	switch {
+	case errors.Is(err, callbacks.ErrForeignUser):
+		return nil, b.t(user, "confirm.foreign")
+	case errors.Is(err, callbacks.ErrNotFound), errors.Is(err, callbacks.ErrExpired):
+		return nil, b.t(user, "confirm.expired")
+	case err != nil:
+		return nil, b.t(user, "error.generic", err)
+	case payload.Action != confirmAction:
+		return nil, b.t(user, "confirm.expired")
+	}
+
+	var staged stagedAction
+	if err := json.Unmarshal([]byte(payload.Path), &staged); err != nil || staged.Action == "" {
+		return nil, b.t(user, "confirm.expired")
+	}
+	if err := b.confirmStore.Revoke(token); err != nil {
+		return nil, b.t(user, "error.generic", err)
+	}
+	return &staged, ""
+}
+
+// runConfirmedAction runs a staged command on the message that ran it or a
+// staged callback, checking the role again since it may have changed while
+// the prompt was shown
+func (b *Bot) runConfirmedAction(callback *tgbotapi.CallbackQuery, user *database.User, staged *stagedAction) (string, bool, *tgbotapi.InlineKeyboardMarkup) {
+	if name, ok := strings.CutPrefix(staged.Action, "/"); ok {
+		cmd := b.commands.command(name)
+		if cmd == nil {
+			return b.t(user, "error.unknown_action"), false, menuKeyboard(b, user)
+		}
+		if !cmd.Role.allows(user) {
+			return b.t(user, "error.admin_required"), false, menuKeyboard(b, user)
+		}
+		message := staged.Message
+		if message == nil {
+			message = callback.Message
+		}
+		response, success := cmd.Handler(b, message, user, staged.Args)
+		return response, success, resolveKeyboard(b, user, cmd.Keyboard)
+	}
+
+	cb := b.commands.callback(staged.Action)
+	if cb == nil {
+		return b.t(user, "error.unknown_action"), false, menuKeyboard(b, user)
+	}
+	if !cb.Role.allows(user) {
+		return b.t(user, "error.admin_required"), false, menuKeyboard(b, user)
+	}
+	pressed := *callback
+	pressed.Data = staged.Action
+	response, success := cb.Handler(b, &pressed, user)
+	return response, success, resolveKeyboard(b, user, cb.Keyboard)
+}
+
+// handleConfirmCallback runs the staged action from its Confirm button
+func (b *Bot) handleConfirmCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	staged, response := b.resolveConfirmation(user, strings.TrimPrefix(callback.Data, confirmOKPrefix))
+	if staged == nil {
+		return response, false
+	}
+
+	log.Printf("User %d (%s) confirmed %s %s", user.ID, user.Username, staged.Action, staged.Args)
+
+	response, success, keyboard := b.runConfirmedAction(callback, user, staged)
+	b.authMw.LogCommand(user.ID, "confirm:"+staged.Action, staged.Args, success, response)
+
+	// The prompt turns into the result
+	if response == "" {
+		return "", success
+	}
+	if err := b.editCallbackMessage(callback, response, keyboard); err != nil {
+		log.Printf("Failed to update message: %v", err)
+		return response, success
+	}
+	return "", success
+}
+
+// handleConfirmCancelCallback drops the staged action from its Cancel button
+func (b *Bot) handleConfirmCancelCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	staged, response := b.resolveConfirmation(user, strings.TrimPrefix(callback.Data, confirmNoPrefix))
+	if staged == nil {
+		return response, false
+	}
+
+	response = b.t(user, "action.cancelled")
+	b.authMw.LogCommand(user.ID, "cancel:"+staged.Action, staged.Args, true, response)
+
+	if err := b.editCallbackMessage(callback, response, menuKeyboard(b, user)); err != nil {
+		log.Printf("Failed to update message: %v", err)
+		return response, true
+	}
+	return "", true
+}
@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/callbacks"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pressButton sends a callback for a button of a message recorded by the fake
+func pressButton(t *testing.T, bot *Bot, from *database.User, on telegramtest.Sent, label string) {
+	t.Helper()
+	data, ok := on.Button(label)
+	if !ok {
+		t.Fatalf("No %q button on message %q", label, on.Text)
+	}
+	bot.handleCallbackQuery(&tgbotapi.CallbackQuery{
+		ID:      "cb",
+		From:    &tgbotapi.User{ID: from.ID},
+		Message: &tgbotapi.Message{MessageID: on.MessageID, Chat: &tgbotapi.Chat{ID: on.ChatID}, ReplyMarkup: on.Keyboard},
+		Data:    data,
+	}, from)
+}
+
+// commandMessage is a message marked as a bot command
+func commandMessage(from *database.User, text string) *tgbotapi.Message {
+	message := plainMessage(from, text)
+	length := len(text)
+	if i := strings.Index(text, " "); i > 0 {
+		length = i
+	}
+	message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	return message
+}
+
+// keyboardMessage is a bot message carrying the keyboard
+func keyboardMessage(chatID int64, kb tgbotapi.InlineKeyboardMarkup) telegramtest.Sent {
+	return telegramtest.Sent{ChatID: chatID, MessageID: 1, Keyboard: &kb}
+}
+
+// lastSent returns the last message or edit containing text
+func lastSent(t *testing.T, fake *telegramtest.Fake, text string) telegramtest.Sent {
+	t.Helper()
+	sent := fake.Sent()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].Method != telegramtest.MethodAnswerCallback && containsString(sent[i].Text, text) {
+			return sent[i]
+		}
+	}
+	t.Fatalf("Nothing sent containing %q", text)
+	return telegramtest.Sent{}
+}
+
+// withConfirm asks to confirm actions
+func withConfirm(actions ...string) func(*testing.T, *Bot) {
+	return func(t *testing.T, bot *Bot) {
+		bot.config.Bot.Confirm.Actions = actions
+	}
+}
+
+func historyCommands(t *testing.T, bot *Bot, userID int64) []string {
+	history, err := bot.db.GetCommandHistory(userID, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, h := range history {
+		commands = append(commands, h.Command)
+	}
+	return commands
+}
+
+func TestConfirmCommand(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, withConfirm("/deleteuser"))
+
+	bot.handleMessage(commandMessage(admin, "/deleteuser 987654321"), admin)
+	prompt := lastSent(t, fake, "Confirm action")
+	if !containsString(prompt.Text, "/deleteuser 987654321") || !containsString(prompt.Text, "within 1m to proceed") {
+		t.Errorf("Prompt should name the staged command and the timeout, got: %s", prompt.Text)
+	}
+	if _, err := bot.db.GetUser(user.ID); err != nil {
+		t.Fatal("User should not be deleted before confirmation")
+	}
+
+	// Another user can't confirm
+	pressButton(t, bot, user, prompt, "Confirm")
+	lastSent(t, fake, "belongs to another user")
+	if _, err := bot.db.GetUser(user.ID); err != nil {
+		t.Fatal("User should not be deleted by a foreign confirmation")
+	}
+
+	pressButton(t, bot, admin, prompt, "Confirm")
+	result := lastSent(t, fake, "deleted")
+	if result.Method != telegramtest.MethodEditMessage || result.MessageID != prompt.MessageID {
+		t.Errorf("Expected the prompt to be edited into the result, got %s of message %d", result.Method, result.MessageID)
+	}
+	if _, err := bot.db.GetUser(user.ID); err == nil {
+		t.Error("User should be deleted after confirmation")
+	}
+
+	// The button is single-use
+	fake.Reset()
+	pressButton(t, bot, admin, prompt, "Confirm")
+	lastSent(t, fake, "expired")
+
+	commands := historyCommands(t, bot, admin.ID)
+	for _, expected := range []string{"deleteuser", "confirm:/deleteuser"} {
+		if !containsAny(commands, expected) {
+			t.Errorf("Expected %s in command history, got %v", expected, commands)
+		}
+	}
+}
+
+func TestConfirmReplaysCommandMessage(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, withConfirm("/probe"))
+	var replayed *tgbotapi.Message
+	bot.commands.addCommand(&Command{
+		Name:        "probe",
+		Description: "cmd.status",
+		Handler: func(b *Bot, message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+			replayed = message
+			return "probed", true
+		},
+	})
+
+	bot.handleMessage(commandMessage(admin, "/probe disk 2"), admin)
+	prompt := lastSent(t, fake, "Confirm action")
+	pressButton(t, bot, admin, prompt, "Confirm")
+	lastSent(t, fake, "probed")
+
+	// The handler sees the command, not the prompt the bot sent
+	if replayed == nil || replayed.From == nil || replayed.From.ID != admin.ID {
+		t.Fatalf("Expected the admin's message replayed, got %+v", replayed)
+	}
+	if replayed.Command() != "probe" || replayed.CommandArguments() != "disk 2" {
+		t.Errorf("Expected /probe with its arguments, got %q %q", replayed.Command(), replayed.CommandArguments())
+	}
+}
+
+func TestFormatTimeout(t *testing.T) {
+	tests := []struct {
+		lang     string
+		input    time.Duration
+		expected string
+	}{
+		{i18n.English, time.Minute, "1m"},
+		{i18n.English, 30 * time.Second, "30s"},
+		{i18n.English, 90 * time.Second, "90s"},
+		{i18n.Russian, 5 * time.Minute, "5 мин."},
+		{i18n.Russian, 45 * time.Second, "45 сек."},
+	}
+	for _, test := range tests {
+		if result := formatTimeout(test.lang, test.input); result != test.expected {
+			t.Errorf("formatTimeout(%s, %v): expected %s, got %s", test.lang, test.input, test.expected, result)
+		}
+	}
+}
+
+func TestConfirmCallbackCancel(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, withConfirm("uptime"))
+
+	menu := keyboardMessage(admin.ID, bot.getMainKeyboard(i18n.English, true))
+	pressButton(t, bot, admin, menu, "Uptime")
+	prompt := lastSent(t, fake, "Confirm action")
+	if !containsString(prompt.Text, "Uptime") {
+		t.Errorf("Prompt should name the pressed button, got: %s", prompt.Text)
+	}
+
+	pressButton(t, bot, admin, prompt, "Cancel")
+	lastSent(t, fake, "Cancelled")
+
+	fake.Reset()
+	pressButton(t, bot, admin, prompt, "Confirm")
+	lastSent(t, fake, "expired")
+
+	commands := historyCommands(t, bot, admin.ID)
+	for _, expected := range []string{"callback:uptime", "cancel:uptime"} {
+		if !containsAny(commands, expected) {
+			t.Errorf("Expected %s in command history, got %v", expected, commands)
+		}
+	}
+	if containsAny(commands, "confirm:uptime") {
+		t.Error("A cancelled action must not run")
+	}
+}
+
+func TestConfirmExpiry(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, withConfirm("/deleteuser"))
+	bot.confirmStore = callbacks.NewStore(bot.db, time.Millisecond)
+
+	bot.handleMessage(commandMessage(admin, "/deleteuser 987654321"), admin)
+	prompt := lastSent(t, fake, "Confirm action")
+
+	time.Sleep(10 * time.Millisecond)
+	pressButton(t, bot, admin, prompt, "Confirm")
+	lastSent(t, fake, "expired")
+	if _, err := bot.db.GetUser(user.ID); err != nil {
+		t.Error("User should not be deleted after the confirmation expired")
+	}
+}
+
+func TestConfirmDefaultPowerActions(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, withConfirm("shutdown_now", "force_shutdown", "force_reboot"))
+
+	power := keyboardMessage(admin.ID, bot.getPowerMenuKeyboard(i18n.English))
+	for _, label := range []string{"Shutdown Now", "Force Shutdown", "Force Reboot"} {
+		fake.Reset()
+		pressButton(t, bot, admin, power, label)
+		prompt := lastSent(t, fake, "Confirm action")
+		if !containsString(prompt.Text, label) {
+			t.Errorf("Prompt should name %s, got: %s", label, prompt.Text)
+		}
+		if _, ok := prompt.Button("Confirm"); !ok {
+			t.Errorf("%s: expected a Confirm button", label)
+		}
+	}
+}
+
+func TestConfirmDialogStagesDelete(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, withConfirm("/deleteuser"))
+
+	if _, success := bot.startDialog(admin.ID, admin, "deleteuser"); !success {
+		t.Fatal("Expected the dialog to start")
+	}
+	bot.handleMessage(plainMessage(admin, "987654321"), admin)
+
+	lastSent(t, fake, "Confirm action")
+	if _, err := bot.db.GetUser(user.ID); err != nil {
+		t.Error("The dialog should stage the deletion instead of running it")
+	}
+}
+
+func containsAny(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
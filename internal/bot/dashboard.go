@@ -0,0 +1,395 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	"github.com/cupbot/cupbot/internal/system"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback data of the dashboard buttons; the dashboard is the message the
+// button is on
+const (
+	dashboardPauseData  = "dash_pause"
+	dashboardResumeData = "dash_resume"
+	dashboardStopData   = "dash_stop"
+)
+
+// maxDashboardBackoff caps the wait after Telegram asks to slow down, unless
+// Telegram itself asks for longer
+const maxDashboardBackoff = 5 * time.Minute
+
+// dashboard is a pinned message refreshed by its own goroutine. Only that
+// goroutine edits the message; buttons change the state and wake it up.
+type dashboard struct {
+	chatID    int64
+	messageID int
+	user      *database.User
+
+	stop chan struct{}
+	wake chan struct{}
+
+	mu       sync.Mutex
+	paused   bool
+	stopped  bool
+	shutdown bool // stopped because the bot is stopping
+
+	// Owned by the refresh goroutine
+	text string
+}
+
+func newDashboard(chatID int64, user *database.User) *dashboard {
+	return &dashboard{
+		chatID: chatID,
+		user:   user,
+		stop:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// setPaused pauses or resumes the refreshes
+func (d *dashboard) setPaused(paused bool) {
+	d.mu.Lock()
+	d.paused = paused
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *dashboard) isPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// close stops the refreshes once; shutdown tells the user why
+func (d *dashboard) close(shutdown bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	d.stopped = true
+	d.shutdown = shutdown
+	close(d.stop)
+}
+
+// dashboardManager keeps the running dashboards of every chat
+type dashboardManager struct {
+	interval   time.Duration
+	maxPerChat int
+	// render builds the dashboard text, replaced in tests
+	render func(d *dashboard) string
+
+	mu      sync.Mutex
+	byChat  map[int64][]*dashboard
+	closed  bool
+	running sync.WaitGroup
+}
+
+func newDashboardManager(interval time.Duration, maxPerChat int) *dashboardManager {
+	return &dashboardManager{
+		interval:   interval,
+		maxPerChat: maxPerChat,
+		byChat:     make(map[int64][]*dashboard),
+	}
+}
+
+// full reports whether a chat can't start another dashboard
+func (m *dashboardManager) full(chatID int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed || len(m.byChat[chatID]) >= m.maxPerChat
+}
+
+// add registers a dashboard whose message is sent. The dispatcher handles
+// the updates of a chat one at a time, so the check in full still holds.
+func (m *dashboardManager) add(d *dashboard) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return false
+	}
+	m.byChat[d.chatID] = append(m.byChat[d.chatID], d)
+	m.running.Add(1)
+	return true
+}
+
+// find returns the running dashboard shown in a message
+func (m *dashboardManager) find(chatID int64, messageID int) *dashboard {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.byChat[chatID] {
+		if d.messageID == messageID {
+			return d
+		}
+	}
+	return nil
+}
+
+// remove forgets a dashboard whose goroutine is done
+func (m *dashboardManager) remove(d *dashboard) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dashboards := m.byChat[d.chatID]
+	for i, other := range dashboards {
+		if other == d {
+			m.byChat[d.chatID] = append(dashboards[:i:i], dashboards[i+1:]...)
+			break
+		}
+	}
+	if len(m.byChat[d.chatID]) == 0 {
+		delete(m.byChat, d.chatID)
+	}
+	m.running.Done()
+}
+
+// stop stops every dashboard and waits for the final edits, but not longer
+// than timeout
+func (m *dashboardManager) stop(timeout time.Duration) error {
+	m.mu.Lock()
+	m.closed = true
+	count := 0
+	for _, dashboards := range m.byChat {
+		for _, d := range dashboards {
+			d.close(true)
+			count++
+		}
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s stopping %d dashboards", timeout, count)
+	}
+}
+
+// handleDashboard обрабатывает команду /dashboard
+func (b *Bot) handleDashboard(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	chatID := message.Chat.ID
+	if b.dashboards.full(chatID) {
+		return b.t(user, "dashboard.limit", b.dashboards.maxPerChat), false
+	}
+
+	d := newDashboard(chatID, user)
+	d.text = b.dashboards.render(d)
+	sent, err := b.sendText(chatID, b.dashboardMessage(d, false), dashboardKeyboard(b, user, false))
+	if err != nil {
+		log.Printf("Failed to send dashboard: %v", err)
+		return "", false
+	}
+	d.messageID = sent.MessageID
+
+	pin := tgbotapi.PinChatMessageConfig{ChatID: chatID, MessageID: sent.MessageID, DisableNotification: true}
+	if err := b.api.PinChatMessage(pin); err != nil {
+		log.Printf("Failed to pin dashboard in chat %d: %v", chatID, err)
+	}
+
+	if !b.dashboards.add(d) {
+		b.finishDashboard(d)
+		return "", false
+	}
+	go b.runDashboard(d)
+
+	return "", true
+}
+
+// runDashboard refreshes a dashboard every interval until it is stopped or
+// its message is gone. Rate limit errors stretch the wait.
+func (b *Bot) runDashboard(d *dashboard) {
+	defer b.dashboards.remove(d)
+
+	interval := b.dashboards.interval
+	var backoff time.Duration
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			b.finishDashboard(d)
+			return
+		case <-d.wake:
+		case <-timer.C:
+			if d.isPaused() {
+				timer.Reset(interval)
+				continue
+			}
+		}
+
+		paused := d.isPaused()
+		if !paused {
+			d.text = b.dashboards.render(d)
+		}
+
+		next := interval
+		err := b.editMessage(d.chatID, d.messageID, b.dashboardMessage(d, paused), dashboardKeyboard(b, d.user, paused))
+		switch {
+		case err == nil || isNotModifiedError(err):
+			backoff = 0
+		default:
+			wait, limited := dashboardBackoff(err, backoff, interval)
+			if !limited {
+				log.Printf("Dashboard %d in chat %d stopped: %v", d.messageID, d.chatID, err)
+				return
+			}
+			log.Printf("Dashboard %d in chat %d rate limited, next refresh in %s", d.messageID, d.chatID, wait)
+			backoff, next = wait, wait
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(next)
+	}
+}
+
+// finishDashboard leaves the last snapshot in the message without buttons
+// and unpins it
+func (b *Bot) finishDashboard(d *dashboard) {
+	d.mu.Lock()
+	reason := "dashboard.stopped"
+	if d.shutdown {
+		reason = "dashboard.shutdown"
+	}
+	d.mu.Unlock()
+
+	if err := b.editMessage(d.chatID, d.messageID, d.text+"\n\n"+b.t(d.user, reason), nil); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to finish dashboard %d in chat %d: %v", d.messageID, d.chatID, err)
+	}
+	if err := b.api.UnpinChatMessage(tgbotapi.UnpinChatMessageConfig{ChatID: d.chatID, MessageID: d.messageID}); err != nil {
+		log.Printf("Failed to unpin dashboard %d in chat %d: %v", d.messageID, d.chatID, err)
+	}
+}
+
+// dashboardBackoff returns the wait after Telegram refused an edit for
+// flooding: at least retry_after and twice the previous wait, up to
+// maxDashboardBackoff. It reports false for other errors.
+func dashboardBackoff(err error, previous, interval time.Duration) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	wait := 2 * previous
+	if wait < 2*interval {
+		wait = 2 * interval
+	}
+	if wait > maxDashboardBackoff {
+		wait = maxDashboardBackoff
+	}
+	if retryAfter := time.Duration(apiErr.RetryAfter) * time.Second; wait < retryAfter {
+		wait = retryAfter
+	}
+	return wait, true
+}
+
+// dashboardMessage adds the refresh state to the last snapshot
+func (b *Bot) dashboardMessage(d *dashboard, paused bool) string {
+	if paused {
+		return d.text + "\n\n" + b.t(d.user, "dashboard.paused")
+	}
+	return d.text + "\n\n" + b.t(d.user, "dashboard.updated", time.Now().Format("15:04:05"), b.dashboards.interval)
+}
+
+// dashboardText renders a snapshot of the system. Network throughput comes
+// from the snapshot, which has none until the collector took two.
+func (b *Bot) dashboardText(d *dashboard) string {
+	lang := b.lang(d.user)
+	info, err := b.systemService.GetSystemInfo()
+	if err != nil {
+		return i18n.T(lang, "status.error", err)
+	}
+
+	lines := []string{i18n.T(lang, "dashboard.title", info.Hostname), ""}
+
+	if len(info.CPUInfo.Usage) > 0 {
+		usage := 0.0
+		for _, u := range info.CPUInfo.Usage {
+			usage += u
+		}
+		lines = append(lines, i18n.T(lang, "dashboard.cpu", usage/float64(len(info.CPUInfo.Usage))))
+	}
+	lines = append(lines, i18n.T(lang, "dashboard.memory",
+		system.FormatBytes(info.MemoryInfo.Used), system.FormatBytes(info.MemoryInfo.Total), info.MemoryInfo.UsedPercent))
+	for _, disk := range info.DiskInfo {
+		if disk.Total > 0 {
+			lines = append(lines, i18n.T(lang, "dashboard.disk", disk.Mountpoint, disk.UsedPercent, system.FormatBytes(disk.Free)))
+		}
+	}
+
+	if info.RateInterval > 0 {
+		var rate float64
+		for _, net := range info.NetworkInfo {
+			rate += net.SentRate + net.RecvRate
+		}
+		lines = append(lines, i18n.T(lang, "dashboard.network", system.FormatBytes(uint64(rate))))
+	} else {
+		lines = append(lines, i18n.T(lang, "dashboard.network_measuring"))
+	}
+
+	lines = append(lines, "")
+	if op := b.powerService.GetScheduledOperation(); op != nil {
+		lines = append(lines, i18n.T(lang, "dashboard.power", op.Type, time.Until(op.ScheduledAt).Round(time.Second)))
+	} else {
+		lines = append(lines, i18n.T(lang, "dashboard.no_power"))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// handleDashboardCallback pauses, resumes or stops the dashboard the button
+// is on. Only its owner and admins control it.
+func (b *Bot) handleDashboardCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	d := b.dashboards.find(callback.Message.Chat.ID, callback.Message.MessageID)
+	if d == nil {
+		return b.t(user, "dashboard.not_running"), false
+	}
+	if d.user.ID != user.ID && !user.IsAdmin {
+		return b.t(user, "dashboard.foreign"), false
+	}
+
+	switch callback.Data {
+	case dashboardPauseData:
+		d.setPaused(true)
+	case dashboardResumeData:
+		d.setPaused(false)
+	case dashboardStopData:
+		d.close(false)
+	}
+	return "", true
+}
+
+// dashboardKeyboard offers pause or resume and stop
+func dashboardKeyboard(b *Bot, user *database.User, paused bool) *tgbotapi.InlineKeyboardMarkup {
+	toggle := tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.pause"), dashboardPauseData)
+	if paused {
+		toggle = tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.resume"), dashboardResumeData)
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(toggle, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.stop"), dashboardStopData)),
+	)
+	return &kb
+}
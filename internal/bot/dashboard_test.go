@@ -0,0 +1,193 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/power"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// withDashboards refreshes dashboards every few milliseconds with numbered
+// snapshots, counting them in renders
+func withDashboards(renders *atomic.Int32) func(*testing.T, *Bot) {
+	return func(t *testing.T, bot *Bot) {
+		bot.dashboards = newDashboardManager(10*time.Millisecond, 1)
+		bot.dashboards.render = func(d *dashboard) string {
+			return fmt.Sprintf("snapshot %d", renders.Add(1))
+		}
+		t.Cleanup(func() { bot.dashboards.stop(time.Second) })
+	}
+}
+
+// waitForEdit waits for an edit of a message containing text
+func waitForEdit(t *testing.T, fake *telegramtest.Fake, messageID int, text string) telegramtest.Sent {
+	t.Helper()
+	sent, ok := fake.WaitFor(time.Second, func(s telegramtest.Sent) bool {
+		return s.Method == telegramtest.MethodEditMessage && s.MessageID == messageID && strings.Contains(s.Text, text)
+	})
+	if !ok {
+		t.Fatalf("Message %d was not edited to contain %q", messageID, text)
+	}
+	return sent
+}
+
+func TestDashboardRefreshesPinnedMessage(t *testing.T) {
+	renders := &atomic.Int32{}
+	bot, fake, _, user := newFakeBot(t, withDashboards(renders))
+
+	bot.handleMessage(commandMessage(user, "/dashboard"), user)
+	dash := lastSent(t, fake, "snapshot 1")
+	if _, ok := fake.WaitFor(0, func(s telegramtest.Sent) bool {
+		return s.Method == telegramtest.MethodPinMessage && s.MessageID == dash.MessageID
+	}); !ok {
+		t.Error("Dashboard should be pinned")
+	}
+	waitForEdit(t, fake, dash.MessageID, "snapshot 3")
+
+	// A paused dashboard keeps its snapshot
+	pressButton(t, bot, user, dash, "Pause")
+	paused := waitForEdit(t, fake, dash.MessageID, "Paused")
+	if _, ok := paused.Button("Resume"); !ok {
+		t.Error("Paused dashboard should offer Resume")
+	}
+	count := renders.Load()
+	time.Sleep(50 * time.Millisecond)
+	if renders.Load() != count {
+		t.Error("Paused dashboard should not refresh")
+	}
+
+	pressButton(t, bot, user, paused, "Resume")
+	waitForEdit(t, fake, dash.MessageID, fmt.Sprintf("snapshot %d", count+1))
+
+	pressButton(t, bot, user, dash, "Stop")
+	stopped := waitForEdit(t, fake, dash.MessageID, "Dashboard stopped")
+	if stopped.Keyboard != nil {
+		t.Error("Stopped dashboard should have no buttons")
+	}
+	if _, ok := fake.WaitFor(time.Second, func(s telegramtest.Sent) bool {
+		return s.Method == telegramtest.MethodUnpinMessage && s.MessageID == dash.MessageID
+	}); !ok {
+		t.Error("Stopped dashboard should be unpinned")
+	}
+
+	// The stale buttons no longer control anything
+	pressButton(t, bot, user, dash, "Pause")
+	lastSent(t, fake, "no longer running")
+}
+
+func TestDashboardLimitPerChat(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, withDashboards(&atomic.Int32{}))
+
+	bot.handleMessage(commandMessage(user, "/dashboard"), user)
+	dash := lastSent(t, fake, "snapshot")
+
+	bot.handleMessage(commandMessage(user, "/dashboard"), user)
+	lastSent(t, fake, "already has 1 running dashboard")
+
+	// Other chats have their own limit
+	bot.handleMessage(commandMessage(admin, "/dashboard"), admin)
+	if other := lastSent(t, fake, "snapshot"); other.ChatID != admin.ID {
+		t.Errorf("Expected a dashboard in the admin chat, got chat %d", other.ChatID)
+	}
+
+	// Only the owner and admins control a dashboard
+	stranger := *user
+	stranger.ID = 555
+	pressButton(t, bot, &stranger, dash, "Stop")
+	lastSent(t, fake, "Only the user who started this dashboard")
+	if bot.dashboards.find(dash.ChatID, dash.MessageID) == nil {
+		t.Fatal("Dashboard should still run")
+	}
+}
+
+func TestDashboardStopsWhenMessageIsGone(t *testing.T) {
+	bot, fake, _, user := newFakeBot(t, withDashboards(&atomic.Int32{}))
+
+	bot.handleMessage(commandMessage(user, "/dashboard"), user)
+	dash := lastSent(t, fake, "snapshot")
+	fake.DeleteMessage(dash.ChatID, dash.MessageID)
+
+	deadline := time.Now().Add(time.Second)
+	for bot.dashboards.find(dash.ChatID, dash.MessageID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Dashboard of a deleted message should stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDashboardsStopOnShutdown(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, withDashboards(&atomic.Int32{}))
+
+	bot.handleMessage(commandMessage(user, "/dashboard"), user)
+	bot.handleMessage(commandMessage(admin, "/dashboard"), admin)
+
+	if err := bot.dashboards.stop(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := 0
+	for _, sent := range fake.Sent() {
+		if sent.Method == telegramtest.MethodEditMessage && strings.Contains(sent.Text, "shutting down") {
+			stopped++
+		}
+	}
+	if stopped != 2 {
+		t.Errorf("Expected 2 dashboards stopped for shutdown, got %d", stopped)
+	}
+	if !bot.dashboards.full(user.ID) {
+		t.Error("No dashboard should start after shutdown")
+	}
+}
+
+func TestDashboardBackoff(t *testing.T) {
+	interval := 10 * time.Second
+	tooMany := func(retryAfter int) error {
+		return &tgbotapi.Error{Code: 429, Message: "Too Many Requests: retry after", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: retryAfter}}
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		previous time.Duration
+		expected time.Duration
+		limited  bool
+	}{
+		{"Other error", fmt.Errorf("Bad Request: message to edit not found"), 0, 0, false},
+		{"First limit", tooMany(3), 0, 20 * time.Second, true},
+		{"Retry after wins", tooMany(60), 0, time.Minute, true},
+		{"Doubles", tooMany(3), 40 * time.Second, 80 * time.Second, true},
+		{"Capped", tooMany(3), 4 * time.Minute, maxDashboardBackoff, true},
+		{"Retry after over the cap", tooMany(600), 4 * time.Minute, 10 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, limited := dashboardBackoff(tt.err, tt.previous, interval)
+			if wait != tt.expected || limited != tt.limited {
+				t.Errorf("Expected %s/%v, got %s/%v", tt.expected, tt.limited, wait, limited)
+			}
+		})
+	}
+}
+
+func TestDashboardText(t *testing.T) {
+	bot, _, _, user := newFakeBot(t)
+	bot.powerService = power.NewService(bot.config)
+
+	// Rates need a previous snapshot
+	if _, err := bot.systemService.GetSystemInfo(); err != nil {
+		t.Fatal(err)
+	}
+	text := bot.dashboardText(newDashboard(user.ID, user))
+	for _, expected := range []string{"Dashboard", "Memory", "Network", "/s", "No scheduled power operations"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Dashboard should contain %q, got:\n%s", expected, text)
+		}
+	}
+}
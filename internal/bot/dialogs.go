@@ -0,0 +1,313 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/cupbot/cupbot/internal/conversation"
+	"github.com/cupbot/cupbot/internal/database"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// dialogPickPrefix starts the callback data of an option button
+	dialogPickPrefix = "dlg_pick_"
+	// dialogCancelData is the callback data of the Cancel button
+	dialogCancelData = "dlg_cancel"
+	// maxDialogOptions limits the option buttons under a prompt
+	maxDialogOptions = 20
+)
+
+// errInvalidUserID is a catalog key, see DialogStep.Parse
+var errInvalidUserID = errors.New("dialog.invalid_user_id")
+
+// DialogOption is a value offered as a button instead of typing it. Value
+// goes into callback data, so it must be short.
+type DialogOption struct {
+	Label string
+	Value string
+}
+
+// DialogStep asks for one value
+type DialogStep struct {
+	Name   string // key of the answer in the collected values
+	Prompt string // catalog key
+	// Options lists values to pick from; nil means the value can only be typed
+	Options func(b *Bot, user *database.User) []DialogOption
+	// Parse validates an answer and returns the value to keep. Its error,
+	// a catalog key or plain text, is shown to the user, who is asked again.
+	Parse func(input string) (string, error)
+}
+
+// Dialog is a multi-step prompt. The next plain-text message or option
+// button in the chat answers the current step. The state is kept in the
+// database, so a prompt can be answered after a restart.
+type Dialog struct {
+	Name  string
+	Role  Role
+	Steps []*DialogStep
+	// Complete runs with the answers of every step. A nil keyboard means
+	// the "Menu" button.
+	Complete func(b *Bot, message *tgbotapi.Message, user *database.User, values map[string]string) (string, bool, *tgbotapi.InlineKeyboardMarkup)
+}
+
+// keyboard builds the option buttons of a step followed by Cancel
+func (d *Dialog) keyboard(b *Bot, user *database.User, step int) *tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if options := d.Steps[step].Options; options != nil {
+		for i, option := range options(b, user) {
+			if i == maxDialogOptions {
+				break
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(option.Label, dialogPickPrefix+option.Value),
+			))
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.cancel"), dialogCancelData),
+	))
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &kb
+}
+
+// startDialog begins a dialog in the chat and returns the first prompt
+func (b *Bot) startDialog(chatID int64, user *database.User, name string) (string, bool) {
+	dialog := b.commands.dialog(name)
+	if dialog == nil {
+		return b.t(user, "dialog.unknown"), false
+	}
+	if !dialog.Role.allows(user) {
+		return b.t(user, "error.admin_required"), false
+	}
+
+	if _, err := b.conversations.Start(chatID, user.ID, name); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	return b.t(user, dialog.Steps[0].Prompt), true
+}
+
+// advanceDialog answers the current step. It returns the reply with the
+// keyboard of the next step, or the result of the dialog once complete.
+func (b *Bot) advanceDialog(state *conversation.State, message *tgbotapi.Message, user *database.User, input string) (string, bool, *tgbotapi.InlineKeyboardMarkup) {
+	dialog := b.commands.dialog(state.Dialog)
+	if dialog == nil || state.Step >= len(dialog.Steps) {
+		b.endDialog(state.ChatID)
+		return b.t(user, "dialog.unavailable"), false, menuKeyboard(b, user)
+	}
+	// The role may have been revoked since the dialog started
+	if !dialog.Role.allows(user) {
+		b.endDialog(state.ChatID)
+		return b.t(user, "error.admin_required"), false, menuKeyboard(b, user)
+	}
+
+	step := dialog.Steps[state.Step]
+	value, err := step.Parse(strings.TrimSpace(input))
+	if err != nil {
+		// Ask again with a fresh timeout
+		if err := b.conversations.Save(state); err != nil {
+			log.Printf("Failed to save dialog of chat %d: %v", state.ChatID, err)
+		}
+		return fmt.Sprintf("❌ %s\n\n%s", b.t(user, err.Error()), b.t(user, step.Prompt)), false, dialog.keyboard(b, user, state.Step)
+	}
+
+	state.Values[step.Name] = value
+	state.Step++
+	if state.Step < len(dialog.Steps) {
+		if err := b.conversations.Save(state); err != nil {
+			return b.t(user, "error.generic", err), false, menuKeyboard(b, user)
+		}
+		return b.t(user, dialog.Steps[state.Step].Prompt), true, dialog.keyboard(b, user, state.Step)
+	}
+
+	b.endDialog(state.ChatID)
+	response, success, keyboard := dialog.Complete(b, message, user, state.Values)
+	if keyboard == nil {
+		keyboard = menuKeyboard(b, user)
+	}
+	return response, success, keyboard
+}
+
+// endDialog forgets the dialog of a chat
+func (b *Bot) endDialog(chatID int64) {
+	if err := b.conversations.Delete(chatID); err != nil {
+		log.Printf("Failed to delete dialog of chat %d: %v", chatID, err)
+	}
+}
+
+// handleDialogInput answers the pending dialog of the chat with a plain
+// text message. Without a dialog waiting for this user the text is ignored.
+func (b *Bot) handleDialogInput(message *tgbotapi.Message, user *database.User) {
+	if message.Text == "" {
+		return
+	}
+
+	state, err := b.conversations.Get(message.Chat.ID)
+	if errors.Is(err, conversation.ErrNotFound) {
+		return
+	}
+	if err != nil && !errors.Is(err, conversation.ErrExpired) {
+		log.Printf("Failed to load dialog of chat %d: %v", message.Chat.ID, err)
+		return
+	}
+	if state.UserID != user.ID {
+		return
+	}
+
+	var response string
+	var success bool
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+
+	if errors.Is(err, conversation.ErrExpired) {
+		b.endDialog(state.ChatID)
+		response = b.t(user, "dialog.expired")
+		keyboard = menuKeyboard(b, user)
+	} else {
+		log.Printf("User %d (%s) answered dialog %s", user.ID, user.Username, state.Dialog)
+		response, success, keyboard = b.advanceDialog(state, message, user, message.Text)
+	}
+
+	if _, err := b.sendText(message.Chat.ID, response, keyboard); err != nil {
+		log.Printf("Failed to send message: %v", err)
+	}
+
+	b.authMw.LogCommand(user.ID, "dialog:"+state.Dialog, message.Text, success, response)
+}
+
+// callbackDialog loads the dialog a button press belongs to. On failure it
+// returns nil and the reply to show.
+func (b *Bot) callbackDialog(callback *tgbotapi.CallbackQuery, user *database.User) (*conversation.State, string) {
+	chatID := callback.Message.Chat.ID
+
+	state, err := b.conversations.Get(chatID)
+	switch {
+	case errors.Is(err, conversation.ErrNotFound):
+		return nil, b.t(user, "dialog.expired")
+	case err != nil && !errors.Is(err, conversation.ErrExpired):
+		return nil, b.t(user, "error.generic", err)
+	case state.UserID != user.ID:
+		return nil, b.t(user, "dialog.foreign")
+	case errors.Is(err, conversation.ErrExpired):
+		b.endDialog(chatID)
+		return nil, b.t(user, "dialog.expired")
+	}
+
+	return state, ""
+}
+
+// handleDialogPickCallback answers the current step with an option button
+func (b *Bot) handleDialogPickCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	state, response := b.callbackDialog(callback, user)
+	if state == nil {
+		return response, false
+	}
+
+	value := strings.TrimPrefix(callback.Data, dialogPickPrefix)
+	response, success, keyboard := b.advanceDialog(state, callback.Message, user, value)
+
+	// The prompt turns into the next prompt or the result
+	if err := b.editCallbackMessage(callback, response, keyboard); err != nil {
+		log.Printf("Failed to update message: %v", err)
+		return response, success
+	}
+	return "", success
+}
+
+// handleDialogCancelCallback ends the dialog from its Cancel button
+func (b *Bot) handleDialogCancelCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	state, response := b.callbackDialog(callback, user)
+	if state == nil {
+		return response, false
+	}
+
+	b.endDialog(state.ChatID)
+
+	response = b.t(user, "action.cancelled")
+	if err := b.editCallbackMessage(callback, response, menuKeyboard(b, user)); err != nil {
+		log.Printf("Failed to update message: %v", err)
+		return response, true
+	}
+	return "", true
+}
+
+// handleCancel обрабатывает команду /cancel
+func (b *Bot) handleCancel(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	state, _ := b.conversations.Get(message.Chat.ID)
+	if state == nil || state.UserID != user.ID {
+		return b.t(user, "cancel.nothing"), false
+	}
+
+	b.endDialog(message.Chat.ID)
+	return b.t(user, "cancel.done"), true
+}
+
+// userDialog asks for a user ID with the prompt catalog key, offering the
+// users matching filter, and runs the command handler with it. If the
+// command of the same name needs confirmation, it is staged instead.
+func userDialog(name, prompt string, filter func(target, user *database.User) bool, handler commandFunc) *Dialog {
+	return &Dialog{
+		Name: name,
+		Role: RoleAdmin,
+		Steps: []*DialogStep{{
+			Name:    "user_id",
+			Prompt:  prompt,
+			Options: userOptions(filter),
+			Parse:   parseDialogUserID,
+		}},
+		Complete: func(b *Bot, message *tgbotapi.Message, user *database.User, values map[string]string) (string, bool, *tgbotapi.InlineKeyboardMarkup) {
+			if b.config.RequiresConfirmation(commandAction(name)) {
+				return b.stageCommand(message, user, name, values["user_id"])
+			}
+			response, success := handler(b, message, user, values["user_id"])
+			return response, success, nil
+		},
+	}
+}
+
+// userOptions lists the known users matching filter
+func userOptions(filter func(target, user *database.User) bool) func(b *Bot, user *database.User) []DialogOption {
+	return func(b *Bot, user *database.User) []DialogOption {
+		users, err := b.db.GetAllUsers()
+		if err != nil {
+			log.Printf("Failed to load users for prompt: %v", err)
+			return nil
+		}
+
+		var options []DialogOption
+		for _, target := range users {
+			if filter(target, user) {
+				options = append(options, DialogOption{
+					Label: userOptionLabel(target),
+					Value: strconv.FormatInt(target.ID, 10),
+				})
+			}
+		}
+		return options
+	}
+}
+
+// userOptionLabel names a user on a button
+func userOptionLabel(u *database.User) string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if u.Username != "" {
+		name = strings.TrimSpace(name + " @" + u.Username)
+	}
+	if name == "" {
+		return strconv.FormatInt(u.ID, 10)
+	}
+	return fmt.Sprintf("%s (%d)", name, u.ID)
+}
+
+// parseDialogUserID accepts a numeric user ID
+func parseDialogUserID(input string) (string, error) {
+	userID, err := strconv.ParseInt(input, 10, 64)
+	if err != nil || userID <= 0 {
+		return "", errInvalidUserID
+	}
+	return strconv.FormatInt(userID, 10), nil
+}
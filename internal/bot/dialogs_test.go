@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func plainMessage(from *database.User, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: from.ID},
+		Chat:      &tgbotapi.Chat{ID: from.ID, Type: "private"},
+		Text:      text,
+	}
+}
+
+func TestUserManagementDialogs(t *testing.T) {
+	bot, _, admin, user := newFakeBot(t)
+
+	for _, name := range []string{"addadmin", "removeadmin", "banuser", "unbanuser", "deleteuser"} {
+		t.Run(name, func(t *testing.T) {
+			response, success := bot.startDialog(admin.ID, admin, name)
+			if !success {
+				t.Fatalf("Expected the dialog to start, got: %s", response)
+			}
+			if bot.commands.dialog(name).keyboard(bot, admin, 0) == nil {
+				t.Error("Expected a prompt keyboard")
+			}
+
+			response, success = bot.startDialog(user.ID, user, name)
+			if success || !containsString(response, "Access denied") {
+				t.Errorf("Expected access denied for a regular user, got: %s", response)
+			}
+		})
+	}
+}
+
+func TestDialogUserOptions(t *testing.T) {
+	bot, _, admin, user := newFakeBot(t)
+
+	kb := bot.commands.dialog("banuser").keyboard(bot, admin, 0)
+	sent := telegramtest.Sent{Keyboard: kb}
+
+	data, ok := sent.Button("@user")
+	if !ok || data != "dlg_pick_987654321" {
+		t.Errorf("Expected a button for user %d, got %q", user.ID, data)
+	}
+	if _, ok := sent.Button("@admin"); ok {
+		t.Error("Admins should not be offered to ban themselves")
+	}
+	if data, ok := sent.Button("Cancel"); !ok || data != dialogCancelData {
+		t.Errorf("Expected a Cancel button, got %q", data)
+	}
+}
+
+func TestDialogValidationAndCancel(t *testing.T) {
+	bot, _, admin, user := newFakeBot(t)
+	fake := bot.api.(*telegramtest.Fake)
+
+	if _, success := bot.startDialog(admin.ID, admin, "banuser"); !success {
+		t.Fatal("Expected the dialog to start")
+	}
+
+	// An invalid answer asks again
+	bot.handleMessage(plainMessage(admin, "not a number"), admin)
+	if _, ok := fake.WaitFor(0, func(s telegramtest.Sent) bool { return containsString(s.Text, "Invalid user ID") }); !ok {
+		t.Error("Expected the prompt to be repeated")
+	}
+	if state, err := bot.conversations.Get(admin.ID); err != nil || state.Step != 0 {
+		t.Errorf("Expected the dialog to stay at the first step, got %+v, %v", state, err)
+	}
+
+	// Text from another user doesn't answer the dialog
+	fake.Reset()
+	bot.handleDialogInput(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: admin.ID}, Text: "987654321"}, user)
+	if len(fake.Sent()) != 0 {
+		t.Error("Expected text from another user to be ignored")
+	}
+
+	response, success := bot.handleCancel(plainMessage(admin, "/cancel"), admin, "")
+	if !success {
+		t.Fatalf("Expected /cancel to succeed, got: %s", response)
+	}
+	if _, success := bot.handleCancel(plainMessage(admin, "/cancel"), admin, ""); success {
+		t.Error("Expected nothing left to cancel")
+	}
+
+	// Plain text without a dialog is ignored
+	fake.Reset()
+	bot.handleMessage(plainMessage(admin, "987654321"), admin)
+	if len(fake.Sent()) != 0 {
+		t.Error("Expected plain text without a dialog to be ignored")
+	}
+	if u, _ := bot.db.GetUser(user.ID); !u.IsActive {
+		t.Error("User should not be banned after cancel")
+	}
+}
+
+func TestDialogSurvivesRestart(t *testing.T) {
+	bot, _, admin, user := newFakeBot(t)
+
+	if _, success := bot.startDialog(admin.ID, admin, "banuser"); !success {
+		t.Fatal("Expected the dialog to start")
+	}
+
+	// A new bot over the same database completes the dialog
+	fake := telegramtest.NewFake()
+	restarted := NewWithClient(bot.config, bot.db, fake)
+	restarted.handleMessage(plainMessage(admin, "987654321"), admin)
+
+	if _, ok := fake.WaitFor(0, func(s telegramtest.Sent) bool { return containsString(s.Text, "banned") }); !ok {
+		t.Errorf("Expected the ban to be confirmed, sent: %+v", fake.Sent())
+	}
+	if u, _ := bot.db.GetUser(user.ID); u.IsActive {
+		t.Error("Expected the user to be banned")
+	}
+	if _, err := restarted.conversations.Get(admin.ID); err == nil {
+		t.Error("Expected the completed dialog to be removed")
+	}
+}
@@ -0,0 +1,160 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// updateDispatcher runs update handlers on a fixed number of workers.
+// Updates of one chat are processed sequentially in arrival order, while
+// different chats are served concurrently.
+type updateDispatcher struct {
+	handle    func(tgbotapi.Update)
+	queueSize int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues map[int64][]tgbotapi.Update
+	// ready holds chats with pending updates that no worker is serving
+	ready []int64
+	// scheduled marks chats that are in ready or being served
+	scheduled map[int64]bool
+	closed    bool
+
+	workers sync.WaitGroup
+}
+
+// newUpdateDispatcher starts the workers. queueSize limits pending updates
+// per chat; updates beyond it are dropped.
+func newUpdateDispatcher(workers, queueSize int, handle func(tgbotapi.Update)) *updateDispatcher {
+	d := &updateDispatcher{
+		handle:    handle,
+		queueSize: queueSize,
+		queues:    make(map[int64][]tgbotapi.Update),
+		scheduled: make(map[int64]bool),
+	}
+	d.cond = sync.NewCond(&d.mu)
+
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+
+	return d
+}
+
+// submit queues an update for its chat. It never blocks.
+func (d *updateDispatcher) submit(update tgbotapi.Update) {
+	chatID := updateChatID(update)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		log.Printf("Dispatcher stopped, dropping update %d", update.UpdateID)
+		return
+	}
+	if len(d.queues[chatID]) >= d.queueSize {
+		log.Printf("Update queue of chat %d is full, dropping update %d", chatID, update.UpdateID)
+		return
+	}
+
+	d.queues[chatID] = append(d.queues[chatID], update)
+	if !d.scheduled[chatID] {
+		d.scheduled[chatID] = true
+		d.ready = append(d.ready, chatID)
+		d.cond.Signal()
+	}
+}
+
+// stop rejects new updates and waits for queued and in-flight ones to be
+// handled. It returns an error if they don't finish within the timeout.
+func (d *updateDispatcher) stop(timeout time.Duration) error {
+	d.mu.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		d.mu.Lock()
+		pending := 0
+		for _, queue := range d.queues {
+			pending += len(queue)
+		}
+		d.mu.Unlock()
+		return fmt.Errorf("timed out after %s with %d updates pending", timeout, pending)
+	}
+}
+
+// work serves ready chats one update at a time, so a busy chat can't starve others
+func (d *updateDispatcher) work() {
+	defer d.workers.Done()
+
+	for {
+		d.mu.Lock()
+		for len(d.ready) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.ready) == 0 {
+			d.mu.Unlock()
+			return
+		}
+
+		chatID := d.ready[0]
+		d.ready = d.ready[1:]
+		update := d.queues[chatID][0]
+		d.queues[chatID] = d.queues[chatID][1:]
+		d.mu.Unlock()
+
+		d.run(update)
+
+		d.mu.Lock()
+		if len(d.queues[chatID]) > 0 {
+			d.ready = append(d.ready, chatID)
+			d.cond.Signal()
+		} else {
+			delete(d.queues, chatID)
+			delete(d.scheduled, chatID)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// run handles one update, keeping the worker alive if the handler panics
+func (d *updateDispatcher) run(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic while handling update %d: %v\n%s", update.UpdateID, r, debug.Stack())
+		}
+	}()
+
+	d.handle(update)
+}
+
+// updateChatID returns the chat an update belongs to, falling back to the sender
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil && update.Message.Chat != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	}
+	if from := update.SentFrom(); from != nil {
+		return from.ID
+	}
+	return 0
+}
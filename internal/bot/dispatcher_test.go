@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func chatUpdate(id int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: chatID},
+		},
+	}
+}
+
+func TestDispatcherPerChatOrdering(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[int64][]int)
+
+	d := newUpdateDispatcher(4, 100, func(update tgbotapi.Update) {
+		// Give later updates a chance to overtake if ordering were broken
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := update.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], update.UpdateID)
+	})
+
+	for i := 0; i < 20; i++ {
+		for chatID := int64(1); chatID <= 3; chatID++ {
+			d.submit(chatUpdate(i, chatID))
+		}
+	}
+
+	if err := d.stop(5 * time.Second); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		ids := seen[chatID]
+		if len(ids) != 20 {
+			t.Fatalf("Chat %d: expected 20 updates, got %d", chatID, len(ids))
+		}
+		for i, id := range ids {
+			if id != i {
+				t.Fatalf("Chat %d: updates out of order: %v", chatID, ids)
+			}
+		}
+	}
+}
+
+func TestDispatcherConcurrencyLimit(t *testing.T) {
+	var running, maxRunning int32
+
+	d := newUpdateDispatcher(2, 100, func(update tgbotapi.Update) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+
+	for i := 0; i < 20; i++ {
+		d.submit(chatUpdate(i, int64(i)))
+	}
+
+	if err := d.stop(5 * time.Second); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent handlers, got %d", maxRunning)
+	}
+}
+
+func TestDispatcherQueueLimit(t *testing.T) {
+	release := make(chan struct{})
+	var handled int32
+
+	d := newUpdateDispatcher(1, 2, func(update tgbotapi.Update) {
+		<-release
+		atomic.AddInt32(&handled, 1)
+	})
+
+	// The first update may already be taken by the worker, so at most
+	// three are accepted
+	for i := 0; i < 10; i++ {
+		d.submit(chatUpdate(i, 1))
+	}
+	close(release)
+
+	if err := d.stop(5 * time.Second); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	if handled < 2 || handled > 3 {
+		t.Errorf("Expected 2-3 handled updates, got %d", handled)
+	}
+}
+
+func TestDispatcherStopTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	d := newUpdateDispatcher(1, 10, func(update tgbotapi.Update) {
+		<-release
+	})
+	d.submit(chatUpdate(1, 1))
+
+	if err := d.stop(50 * time.Millisecond); err == nil {
+		t.Error("Expected stop to time out while a handler is blocked")
+	}
+
+	// Updates after stop are dropped
+	d.submit(chatUpdate(2, 2))
+	d.mu.Lock()
+	_, queued := d.queues[2]
+	d.mu.Unlock()
+	if queued {
+		t.Error("Update submitted after stop should be dropped")
+	}
+}
+
+func TestDispatcherRecoversFromPanic(t *testing.T) {
+	var handled int32
+
+	d := newUpdateDispatcher(1, 10, func(update tgbotapi.Update) {
+		if update.UpdateID == 1 {
+			panic("boom")
+		}
+		atomic.AddInt32(&handled, 1)
+	})
+
+	d.submit(chatUpdate(1, 1))
+	d.submit(chatUpdate(2, 1))
+
+	if err := d.stop(5 * time.Second); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	if handled != 1 {
+		t.Errorf("Expected the update after the panic to be handled, got %d", handled)
+	}
+}
+
+func TestUpdateChatID(t *testing.T) {
+	callback := tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: 7},
+			Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 42}},
+		},
+	}
+	if id := updateChatID(callback); id != 42 {
+		t.Errorf("Expected chat 42 for callback, got %d", id)
+	}
+
+	inline := tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{From: &tgbotapi.User{ID: 7}},
+	}
+	if id := updateChatID(inline); id != 7 {
+		t.Errorf("Expected sender 7 for callback without message, got %d", id)
+	}
+}
@@ -0,0 +1,398 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/callbacks"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+	"github.com/cupbot/cupbot/internal/metrics"
+)
+
+// Callback data of the event log. The list keeps its filter in a callback
+// token: evt_p_<token>, evt_s_<id>_<token> and evt_ack_<id>_<token>. An
+// acknowledgement from a notification has no list token.
+const (
+	eventLogData     = "events_log"
+	eventsPagePrefix = "evt_p_"
+	eventShowPrefix  = "evt_s_"
+	eventAckPrefix   = "evt_ack_"
+)
+
+// eventListAction is the token action of a filtered event list
+const eventListAction = "evt_list"
+
+const (
+	eventsPageSize     = 10
+	eventMessageWidth  = 60
+	eventDateFormat    = "2006-01-02"
+	eventListTimestamp = "01-02 15:04"
+)
+
+// handleSystemEvent records every event and passes it on to be notified
+// about unless the throttle holds it back
+func (b *Bot) handleSystemEvent(event events.SystemEvent) {
+	record := &database.Event{
+		Type:       string(event.Type),
+		Severity:   event.Severity,
+		Source:     event.Source,
+		Message:    event.Message,
+		Details:    event.Details,
+		OccurredAt: event.Timestamp,
+	}
+	if record.OccurredAt.IsZero() {
+		record.OccurredAt = time.Now()
+	}
+	if err := b.db.AddEvent(record); err != nil {
+		log.Printf("Warning: Failed to record a %s event: %v", event.Type, err)
+	}
+	event.ID = record.ID
+	b.notifications.Publish(event)
+}
+
+// handleEvents обрабатывает команду /events: [type] [severity] [period|date[..date]]
+func (b *Bot) handleEvents(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	filter := strings.ToLower(strings.Join(strings.Fields(args), " "))
+	if _, err := parseEventFilter(filter, time.Now()); err != nil {
+		return b.t(user, "events.bad_filter", err.Error()) + "\n" + b.t(user, "events.usage"), false
+	}
+	return b.sendEventList(message.Chat.ID, user, filter)
+}
+
+// handleEventDigestCallback sends the log of the type of a digest, which
+// lists the events the digest summarized
+func (b *Bot) handleEventDigestCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	eventType := strings.TrimPrefix(callback.Data, eventDigestPrefix)
+	if !slices.Contains(events.EventTypes, events.EventType(eventType)) {
+		return b.t(user, "events.unknown_type", eventType), false
+	}
+	return b.sendEventList(callback.Message.Chat.ID, user, eventType)
+}
+
+// sendEventList sends the first page of the log a filter selects
+func (b *Bot) sendEventList(chatID int64, user *database.User, filter string) (string, bool) {
+	token, err := b.callbackStore.Issue(user.ID, eventListAction, filter, 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	text, keyboard, ok := b.eventListView(user, token, filter, 0)
+	if !ok {
+		return text, false
+	}
+	if _, err := b.sendText(chatID, text, keyboard); err != nil {
+		log.Printf("Failed to send event log: %v", err)
+		return b.t(user, "error.generic", err), false
+	}
+	return "", true
+}
+
+// handleEventLogCallback opens the unfiltered event log from the events panel
+func (b *Bot) handleEventLogCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	token, err := b.callbackStore.Issue(user.ID, eventListAction, "", 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.editEventList(callback, user, token, "", 0)
+}
+
+// handleEventsPageCallback shows a page of the list a token describes
+func (b *Bot) handleEventsPageCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	token := strings.TrimPrefix(callback.Data, eventsPagePrefix)
+	payload, response := b.resolveEventListToken(user, token)
+	if payload == nil {
+		return response, false
+	}
+	return b.editEventList(callback, user, token, payload.Path, payload.Page)
+}
+
+// handleEventShowCallback shows one recorded event with the list to go back to
+func (b *Bot) handleEventShowCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	idText, token, _ := strings.Cut(strings.TrimPrefix(callback.Data, eventShowPrefix), "_")
+	eventID, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil {
+		return b.t(user, "error.unknown_action"), false
+	}
+	return b.editEventDetails(callback, user, eventID, token, "")
+}
+
+// handleEventAckCallback acknowledges an event. From the event log it shows
+// the event again, from a notification it replies.
+func (b *Bot) handleEventAckCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	idText, token, _ := strings.Cut(strings.TrimPrefix(callback.Data, eventAckPrefix), "_")
+	eventID, err := strconv.ParseInt(idText, 10, 64)
+	if err != nil {
+		return b.t(user, "error.unknown_action"), false
+	}
+
+	acknowledged, err := b.db.AcknowledgeEvent(eventID, user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	result := b.t(user, "events.already_acknowledged", eventID)
+	if acknowledged {
+		log.Printf("User %d (%s) acknowledged event %d", user.ID, user.Username, eventID)
+		result = b.t(user, "events.acknowledged", eventID)
+	}
+
+	if token == "" {
+		return result, acknowledged
+	}
+	if response, ok := b.editEventDetails(callback, user, eventID, token, result); !ok {
+		return response, false
+	}
+	return "", acknowledged
+}
+
+// editEventList replaces the message of a callback with a page of the log
+func (b *Bot) editEventList(callback *tgbotapi.CallbackQuery, user *database.User, token, filter string, page int) (string, bool) {
+	text, keyboard, ok := b.eventListView(user, token, filter, page)
+	if !ok {
+		return text, false
+	}
+	if err := b.editCallbackMessage(callback, text, keyboard); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to update message: %v", err)
+		return b.t(user, "error.update_interface"), false
+	}
+	return "", true
+}
+
+// eventListView renders a page of the event log and its keyboard. On
+// failure it returns the response to show instead.
+func (b *Bot) eventListView(user *database.User, token, filter string, page int) (string, *tgbotapi.InlineKeyboardMarkup, bool) {
+	query, err := parseEventFilter(filter, time.Now())
+	if err != nil {
+		return b.t(user, "events.bad_filter", err.Error()), nil, false
+	}
+	total, err := b.db.CountEvents(query)
+	if err != nil {
+		return b.t(user, "error.generic", err), nil, false
+	}
+
+	pages := (total + eventsPageSize - 1) / eventsPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	list, err := b.db.GetEvents(query, eventsPageSize, page*eventsPageSize)
+	if err != nil {
+		return b.t(user, "error.generic", err), nil, false
+	}
+
+	text := b.t(user, "events.log_title", total, page+1, pages) + "\n"
+	if filter != "" {
+		text += b.t(user, "events.log_filter", filter) + "\n"
+	}
+	if len(list) == 0 {
+		return text + "\n" + b.t(user, "events.log_none"), b.eventListKeyboard(user, token, filter, page, pages, nil), true
+	}
+
+	for _, e := range list {
+		line := b.t(user, "events.log_entry", severityIcon(e.Severity), e.ID, e.OccurredAt.Format(eventListTimestamp),
+			b.eventTypeName(user, events.EventType(e.Type)), shortEventMessage(e.Message))
+		if e.AcknowledgedAt != nil {
+			line += " ✅"
+		}
+		text += "\n" + line
+	}
+	return text, b.eventListKeyboard(user, token, filter, page, pages, list), true
+}
+
+// eventListKeyboard has a button per shown event and the page navigation
+func (b *Bot) eventListKeyboard(user *database.User, token, filter string, page, pages int, shown []*database.Event) *tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, e := range shown {
+		label := fmt.Sprintf("#%d %s", e.ID, b.eventTypeName(user, events.EventType(e.Type)))
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%s%d_%s", eventShowPrefix, e.ID, token)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.prev"), b.eventsPageData(user, filter, page-1)))
+	}
+	nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), eventsPagePrefix+token))
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.next"), b.eventsPageData(user, filter, page+1)))
+	}
+	rows = append(rows, nav, b.menuRow(user))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// editEventDetails replaces the message of a callback with one event;
+// result is shown above it
+func (b *Bot) editEventDetails(callback *tgbotapi.CallbackQuery, user *database.User, eventID int64, token, result string) (string, bool) {
+	event, err := b.db.GetEvent(eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return b.t(user, "events.not_found", eventID), false
+	}
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	text := b.eventDetailsText(user, event)
+	if result != "" {
+		text = result + "\n\n" + text
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if user.IsAdmin && event.AcknowledgedAt == nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			b.t(user, "button.event_acknowledge"), fmt.Sprintf("%s%d_%s", eventAckPrefix, event.ID, token))))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.back_events"), eventsPagePrefix+token),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), fmt.Sprintf("%s%d_%s", eventShowPrefix, event.ID, token)),
+		),
+		b.menuRow(user),
+	)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	if err := b.editCallbackMessage(callback, text, &keyboard); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to update message: %v", err)
+		return b.t(user, "error.update_interface"), false
+	}
+	return "", true
+}
+
+// eventDetailsText describes a recorded event
+func (b *Bot) eventDetailsText(user *database.User, e *database.Event) string {
+	lines := []string{
+		b.t(user, "events.entry_title", severityIcon(e.Severity), b.eventTypeName(user, events.EventType(e.Type)), e.ID),
+		b.t(user, "events.entry_message", e.Message),
+		b.t(user, "events.entry_severity", e.Severity),
+		b.t(user, "events.entry_time", e.OccurredAt.Format(eventTimeFormat)),
+	}
+	if e.Source != "" {
+		lines = append(lines, b.t(user, "events.entry_source", e.Source))
+	}
+	if e.AcknowledgedAt != nil {
+		lines = append(lines, b.t(user, "events.entry_acknowledged", b.userLabel(e.AcknowledgedBy), e.AcknowledgedAt.Format(eventTimeFormat)))
+	} else {
+		lines = append(lines, b.t(user, "events.entry_open"))
+	}
+	if details := strings.TrimSpace(e.Details); details != "" {
+		lines = append(lines, "", b.t(user, "events.entry_details", details))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// userLabel names a user by username, first name or ID
+func (b *Bot) userLabel(userID int64) string {
+	user, err := b.db.GetUser(userID)
+	switch {
+	case err != nil:
+		return strconv.FormatInt(userID, 10)
+	case user.Username != "":
+		return "@" + user.Username
+	case user.FirstName != "":
+		return user.FirstName
+	}
+	return strconv.FormatInt(userID, 10)
+}
+
+// eventsPageData issues a token for a page of the log
+func (b *Bot) eventsPageData(user *database.User, filter string, page int) string {
+	token, err := b.callbackStore.Issue(user.ID, eventListAction, filter, page)
+	if err != nil {
+		log.Printf("Failed to issue callback token for user %d: %v", user.ID, err)
+		return eventsPagePrefix
+	}
+	return eventsPagePrefix + token
+}
+
+// resolveEventListToken resolves a list token. On failure it returns nil
+// and the response to show to the user.
+func (b *Bot) resolveEventListToken(user *database.User, token string) (*callbacks.Payload, string) {
+	payload, err := b.callbackStore.Resolve(token, user.ID)
+	switch {
+	case err == nil && payload.Action == eventListAction:
+		return payload, ""
+	case err == nil, errors.Is(err, callbacks.ErrNotFound), errors.Is(err, callbacks.ErrExpired):
+		return nil, b.t(user, "events.log_expired")
+	case errors.Is(err, callbacks.ErrForeignUser):
+		log.Printf("User %d tried to use a callback token of another user", user.ID)
+		return nil, b.t(user, "events.log_expired")
+	default:
+		return nil, b.t(user, "error.generic", err)
+	}
+}
+
+// parseEventFilter parses the words of an /events filter: event types, a
+// minimum severity, a period back from now such as 24h, or a day or range
+// of days such as 2026-10-01..2026-10-07. "error" is also an event type, so
+// a severity may be written with a trailing "+" (error+).
+func parseEventFilter(filter string, now time.Time) (database.EventFilter, error) {
+	var f database.EventFilter
+	for _, word := range strings.Fields(filter) {
+		if slices.Contains(events.EventTypes, events.EventType(word)) {
+			f.Types = append(f.Types, word)
+			continue
+		}
+		if severities := events.SeveritiesFrom(strings.TrimSuffix(word, "+")); severities != nil {
+			f.Severities = severities
+			continue
+		}
+		if period, err := metrics.ParsePeriod(word); err == nil {
+			f.Since, f.Until = now.Add(-period), time.Time{}
+			continue
+		}
+
+		first, last, isRange := strings.Cut(word, "..")
+		if !isRange {
+			last = first
+		}
+		from, err := time.ParseInLocation(eventDateFormat, first, now.Location())
+		if err != nil {
+			return f, fmt.Errorf("%s", word)
+		}
+		to, err := time.ParseInLocation(eventDateFormat, last, now.Location())
+		if err != nil || to.Before(from) {
+			return f, fmt.Errorf("%s", word)
+		}
+		f.Since, f.Until = from, to.AddDate(0, 0, 1)
+	}
+	return f, nil
+}
+
+// severityIcon marks an event by its severity
+func severityIcon(severity string) string {
+	if icon, ok := eventSeverityIcons[severity]; ok {
+		return icon
+	}
+	return "🔔"
+}
+
+// shortEventMessage keeps the list to one line per event
+func shortEventMessage(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if utf8.RuneCountInString(message) <= eventMessageWidth {
+		return message
+	}
+	return string([]rune(message)[:eventMessageWidth-1]) + "…"
+}
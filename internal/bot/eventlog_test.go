@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/events"
+)
+
+func TestParseEventFilter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+	f, err := parseEventFilter("login error+ 24h", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Types) != 1 || f.Types[0] != "login" {
+		t.Errorf("Expected the login type, got %v", f.Types)
+	}
+	if len(f.Severities) != 2 || f.Severities[0] != "error" || f.Severities[1] != "critical" {
+		t.Errorf("Expected error and critical, got %v", f.Severities)
+	}
+	if !f.Since.Equal(now.Add(-24*time.Hour)) || !f.Until.IsZero() {
+		t.Errorf("Unexpected range %v..%v", f.Since, f.Until)
+	}
+
+	f, err = parseEventFilter("2026-10-01..2026-10-03", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Since.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)) || !f.Until.Equal(time.Date(2026, 10, 4, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Unexpected range %v..%v", f.Since, f.Until)
+	}
+
+	for _, bad := range []string{"reboot", "2026-10-05..2026-10-01", "yesterday"} {
+		if _, err := parseEventFilter(bad, now); err == nil {
+			t.Errorf("Expected %q rejected", bad)
+		}
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t)
+	start := time.Now().Add(-time.Hour)
+	for i := range 12 {
+		bot.handleSystemEvent(events.SystemEvent{
+			Type:      events.EventProcess,
+			Message:   fmt.Sprintf("New process started: worker-%d", i),
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Severity:  events.SeverityInfo,
+			Source:    "process_monitor",
+		})
+	}
+	bot.handleSystemEvent(events.SystemEvent{
+		Type:      events.EventError,
+		Message:   "Disk failure",
+		Details:   "sda: I/O error",
+		Timestamp: start.Add(30 * time.Minute),
+		Severity:  events.SeverityCritical,
+		Source:    "journal",
+	})
+
+	// Everything is listed newest first, ten to a page
+	bot.handleMessage(commandMessage(user, "/events"), user)
+	list := lastSent(t, fake, "Event Log")
+	for _, expected := range []string{"13 total · page 1/2", "🚨 #13", "<b>System error</b> Disk failure", "worker-11"} {
+		if !containsString(list.Text, expected) {
+			t.Errorf("Expected %q in the list, got %s", expected, list.Text)
+		}
+	}
+	fake.Reset()
+	pressButton(t, bot, user, list, "Next ▶️")
+	page := lastSent(t, fake, "page 2/2")
+	if !containsString(page.Text, "worker-0") || containsString(page.Text, "worker-5") {
+		t.Errorf("Unexpected second page: %s", page.Text)
+	}
+
+	// Filters narrow the list
+	fake.Reset()
+	bot.handleMessage(commandMessage(user, "/events critical 24h"), user)
+	filtered := lastSent(t, fake, "1 total")
+	if !containsString(filtered.Text, "Filter: critical 24h") || containsString(filtered.Text, "worker") {
+		t.Errorf("Unexpected filtered list: %s", filtered.Text)
+	}
+	fake.Reset()
+	bot.handleMessage(commandMessage(user, "/events yesterday"), user)
+	lastSent(t, fake, "Unknown filter: yesterday")
+
+	// Users see the details but can't acknowledge
+	fake.Reset()
+	pressButton(t, bot, user, filtered, "#13 System error")
+	details := lastSent(t, fake, "<pre>sda: I/O error</pre>")
+	if _, ok := details.Button("✅ Acknowledge"); ok {
+		t.Error("Expected no acknowledge button for a user")
+	}
+	ackData := fmt.Sprintf("%s13", eventAckPrefix)
+	fake.Reset()
+	pressButton(t, bot, user, keyboardMessage(user.ID, tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✅ Acknowledge", ackData)))), "✅ Acknowledge")
+	lastSent(t, fake, "Admin privileges required")
+
+	// Admins acknowledge from the details
+	fake.Reset()
+	bot.handleMessage(commandMessage(admin, "/events error"), admin)
+	adminList := lastSent(t, fake, "1 total")
+	fake.Reset()
+	pressButton(t, bot, admin, adminList, "#13 System error")
+	adminDetails := lastSent(t, fake, "Not acknowledged")
+	fake.Reset()
+	pressButton(t, bot, admin, adminDetails, "✅ Acknowledge")
+	acked := lastSent(t, fake, "Event #13 acknowledged")
+	if !containsString(acked.Text, "Acknowledged by") {
+		t.Errorf("Expected who acknowledged the event, got %s", acked.Text)
+	}
+	if _, ok := acked.Button("✅ Acknowledge"); ok {
+		t.Error("Expected no acknowledge button once acknowledged")
+	}
+	fake.Reset()
+	pressButton(t, bot, admin, acked, "🔙 Back to Events")
+	if back := lastSent(t, fake, "1 total"); !containsString(back.Text, "✅") {
+		t.Errorf("Expected the event marked acknowledged, got %s", back.Text)
+	}
+
+	// A second acknowledgement changes nothing
+	fake.Reset()
+	pressButton(t, bot, admin, keyboardMessage(admin.ID, tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("✅ Acknowledge", ackData)))), "✅ Acknowledge")
+	lastSent(t, fake, "already acknowledged")
+}
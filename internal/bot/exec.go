@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/executor"
+	"github.com/cupbot/cupbot/internal/system"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// execOutputFileName names the document output too long for a message is
+// sent as
+const execOutputFileName = "output.txt"
+
+// handleExec обрабатывает команду /exec (только админы). The handler writes
+// its own history entry with the exit code and duration.
+func (b *Bot) handleExec(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	line := strings.TrimSpace(args)
+
+	if !b.executor.Enabled() {
+		response := b.t(user, "exec.disabled")
+		b.authMw.LogCommand(user.ID, "exec", line, false, response)
+		return response, false
+	}
+	if line == "" {
+		response := b.execUsage(user)
+		b.authMw.LogCommand(user.ID, "exec", line, true, response)
+		return response, true
+	}
+
+	log.Printf("User %d (%s) runs host command: %s", user.ID, user.Username, line)
+	result, err := b.executor.Run(context.Background(), line)
+	if err != nil {
+		response := b.t(user, "exec.rejected", err)
+		b.authMw.LogCommand(user.ID, "exec", line, false, response)
+		return response, false
+	}
+
+	success := result.ExitCode == 0 && !result.TimedOut
+	header := b.execHeader(user, line, result)
+	response := header + "\n\n" + b.t(user, "exec.no_output")
+	if result.Output != "" {
+		response = header + "\n\n<pre>" + render.Escape(result.Output) + "</pre>"
+	}
+
+	if render.Length(response) > render.MaxMessageLength {
+		// Too long for a message: the output goes as a file captioned with the header
+		doc := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{Name: execOutputFileName, Bytes: []byte(result.Output)})
+		doc.Caption = render.Plain(header)
+		doc.ReplyMarkup = menuKeyboard(b, user)
+		if _, err := b.sendDocument(doc); err != nil {
+			log.Printf("Failed to send command output: %v", err)
+		}
+		response = header
+		b.authMw.LogExecution(user.ID, "exec", line, result.ExitCode, result.Duration, success, response)
+		return "", success
+	}
+
+	b.authMw.LogExecution(user.ID, "exec", line, result.ExitCode, result.Duration, success, response)
+	return response, success
+}
+
+// execHeader describes a finished command: exit code, duration, and whether
+// it was killed or its output cut
+func (b *Bot) execHeader(user *database.User, line string, result *executor.Result) string {
+	header := b.t(user, "exec.result", render.Code(line), result.ExitCode, result.Duration.Round(time.Millisecond))
+	if result.TimedOut {
+		header += "\n" + b.t(user, "exec.timed_out")
+	}
+	if result.Truncated {
+		header += "\n" + b.t(user, "exec.truncated", system.FormatBytes(uint64(b.config.Exec.MaxOutput)))
+	}
+	return header
+}
+
+// execUsage lists the allowlisted commands and the arguments they accept
+func (b *Bot) execUsage(user *database.User) string {
+	commands := b.executor.Commands()
+	if len(commands) == 0 {
+		return b.t(user, "exec.no_commands")
+	}
+
+	lines := []string{b.t(user, "exec.usage"), ""}
+	for _, cmd := range commands {
+		lines = append(lines, "• "+string(render.Code(cmd.Usage())))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/executor"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+)
+
+// enableExec allows a say command that prints its argument and exits with 3,
+// and a flood command that prints more than fits in a message
+func enableExec(t *testing.T, bot *Bot) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix commands")
+	}
+	bot.config.Exec = config.ExecConfig{
+		Enabled:   true,
+		Timeout:   5,
+		MaxOutput: 64 * 1024,
+		Commands: []config.ExecCommand{
+			{Name: "say", Path: "sh", Args: []string{"-c", `echo "<$1>"; exit 3`, "say"}, AllowArgs: []string{`\w+`}},
+			{Name: "flood", Path: "sh", Args: []string{"-c", "head -c 6000 /dev/zero | tr '\\0' x"}},
+		},
+	}
+	bot.executor = executor.NewService(bot.config)
+}
+
+func TestExecRunsAllowedCommand(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, enableExec)
+
+	bot.handleMessage(commandMessage(admin, "/exec say hello"), admin)
+	sent := lastSent(t, fake, "Exit code")
+	if !strings.Contains(sent.Text, "&lt;hello&gt;") || !strings.Contains(sent.Text, "3") {
+		t.Errorf("Expected escaped output and exit code 3, got %q", sent.Text)
+	}
+
+	history, err := bot.db.GetCommandHistory(admin.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected one history entry, got %d", len(history))
+	}
+	if entry := history[0]; entry.ExitCode == nil || *entry.ExitCode != 3 || entry.Success {
+		t.Errorf("Expected a failed entry with exit code 3, got %+v", entry)
+	}
+}
+
+func TestExecRejectsCommands(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, enableExec)
+
+	bot.handleMessage(commandMessage(user, "/exec say hello"), user)
+	lastSent(t, fake, "Admin privileges required")
+
+	bot.handleMessage(commandMessage(admin, "/exec rm -rf /"), admin)
+	lastSent(t, fake, "command is not allowed")
+
+	bot.handleMessage(commandMessage(admin, "/exec say a;b"), admin)
+	lastSent(t, fake, "argument is not allowed")
+
+	bot.handleMessage(commandMessage(admin, "/exec"), admin)
+	lastSent(t, fake, "Allowed commands")
+
+	bot.config.Exec.Enabled = false
+	bot.handleMessage(commandMessage(admin, "/exec say hello"), admin)
+	lastSent(t, fake, "disabled")
+}
+
+func TestExecSendsLongOutputAsFile(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, enableExec)
+
+	bot.handleMessage(commandMessage(admin, "/exec flood"), admin)
+	sent := fake.Sent()
+	last := sent[len(sent)-1]
+	if last.Method != telegramtest.MethodSendDocument {
+		t.Fatalf("Expected the output as a document, got %s %q", last.Method, last.Text)
+	}
+	if !strings.Contains(last.Text, "Exit code") {
+		t.Errorf("Document should be captioned with the result, got %q", last.Text)
+	}
+}
@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/events"
+	"github.com/cupbot/cupbot/internal/exporter"
+	"github.com/cupbot/cupbot/internal/power"
+)
+
+// updateDurationBuckets are the upper bounds of the handler latency
+// histogram, in seconds
+var updateDurationBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// powerOperationTypes label cupbot_power_operation_scheduled
+var powerOperationTypes = []power.OperationType{
+	power.OperationShutdown, power.OperationReboot, power.OperationForceShutdown, power.OperationForceReboot,
+}
+
+// botStats counts what the bot handled since it started. A nil botStats
+// counts nothing.
+type botStats struct {
+	mu      sync.Mutex
+	updates map[string]uint64
+	events  map[eventKey]uint64
+	latency *exporter.Histogram
+}
+
+type eventKey struct {
+	kind, severity string
+}
+
+func newBotStats() *botStats {
+	return &botStats{
+		updates: make(map[string]uint64),
+		events:  make(map[eventKey]uint64),
+		latency: exporter.NewHistogram(updateDurationBuckets...),
+	}
+}
+
+// observeUpdate counts an update and how long its handler took
+func (s *botStats) observeUpdate(update tgbotapi.Update, took time.Duration) {
+	if s == nil {
+		return
+	}
+	kind := "other"
+	switch {
+	case update.Message != nil:
+		kind = "message"
+	case update.CallbackQuery != nil:
+		kind = "callback"
+	}
+
+	s.mu.Lock()
+	s.updates[kind]++
+	s.mu.Unlock()
+	s.latency.Observe(took.Seconds())
+}
+
+// observeEvent counts a system event
+func (s *botStats) observeEvent(event events.SystemEvent) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[eventKey{string(event.Type), event.Severity}]++
+}
+
+func (s *botStats) collect(w *exporter.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kinds := make([]string, 0, len(s.updates))
+	for kind := range s.updates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		w.Counter("cupbot_updates_total", "Telegram updates handled.", float64(s.updates[kind]), "type", kind)
+	}
+	w.Histogram("cupbot_update_duration_seconds", "Time spent handling an update.", s.latency)
+
+	keys := make([]eventKey, 0, len(s.events))
+	for key := range s.events {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].kind != keys[j].kind {
+			return keys[i].kind < keys[j].kind
+		}
+		return keys[i].severity < keys[j].severity
+	})
+	for _, key := range keys {
+		w.Counter("cupbot_events_total", "System events detected.", float64(s.events[key]), "type", key.kind, "severity", key.severity)
+	}
+
+}
+
+// newExporter creates the metrics listener when it is enabled
+func (b *Bot) newExporter() *exporter.Server {
+	if !b.config.Prometheus.Enabled {
+		return nil
+	}
+
+	s := exporter.NewServer(b.config.Prometheus)
+	s.AddCollector(b.collectHost)
+	s.AddCollector(b.stats.collect)
+	s.AddCollector(b.collectCommands)
+	s.AddCollector(b.collectPower)
+	s.AddCheck("telegram", func(ctx context.Context) error {
+		_, err := b.api.GetMe()
+		return err
+	})
+	s.AddCheck("database", b.db.Ping)
+	return s
+}
+
+// collectHost reports the same host metrics as /status
+func (b *Bot) collectHost(w *exporter.Writer) {
+	info, err := b.systemService.GetSystemInfo()
+	if err != nil {
+		log.Printf("Failed to collect system info for metrics: %v", err)
+		return
+	}
+
+	w.Gauge("cupbot_host_uptime_seconds", "Host uptime.", info.Uptime.Seconds())
+	w.Gauge("cupbot_host_processes", "Running processes.", float64(info.ProcessCount))
+
+	if len(info.CPUInfo.Usage) > 0 {
+		var total float64
+		for _, usage := range info.CPUInfo.Usage {
+			total += usage
+		}
+		w.Gauge("cupbot_host_cpu_usage_percent", "Average CPU usage.", total/float64(len(info.CPUInfo.Usage)))
+		for i, usage := range info.CPUInfo.Usage {
+			w.Gauge("cupbot_host_cpu_core_usage_percent", "CPU usage of a core.", usage, "core", strconv.Itoa(i))
+		}
+	}
+	if len(info.Load) == 3 {
+		for i, period := range []string{"1m", "5m", "15m"} {
+			w.Gauge("cupbot_host_load", "Load average.", info.Load[i], "period", period)
+		}
+	}
+
+	w.Gauge("cupbot_host_memory_total_bytes", "Total memory.", float64(info.MemoryInfo.Total))
+	w.Gauge("cupbot_host_memory_used_bytes", "Used memory.", float64(info.MemoryInfo.Used))
+	w.Gauge("cupbot_host_memory_used_percent", "Used memory.", info.MemoryInfo.UsedPercent)
+
+	for _, disk := range info.DiskInfo {
+		w.Gauge("cupbot_host_disk_total_bytes", "Disk size.", float64(disk.Total), "mountpoint", disk.Mountpoint)
+		w.Gauge("cupbot_host_disk_used_bytes", "Used disk space.", float64(disk.Used), "mountpoint", disk.Mountpoint)
+		w.Gauge("cupbot_host_disk_used_percent", "Used disk space.", disk.UsedPercent, "mountpoint", disk.Mountpoint)
+	}
+	for _, iface := range info.NetworkInfo {
+		w.Counter("cupbot_host_network_received_bytes_total", "Bytes received by an interface.", float64(iface.BytesRecv), "interface", iface.Name)
+		w.Counter("cupbot_host_network_sent_bytes_total", "Bytes sent by an interface.", float64(iface.BytesSent), "interface", iface.Name)
+	}
+}
+
+// collectCommands reports the command history by registered command
+func (b *Bot) collectCommands(w *exporter.Writer) {
+	counts, err := b.db.GetCommandCounts(b.commands.commandNames())
+	if err != nil {
+		log.Printf("Failed to count commands for metrics: %v", err)
+		return
+	}
+	for _, c := range counts {
+		w.Counter("cupbot_commands_total", "Commands run by users.", float64(c.Count),
+			"command", c.Command, "success", strconv.FormatBool(c.Success))
+	}
+}
+
+// collectPower reports which power operation is scheduled, every type
+// with the same labels
+func (b *Bot) collectPower(w *exporter.Writer) {
+	if b.powerService == nil {
+		return
+	}
+	op := b.powerService.GetScheduledOperation()
+	for _, opType := range powerOperationTypes {
+		scheduled := 0.0
+		if op != nil && op.Type == opType {
+			scheduled = 1
+		}
+		w.Gauge("cupbot_power_operation_scheduled", "Whether a power operation is scheduled.", scheduled, "type", string(opType))
+	}
+	if op != nil {
+		w.Gauge("cupbot_power_operation_timestamp_seconds", "When the scheduled power operation runs.",
+			float64(op.ScheduledAt.Unix()), "type", string(op.Type))
+	}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+	"github.com/cupbot/cupbot/internal/power"
+)
+
+func TestExporter(t *testing.T) {
+	bot, fake, _, user := newFakeBot(t)
+	if bot.newExporter() != nil {
+		t.Fatal("Expected no listener while Prometheus is disabled")
+	}
+
+	bot.config.Prometheus = config.PrometheusConfig{Enabled: true, Listen: "127.0.0.1:0"}
+	bot.stats = newBotStats()
+	bot.powerService = power.NewService(bot.config)
+	s := bot.newExporter()
+
+	// Recorded before a restart
+	if err := bot.db.AddCommandHistory(&database.CommandHistory{UserID: user.ID, Command: "help", Success: true, ExecutedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	bot.handleUpdate(tgbotapi.Update{Message: commandMessage(user, "/help")})
+	bot.handleUpdate(tgbotapi.Update{Message: commandMessage(user, "/nosuchcommand")})
+	bot.stats.observeEvent(events.SystemEvent{Type: events.EventLogin, Severity: "info"})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/metrics")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the metrics, got %d", rec.Code)
+	}
+	for _, line := range []string{
+		`cupbot_updates_total{type="message"} 2`,
+		`cupbot_update_duration_seconds_count 2`,
+		`cupbot_events_total{type="login",severity="info"} 1`,
+		`cupbot_commands_total{command="help",success="true"} 2`,
+		`cupbot_commands_total{command="unknown",success="false"} 1`,
+		`cupbot_power_operation_scheduled{type="shutdown"} 0`,
+		`# TYPE cupbot_host_memory_used_percent gauge`,
+	} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("Expected %q in the metrics:\n%s", line, rec.Body.String())
+		}
+	}
+
+	if strings.Contains(rec.Body.String(), "nosuchcommand") {
+		t.Error("Unknown commands should not become labels")
+	}
+
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("Expected a healthy bot, got %d: %s", rec.Code, rec.Body.String())
+	}
+	fake.FailWith(errors.New("unauthorized"))
+	rec = get("/healthz")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"telegram":"unauthorized"`) {
+		t.Errorf("Expected the failing Bot API reported, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/chart"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/metrics"
+	"github.com/cupbot/cupbot/internal/system"
+)
+
+const (
+	graphDefaultPeriod = "24h"
+	graphFileName      = "graph.png"
+)
+
+// graphMetrics maps the names accepted by /graph to the history; the network
+// chart shows both directions
+var graphMetrics = map[string]string{
+	"cpu":     metrics.CPU,
+	"memory":  metrics.Memory,
+	"mem":     metrics.Memory,
+	"disk":    metrics.DiskUsed,
+	"net":     metrics.NetRecv,
+	"network": metrics.NetRecv,
+}
+
+// graphTitles are drawn into the image, whose font only has Latin letters
+var graphTitles = map[string]string{
+	metrics.CPU:      "CPU usage, %",
+	metrics.Memory:   "Memory usage, %",
+	metrics.DiskUsed: "Disk usage, %",
+	metrics.NetRecv:  "Network traffic",
+}
+
+// handleGraph обрабатывает команду /graph: график метрики за период
+func (b *Bot) handleGraph(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	if !b.config.Metrics.Enabled {
+		return b.t(user, "graph.disabled"), false
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return b.t(user, "graph.usage"), false
+	}
+	metric, ok := graphMetrics[strings.ToLower(fields[0])]
+	if !ok {
+		return b.t(user, "graph.unknown_metric", fields[0]), false
+	}
+
+	// The period is optional, a target disk or interface may follow
+	label := graphDefaultPeriod
+	rest := fields[1:]
+	if len(rest) > 0 {
+		if _, err := metrics.ParsePeriod(rest[0]); err == nil {
+			label, rest = strings.ToLower(rest[0]), rest[1:]
+		}
+	}
+	if len(rest) > 1 || (len(rest) == 1 && (metric == metrics.CPU || metric == metrics.Memory)) {
+		return b.t(user, "graph.usage"), false
+	}
+	target := ""
+	if len(rest) == 1 {
+		target = rest[0]
+	}
+
+	period, _ := metrics.ParsePeriod(label)
+	if retention := time.Duration(b.config.Metrics.Retention) * 24 * time.Hour; period > retention {
+		period, label = retention, fmt.Sprintf("%dd", b.config.Metrics.Retention)
+	}
+	to := time.Now()
+	from := to.Add(-period)
+
+	series, err := metrics.History(b.db, metric, target, from, to)
+	if err != nil {
+		return b.t(user, "graph.error", err), false
+	}
+
+	title := graphTitles[metric]
+	if target != "" {
+		title += " (" + target + ")"
+	}
+	c := &chart.Chart{
+		Title:  title + ", " + label,
+		Series: series,
+		From:   from,
+		To:     to,
+		Gap:    3 * time.Duration(b.config.Metrics.Interval) * time.Second,
+		Format: func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+	}
+	if period > time.Duration(b.config.Metrics.RawHours)*time.Hour {
+		c.Gap = 3 * time.Duration(b.config.Metrics.Resolution) * time.Second
+	}
+	if metric == metrics.NetRecv {
+		c.Format = func(v float64) string { return system.FormatBytes(uint64(v)) + "/s" }
+	} else {
+		c.Min, c.Max = 0, 100
+	}
+
+	var buf bytes.Buffer
+	if err := chart.Render(&buf, c); errors.Is(err, chart.ErrNoData) {
+		return b.t(user, "graph.no_data", label), false
+	} else if err != nil {
+		return b.t(user, "graph.error", err), false
+	}
+
+	photo := tgbotapi.NewPhoto(message.Chat.ID, tgbotapi.FileBytes{Name: graphFileName, Bytes: buf.Bytes()})
+	photo.Caption = b.t(user, "graph.caption", b.t(user, "graph.metric."+metric), label)
+	photo.ReplyMarkup = menuKeyboard(b, user)
+	if _, err := b.sendPhoto(photo); err != nil {
+		return b.t(user, "graph.error", err), false
+	}
+	return "", true
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+)
+
+func TestGraph(t *testing.T) {
+	bot, fake, _, user := newFakeBot(t)
+	bot.config.Metrics = config.MetricsConfig{Enabled: true, Interval: 60, RawHours: 24, Resolution: 600, Retention: 30}
+
+	now := time.Now()
+	var samples []*database.MetricSample
+	for i := 0; i < 60; i++ {
+		at := now.Add(-time.Duration(i) * time.Minute)
+		samples = append(samples,
+			&database.MetricSample{Metric: "cpu", Value: float64(i), RecordedAt: at},
+			&database.MetricSample{Metric: "net_recv", Target: "eth0", Value: 2048, RecordedAt: at},
+		)
+	}
+	if err := bot.db.AddMetricSamples(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	bot.handleMessage(commandMessage(user, "/graph cpu 24h"), user)
+	photo := lastSent(t, fake, "CPU usage, last 24h")
+	if photo.Method != telegramtest.MethodSendPhoto {
+		t.Fatalf("Expected a photo, got %s: %s", photo.Method, photo.Text)
+	}
+	file, ok := photo.File.(tgbotapi.FileBytes)
+	if !ok {
+		t.Fatalf("Expected the chart as bytes, got %T", photo.File)
+	}
+	if _, err := png.Decode(bytes.NewReader(file.Bytes)); err != nil {
+		t.Errorf("Expected a PNG chart: %v", err)
+	}
+
+	bot.handleMessage(commandMessage(user, "/graph net eth0"), user)
+	lastSent(t, fake, "Network traffic, last 24h")
+
+	bot.handleMessage(commandMessage(user, "/graph disk 1h"), user)
+	lastSent(t, fake, "No data for the last 1h")
+	bot.handleMessage(commandMessage(user, "/graph cpu 365d"), user)
+	lastSent(t, fake, "last 30d")
+	bot.handleMessage(commandMessage(user, "/graph swap"), user)
+	lastSent(t, fake, "Unknown metric swap")
+	bot.handleMessage(commandMessage(user, "/graph cpu 24h extra"), user)
+	lastSent(t, fake, "Usage")
+
+	bot.config.Metrics.Enabled = false
+	bot.handleMessage(commandMessage(user, "/graph cpu"), user)
+	lastSent(t, fake, "disabled")
+}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// languagePrefix starts the data of the /language buttons, followed by
+	// the language or languageAuto
+	languagePrefix = "lang_"
+	// languageAuto goes back to the language of the Telegram client
+	languageAuto = "auto"
+)
+
+// defaultLanguage returns bot.language, used when the language of a user
+// has no catalog
+func (b *Bot) defaultLanguage() string {
+	if b.config != nil && i18n.Supported(b.config.Bot.Language) {
+		return b.config.Bot.Language
+	}
+	return i18n.Default
+}
+
+// lang picks the language of the replies to a user: the /language choice,
+// then the language of the Telegram client, then bot.language
+func (b *Bot) lang(user *database.User) string {
+	if user != nil {
+		if i18n.Supported(user.Language) {
+			return user.Language
+		}
+		if lang := i18n.Match(user.LanguageCode); lang != "" {
+			return lang
+		}
+	}
+	return b.defaultLanguage()
+}
+
+// t formats a catalog message in the language of the user
+func (b *Bot) t(user *database.User, key string, args ...any) string {
+	return i18n.T(b.lang(user), key, args...)
+}
+
+// handleLanguage обрабатывает команду /language
+func (b *Bot) handleLanguage(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	args = strings.ToLower(strings.TrimSpace(args))
+	if args == "" {
+		return b.languagePrompt(user), true
+	}
+
+	language := args
+	if language == languageAuto {
+		language = ""
+	} else if !i18n.Supported(language) {
+		return b.t(user, "language.unknown", args, strings.Join(i18n.Languages(), ", ")), false
+	}
+
+	return b.setLanguage(user, language)
+}
+
+// handleLanguageCallback stores the language picked with a /language button
+// and turns the prompt into the result
+func (b *Bot) handleLanguageCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	language := strings.TrimPrefix(callback.Data, languagePrefix)
+	if language == languageAuto {
+		language = ""
+	} else if !i18n.Supported(language) {
+		return b.t(user, "language.unknown", language, strings.Join(i18n.Languages(), ", ")), false
+	}
+
+	response, success := b.setLanguage(user, language)
+	if err := b.editCallbackMessage(callback, response, menuKeyboard(b, user)); err != nil {
+		log.Printf("Failed to update message: %v", err)
+		return response, success
+	}
+	return "", success
+}
+
+// setLanguage stores the language of a user; an empty language follows the
+// Telegram client again
+func (b *Bot) setLanguage(user *database.User, language string) (string, bool) {
+	if err := b.db.SetUserLanguage(user.ID, language); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	user.Language = language
+
+	// The command menu of admins is published per chat in their language
+	if user.IsAdmin {
+		b.publishUserCommands(user.ID)
+	}
+
+	return b.t(user, "language.changed", render.HTML(b.languageLabel(user))), true
+}
+
+// languagePrompt describes the current language of a user
+func (b *Bot) languagePrompt(user *database.User) string {
+	return b.t(user, "language.prompt", render.HTML(b.languageLabel(user)))
+}
+
+// languageLabel names the current language of a user, noting when it
+// follows the Telegram client
+func (b *Bot) languageLabel(user *database.User) string {
+	name := i18n.Name(b.lang(user))
+	if !i18n.Supported(user.Language) {
+		return b.t(user, "language.auto", name)
+	}
+	return name
+}
+
+// languageKeyboard offers every language with a catalog and the Telegram one
+func languageKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, lang := range i18n.Languages() {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.Name(lang), languagePrefix+lang))
+	}
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		row,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.language_auto"), languagePrefix+languageAuto),
+		),
+	)
+	return &kb
+}
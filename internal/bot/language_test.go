@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+)
+
+func TestLanguageFromTelegramClient(t *testing.T) {
+	bot := setupTestBot(t)
+	defer teardownTestBot(t, bot)
+
+	tests := []struct {
+		user     *database.User
+		expected string
+	}{
+		{&database.User{LanguageCode: "en-US"}, i18n.English},
+		{&database.User{LanguageCode: "ru"}, i18n.Russian},
+		{&database.User{LanguageCode: "de"}, i18n.Default},
+		{&database.User{LanguageCode: "de", Language: i18n.English}, i18n.English},
+		{nil, i18n.Default},
+	}
+
+	for _, tt := range tests {
+		if lang := bot.lang(tt.user); lang != tt.expected {
+			t.Errorf("lang(%+v): expected %s, got %s", tt.user, tt.expected, lang)
+		}
+	}
+
+	bot.config.Bot.Language = i18n.English
+	if lang := bot.lang(&database.User{LanguageCode: "de"}); lang != i18n.English {
+		t.Errorf("Unsupported client language should fall back to bot.language, got %s", lang)
+	}
+}
+
+func TestLanguageCommand(t *testing.T) {
+	bot, fake, _, user := newFakeBot(t)
+
+	bot.handleMessage(commandMessage(user, "/language"), user)
+	prompt := lastSent(t, fake, "Current language")
+	if _, ok := prompt.Button(i18n.Name(i18n.Russian)); !ok {
+		t.Fatal("Prompt has no Russian button")
+	}
+
+	bot.handleMessage(commandMessage(user, "/language de"), user)
+	lastSent(t, fake, "Unknown language")
+
+	bot.handleMessage(commandMessage(user, "/language ru"), user)
+	lastSent(t, fake, "Язык: Русский")
+
+	stored, err := bot.db.GetUser(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Language != i18n.Russian {
+		t.Errorf("Expected stored language ru, got %q", stored.Language)
+	}
+
+	// Replies switch to the chosen language
+	bot.handleMessage(commandMessage(stored, "/uptime"), stored)
+	lastSent(t, fake, "Время работы системы")
+}
+
+func TestLanguageButton(t *testing.T) {
+	bot, fake, _, user := newFakeBot(t)
+	user.Language = i18n.Russian
+	if err := bot.db.SetUserLanguage(user.ID, user.Language); err != nil {
+		t.Fatal(err)
+	}
+
+	bot.handleMessage(commandMessage(user, "/language"), user)
+	prompt := lastSent(t, fake, "Текущий язык")
+
+	// Auto goes back to the language of the Telegram client
+	pressButton(t, bot, user, prompt, "Язык Telegram")
+	result := lastSent(t, fake, "Language: English (Telegram language)")
+	if result.MessageID != prompt.MessageID {
+		t.Error("The result should replace the prompt")
+	}
+
+	stored, err := bot.db.GetUser(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Language != "" {
+		t.Errorf("Expected the chosen language to be cleared, got %q", stored.Language)
+	}
+}
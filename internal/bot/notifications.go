@@ -0,0 +1,303 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+)
+
+// Callback data of event notifications: evt_mute_<type>, evt_unmute_<type>
+// and evt_info_<id>. Details are read from the recorded event with that ID.
+// A digest isn't recorded; evt_digest_<type> opens the log of its type.
+const (
+	eventMutePrefix   = "evt_mute_"
+	eventUnmutePrefix = "evt_unmute_"
+	eventInfoPrefix   = "evt_info_"
+	eventDigestPrefix = "evt_digest_"
+)
+
+const eventTimeFormat = "2006-01-02 15:04:05"
+
+// digestPreview is how many events of a digest its notification lists
+const digestPreview = 5
+
+// eventSeverityIcons mark notifications by severity
+var eventSeverityIcons = map[string]string{
+	"info":     "ℹ️",
+	"warning":  "⚠️",
+	"error":    "❌",
+	"critical": "🚨",
+}
+
+// sendEvent notifies the users subscribed to the type and severity of an
+// event, except those who muted its type or got events.recipient_limit
+// notifications in the last minute. Non-critical events are held over the
+// quiet hours of a user; critical ones are sent regardless of them and of
+// the limit. Admins can acknowledge the event if it was recorded.
+func (b *Bot) sendEvent(event events.SystemEvent) {
+	recipients := b.eventRecipients()
+	if len(recipients) == 0 {
+		return
+	}
+
+	now := time.Now()
+	b.notifyUsers(recipients, func(userID int64, user *database.User) (string, *tgbotapi.InlineKeyboardMarkup) {
+		sub, err := b.eventSubscription(userID)
+		if err != nil {
+			log.Printf("Warning: Failed to get the event subscription of user %d: %v", userID, err)
+			return "", nil
+		}
+		if !subscribedTo(sub, event) {
+			return "", nil
+		}
+		muted, err := b.db.GetMutedEventTypes(userID)
+		if err != nil {
+			log.Printf("Warning: Failed to get muted events of user %d: %v", userID, err)
+		}
+		if slices.Contains(muted, string(event.Type)) {
+			return "", nil
+		}
+		if event.Severity != events.SeverityCritical && inQuietHours(sub, now) {
+			b.holdEvent(userID, event)
+			return "", nil
+		}
+
+		text := b.eventText(user, event)
+		if event.Severity != events.SeverityCritical {
+			allowed, skipped := b.notifyLimits.allow(userID, b.config.Events.RecipientLimit, now)
+			if !allowed {
+				return "", nil
+			}
+			if skipped > 0 {
+				text += "\n" + b.t(user, "events.skipped", skipped)
+			}
+		}
+		return text, b.eventKeyboard(user, event)
+	})
+}
+
+// eventText formats a notification, marked by the severity of the event. A
+// digest lists its first events; the rest are behind the details button.
+func (b *Bot) eventText(user *database.User, event events.SystemEvent) string {
+	icon, ok := eventSeverityIcons[event.Severity]
+	if !ok {
+		icon = "🔔"
+	}
+
+	var text string
+	if event.Count > 0 {
+		lines := strings.Split(strings.TrimSpace(event.Details), "\n")
+		if len(lines) > digestPreview {
+			lines = append(lines[:digestPreview], "…")
+		}
+		text = b.t(user, "events.digest", icon, b.eventTypeName(user, event.Type), event.Count,
+			event.Timestamp.Format(eventTimeFormat), strings.Join(lines, "\n"))
+	} else {
+		text = b.t(user, "events.notification", icon, b.eventTypeName(user, event.Type), event.Message,
+			event.Timestamp.Format(eventTimeFormat), event.Source)
+	}
+	if event.Repeats > 0 {
+		text += "\n" + b.t(user, "events.repeats", event.Repeats)
+	}
+	return text
+}
+
+// notifyLimiter counts the event notifications of every recipient for
+// events.recipient_limit. The zero value is ready to use.
+type notifyLimiter struct {
+	mu      sync.Mutex
+	windows map[int64]*notifyWindow
+}
+
+// notifyWindow counts the notifications of a recipient in one minute
+type notifyWindow struct {
+	start   time.Time
+	sent    int
+	skipped int
+}
+
+// allow reports whether a user may get another notification and how many
+// were skipped since the previous one they got. A limit below 1 allows all.
+func (l *notifyLimiter) allow(userID int64, limit int, now time.Time) (bool, int) {
+	if limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.windows == nil {
+		l.windows = make(map[int64]*notifyWindow)
+	}
+
+	w, ok := l.windows[userID]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		skipped := 0
+		if ok {
+			skipped = w.skipped
+		}
+		w = &notifyWindow{start: now, skipped: skipped}
+		l.windows[userID] = w
+	}
+	if w.sent >= limit {
+		w.skipped++
+		return false, 0
+	}
+	w.sent++
+	skipped := w.skipped
+	w.skipped = 0
+	return true, skipped
+}
+
+// eventKeyboard offers to mute the type of an event and to show the details
+// of a recorded event or the log behind a digest. Admins can also
+// acknowledge a recorded event.
+func (b *Bot) eventKeyboard(user *database.User, event events.SystemEvent) *tgbotapi.InlineKeyboardMarkup {
+	row := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(
+			b.t(user, "button.event_mute", b.eventTypeName(user, event.Type)), eventMutePrefix+string(event.Type)),
+	}
+	switch {
+	case event.ID != 0:
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.event_details"), fmt.Sprintf("%s%d", eventInfoPrefix, event.ID)))
+	case event.Count > 0:
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.event_details"), eventDigestPrefix+string(event.Type)))
+	}
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(row)
+	if event.ID != 0 && user != nil && user.IsAdmin {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			b.t(user, "button.event_acknowledge"), fmt.Sprintf("%s%d", eventAckPrefix, event.ID))))
+	}
+	return &kb
+}
+
+// eventTypeName returns the name of an event type in the language of a user
+func (b *Bot) eventTypeName(user *database.User, eventType events.EventType) string {
+	if !slices.Contains(events.EventTypes, eventType) {
+		return string(eventType)
+	}
+	return b.t(user, "events.type."+string(eventType))
+}
+
+// eventTypeNames joins the names of event types
+func (b *Bot) eventTypeNames(user *database.User, types []string) string {
+	names := make([]string, 0, len(types))
+	for _, eventType := range types {
+		names = append(names, b.eventTypeName(user, events.EventType(eventType)))
+	}
+	return strings.Join(names, ", ")
+}
+
+// handleEventsCallback shows the state of event monitoring, what the user
+// receives and the types they muted
+func (b *Bot) handleEventsCallback(user *database.User) (string, bool) {
+	status := b.t(user, "events.running")
+	if !b.config.Events.Enabled {
+		status = b.t(user, "events.disabled")
+	}
+	watched := b.t(user, "events.none")
+	if len(b.config.Events.WatchEvents) > 0 {
+		watched = b.eventTypeNames(user, b.config.Events.WatchEvents)
+	}
+
+	lines := []string{b.t(user, "events.info", status, watched)}
+	sub, err := b.eventSubscription(user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	if sub.Enabled {
+		lines = append(lines, b.t(user, "events.recipient"))
+	} else {
+		lines = append(lines, b.t(user, "events.not_recipient"))
+	}
+
+	muted, err := b.db.GetMutedEventTypes(user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	if len(muted) > 0 {
+		lines = append(lines, "", b.t(user, "events.muted_list", b.eventTypeNames(user, muted)))
+	}
+
+	return strings.Join(lines, "\n"), true
+}
+
+// eventsKeyboard opens the event log and the subscription settings and has
+// an unmute button for every muted type
+func eventsKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	muted, err := b.db.GetMutedEventTypes(user.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to get muted events of user %d: %v", user.ID, err)
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.event_log"), eventLogData),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.subscription"), subscriptionData),
+		),
+	}
+	for _, eventType := range muted {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			b.t(user, "button.event_unmute", b.eventTypeName(user, events.EventType(eventType))), eventUnmutePrefix+eventType)))
+	}
+	rows = append(rows, menuKeyboard(b, user).InlineKeyboard...)
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &kb
+}
+
+// handleEventMuteCallback stops notifications of the type of an event
+func (b *Bot) handleEventMuteCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	eventType := strings.TrimPrefix(callback.Data, eventMutePrefix)
+	if !slices.Contains(events.EventTypes, events.EventType(eventType)) {
+		return b.t(user, "events.unknown_type", eventType), false
+	}
+	if err := b.db.MuteEventType(user.ID, eventType); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.t(user, "events.muted", b.eventTypeName(user, events.EventType(eventType))), true
+}
+
+// handleEventUnmuteCallback resumes notifications of a type and shows the
+// events panel again
+func (b *Bot) handleEventUnmuteCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	eventType := strings.TrimPrefix(callback.Data, eventUnmutePrefix)
+	if err := b.db.UnmuteEventType(user.ID, eventType); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	panel, ok := b.handleEventsCallback(user)
+	return b.t(user, "events.unmuted", b.eventTypeName(user, events.EventType(eventType))) + "\n\n" + panel, ok
+}
+
+// handleEventInfoCallback shows everything recorded about a notified event
+func (b *Bot) handleEventInfoCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	eventID, err := strconv.ParseInt(strings.TrimPrefix(callback.Data, eventInfoPrefix), 10, 64)
+	if err != nil {
+		return b.t(user, "error.unknown_action"), false
+	}
+	event, err := b.db.GetEvent(eventID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return b.t(user, "events.not_found", eventID), false
+	}
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	args := []any{b.eventTypeName(user, events.EventType(event.Type)), event.Message, event.OccurredAt.Format(eventTimeFormat), event.Source}
+	if strings.TrimSpace(event.Details) == "" {
+		return b.t(user, "events.no_details", args...), true
+	}
+	return b.t(user, "events.details", append(args, strings.TrimSpace(event.Details))...), true
+}
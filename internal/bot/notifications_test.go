@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+)
+
+func TestSendEvent(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t)
+	bot.config.Events.NotifyUsers = []int64{admin.ID, user.ID}
+	bot.config.Events.WatchEvents = []string{"login", "error"}
+	if err := bot.db.UpdateUserSession(&database.UserSession{UserID: user.ID, ChatID: -200, LastSeen: time.Now(), IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	login := events.SystemEvent{
+		Type:      events.EventLogin,
+		Message:   "User logged in",
+		Details:   "4624 <admin> from 10.0.0.5",
+		Timestamp: time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local),
+		Severity:  "info",
+		Source:    "security_log",
+	}
+	record := &database.Event{Type: "login", Severity: "info", Source: login.Source, Message: login.Message, Details: login.Details, OccurredAt: login.Timestamp}
+	if err := bot.db.AddEvent(record); err != nil {
+		t.Fatal(err)
+	}
+	login.ID = record.ID
+	bot.sendEvent(login)
+
+	sent := fake.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("Expected both recipients notified, got %+v", sent)
+	}
+	if sent[1].ChatID != -200 {
+		t.Errorf("Expected the user notified in their last chat, got %d", sent[1].ChatID)
+	}
+	for _, expected := range []string{"ℹ️ <b>Login</b>", "User logged in", "2026-10-16 09:30:00 · security_log"} {
+		if !containsString(sent[1].Text, expected) {
+			t.Errorf("Expected %q in the notification, got %s", expected, sent[1].Text)
+		}
+	}
+
+	// Details are read from the recorded event, no token is kept for them
+	if data, _ := sent[1].Button("📄 Details"); data != fmt.Sprintf("evt_info_%d", record.ID) {
+		t.Errorf("Expected the details button to name event %d, got %q", record.ID, data)
+	}
+	fake.Reset()
+	pressButton(t, bot, user, sent[1], "📄 Details")
+	lastSent(t, fake, "<pre>4624 &lt;admin&gt; from 10.0.0.5</pre>")
+
+	// Muting the type stops notifications of it for that user only
+	fake.Reset()
+	pressButton(t, bot, user, sent[1], "🔕 Mute Login")
+	muted := lastSent(t, fake, "no longer be notified about Login events")
+	fake.Reset()
+	bot.sendEvent(login)
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].ChatID != admin.ID {
+		t.Fatalf("Expected only the admin notified, got %+v", sent)
+	}
+	fake.Reset()
+	bot.sendEvent(events.SystemEvent{Type: events.EventError, Message: "Disk failure", Severity: "critical", Timestamp: time.Now()})
+	if sent := fake.Sent(); len(sent) != 2 || !containsString(sent[1].Text, "🚨 <b>System error</b>") {
+		t.Fatalf("Expected both notified about the critical error, got %+v", sent)
+	}
+
+	// Events that are not watched are not sent
+	fake.Reset()
+	bot.sendEvent(events.SystemEvent{Type: events.EventProcess, Message: "New process started: x", Severity: "info"})
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Errorf("Expected no notification about an unwatched event, got %+v", sent)
+	}
+
+	// The events panel lists muted types and unmutes them
+	response, _ := bot.handleEventsCallback(user)
+	if !containsString(response, "Muted: Login") || !containsString(response, "You receive notifications") {
+		t.Errorf("Unexpected events panel: %s", response)
+	}
+	fake.Reset()
+	pressButton(t, bot, user, muted, "🔔 Unmute Login")
+	lastSent(t, fake, "Notifications about Login events are back on")
+	if types, _ := bot.db.GetMutedEventTypes(user.ID); len(types) != 0 {
+		t.Errorf("Expected nothing muted, got %v", types)
+	}
+}
+
+func TestSendEventLimits(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+	bot.config.Events.NotifyUsers = []int64{admin.ID}
+	bot.config.Events.WatchEvents = []string{"process", "error"}
+	bot.config.Events.RecipientLimit = 2
+
+	process := func(message string) events.SystemEvent {
+		return events.SystemEvent{Type: events.EventProcess, Message: message, Severity: "info", Timestamp: time.Now()}
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		bot.sendEvent(process("New process started: " + name))
+	}
+	if sent := fake.Sent(); len(sent) != 2 {
+		t.Fatalf("Expected 2 notifications within the limit, got %+v", sent)
+	}
+
+	// Critical events are sent over the limit
+	bot.sendEvent(events.SystemEvent{Type: events.EventError, Message: "Disk failure", Severity: "critical", Timestamp: time.Now()})
+	lastSent(t, fake, "Disk failure")
+
+	// The next minute reports what was skipped
+	bot.notifyLimits.windows[admin.ID].start = time.Now().Add(-time.Minute)
+	fake.Reset()
+	bot.sendEvent(events.SystemEvent{
+		Type:      events.EventProcess,
+		Message:   "Digest of 7 process events",
+		Details:   "09:00:01 New process started: p1\n09:00:02 New process started: p2\n09:00:03 New process started: p3\n09:00:04 New process started: p4\n09:00:05 New process started: p5\n09:00:06 New process started: p6",
+		Severity:  "info",
+		Source:    "digest",
+		Timestamp: time.Now(),
+		Count:     7,
+		Repeats:   2,
+	})
+	digest := lastSent(t, fake, "digest of 7 events")
+	for _, expected := range []string{"09:00:05 New process started: p5\n…", "Repeated 2 more times", "2 notifications were skipped"} {
+		if !containsString(digest.Text, expected) {
+			t.Errorf("Expected %q in the digest, got %s", expected, digest.Text)
+		}
+	}
+	if containsString(digest.Text, "p6") {
+		t.Errorf("Expected the digest preview capped, got %s", digest.Text)
+	}
+
+	// A digest isn't recorded; its details are the log of its type
+	fake.Reset()
+	pressButton(t, bot, admin, digest, "📄 Details")
+	lastSent(t, fake, "Filter: process")
+}
+
+func TestThrottleOnlyNotifications(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+	bot.config.Events.NotifyUsers = []int64{admin.ID}
+	bot.config.Events.WatchEvents = []string{"process"}
+	bot.config.Events.Throttle = map[string]config.EventThrottle{"process": {DedupWindow: 300}}
+	bot.notifications = events.NewThrottle(bot.config, bot.sendEvent)
+
+	for range 3 {
+		bot.handleSystemEvent(events.SystemEvent{Type: events.EventProcess, Message: "New process started: cron", Severity: "info", Timestamp: time.Now()})
+	}
+
+	if sent := fake.Sent(); len(sent) != 1 {
+		t.Errorf("Expected the duplicates collapsed into one notification, got %+v", sent)
+	}
+	if total, err := bot.db.CountEvents(database.EventFilter{}); err != nil || total != 3 {
+		t.Errorf("Expected every event recorded, got %d, %v", total, err)
+	}
+}
@@ -0,0 +1,350 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cupbot/cupbot/internal/callbacks"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/system"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback prefixes of the process manager. The list keeps its sort order
+// and filter in a callback token; details and signals name the process by
+// PID and start time, so a reused PID is never signalled.
+const (
+	psPagePrefix      = "ps_p_"
+	psInfoPrefix      = "ps_i_"
+	psTerminatePrefix = "ps_term_"
+	psKillPrefix      = "ps_kill_"
+)
+
+const (
+	psPageSize      = 10
+	psNameWidth     = 24
+	psShownFiles    = 5
+	psShownChildren = 10
+)
+
+// psListAction is the token action of a list sorted by sortBy
+func psListAction(sortBy string) string {
+	return "ps_" + sortBy
+}
+
+// handlePs обрабатывает команду /ps: [cpu|mem] [filter]
+func (b *Bot) handlePs(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	sortBy := system.SortByCPU
+	fields := strings.Fields(args)
+	if len(fields) > 0 {
+		switch strings.ToLower(fields[0]) {
+		case system.SortByCPU, system.SortByMemory:
+			sortBy = strings.ToLower(fields[0])
+			fields = fields[1:]
+		}
+	}
+
+	token, err := b.callbackStore.Issue(user.ID, psListAction(sortBy), strings.Join(fields, " "), 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	text, keyboard, ok := b.processListView(user, token, sortBy, strings.Join(fields, " "), 0)
+	if !ok {
+		return text, false
+	}
+	if _, err := b.sendText(message.Chat.ID, text, keyboard); err != nil {
+		log.Printf("Failed to send process list: %v", err)
+		return b.t(user, "error.generic", err), false
+	}
+	return "", true
+}
+
+// handlePsPageCallback shows a page of the list a token describes
+func (b *Bot) handlePsPageCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	token := strings.TrimPrefix(callback.Data, psPagePrefix)
+	payload, response := b.resolvePsToken(user, token)
+	if payload == nil {
+		return response, false
+	}
+
+	text, keyboard, ok := b.processListView(user, token, strings.TrimPrefix(payload.Action, "ps_"), payload.Path, payload.Page)
+	if !ok {
+		return text, false
+	}
+	if err := b.editCallbackMessage(callback, text, keyboard); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to update message: %v", err)
+		return b.t(user, "error.update_interface"), false
+	}
+	return "", true
+}
+
+// handlePsInfoCallback shows the details of a process, with the list token
+// to go back to
+func (b *Bot) handlePsInfoCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(callback.Data, psInfoPrefix), "_", 3)
+	if len(parts) != 3 {
+		return b.t(user, "error.unknown_action"), false
+	}
+	pid, createTime, err := parseProcessRef(parts[0] + "_" + parts[1])
+	if err != nil {
+		return b.t(user, "error.unknown_action"), false
+	}
+	backToken := parts[2]
+
+	details, err := b.systemService.GetProcess(pid, createTime)
+	if err != nil {
+		return b.processError(user, err), false
+	}
+
+	ref := processRef(details.PID, details.CreateTime)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.terminate", details.PID), psTerminatePrefix+ref),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.kill_process", details.PID), psKillPrefix+ref),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.back_processes"), psPagePrefix+backToken),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), callback.Data),
+		),
+		b.menuRow(user),
+	)
+	if err := b.editCallbackMessage(callback, b.processDetailsText(user, details), &keyboard); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to update message: %v", err)
+		return b.t(user, "error.update_interface"), false
+	}
+	return "", true
+}
+
+// handlePsSignalCallback terminates or kills a process; the buttons are
+// always confirmed first
+func (b *Bot) handlePsSignalCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	kill := strings.HasPrefix(callback.Data, psKillPrefix)
+	ref := strings.TrimPrefix(strings.TrimPrefix(callback.Data, psKillPrefix), psTerminatePrefix)
+	pid, createTime, err := parseProcessRef(ref)
+	if err != nil {
+		return b.t(user, "error.unknown_action"), false
+	}
+
+	details, err := b.systemService.GetProcess(pid, createTime)
+	if err != nil {
+		return b.processError(user, err), false
+	}
+	if err := b.systemService.SignalProcess(pid, createTime, kill); err != nil {
+		return b.processError(user, err), false
+	}
+
+	if kill {
+		log.Printf("User %d (%s) killed process %d (%s)", user.ID, user.Username, pid, details.Name)
+		return b.t(user, "ps.killed", details.Name, pid), true
+	}
+	log.Printf("User %d (%s) terminated process %d (%s)", user.ID, user.Username, pid, details.Name)
+	return b.t(user, "ps.terminated", details.Name, pid), true
+}
+
+// processListView renders a page of the process list and its keyboard.
+// On failure it returns the response to show instead.
+func (b *Bot) processListView(user *database.User, token, sortBy, filter string, page int) (string, *tgbotapi.InlineKeyboardMarkup, bool) {
+	list, err := b.systemService.ListProcesses(sortBy, filter)
+	if err != nil {
+		return b.t(user, "error.generic", err), nil, false
+	}
+
+	pages := (len(list) + psPageSize - 1) / psPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	start := page * psPageSize
+	end := start + psPageSize
+	if end > len(list) {
+		end = len(list)
+	}
+	shown := list[start:end]
+
+	sortLabel := b.t(user, "ps.sort_"+sortBy)
+	text := b.t(user, "ps.title", render.HTML(sortLabel), len(list), page+1, pages) + "\n"
+	if filter != "" {
+		text += b.t(user, "ps.filter", filter) + "\n"
+	}
+	if len(shown) == 0 {
+		return text + "\n" + b.t(user, "ps.none"), b.processListKeyboard(user, token, sortBy, filter, page, pages, nil), true
+	}
+
+	var table strings.Builder
+	fmt.Fprintf(&table, "%7s %6s %9s  %s\n", "PID", "CPU%", "RSS", b.t(user, "ps.column_name"))
+	for _, p := range shown {
+		fmt.Fprintf(&table, "%7d %6.1f %9s  %s\n", p.PID, p.CPUPercent, system.FormatBytes(p.RSS), shortProcessName(p.Name))
+	}
+	text += "\n<pre>" + render.Escape(table.String()) + "</pre>"
+	return text, b.processListKeyboard(user, token, sortBy, filter, page, pages, shown), true
+}
+
+// processListKeyboard has a button per shown process, the page navigation
+// and the sort toggle
+func (b *Bot) processListKeyboard(user *database.User, token, sortBy, filter string, page, pages int, shown []system.ProcessInfo) *tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, p := range shown {
+		label := fmt.Sprintf("%d %s", p.PID, shortProcessName(p.Name))
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, psInfoPrefix+processRef(p.PID, p.CreateTime)+"_"+token))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.prev"), b.psPageData(user, sortBy, filter, page-1)))
+	}
+	nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), psPagePrefix+token))
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.next"), b.psPageData(user, sortBy, filter, page+1)))
+	}
+	rows = append(rows, nav)
+
+	other := system.SortByMemory
+	if sortBy == system.SortByMemory {
+		other = system.SortByCPU
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.sort_"+other), b.psPageData(user, other, filter, 0))),
+		b.menuRow(user),
+	)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// psPageData issues a token for a page of the list
+func (b *Bot) psPageData(user *database.User, sortBy, filter string, page int) string {
+	token, err := b.callbackStore.Issue(user.ID, psListAction(sortBy), filter, page)
+	if err != nil {
+		log.Printf("Failed to issue callback token for user %d: %v", user.ID, err)
+		return psPagePrefix
+	}
+	return psPagePrefix + token
+}
+
+// resolvePsToken resolves a list token. On failure it returns nil and the
+// response to show to the user.
+func (b *Bot) resolvePsToken(user *database.User, token string) (*callbacks.Payload, string) {
+	payload, err := b.callbackStore.Resolve(token, user.ID)
+	switch {
+	case err == nil && strings.HasPrefix(payload.Action, "ps_"):
+		return payload, ""
+	case err == nil, errors.Is(err, callbacks.ErrNotFound), errors.Is(err, callbacks.ErrExpired):
+		return nil, b.t(user, "ps.expired")
+	case errors.Is(err, callbacks.ErrForeignUser):
+		log.Printf("User %d tried to use a callback token of another user", user.ID)
+		return nil, b.t(user, "ps.expired")
+	default:
+		return nil, b.t(user, "error.generic", err)
+	}
+}
+
+// processDetailsText describes one process
+func (b *Bot) processDetailsText(user *database.User, d *system.ProcessDetails) string {
+	var text strings.Builder
+	text.WriteString(b.t(user, "ps.details_title", d.Name, d.PID) + "\n\n")
+	if d.Username != "" {
+		text.WriteString(b.t(user, "ps.user", d.Username) + "\n")
+	}
+	text.WriteString(b.t(user, "ps.parent", d.PPID) + "\n")
+	if d.Status != "" {
+		text.WriteString(b.t(user, "ps.status", d.Status) + "\n")
+	}
+	text.WriteString(b.t(user, "ps.started", d.StartedAt.Format("2006-01-02 15:04:05")) + "\n")
+	text.WriteString(b.t(user, "ps.memory", system.FormatBytes(d.RSS), d.MemoryPercent) + "\n")
+	text.WriteString(b.t(user, "ps.threads", d.Threads) + "\n")
+	if d.Exe != "" {
+		text.WriteString(b.t(user, "ps.exe", d.Exe) + "\n")
+	}
+	if d.Cmdline != "" {
+		text.WriteString(b.t(user, "ps.cmdline", d.Cmdline) + "\n")
+	}
+
+	text.WriteString("\n")
+	if d.OpenFilesErr != nil {
+		text.WriteString(b.t(user, "ps.open_files_unavailable") + "\n")
+	} else {
+		text.WriteString(b.t(user, "ps.open_files", len(d.OpenFiles)) + "\n")
+		for i, path := range d.OpenFiles {
+			if i == psShownFiles {
+				text.WriteString(b.t(user, "ps.more", len(d.OpenFiles)-i) + "\n")
+				break
+			}
+			text.WriteString("• " + string(render.Code(path)) + "\n")
+		}
+	}
+
+	text.WriteString(b.t(user, "ps.children", len(d.Children)) + "\n")
+	for i, child := range d.Children {
+		if i == psShownChildren {
+			text.WriteString(b.t(user, "ps.more", len(d.Children)-i) + "\n")
+			break
+		}
+		text.WriteString(fmt.Sprintf("• %s (%d)\n", render.Escape(child.Name), child.PID))
+	}
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// processError turns an error of the process functions into a response
+func (b *Bot) processError(user *database.User, err error) string {
+	switch {
+	case errors.Is(err, system.ErrProcessNotFound):
+		return b.t(user, "ps.gone")
+	case errors.Is(err, system.ErrOwnProcess):
+		return b.t(user, "ps.own")
+	default:
+		return b.t(user, "error.generic", err)
+	}
+}
+
+// menuRow is a row with the "Menu" button
+func (b *Bot) menuRow(user *database.User) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.menu"), "menu"))
+}
+
+// processRef names a process in callback data as "<pid>_<start time>"
+func processRef(pid int32, createTime int64) string {
+	return fmt.Sprintf("%d_%d", pid, createTime)
+}
+
+func parseProcessRef(ref string) (int32, int64, error) {
+	pidText, createText, ok := strings.Cut(ref, "_")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid process reference %q", ref)
+	}
+	pid, err := strconv.ParseInt(pidText, 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	createTime, err := strconv.ParseInt(createText, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int32(pid), createTime, nil
+}
+
+// shortProcessName cuts long names to fit the table and the buttons
+func shortProcessName(name string) string {
+	if utf8.RuneCountInString(name) <= psNameWidth {
+		return name
+	}
+	return string([]rune(name)[:psNameWidth-1]) + "…"
+}
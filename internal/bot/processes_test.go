@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+)
+
+// startTestProcess starts a sleep the tests can find and signal
+func startTestProcess(t *testing.T) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd
+}
+
+func TestProcessList(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+	child := startTestProcess(t)
+	pid := fmt.Sprint(child.Process.Pid)
+
+	bot.handleMessage(commandMessage(admin, "/ps mem SLEEP"), admin)
+	list := lastSent(t, fake, "sorted by memory")
+	if list.Method != telegramtest.MethodSendMessage || !containsString(list.Text, pid) || !containsString(list.Text, "<pre>") {
+		t.Fatalf("Expected a table with the child process, got %s: %s", list.Method, list.Text)
+	}
+	if _, ok := list.Button(pid + " sleep"); !ok {
+		t.Error("Every shown process should have a details button")
+	}
+
+	pressButton(t, bot, admin, list, "Sort by CPU")
+	sorted := lastSent(t, fake, "sorted by CPU")
+	if sorted.Method != telegramtest.MethodEditMessage || !containsString(sorted.Text, pid) {
+		t.Errorf("Expected the list edited in place and still filtered, got %s: %s", sorted.Method, sorted.Text)
+	}
+	if _, ok := sorted.Button("Sort by Memory"); !ok {
+		t.Error("Sort toggle should switch back to memory")
+	}
+
+	bot.handleMessage(commandMessage(admin, "/ps no-such-process-name"), admin)
+	lastSent(t, fake, "No processes match")
+}
+
+func TestProcessListPages(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+	for i := 0; i < psPageSize+1; i++ {
+		startTestProcess(t)
+	}
+
+	bot.handleMessage(commandMessage(admin, "/ps sleep"), admin)
+	first := lastSent(t, fake, "page 1/")
+	if _, ok := first.Button("Prev"); ok {
+		t.Error("First page should have no Prev button")
+	}
+
+	pressButton(t, bot, admin, first, "Next")
+	second := lastSent(t, fake, "page 2/")
+	if _, ok := second.Button("Prev"); !ok {
+		t.Error("Second page should lead back")
+	}
+}
+
+func TestProcessDetailsAndTerminate(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+	child := startTestProcess(t)
+	pid := fmt.Sprint(child.Process.Pid)
+
+	bot.handleMessage(commandMessage(admin, "/ps sleep"), admin)
+	list := lastSent(t, fake, "Processes")
+	pressButton(t, bot, admin, list, pid+" sleep")
+	details := lastSent(t, fake, "Parent PID")
+	if !containsString(details.Text, "sleep 30") {
+		t.Errorf("Details should show the command line, got: %s", details.Text)
+	}
+	if _, ok := details.Button("Back to Processes"); !ok {
+		t.Error("Details should lead back to the list")
+	}
+
+	// Signals are always confirmed
+	exited := waitProcess(child)
+	pressButton(t, bot, admin, details, "Terminate "+pid)
+	prompt := lastSent(t, fake, "Confirm action")
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case <-exited:
+		t.Fatal("Process should not be signalled before confirmation")
+	}
+
+	pressButton(t, bot, admin, prompt, "Confirm")
+	lastSent(t, fake, "to terminate")
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Terminated process is still running")
+	}
+
+	// The details of an exited process are gone
+	pressButton(t, bot, admin, list, pid+" sleep")
+	lastSent(t, fake, "has exited")
+}
+
+func TestProcessesRequireAdmin(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t)
+
+	bot.handleMessage(commandMessage(user, "/ps"), user)
+	lastSent(t, fake, "Admin privileges required")
+
+	bot.handleMessage(commandMessage(admin, "/ps"), admin)
+	list := lastSent(t, fake, "Processes")
+	pressButton(t, bot, user, list, "Refresh")
+	lastSent(t, fake, "Admin privileges required")
+
+	// An admin can't reuse the list of another admin
+	user.IsAdmin = true
+	pressButton(t, bot, user, list, "Refresh")
+	lastSent(t, fake, "has expired")
+}
+
+// waitProcess reports when a started process exits
+func waitProcess(cmd *exec.Cmd) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		cmd.Process.Wait()
+		close(done)
+	}()
+	return done
+}
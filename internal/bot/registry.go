@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Role is the minimal role required to run a command or callback
+type Role int
+
+const (
+	RoleUser Role = iota
+	RoleAdmin
+)
+
+// allows reports whether the user has at least this role
+func (r Role) allows(user *database.User) bool {
+	return r == RoleUser || user.IsAdmin
+}
+
+// commandFunc handles a slash command and returns the text reply
+type commandFunc func(b *Bot, message *tgbotapi.Message, user *database.User, args string) (string, bool)
+
+// callbackFunc handles an inline keyboard callback and returns the text reply
+type callbackFunc func(b *Bot, callback *tgbotapi.CallbackQuery, user *database.User) (string, bool)
+
+// keyboardFunc builds the keyboard attached to a reply. A nil result means
+// the reply is sent without a keyboard.
+type keyboardFunc func(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup
+
+// Command describes a slash command
+type Command struct {
+	Name        string
+	Aliases     []string
+	Role        Role
+	Usage       string // argument hint shown in /help, e.g. "[N]"; may be a catalog key
+	Description string // catalog key of the description
+	Handler     commandFunc
+	// Keyboard is attached to the reply; defaults to the "Menu" button
+	Keyboard keyboardFunc
+	// LogsHistory is set when the handler writes its own command_history entry
+	LogsHistory bool
+}
+
+// Callback describes an inline keyboard callback. Data matches the callback
+// data exactly, Prefix matches every callback starting with it.
+type Callback struct {
+	Data    string
+	Prefix  string
+	Role    Role
+	Handler callbackFunc
+	// Keyboard is attached to the reply; defaults to the "Menu" button
+	Keyboard keyboardFunc
+	// Panel replies replace the message the button is on for users with
+	// the panel menu style
+	Panel bool
+	// Confirm stages the button for confirmation whether or not it is
+	// listed in bot.confirm.actions
+	Confirm bool
+}
+
+// commandRegistry keeps every command, callback and dialog the bot understands
+type commandRegistry struct {
+	commands  []*Command
+	byName    map[string]*Command
+	callbacks map[string]*Callback
+	prefixes  []*Callback
+	dialogs   map[string]*Dialog
+}
+
+func newRegistry() *commandRegistry {
+	return &commandRegistry{
+		byName:    make(map[string]*Command),
+		callbacks: make(map[string]*Callback),
+		dialogs:   make(map[string]*Dialog),
+	}
+}
+
+// addCommand registers a command under its name and aliases
+func (r *commandRegistry) addCommand(cmd *Command) {
+	r.commands = append(r.commands, cmd)
+	r.byName[cmd.Name] = cmd
+	for _, alias := range cmd.Aliases {
+		r.byName[alias] = cmd
+	}
+}
+
+// addCallback registers a callback by exact data or by prefix
+func (r *commandRegistry) addCallback(cb *Callback) {
+	if cb.Prefix != "" {
+		r.prefixes = append(r.prefixes, cb)
+		// Longest prefix wins
+		sort.SliceStable(r.prefixes, func(i, j int) bool {
+			return len(r.prefixes[i].Prefix) > len(r.prefixes[j].Prefix)
+		})
+		return
+	}
+	r.callbacks[cb.Data] = cb
+}
+
+// addDialog registers a dialog under its name
+func (r *commandRegistry) addDialog(d *Dialog) {
+	r.dialogs[d.Name] = d
+}
+
+// command looks up a command by name or alias
+func (r *commandRegistry) command(name string) *Command {
+	return r.byName[name]
+}
+
+// commandNames returns the names and aliases of every command
+func (r *commandRegistry) commandNames() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// callback looks up the callback matching the data
+func (r *commandRegistry) callback(data string) *Callback {
+	if cb, ok := r.callbacks[data]; ok {
+		return cb
+	}
+	for _, cb := range r.prefixes {
+		if strings.HasPrefix(data, cb.Prefix) {
+			return cb
+		}
+	}
+	return nil
+}
+
+// dialog looks up a dialog by name
+func (r *commandRegistry) dialog(name string) *Dialog {
+	return r.dialogs[name]
+}
+
+// commandsFor returns the commands available with exactly the given role
+func (r *commandRegistry) commandsFor(role Role) []*Command {
+	var result []*Command
+	for _, cmd := range r.commands {
+		if cmd.Role == role {
+			result = append(result, cmd)
+		}
+	}
+	return result
+}
+
+// botCommands builds the setMyCommands list for a role in lang. Admins get
+// the user commands followed by their own.
+func (r *commandRegistry) botCommands(role Role, lang string) []tgbotapi.BotCommand {
+	var result []tgbotapi.BotCommand
+	for _, cmd := range r.commands {
+		if cmd.Role > role {
+			continue
+		}
+		result = append(result, tgbotapi.BotCommand{
+			Command:     cmd.Name,
+			Description: i18n.T(lang, cmd.Description),
+		})
+	}
+	return result
+}
+
+// helpSection formats the commands of one role as help lines in lang
+func (r *commandRegistry) helpSection(role Role, lang string) string {
+	var lines []string
+	for _, cmd := range r.commandsFor(role) {
+		line := "/" + cmd.Name
+		if cmd.Usage != "" {
+			line += " " + i18n.T(lang, cmd.Usage)
+		}
+		lines = append(lines, line+" - "+i18n.T(lang, cmd.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resolveKeyboard returns the keyboard for a reply, falling back to the menu button
+func resolveKeyboard(b *Bot, user *database.User, kb keyboardFunc) *tgbotapi.InlineKeyboardMarkup {
+	if kb == nil {
+		return menuKeyboard(b, user)
+	}
+	return kb(b, user)
+}
+
+// Common keyboard functions used by registrations
+
+func menuKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	kb := b.getMenuKeyboard(b.lang(user))
+	return &kb
+}
+
+func mainKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	kb := b.getMainKeyboard(b.lang(user), user.IsAdmin)
+	return &kb
+}
+
+func noKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	return nil
+}
+
+// staticKeyboard adapts a keyboard builder that only depends on the
+// language of the user
+func staticKeyboard(build func(b *Bot, lang string) tgbotapi.InlineKeyboardMarkup) keyboardFunc {
+	return func(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+		kb := build(b, b.lang(user))
+		return &kb
+	}
+}
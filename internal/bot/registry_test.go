@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRegistryCommandLookup(t *testing.T) {
+	r := newCommandRegistry()
+
+	help := r.command("help")
+	if help == nil {
+		t.Fatal("help command should be registered")
+	}
+	if r.command("menu") != help {
+		t.Error("menu should be an alias of help")
+	}
+	if r.command("nonexistent") != nil {
+		t.Error("unknown command should not be found")
+	}
+
+	for _, name := range []string{"users", "stats", "cleanup", "addadmin", "removeadmin", "banuser", "unbanuser", "deleteuser"} {
+		cmd := r.command(name)
+		if cmd == nil {
+			t.Errorf("command %s should be registered", name)
+			continue
+		}
+		if cmd.Role != RoleAdmin {
+			t.Errorf("command %s should require admin role", name)
+		}
+	}
+}
+
+func TestRegistryCallbackLookup(t *testing.T) {
+	r := newCommandRegistry()
+
+	testCases := []struct {
+		data   string
+		prefix string
+	}{
+		{"fm_drives", ""},
+		{"fm_page_info", ""},
+		{"fm_dir_QzpcXA==", "fm_dir_"},
+		{"fm_drive_C", "fm_drive_"},
+		{"fm_page_QzpcXA==_2", "fm_page_"},
+		{"fm_something_else", "fm_"},
+	}
+
+	for _, tc := range testCases {
+		cb := r.callback(tc.data)
+		if cb == nil {
+			t.Errorf("callback %s should be registered", tc.data)
+			continue
+		}
+		if cb.Prefix != tc.prefix {
+			t.Errorf("callback %s matched prefix %q, expected %q", tc.data, cb.Prefix, tc.prefix)
+		}
+	}
+}
+
+func TestRegistryRoleCheck(t *testing.T) {
+	user := &database.User{ID: 1, IsAdmin: false}
+	admin := &database.User{ID: 2, IsAdmin: true}
+
+	if !RoleUser.allows(user) || !RoleUser.allows(admin) {
+		t.Error("user role should allow everyone")
+	}
+	if RoleAdmin.allows(user) {
+		t.Error("admin role should reject regular users")
+	}
+	if !RoleAdmin.allows(admin) {
+		t.Error("admin role should allow admins")
+	}
+}
+
+func TestRegistryBotCommands(t *testing.T) {
+	r := newCommandRegistry()
+
+	userCommands := r.botCommands(RoleUser, i18n.English)
+	adminCommands := r.botCommands(RoleAdmin, i18n.English)
+
+	if len(adminCommands) <= len(userCommands) {
+		t.Error("admins should see more commands than regular users")
+	}
+
+	for _, cmd := range userCommands {
+		if cmd.Command == "banuser" {
+			t.Error("admin commands should not be published for regular users")
+		}
+		if cmd.Description == "" {
+			t.Errorf("command %s should have a description", cmd.Command)
+		}
+	}
+}
+
+func TestHelpGeneratedFromRegistry(t *testing.T) {
+	bot := setupTestBot(t)
+	defer teardownTestBot(t, bot)
+
+	message := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}}
+
+	help, _ := bot.handleHelp(message, &database.User{ID: 1})
+	for _, cmd := range bot.commands.commandsFor(RoleUser) {
+		if !strings.Contains(help, "/"+cmd.Name) {
+			t.Errorf("help should mention /%s", cmd.Name)
+		}
+	}
+	if strings.Contains(help, "/banuser") {
+		t.Error("help for regular users should not list admin commands")
+	}
+
+	adminHelp, _ := bot.handleHelp(message, &database.User{ID: 2, IsAdmin: true})
+	if !strings.Contains(adminHelp, "/banuser [ID]") {
+		t.Error("admin help should list admin commands with usage")
+	}
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxMessageParts is the number of messages a long reply may be split into;
+// longer replies are sent as a text document
+const maxMessageParts = 4
+
+// replyFileName names the document a long reply is sent as
+const replyFileName = "reply.txt"
+
+// sendText sends an HTML reply to a chat. Replies over the message limit are
+// split, the keyboard goes with the last part. It returns the last message.
+func (b *Bot) sendText(chatID int64, text string, keyboard *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+	return b.sendParts(chatID, text, render.Split(text, render.MaxMessageLength), keyboard)
+}
+
+// sendParts sends the parts of a split reply, or the whole reply as a
+// document when there are too many of them
+func (b *Bot) sendParts(chatID int64, text string, parts []string, keyboard *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+	if len(parts) > maxMessageParts {
+		return b.sendTextDocument(chatID, text, keyboard)
+	}
+
+	var sent tgbotapi.Message
+	for i, part := range parts {
+		msg := tgbotapi.NewMessage(chatID, part)
+		msg.ParseMode = tgbotapi.ModeHTML
+		if i == len(parts)-1 && keyboard != nil {
+			msg.ReplyMarkup = keyboard
+		}
+
+		var err error
+		if sent, err = b.sendMessage(msg); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// sendMessage sends one message. When Telegram can't parse the markup the
+// message is sent again as plain text rather than lost.
+func (b *Bot) sendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	sent, err := b.api.SendMessage(msg)
+	if err != nil && msg.ParseMode != "" && isParseError(err) {
+		log.Printf("Failed to render message for chat %d, sending plain text: %v", msg.ChatID, err)
+		msg.Text = render.Plain(msg.Text)
+		msg.ParseMode = ""
+		sent, err = b.api.SendMessage(msg)
+	}
+	return sent, err
+}
+
+// editText replaces the text of a message with an HTML reply. A reply over
+// the message limit keeps its first part in the message and continues in
+// new ones.
+func (b *Bot) editText(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	parts := render.Split(text, render.MaxMessageLength)
+	if len(parts) == 1 {
+		return b.editMessage(chatID, messageID, text, keyboard)
+	}
+
+	if err := b.editMessage(chatID, messageID, parts[0], nil); err != nil {
+		return err
+	}
+	_, err := b.sendParts(chatID, text, parts[1:], keyboard)
+	return err
+}
+
+// editMessage edits one message, falling back to plain text like sendMessage
+func (b *Bot) editMessage(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.ReplyMarkup = keyboard
+
+	_, err := b.api.EditMessageText(edit)
+	if err != nil && isParseError(err) {
+		log.Printf("Failed to render message %d in chat %d, sending plain text: %v", messageID, chatID, err)
+		edit.Text = render.Plain(text)
+		edit.ParseMode = ""
+		_, err = b.api.EditMessageText(edit)
+	}
+	return err
+}
+
+// sendTextDocument sends a reply too long for messages as a text file,
+// captioned with its first line
+func (b *Bot) sendTextDocument(chatID int64, text string, keyboard *tgbotapi.InlineKeyboardMarkup) (tgbotapi.Message, error) {
+	plain := render.Plain(text)
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: replyFileName, Bytes: []byte(plain)})
+	doc.Caption = strings.TrimSpace(strings.SplitN(strings.TrimSpace(plain), "\n", 2)[0])
+	if keyboard != nil {
+		doc.ReplyMarkup = keyboard
+	}
+	return b.sendDocument(doc)
+}
+
+// sendDocument sends a file, shortening a caption over the Telegram limit
+func (b *Bot) sendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	doc.Caption = render.Truncate(doc.Caption, render.MaxCaptionLength)
+	return b.api.SendDocument(doc)
+}
+
+// sendPhoto sends a picture, shortening a caption over the Telegram limit
+func (b *Bot) sendPhoto(photo tgbotapi.PhotoConfig) (tgbotapi.Message, error) {
+	photo.Caption = render.Truncate(photo.Caption, render.MaxCaptionLength)
+	return b.api.SendPhoto(photo)
+}
+
+// isParseError reports whether Telegram rejected the markup of a message
+func isParseError(err error) bool {
+	return strings.Contains(err.Error(), "can't parse entities")
+}
@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sentMessages returns the recorded requests of a method
+func sentMessages(fake *telegramtest.Fake, method string) []telegramtest.Sent {
+	var result []telegramtest.Sent
+	for _, sent := range fake.Sent() {
+		if sent.Method == method {
+			result = append(result, sent)
+		}
+	}
+	return result
+}
+
+func TestSendEscapesUserContent(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	odd := &database.User{ID: 555, Username: "john_doe", FirstName: "<Bob>", LastName: "*&*", IsActive: true}
+	if err := bot.db.CreateOrUpdateUser(odd); err != nil {
+		t.Fatal(err)
+	}
+
+	bot.handleMessage(commandMessage(admin, "/users"), admin)
+	reply := lastSent(t, fake, "john_doe")
+	if reply.ParseMode != tgbotapi.ModeHTML {
+		t.Errorf("Expected HTML reply, got %q", reply.ParseMode)
+	}
+	if !strings.Contains(reply.Text, "&lt;Bob&gt; *&amp;*") {
+		t.Errorf("User names should be escaped, got: %s", reply.Text)
+	}
+}
+
+func TestSendSplitsLongReplies(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	for i := 0; i < 60; i++ {
+		u := &database.User{ID: int64(1000 + i), Username: fmt.Sprintf("user_%d", i),
+			FirstName: strings.Repeat("Name", 10), LastName: strings.Repeat("Last", 10), IsActive: true}
+		if err := bot.db.CreateOrUpdateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bot.handleMessage(commandMessage(admin, "/users"), admin)
+	messages := sentMessages(fake, telegramtest.MethodSendMessage)
+	if len(messages) < 2 {
+		t.Fatalf("Expected the reply to be split, got %d messages", len(messages))
+	}
+	for i, msg := range messages {
+		if render.Length(msg.Text) > render.MaxMessageLength {
+			t.Errorf("Part %d exceeds the message limit", i)
+		}
+		if hasKeyboard := msg.Keyboard != nil; hasKeyboard != (i == len(messages)-1) {
+			t.Errorf("Only the last part should carry the keyboard, part %d has one: %v", i, hasKeyboard)
+		}
+	}
+}
+
+func TestSendLongReplyAsDocument(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	text := "<b>Report</b>\n" + strings.Repeat("a line of the report\n", 2000)
+	keyboard := bot.getMenuKeyboard("en")
+	if _, err := bot.sendText(admin.ID, text, &keyboard); err != nil {
+		t.Fatalf("sendText failed: %v", err)
+	}
+
+	if messages := sentMessages(fake, telegramtest.MethodSendMessage); len(messages) != 0 {
+		t.Errorf("Expected no messages, got %d", len(messages))
+	}
+	docs := sentMessages(fake, telegramtest.MethodSendDocument)
+	if len(docs) != 1 {
+		t.Fatalf("Expected one document, got %d", len(docs))
+	}
+	if docs[0].Text != "Report" {
+		t.Errorf("Document should be captioned with the first line, got %q", docs[0].Text)
+	}
+	if docs[0].Keyboard == nil {
+		t.Error("Document should carry the keyboard")
+	}
+	file, ok := docs[0].File.(tgbotapi.FileBytes)
+	if !ok || strings.Contains(string(file.Bytes), "<b>") || !strings.HasPrefix(string(file.Bytes), "Report\n") {
+		t.Errorf("Document should hold the plain text of the reply")
+	}
+}
+
+func TestSendFallsBackToPlainText(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	if _, err := bot.sendText(admin.ID, "<b>broken", nil); err != nil {
+		t.Fatalf("sendText failed: %v", err)
+	}
+
+	reply := lastSent(t, fake, "broken")
+	if reply.ParseMode != "" || reply.Text != "broken" {
+		t.Errorf("Expected a plain text retry, got %q in mode %q", reply.Text, reply.ParseMode)
+	}
+}
+
+func TestEditTextContinuesLongReplies(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	text := strings.Repeat("<i>line</i> of a long listing\n", 300)
+	keyboard := bot.getMenuKeyboard("en")
+	if err := bot.editText(admin.ID, 42, text, &keyboard); err != nil {
+		t.Fatalf("editText failed: %v", err)
+	}
+
+	edits := sentMessages(fake, telegramtest.MethodEditMessage)
+	if len(edits) != 1 || edits[0].MessageID != 42 || edits[0].Keyboard != nil {
+		t.Fatalf("Expected the message to keep the first part without keyboard, got %+v", edits)
+	}
+	messages := sentMessages(fake, telegramtest.MethodSendMessage)
+	if len(messages) == 0 || messages[len(messages)-1].Keyboard == nil {
+		t.Error("The rest of the reply should follow with the keyboard")
+	}
+}
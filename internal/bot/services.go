@@ -0,0 +1,418 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/callbacks"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/services"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback data of the service manager. Service names can be longer than
+// callback data allows, so the name and the list to go back to are kept in
+// callback tokens: svc_i_<name token>_<list token> and
+// svc_do_<action>_<name token>_<list token>.
+const (
+	servicesData       = "services"
+	servicesPagePrefix = "svc_p_"
+	serviceInfoPrefix  = "svc_i_"
+	serviceDoPrefix    = "svc_do_"
+)
+
+// Token actions of the service manager
+const (
+	serviceListAction = "svc_list"
+	serviceNameAction = "svc_name"
+)
+
+const servicesPageSize = 10
+
+// handleServices обрабатывает команду /services: [filter]
+func (b *Bot) handleServices(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	if response := b.servicesUnavailable(user); response != "" {
+		return response, false
+	}
+
+	filter := strings.TrimSpace(args)
+	token, err := b.callbackStore.Issue(user.ID, serviceListAction, filter, 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	text, keyboard, ok := b.serviceListView(user, token, filter, 0)
+	if !ok {
+		return text, false
+	}
+	if _, err := b.sendText(message.Chat.ID, text, keyboard); err != nil {
+		log.Printf("Failed to send service list: %v", err)
+		return b.t(user, "error.generic", err), false
+	}
+	return "", true
+}
+
+// handleService обрабатывает команду /service: name [action]
+func (b *Bot) handleService(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	if response := b.servicesUnavailable(user); response != "" {
+		return response, false
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) == 0 || len(fields) > 2 {
+		return b.t(user, "services.usage"), false
+	}
+	name := strings.TrimSuffix(fields[0], ".service")
+	if !b.config.IsServiceVisible(name) {
+		return b.t(user, "services.not_allowed", name), false
+	}
+
+	var result string
+	success := true
+	if len(fields) == 2 && strings.ToLower(fields[1]) != "status" {
+		result, success = b.runServiceAction(user, name, strings.ToLower(fields[1]))
+	}
+
+	listToken, err := b.callbackStore.Issue(user.ID, serviceListAction, "", 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	text, keyboard, ok := b.serviceDetailsView(user, name, listToken)
+	if !ok {
+		if result != "" {
+			return result, false
+		}
+		return text, false
+	}
+	if result != "" {
+		text = result + "\n\n" + text
+	}
+	if _, err := b.sendText(message.Chat.ID, text, keyboard); err != nil {
+		log.Printf("Failed to send service status: %v", err)
+		return b.t(user, "error.generic", err), false
+	}
+	return "", success
+}
+
+// handleServicesCallback opens the service list from the system tools menu
+func (b *Bot) handleServicesCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	if response := b.servicesUnavailable(user); response != "" {
+		return response, false
+	}
+	token, err := b.callbackStore.Issue(user.ID, serviceListAction, "", 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.editServiceList(callback, user, token, "", 0)
+}
+
+// handleServicesPageCallback shows a page of the list a token describes
+func (b *Bot) handleServicesPageCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	token := strings.TrimPrefix(callback.Data, servicesPagePrefix)
+	payload, response := b.resolveServiceToken(user, serviceListAction, token)
+	if payload == nil {
+		return response, false
+	}
+	return b.editServiceList(callback, user, token, payload.Path, payload.Page)
+}
+
+// handleServiceInfoCallback shows the status of a service
+func (b *Bot) handleServiceInfoCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	nameToken, listToken, _ := strings.Cut(strings.TrimPrefix(callback.Data, serviceInfoPrefix), "_")
+	payload, response := b.resolveServiceToken(user, serviceNameAction, nameToken)
+	if payload == nil {
+		return response, false
+	}
+	return b.editServiceDetails(callback, user, payload.Path, listToken, "")
+}
+
+// handleServiceActionCallback runs an action and shows the new status
+func (b *Bot) handleServiceActionCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(callback.Data, serviceDoPrefix), "_", 3)
+	if len(parts) != 3 {
+		return b.t(user, "error.unknown_action"), false
+	}
+	action, nameToken, listToken := parts[0], parts[1], parts[2]
+	payload, response := b.resolveServiceToken(user, serviceNameAction, nameToken)
+	if payload == nil {
+		return response, false
+	}
+
+	result, success := b.runServiceAction(user, payload.Path, action)
+	if response, ok := b.editServiceDetails(callback, user, payload.Path, listToken, result); !ok {
+		return response, false
+	}
+	return "", success
+}
+
+// runServiceAction checks the allowlist and runs an action. It returns a
+// line describing the outcome.
+func (b *Bot) runServiceAction(user *database.User, name, action string) (string, bool) {
+	if !slices.Contains(services.Actions, action) {
+		return b.t(user, "services.unknown_action", action), false
+	}
+	if !b.config.IsServiceActionAllowed(name, action) {
+		return b.t(user, "services.action_not_allowed", action, name), false
+	}
+
+	ctx, cancel := b.servicesContext()
+	defer cancel()
+	started := time.Now()
+	err := services.Do(ctx, b.services, name, action)
+	if err != nil {
+		log.Printf("User %d (%s) failed to %s service %s: %v", user.ID, user.Username, action, name, err)
+		return b.serviceError(user, name, err), false
+	}
+	log.Printf("User %d (%s) ran %s on service %s", user.ID, user.Username, action, name)
+	return b.t(user, "services.done", b.t(user, "services.action_"+action), name, time.Since(started).Round(time.Millisecond)), true
+}
+
+// editServiceList replaces the message with a page of the list
+func (b *Bot) editServiceList(callback *tgbotapi.CallbackQuery, user *database.User, token, filter string, page int) (string, bool) {
+	text, keyboard, ok := b.serviceListView(user, token, filter, page)
+	if !ok {
+		return text, false
+	}
+	if err := b.editCallbackMessage(callback, text, keyboard); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to update message: %v", err)
+		return b.t(user, "error.update_interface"), false
+	}
+	return "", true
+}
+
+// editServiceDetails replaces the message with the status of a service,
+// below the outcome of an action when there is one
+func (b *Bot) editServiceDetails(callback *tgbotapi.CallbackQuery, user *database.User, name, listToken, result string) (string, bool) {
+	text, keyboard, ok := b.serviceDetailsView(user, name, listToken)
+	if result != "" {
+		text = result + "\n\n" + text
+	}
+	if !ok {
+		return text, false
+	}
+	if err := b.editCallbackMessage(callback, text, keyboard); err != nil && !isNotModifiedError(err) {
+		log.Printf("Failed to update message: %v", err)
+		return b.t(user, "error.update_interface"), false
+	}
+	return "", true
+}
+
+// serviceListView renders a page of the services allowed in
+// services.allowed. On failure it returns the response to show instead.
+func (b *Bot) serviceListView(user *database.User, token, filter string, page int) (string, *tgbotapi.InlineKeyboardMarkup, bool) {
+	ctx, cancel := b.servicesContext()
+	defer cancel()
+	all, err := b.services.List(ctx)
+	if err != nil {
+		return b.serviceError(user, "", err), nil, false
+	}
+
+	var list []services.Status
+	for _, service := range all {
+		if !b.config.IsServiceVisible(service.Name) {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(service.Name), strings.ToLower(filter)) {
+			continue
+		}
+		list = append(list, service)
+	}
+
+	pages := max((len(list)+servicesPageSize-1)/servicesPageSize, 1)
+	page = min(max(page, 0), pages-1)
+	shown := list[page*servicesPageSize : min((page+1)*servicesPageSize, len(list))]
+
+	text := b.t(user, "services.title", len(list), page+1, pages) + "\n"
+	if filter != "" {
+		text += b.t(user, "services.filter", filter) + "\n"
+	}
+	if len(shown) == 0 {
+		text += "\n" + b.t(user, "services.none")
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, service := range shown {
+		text += "\n" + b.t(user, "services.line", serviceIcon(service.State), service.Name, b.serviceStateLabel(user, service.State), b.serviceBootLabel(user, service))
+
+		nameToken, err := b.callbackStore.Issue(user.ID, serviceNameAction, service.Name, 0)
+		if err != nil {
+			log.Printf("Failed to issue callback token for user %d: %v", user.ID, err)
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(serviceIcon(service.State)+" "+service.Name, serviceInfoPrefix+nameToken+"_"+token))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.prev"), b.servicesPageData(user, filter, page-1)))
+	}
+	nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), servicesPagePrefix+token))
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.next"), b.servicesPageData(user, filter, page+1)))
+	}
+	rows = append(rows, nav, b.menuRow(user))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return text, &keyboard, true
+}
+
+// serviceDetailsView renders the status of a service with a button for
+// every allowed action that applies to its state
+func (b *Bot) serviceDetailsView(user *database.User, name, listToken string) (string, *tgbotapi.InlineKeyboardMarkup, bool) {
+	ctx, cancel := b.servicesContext()
+	defer cancel()
+	status, err := b.services.Status(ctx, name)
+	if err != nil {
+		return b.serviceError(user, name, err), nil, false
+	}
+
+	var text strings.Builder
+	text.WriteString(b.t(user, "services.details_title", serviceIcon(status.State), status.Name) + "\n\n")
+	if status.Description != "" && status.Description != status.Name {
+		text.WriteString(b.t(user, "services.description", status.Description) + "\n")
+	}
+	text.WriteString(b.t(user, "services.state", b.serviceStateLabel(user, status.State), status.Detail) + "\n")
+	text.WriteString(b.t(user, "services.boot", b.serviceBootLabel(user, *status), status.StartType) + "\n")
+	if status.PID != 0 {
+		text.WriteString(b.t(user, "services.pid", status.PID) + "\n")
+	}
+
+	nameToken, err := b.callbackStore.Issue(user.ID, serviceNameAction, status.Name, 0)
+	if err != nil {
+		return b.t(user, "error.generic", err), nil, false
+	}
+
+	var actions []tgbotapi.InlineKeyboardButton
+	for _, action := range serviceActionsFor(status) {
+		if b.config.IsServiceActionAllowed(status.Name, action) {
+			data := serviceDoPrefix + action + "_" + nameToken + "_" + listToken
+			actions = append(actions, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.service_"+action), data))
+		}
+	}
+	if len(actions) == 0 {
+		text.WriteString("\n" + b.t(user, "services.read_only"))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i := 0; i < len(actions); i += 2 {
+		rows = append(rows, actions[i:min(i+2, len(actions))])
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.back_services"), servicesPagePrefix+listToken),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), serviceInfoPrefix+nameToken+"_"+listToken),
+		),
+		b.menuRow(user),
+	)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return strings.TrimRight(text.String(), "\n"), &keyboard, true
+}
+
+// serviceActionsFor lists the actions that change a service in its state
+func serviceActionsFor(status *services.Status) []string {
+	var actions []string
+	switch status.State {
+	case services.StateRunning, services.StateStarting:
+		actions = append(actions, services.ActionStop, services.ActionRestart)
+	default:
+		actions = append(actions, services.ActionStart)
+	}
+	if status.Enabled {
+		actions = append(actions, services.ActionDisable)
+	} else {
+		actions = append(actions, services.ActionEnable)
+	}
+	return actions
+}
+
+// servicesPageData issues a token for a page of the list
+func (b *Bot) servicesPageData(user *database.User, filter string, page int) string {
+	token, err := b.callbackStore.Issue(user.ID, serviceListAction, filter, page)
+	if err != nil {
+		log.Printf("Failed to issue callback token for user %d: %v", user.ID, err)
+		return servicesPagePrefix
+	}
+	return servicesPagePrefix + token
+}
+
+// resolveServiceToken resolves a token of the service manager. On failure
+// it returns nil and the response to show to the user.
+func (b *Bot) resolveServiceToken(user *database.User, action, token string) (*callbacks.Payload, string) {
+	payload, err := b.callbackStore.Resolve(token, user.ID)
+	switch {
+	case err == nil && payload.Action == action:
+		return payload, ""
+	case err == nil, errors.Is(err, callbacks.ErrNotFound), errors.Is(err, callbacks.ErrExpired):
+		return nil, b.t(user, "services.expired")
+	case errors.Is(err, callbacks.ErrForeignUser):
+		log.Printf("User %d tried to use a callback token of another user", user.ID)
+		return nil, b.t(user, "services.expired")
+	default:
+		return nil, b.t(user, "error.generic", err)
+	}
+}
+
+// servicesUnavailable returns the response when /services can't be used
+func (b *Bot) servicesUnavailable(user *database.User) string {
+	switch {
+	case !b.config.Services.Enabled:
+		return b.t(user, "services.disabled")
+	case len(b.config.Services.Allowed) == 0:
+		return b.t(user, "services.no_rules")
+	}
+	return ""
+}
+
+// servicesContext bounds a call to the service manager by services.timeout
+func (b *Bot) servicesContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(b.config.Services.Timeout)*time.Second)
+}
+
+// serviceError turns an error of the service manager into a response
+func (b *Bot) serviceError(user *database.User, name string, err error) string {
+	switch {
+	case errors.Is(err, services.ErrNotFound), errors.Is(err, services.ErrInvalidName):
+		return b.t(user, "services.not_found", name)
+	case errors.Is(err, services.ErrUnsupported):
+		return b.t(user, "services.unsupported")
+	default:
+		return b.t(user, "services.error", err)
+	}
+}
+
+func (b *Bot) serviceStateLabel(user *database.User, state string) string {
+	return b.t(user, "services.state_"+state)
+}
+
+func (b *Bot) serviceBootLabel(user *database.User, status services.Status) string {
+	if status.Enabled {
+		return b.t(user, "services.enabled")
+	}
+	return b.t(user, "services.not_enabled")
+}
+
+func serviceIcon(state string) string {
+	switch state {
+	case services.StateRunning:
+		return "🟢"
+	case services.StateStopped:
+		return "⚪"
+	case services.StateFailed:
+		return "🔴"
+	case services.StateStarting, services.StateStopping:
+		return "🟡"
+	}
+	return "❔"
+}
@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/services"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+)
+
+// fakeServices is a service manager over a map
+type fakeServices struct {
+	byName  map[string]*services.Status
+	actions []string
+}
+
+func newFakeServices(names ...string) *fakeServices {
+	f := &fakeServices{byName: make(map[string]*services.Status)}
+	for _, name := range names {
+		f.byName[name] = &services.Status{Name: name, State: services.StateStopped, Detail: "dead", StartType: "disabled"}
+	}
+	return f
+}
+
+func (f *fakeServices) List(ctx context.Context) ([]services.Status, error) {
+	var list []services.Status
+	for _, status := range f.byName {
+		list = append(list, *status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+func (f *fakeServices) Status(ctx context.Context, name string) (*services.Status, error) {
+	status, ok := f.byName[name]
+	if !ok {
+		return nil, services.ErrNotFound
+	}
+	copied := *status
+	return &copied, nil
+}
+
+func (f *fakeServices) set(name, action string, change func(s *services.Status)) error {
+	status, ok := f.byName[name]
+	if !ok {
+		return services.ErrNotFound
+	}
+	f.actions = append(f.actions, action+" "+name)
+	change(status)
+	return nil
+}
+
+func (f *fakeServices) Start(ctx context.Context, name string) error {
+	return f.set(name, "start", func(s *services.Status) { s.State, s.PID = services.StateRunning, 100 })
+}
+
+func (f *fakeServices) Stop(ctx context.Context, name string) error {
+	return f.set(name, "stop", func(s *services.Status) { s.State, s.PID = services.StateStopped, 0 })
+}
+
+func (f *fakeServices) Restart(ctx context.Context, name string) error {
+	return f.set(name, "restart", func(s *services.Status) { s.State, s.PID = services.StateRunning, s.PID+1 })
+}
+
+func (f *fakeServices) Enable(ctx context.Context, name string) error {
+	return f.set(name, "enable", func(s *services.Status) { s.Enabled = true })
+}
+
+func (f *fakeServices) Disable(ctx context.Context, name string) error {
+	return f.set(name, "disable", func(s *services.Status) { s.Enabled = false })
+}
+
+// withServices manages services through manager as rules allow
+func withServices(manager *fakeServices, rules ...config.ServiceRule) func(*testing.T, *Bot) {
+	return func(t *testing.T, bot *Bot) {
+		bot.services = manager
+		bot.config.Services = config.ServicesConfig{Enabled: true, Timeout: 5, Allowed: rules}
+	}
+}
+
+func TestServicesList(t *testing.T) {
+	manager := newFakeServices("nginx", "postgresql", "sshd")
+	bot, fake, admin, _ := newFakeBot(t, withServices(manager,
+		config.ServiceRule{Name: "nginx", Actions: []string{"start", "stop", "restart"}},
+		config.ServiceRule{Name: "postgres*"},
+	))
+
+	bot.handleMessage(commandMessage(admin, "/services"), admin)
+	list := lastSent(t, fake, "Services")
+	if !containsString(list.Text, "nginx") || !containsString(list.Text, "postgresql") {
+		t.Errorf("Allowed services should be listed, got: %s", list.Text)
+	}
+	if containsString(list.Text, "sshd") {
+		t.Error("Services missing from services.allowed should be hidden")
+	}
+
+	bot.handleMessage(commandMessage(admin, "/services post"), admin)
+	filtered := lastSent(t, fake, "Name contains")
+	if containsString(filtered.Text, "nginx") {
+		t.Errorf("Filter should hide nginx, got: %s", filtered.Text)
+	}
+
+	pressButton(t, bot, admin, list, "postgresql")
+	details := lastSent(t, fake, "At boot")
+	if _, ok := details.Button("Start"); ok {
+		t.Error("A rule without actions should not offer buttons")
+	}
+	if !containsString(details.Text, "no actions") {
+		t.Errorf("Details should say the service is read-only, got: %s", details.Text)
+	}
+
+	pressButton(t, bot, admin, details, "Back to Services")
+	lastSent(t, fake, "2 total")
+}
+
+func TestServiceActions(t *testing.T) {
+	manager := newFakeServices("nginx", "postgresql", "sshd")
+	bot, fake, admin, _ := newFakeBot(t, withServices(manager,
+		config.ServiceRule{Name: "nginx", Actions: []string{"start", "stop", "restart"}},
+	))
+
+	bot.handleMessage(commandMessage(admin, "/service nginx"), admin)
+	details := lastSent(t, fake, "nginx")
+	if _, ok := details.Button("Enable at boot"); ok {
+		t.Error("Enable is not allowed and should not be offered")
+	}
+
+	pressButton(t, bot, admin, details, "Start")
+	started := lastSent(t, fake, "Started")
+	if started.Method != telegramtest.MethodEditMessage || !containsString(started.Text, "running") {
+		t.Errorf("Expected the details edited with the new state, got %s: %s", started.Method, started.Text)
+	}
+	if _, ok := started.Button("Stop"); !ok {
+		t.Error("A running service should offer Stop")
+	}
+
+	bot.handleMessage(commandMessage(admin, "/service nginx restart"), admin)
+	lastSent(t, fake, "Restarted")
+
+	bot.handleMessage(commandMessage(admin, "/service nginx disable"), admin)
+	lastSent(t, fake, "not allowed")
+	bot.handleMessage(commandMessage(admin, "/service sshd stop"), admin)
+	lastSent(t, fake, "not in services.allowed")
+	bot.handleMessage(commandMessage(admin, "/service nginx delete"), admin)
+	lastSent(t, fake, "Unknown service action")
+
+	if len(manager.actions) != 2 || manager.actions[0] != "start nginx" || manager.actions[1] != "restart nginx" {
+		t.Errorf("Expected only the allowed actions to run, got %v", manager.actions)
+	}
+}
+
+func TestServicesRequireAdminAndConfig(t *testing.T) {
+	manager := newFakeServices("nginx", "postgresql", "sshd")
+	bot, fake, admin, user := newFakeBot(t, withServices(manager,
+		config.ServiceRule{Name: "*", Actions: []string{"stop"}},
+	))
+
+	bot.handleMessage(commandMessage(user, "/service nginx stop"), user)
+	lastSent(t, fake, "Admin privileges required")
+
+	bot.handleMessage(commandMessage(admin, "/services"), admin)
+	list := lastSent(t, fake, "3 total")
+	pressButton(t, bot, user, list, "nginx")
+	lastSent(t, fake, "Admin privileges required")
+
+	// An admin can't reuse the buttons of another admin
+	user.IsAdmin = true
+	pressButton(t, bot, user, list, "nginx")
+	lastSent(t, fake, "has expired")
+
+	bot.handleMessage(commandMessage(admin, "/service missing"), admin)
+	lastSent(t, fake, "not found")
+
+	bot.config.Services.Enabled = false
+	bot.handleMessage(commandMessage(admin, "/services"), admin)
+	lastSent(t, fake, "disabled")
+
+	if len(manager.actions) != 0 {
+		t.Errorf("No action should run, got %v", manager.actions)
+	}
+}
@@ -0,0 +1,462 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/shell"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Callback data of the shell buttons; they act on the session of the user
+// who presses them
+const (
+	shellInterruptData = "shell_int"
+	shellKillData      = "shell_kill"
+)
+
+// Why a shell session ended, as stored in shell_sessions
+const (
+	shellEndExit     = "exit"
+	shellEndKill     = "kill"
+	shellEndIdle     = "idle"
+	shellEndShutdown = "shutdown"
+)
+
+// shellCommands control a shell session; every other message of its user
+// in the chat, commands included, is input for the shell
+var shellCommands = []string{"shell", "shell_kill"}
+
+// shellPageLimit leaves room under the message limit for the final status
+const shellPageLimit = render.MaxMessageLength - 256
+
+// shellSession is the /shell session of an admin. Only its goroutine edits
+// the rolling message; input and buttons reach the shell directly.
+type shellSession struct {
+	id      int64 // row in shell_sessions
+	user    *database.User
+	chatID  int64
+	command string
+	started time.Time
+	session *shell.Session
+
+	// input resets the idle timer; roll makes the next output start a new
+	// message below the input that caused it
+	input chan struct{}
+	roll  atomic.Bool
+	stop  chan struct{}
+
+	mu     sync.Mutex
+	reason string // why the session was stopped, empty while it runs
+
+	// Owned by the session goroutine
+	messageID int
+	text      string // output shown in the rolling message
+}
+
+func newShellSession(chatID int64, user *database.User, command string, session *shell.Session) *shellSession {
+	return &shellSession{
+		user:    user,
+		chatID:  chatID,
+		command: command,
+		started: time.Now(),
+		session: session,
+		input:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// touch records input typed into the session
+func (s *shellSession) touch(roll bool) {
+	if roll {
+		s.roll.Store(true)
+	}
+	select {
+	case s.input <- struct{}{}:
+	default:
+	}
+}
+
+// close kills the shell once, remembering why
+func (s *shellSession) close(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reason != "" {
+		return
+	}
+	s.reason = reason
+	close(s.stop)
+}
+
+func (s *shellSession) stopReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reason
+}
+
+// shellManager keeps the shell session of every admin
+type shellManager struct {
+	idleTimeout   time.Duration
+	flushInterval time.Duration // batches output so edits stay under the Telegram limits
+
+	mu      sync.Mutex
+	byUser  map[int64]*shellSession
+	closed  bool
+	running sync.WaitGroup
+}
+
+func newShellManager(idleTimeout, flushInterval time.Duration) *shellManager {
+	return &shellManager{
+		idleTimeout:   idleTimeout,
+		flushInterval: flushInterval,
+		byUser:        make(map[int64]*shellSession),
+	}
+}
+
+// get returns the running session of a user
+func (m *shellManager) get(userID int64) *shellSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.byUser[userID]
+}
+
+// add registers a session unless its user already has one
+func (m *shellManager) add(s *shellSession) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed || m.byUser[s.user.ID] != nil {
+		return false
+	}
+	m.byUser[s.user.ID] = s
+	m.running.Add(1)
+	return true
+}
+
+// remove forgets a session whose goroutine is done
+func (m *shellManager) remove(s *shellSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.byUser[s.user.ID] == s {
+		delete(m.byUser, s.user.ID)
+	}
+	m.running.Done()
+}
+
+// stop kills every session and waits for the final edits, but not longer
+// than timeout
+func (m *shellManager) stop(timeout time.Duration) error {
+	m.mu.Lock()
+	m.closed = true
+	count := len(m.byUser)
+	for _, s := range m.byUser {
+		s.close(shellEndShutdown)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s stopping %d shell sessions", timeout, count)
+	}
+}
+
+// handleShell обрабатывает команду /shell (только админы). The session
+// writes its own history entry when it ends.
+func (b *Bot) handleShell(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	fail := func(response string) (string, bool) {
+		b.authMw.LogCommand(user.ID, "shell", args, false, response)
+		return response, false
+	}
+
+	if !b.config.Shell.Enabled {
+		return fail(b.t(user, "shell.disabled"))
+	}
+	if b.shells.get(user.ID) != nil {
+		return fail(b.t(user, "shell.running"))
+	}
+
+	session, err := shell.Start(b.config.Shell)
+	if err != nil {
+		return fail(b.t(user, "shell.start_error", err))
+	}
+	s := newShellSession(message.Chat.ID, user, session.Command(), session)
+
+	// No audit record, no shell
+	record := &database.ShellSession{UserID: user.ID, ChatID: s.chatID, Command: s.command, StartedAt: s.started}
+	if err := b.db.CreateShellSession(record); err != nil {
+		session.Kill()
+		return fail(b.t(user, "shell.audit_error", err))
+	}
+	s.id = record.ID
+
+	b.showShellPage(s, true)
+	if !b.shells.add(s) {
+		session.Kill()
+		b.db.EndShellSession(s.id, nil, shellEndKill)
+		return fail(b.t(user, "shell.running"))
+	}
+	log.Printf("User %d (%s) opened shell session %d: %s", user.ID, user.Username, s.id, s.command)
+	go b.runShell(s)
+
+	return "", true
+}
+
+// isShellCommand reports whether a message is one of shellCommands
+func isShellCommand(message *tgbotapi.Message) bool {
+	return message.IsCommand() && slices.Contains(shellCommands, message.Command())
+}
+
+// handleShellInput writes a message of a user with a shell session in the
+// chat to the shell. It reports whether the message was meant for a shell.
+func (b *Bot) handleShellInput(message *tgbotapi.Message, user *database.User) bool {
+	s := b.shells.get(user.ID)
+	if s == nil || s.chatID != message.Chat.ID || message.Text == "" {
+		return false
+	}
+	if !user.IsAdmin {
+		log.Printf("User %d lost admin rights, killing shell session %d", user.ID, s.id)
+		s.close(shellEndKill)
+		return false
+	}
+
+	if err := s.session.Write(message.Text); err != nil {
+		if _, err := b.sendText(message.Chat.ID, b.t(user, "shell.write_error", err), nil); err != nil {
+			log.Printf("Failed to send message: %v", err)
+		}
+		return true
+	}
+	if err := b.db.AddShellTranscript(s.id, database.ShellInput, message.Text); err != nil {
+		log.Printf("Failed to record input of shell session %d: %v", s.id, err)
+	}
+	s.touch(true)
+	return true
+}
+
+// handleShellKill обрабатывает команду /shell_kill
+func (b *Bot) handleShellKill(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	s := b.shells.get(user.ID)
+	if s == nil {
+		return b.t(user, "shell.not_running"), false
+	}
+	s.close(shellEndKill)
+	return b.t(user, "shell.kill_sent"), true
+}
+
+// handleShellCallback sends Ctrl-C to the shell of the user or kills it
+func (b *Bot) handleShellCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	s := b.shells.get(user.ID)
+	if s == nil || s.chatID != callback.Message.Chat.ID {
+		return b.t(user, "shell.not_running"), false
+	}
+
+	switch callback.Data {
+	case shellInterruptData:
+		if err := s.session.Interrupt(); err != nil {
+			return b.t(user, "shell.interrupt_error", err), false
+		}
+		if err := b.db.AddShellTranscript(s.id, database.ShellInput, "^C"); err != nil {
+			log.Printf("Failed to record input of shell session %d: %v", s.id, err)
+		}
+		s.touch(false)
+	case shellKillData:
+		s.close(shellEndKill)
+	}
+	return "", true
+}
+
+// runShell streams the output of a session into its rolling message until
+// the shell exits, is killed or stays idle for too long
+func (b *Bot) runShell(s *shellSession) {
+	defer b.shells.remove(s)
+
+	idle := time.NewTimer(b.shells.idleTimeout)
+	defer idle.Stop()
+	var flush <-chan time.Time
+	stop := s.stop
+
+	for {
+		select {
+		case <-s.session.Changed():
+			if flush == nil {
+				flush = time.After(b.shells.flushInterval)
+			}
+		case <-flush:
+			flush = nil
+			b.flushShell(s)
+		case <-s.input:
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(b.shells.idleTimeout)
+		case <-idle.C:
+			s.close(shellEndIdle)
+		case <-stop:
+			// The shell is done once its output is collected
+			stop = nil
+			if err := s.session.Kill(); err != nil {
+				log.Printf("Failed to kill shell session %d: %v", s.id, err)
+			}
+		case <-s.session.Done():
+			b.flushShell(s)
+			b.finishShell(s)
+			return
+		}
+	}
+}
+
+// flushShell records the new output and adds it to the rolling message.
+// Output that doesn't fit leaves the message as it is and continues in a
+// new one.
+func (b *Bot) flushShell(s *shellSession) {
+	output := s.session.ReadOutput()
+	if output == "" {
+		return
+	}
+	if err := b.db.AddShellTranscript(s.id, database.ShellOutput, output); err != nil {
+		log.Printf("Failed to record output of shell session %d: %v", s.id, err)
+	}
+
+	if s.roll.Swap(false) {
+		b.showShellPage(s, false)
+		s.messageID, s.text = 0, ""
+	}
+
+	text := s.text + output
+	for {
+		page, rest := splitShellText(text, shellPageLimit)
+		s.text = page
+		if rest == "" {
+			break
+		}
+		b.showShellPage(s, false)
+		s.messageID = 0
+		text = rest
+	}
+	b.showShellPage(s, true)
+}
+
+// finishShell leaves the output with the reason the session ended, records
+// the end and writes the history entry
+func (b *Bot) finishShell(s *shellSession) {
+	reason := s.stopReason()
+	if reason == "" {
+		reason = shellEndExit
+	}
+	exitCode := s.session.ExitCode()
+
+	var status string
+	switch reason {
+	case shellEndExit:
+		status = b.t(s.user, "shell.exited", exitCode)
+	case shellEndIdle:
+		status = b.t(s.user, "shell.idle", b.shells.idleTimeout)
+	case shellEndShutdown:
+		status = b.t(s.user, "shell.shutdown")
+	default:
+		status = b.t(s.user, "shell.killed")
+	}
+	b.showShellText(s, b.shellText(s)+"\n\n"+status, nil)
+
+	var code *int
+	if reason == shellEndExit && exitCode >= 0 {
+		code = &exitCode
+	}
+	if err := b.db.EndShellSession(s.id, code, reason); err != nil {
+		log.Printf("Failed to record the end of shell session %d: %v", s.id, err)
+	}
+	log.Printf("Shell session %d of user %d ended: %s", s.id, s.user.ID, reason)
+	b.authMw.LogExecution(s.user.ID, "shell", s.command, exitCode, time.Since(s.started),
+		reason == shellEndExit && exitCode == 0, render.Plain(status))
+}
+
+// shellText renders the output of the rolling message
+func (b *Bot) shellText(s *shellSession) string {
+	if s.text == "" {
+		return b.t(s.user, "shell.started", render.Code(s.command))
+	}
+	return "<pre>" + render.Escape(s.text) + "</pre>"
+}
+
+// showShellPage shows the output of the rolling message, with the buttons
+// while the message is the last one
+func (b *Bot) showShellPage(s *shellSession, current bool) {
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+	if current {
+		keyboard = shellKeyboard(b, s.user)
+	}
+	b.showShellText(s, b.shellText(s), keyboard)
+}
+
+// showShellText edits the rolling message, or sends a new one when there
+// is none or it can't be edited anymore
+func (b *Bot) showShellText(s *shellSession, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	if s.messageID != 0 {
+		err := b.editMessage(s.chatID, s.messageID, text, keyboard)
+		if err == nil || isNotModifiedError(err) {
+			return
+		}
+		log.Printf("Failed to edit shell message %d in chat %d, sending a new one: %v", s.messageID, s.chatID, err)
+	}
+
+	sent, err := b.sendText(s.chatID, text, keyboard)
+	if err != nil {
+		log.Printf("Failed to send shell output to chat %d: %v", s.chatID, err)
+		return
+	}
+	s.messageID = sent.MessageID
+}
+
+// splitShellText returns the longest start of text that fits in a message,
+// cut after a line break where there is one, and the rest
+func splitShellText(text string, limit int) (string, string) {
+	fits := func(text string) bool {
+		return render.Length("<pre>"+render.Escape(text)+"</pre>") <= limit
+	}
+	if fits(text) {
+		return text, ""
+	}
+
+	runes := []rune(text)
+	lo, hi := 1, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fits(string(runes[:mid])) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	page := string(runes[:lo])
+	if i := strings.LastIndexByte(page, '\n'); i >= len(page)/2 {
+		page = page[:i+1]
+	}
+	return page, text[len(page):]
+}
+
+// shellKeyboard offers Ctrl-C and kill
+func shellKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.ctrl_c"), shellInterruptData),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.kill"), shellKillData),
+		),
+	)
+	return &kb
+}
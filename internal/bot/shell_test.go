@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+)
+
+// withShell opens /bin/sh sessions that flush their output every few
+// milliseconds
+func withShell(idleTimeout time.Duration) func(*testing.T, *Bot) {
+	return func(t *testing.T, bot *Bot) {
+		if runtime.GOOS == "windows" {
+			t.Skip("uses /bin/sh")
+		}
+		bot.config.Shell = config.ShellConfig{Enabled: true, Command: "/bin/sh"}
+		bot.shells = newShellManager(idleTimeout, 10*time.Millisecond)
+		t.Cleanup(func() { bot.shells.stop(5 * time.Second) })
+	}
+}
+
+// waitForShell waits for a message sent or edited to contain text
+func waitForShell(t *testing.T, fake *telegramtest.Fake, text string) telegramtest.Sent {
+	t.Helper()
+	sent, ok := fake.WaitFor(5*time.Second, func(s telegramtest.Sent) bool {
+		return (s.Method == telegramtest.MethodSendMessage || s.Method == telegramtest.MethodEditMessage) && strings.Contains(s.Text, text)
+	})
+	if !ok {
+		t.Fatalf("No shell message contains %q", text)
+	}
+	return sent
+}
+
+func waitForShellRemoved(t *testing.T, bot *Bot, userID int64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for bot.shells.get(userID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Finished session should be removed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShellSession(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, withShell(time.Minute))
+
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	started := lastSent(t, fake, "/bin/sh")
+	if _, ok := started.Button("Ctrl-C"); !ok {
+		t.Error("Shell message should offer Ctrl-C")
+	}
+	s := bot.shells.get(admin.ID)
+	if s == nil {
+		t.Fatal("Shell session should run")
+	}
+
+	bot.handleMessage(plainMessage(admin, "echo hi-$((2 + 3)) '<b>'"), admin)
+	output := waitForShell(t, fake, "hi-5")
+	if !strings.Contains(output.Text, "&lt;b&gt;") {
+		t.Errorf("Output should be escaped, got %q", output.Text)
+	}
+
+	// Paths look like bot commands but are shell input
+	bot.handleMessage(commandMessage(admin, "/bin/echo path-ok"), admin)
+	waitForShell(t, fake, "path-ok")
+
+	bot.handleMessage(plainMessage(admin, "exit 4"), admin)
+	finished := waitForShell(t, fake, "exited with code 4")
+	if finished.Keyboard != nil {
+		t.Error("Finished shell should have no buttons")
+	}
+
+	waitForShellRemoved(t, bot, admin.ID)
+
+	record, err := bot.db.GetShellSession(s.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ExitCode == nil || *record.ExitCode != 4 || record.EndReason != shellEndExit {
+		t.Errorf("Expected the session recorded as exited with 4, got %+v", record)
+	}
+	transcript, err := bot.db.GetShellTranscript(s.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input, out []string
+	for _, entry := range transcript {
+		if entry.Stream == database.ShellInput {
+			input = append(input, entry.Data)
+		} else {
+			out = append(out, entry.Data)
+		}
+	}
+	if strings.Join(input, "|") != "echo hi-$((2 + 3)) '<b>'|/bin/echo path-ok|exit 4" || !strings.Contains(strings.Join(out, ""), "hi-5") {
+		t.Errorf("Unexpected transcript: input %q, output %q", input, out)
+	}
+
+	history, err := bot.db.GetCommandHistory(admin.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Command != "shell" || history[0].ExitCode == nil || *history[0].ExitCode != 4 {
+		t.Errorf("Expected one shell history entry with exit code 4, got %d entries", len(history))
+	}
+}
+
+func TestShellRequiresAdminAndOneSession(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t, withShell(time.Minute))
+
+	bot.handleMessage(commandMessage(user, "/shell"), user)
+	lastSent(t, fake, "Admin privileges required")
+
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	lastSent(t, fake, "/bin/sh")
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	lastSent(t, fake, "already have a shell session")
+
+	// Plain text of other users is not shell input
+	if bot.handleShellInput(plainMessage(user, "id"), user) {
+		t.Error("Input of a user without a session should not reach a shell")
+	}
+
+	bot.handleMessage(commandMessage(admin, "/shell_kill"), admin)
+	waitForShell(t, fake, "Shell session killed")
+	bot.handleMessage(commandMessage(admin, "/shell_kill"), admin)
+	lastSent(t, fake, "no running shell session")
+
+	bot.config.Shell.Enabled = false
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	lastSent(t, fake, "disabled")
+}
+
+func TestShellButtons(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Ctrl-C needs a pseudo-terminal")
+	}
+	bot, fake, admin, _ := newFakeBot(t, withShell(time.Minute))
+
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	bot.handleMessage(plainMessage(admin, "sleep 30; echo finished"), admin)
+	current := waitForShell(t, fake, "sleep 30")
+
+	pressButton(t, bot, admin, current, "Ctrl-C")
+	waitForShell(t, fake, "^C")
+	bot.handleMessage(plainMessage(admin, "echo after-$((1 + 1))"), admin)
+	current = waitForShell(t, fake, "after-2")
+
+	pressButton(t, bot, admin, current, "Kill")
+	waitForShell(t, fake, "Shell session killed")
+	waitForShellRemoved(t, bot, admin.ID)
+
+	// The buttons of a finished session do nothing
+	pressButton(t, bot, admin, current, "Ctrl-C")
+	lastSent(t, fake, "no running shell session")
+}
+
+func TestShellIdleTimeout(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, withShell(100*time.Millisecond))
+
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	s := bot.shells.get(admin.ID)
+	waitForShell(t, fake, "without input")
+	waitForShellRemoved(t, bot, admin.ID)
+
+	record, err := bot.db.GetShellSession(s.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.EndReason != shellEndIdle || record.EndedAt == nil {
+		t.Errorf("Expected the session recorded as idle, got %+v", record)
+	}
+}
+
+func TestShellsStopOnShutdown(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t, withShell(time.Minute))
+
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	if err := bot.shells.stop(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	lastSent(t, fake, "bot is shutting down")
+
+	bot.handleMessage(commandMessage(admin, "/shell"), admin)
+	lastSent(t, fake, "already have a shell session")
+}
+
+func TestSplitShellText(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	text := strings.Repeat(line, 100)
+
+	page, rest := splitShellText(text, 1000)
+	if page+rest != text {
+		t.Fatal("Pages should keep all the text")
+	}
+	if !strings.HasSuffix(page, "\n") || render.Length("<pre>"+render.Escape(page)+"</pre>") > 1000 {
+		t.Errorf("Expected a page of whole lines under the limit, got %d characters", len(page))
+	}
+
+	// Escaping makes the text longer
+	page, rest = splitShellText(strings.Repeat("<", 500), 1000)
+	if rest == "" || render.Length("<pre>"+render.Escape(page)+"</pre>") > 1000 {
+		t.Errorf("Expected escaped text cut under the limit, got %d and %d characters", len(page), len(rest))
+	}
+}
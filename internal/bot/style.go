@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"log"
+	"strings"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// stylePrefix starts the data of the /style buttons, followed by the style
+const stylePrefix = "style_"
+
+// menuStyle picks how menu buttons answer a user: the /style choice, then
+// bot.menu_style
+func (b *Bot) menuStyle(user *database.User) string {
+	if user != nil && config.ValidMenuStyle(user.MenuStyle) {
+		return user.MenuStyle
+	}
+	if b.config != nil && config.ValidMenuStyle(b.config.Bot.MenuStyle) {
+		return b.config.Bot.MenuStyle
+	}
+	return config.MenuStylePanel
+}
+
+// replyToCallback answers a button press. Panel buttons of users with the
+// panel style replace the message they are on; when Telegram refuses the
+// edit, the panel is stale and the reply starts a new one.
+func (b *Bot) replyToCallback(callback *tgbotapi.CallbackQuery, user *database.User, panel bool, response string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	chatID := callback.Message.Chat.ID
+	if panel && b.menuStyle(user) == config.MenuStylePanel {
+		err := b.editText(chatID, callback.Message.MessageID, response, keyboard)
+		if err == nil || isNotModifiedError(err) {
+			// A refresh with nothing new leaves the panel as it is
+			return
+		}
+		log.Printf("Stale panel %d in chat %d, sending a new one: %v", callback.Message.MessageID, chatID, err)
+	}
+
+	if _, err := b.sendText(chatID, response, keyboard); err != nil {
+		log.Printf("Failed to send callback response: %v", err)
+	}
+}
+
+// isNotModifiedError reports whether Telegram refused an edit that would
+// leave the message unchanged
+func isNotModifiedError(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
+
+// handleStyle обрабатывает команду /style
+func (b *Bot) handleStyle(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	style := strings.ToLower(strings.TrimSpace(args))
+	if style == "" {
+		return b.t(user, "style.prompt", render.HTML(b.styleLabel(user))), true
+	}
+	if !config.ValidMenuStyle(style) {
+		return b.t(user, "style.unknown", args), false
+	}
+	return b.setMenuStyle(user, style)
+}
+
+// handleStyleCallback stores the style picked with a /style button and
+// turns the prompt into the result
+func (b *Bot) handleStyleCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	style := strings.TrimPrefix(callback.Data, stylePrefix)
+	if !config.ValidMenuStyle(style) {
+		return b.t(user, "style.unknown", style), false
+	}
+
+	response, success := b.setMenuStyle(user, style)
+	if err := b.editCallbackMessage(callback, response, menuKeyboard(b, user)); err != nil {
+		log.Printf("Failed to update message: %v", err)
+		return response, success
+	}
+	return "", success
+}
+
+// setMenuStyle stores the menu style of a user
+func (b *Bot) setMenuStyle(user *database.User, style string) (string, bool) {
+	if err := b.db.SetUserMenuStyle(user.ID, style); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	user.MenuStyle = style
+	return b.t(user, "style.changed", render.HTML(b.styleLabel(user))), true
+}
+
+// styleLabel names the current menu style of a user
+func (b *Bot) styleLabel(user *database.User) string {
+	return b.t(user, "style."+b.menuStyle(user))
+}
+
+// styleKeyboard offers both menu styles
+func styleKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "style."+config.MenuStylePanel), stylePrefix+config.MenuStylePanel),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "style."+config.MenuStyleLog), stylePrefix+config.MenuStyleLog),
+		),
+	)
+	return &kb
+}
+
+// statusKeyboard refreshes the status in place and leads back to the menu
+func statusKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.refresh"), "status"),
+			tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.menu"), "menu"),
+		),
+	)
+	return &kb
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/telegram/telegramtest"
+)
+
+// countSent counts the recorded requests of a method
+func countSent(fake *telegramtest.Fake, method string) int {
+	n := 0
+	for _, sent := range fake.Sent() {
+		if sent.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPanelEditsMenuInPlace(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	bot.handleMessage(commandMessage(admin, "/help"), admin)
+	help := lastSent(t, fake, "/style")
+	messages := countSent(fake, telegramtest.MethodSendMessage)
+
+	pressButton(t, bot, admin, help, "Admin Menu")
+	menu := lastSent(t, fake, "Admin Menu")
+	if menu.Method != telegramtest.MethodEditMessage || menu.MessageID != help.MessageID {
+		t.Fatalf("Admin menu should replace the help message, got %s of message %d", menu.Method, menu.MessageID)
+	}
+
+	pressButton(t, bot, admin, menu, "System Tools")
+	tools := lastSent(t, fake, "System Tools")
+	if tools.Method != telegramtest.MethodEditMessage || tools.MessageID != help.MessageID {
+		t.Fatalf("System tools should replace the admin menu, got %s of message %d", tools.Method, tools.MessageID)
+	}
+
+	// Pressing the same button again changes nothing and sends nothing
+	pressButton(t, bot, admin, menu, "System Tools")
+
+	if n := countSent(fake, telegramtest.MethodSendMessage); n != messages {
+		t.Errorf("Menu navigation should not send new messages, sent %d", n-messages)
+	}
+}
+
+func TestStalePanelStartsNewOne(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+
+	bot.handleMessage(commandMessage(admin, "/help"), admin)
+	help := lastSent(t, fake, "/style")
+	fake.DeleteMessage(help.ChatID, help.MessageID)
+
+	pressButton(t, bot, admin, help, "Admin Menu")
+	menu := lastSent(t, fake, "Admin Menu")
+	if menu.Method != telegramtest.MethodSendMessage || menu.MessageID == help.MessageID {
+		t.Errorf("A deleted panel should be replaced by a new message, got %s of message %d", menu.Method, menu.MessageID)
+	}
+	if _, ok := menu.Button("System Tools"); !ok {
+		t.Error("The new panel should carry the admin keyboard")
+	}
+}
+
+func TestLogStyleSendsNewMessages(t *testing.T) {
+	bot, fake, admin, _ := newFakeBot(t)
+	bot.config.Bot.MenuStyle = config.MenuStyleLog
+
+	bot.handleMessage(commandMessage(admin, "/help"), admin)
+	help := lastSent(t, fake, "/style")
+
+	pressButton(t, bot, admin, help, "Admin Menu")
+	menu := lastSent(t, fake, "Admin Menu")
+	if menu.Method != telegramtest.MethodSendMessage || menu.MessageID == help.MessageID {
+		t.Errorf("The chat log style should answer with a new message, got %s of message %d", menu.Method, menu.MessageID)
+	}
+}
+
+func TestStyleCommand(t *testing.T) {
+	bot, fake, _, user := newFakeBot(t)
+
+	bot.handleMessage(commandMessage(user, "/style"), user)
+	prompt := lastSent(t, fake, "Current style: 🪟 Panel")
+
+	bot.handleMessage(commandMessage(user, "/style tiles"), user)
+	lastSent(t, fake, "Unknown menu style")
+
+	pressButton(t, bot, user, prompt, "Chat log")
+	result := lastSent(t, fake, "Menu style: 📜 Chat log")
+	if result.MessageID != prompt.MessageID {
+		t.Error("The result should replace the prompt")
+	}
+
+	stored, err := bot.db.GetUser(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.MenuStyle != config.MenuStyleLog {
+		t.Errorf("Expected stored style log, got %q", stored.MenuStyle)
+	}
+
+	// The user choice wins over bot.menu_style
+	bot.config.Bot.MenuStyle = config.MenuStylePanel
+	if style := bot.menuStyle(stored); style != config.MenuStyleLog {
+		t.Errorf("Expected style log, got %s", style)
+	}
+}
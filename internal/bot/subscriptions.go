@@ -0,0 +1,488 @@
+package bot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+)
+
+// Callback data of the subscription settings: evt_sub shows them,
+// evt_sub_t_<type> toggles a type, evt_sub_s_<severity> sets the minimum
+// severity and evt_sub_q_<HH:MM-HH:MM|off> the quiet hours
+const (
+	subscriptionData         = "evt_sub"
+	subscriptionOnData       = "evt_sub_on"
+	subscriptionOffData      = "evt_sub_off"
+	subscriptionTypePrefix   = "evt_sub_t_"
+	subscriptionLevelPrefix  = "evt_sub_s_"
+	subscriptionQuietPrefix  = "evt_sub_q_"
+	subscriptionQuietOffData = subscriptionQuietPrefix + "off"
+)
+
+// quietHoursPresets are offered by the settings keyboard; /subscribe quiet
+// takes any other hours
+var quietHoursPresets = []string{"22:00-07:00", "23:00-08:00"}
+
+const (
+	// heldDeliveryInterval is how often held notifications are checked
+	heldDeliveryInterval = time.Minute
+	// heldPreview caps the held events listed in one message
+	heldPreview = 20
+)
+
+// eventSubscription returns the subscription of a user, or the defaults of
+// the configuration if they haven't changed it
+func (b *Bot) eventSubscription(userID int64) (*database.EventSubscription, error) {
+	sub, err := b.db.GetEventSubscription(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return b.defaultSubscription(userID), nil
+	}
+	return sub, err
+}
+
+// defaultSubscription is the subscription events.defaults describes; only
+// events.notify_users are subscribed by default
+func (b *Bot) defaultSubscription(userID int64) *database.EventSubscription {
+	defaults := b.config.Events.Defaults
+	types := defaults.Types
+	if len(types) == 0 {
+		types = b.config.Events.WatchEvents
+	}
+	severity := defaults.MinSeverity
+	if severity == "" {
+		severity = events.SeverityInfo
+	}
+	return &database.EventSubscription{
+		UserID:      userID,
+		Enabled:     b.config.ShouldNotifyUser(userID),
+		Types:       slices.Clone(types),
+		MinSeverity: severity,
+		QuietHours:  defaults.QuietHours,
+		Timezone:    defaults.Timezone,
+	}
+}
+
+// eventRecipients returns the users subscribed to events: those who
+// subscribed themselves and events.notify_users who haven't changed their
+// subscription
+func (b *Bot) eventRecipients() []int64 {
+	subscriptions, err := b.db.GetEventSubscriptions()
+	if err != nil {
+		log.Printf("Warning: Failed to get event subscriptions: %v", err)
+	}
+
+	own := make(map[int64]bool, len(subscriptions))
+	var recipients []int64
+	for _, sub := range subscriptions {
+		own[sub.UserID] = true
+		if sub.Enabled {
+			recipients = append(recipients, sub.UserID)
+		}
+	}
+	for _, userID := range b.config.Events.NotifyUsers {
+		if !own[userID] {
+			recipients = append(recipients, userID)
+		}
+	}
+	return recipients
+}
+
+// subscribedTo reports whether a subscription covers the type and severity
+// of an event
+func subscribedTo(sub *database.EventSubscription, event events.SystemEvent) bool {
+	if !sub.Enabled || !slices.Contains(sub.Types, string(event.Type)) {
+		return false
+	}
+	severities := events.SeveritiesFrom(sub.MinSeverity)
+	return severities == nil || slices.Contains(severities, event.Severity)
+}
+
+// inQuietHours reports whether now falls into the quiet hours of a
+// subscription, in its time zone or the host's when it has none
+func inQuietHours(sub *database.EventSubscription, now time.Time) bool {
+	if sub.QuietHours == "" {
+		return false
+	}
+	start, end, err := config.ParseQuietHours(sub.QuietHours)
+	if err != nil {
+		return false
+	}
+	// LoadLocation("") is UTC, not the host's zone
+	location := time.Local
+	if sub.Timezone != "" {
+		if loaded, err := time.LoadLocation(sub.Timezone); err == nil {
+			location = loaded
+		}
+	}
+	now = now.In(location)
+
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// holdEvent keeps a notification until the quiet hours of a user end
+func (b *Bot) holdEvent(userID int64, event events.SystemEvent) {
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = b.db.HoldEvent(&database.HeldEvent{UserID: userID, EventID: event.ID, Event: string(data), HeldAt: time.Now()})
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to hold a %s event for user %d: %v", event.Type, userID, err)
+	}
+}
+
+// deliverHeldEvents sends the users whose quiet hours ended what arrived
+// meanwhile. Events held for users who unsubscribed since are dropped.
+func (b *Bot) deliverHeldEvents(now time.Time) {
+	users, err := b.db.GetHeldEventUsers()
+	if err != nil {
+		log.Printf("Warning: Failed to get held events: %v", err)
+		return
+	}
+
+	for _, userID := range users {
+		sub, err := b.eventSubscription(userID)
+		if err != nil {
+			log.Printf("Warning: Failed to get the event subscription of user %d: %v", userID, err)
+			continue
+		}
+		if sub.Enabled && inQuietHours(sub, now) {
+			continue
+		}
+
+		held, err := b.db.GetHeldEvents(userID)
+		if err != nil || len(held) == 0 {
+			continue
+		}
+		if sub.Enabled {
+			b.notifyUsers([]int64{userID}, func(userID int64, user *database.User) (string, *tgbotapi.InlineKeyboardMarkup) {
+				return b.heldEventsText(user, held), nil
+			})
+		}
+		if err := b.db.DeleteHeldEvents(userID, held[len(held)-1].ID); err != nil {
+			log.Printf("Warning: Failed to delete held events of user %d: %v", userID, err)
+		}
+	}
+}
+
+// heldEventsText lists the events held over quiet hours
+func (b *Bot) heldEventsText(user *database.User, held []*database.HeldEvent) string {
+	lines := []string{b.t(user, "subscription.held_title", len(held))}
+	for i, h := range held {
+		if i == heldPreview {
+			lines = append(lines, b.t(user, "subscription.held_more", len(held)-heldPreview))
+			break
+		}
+		var event events.SystemEvent
+		if err := json.Unmarshal([]byte(h.Event), &event); err != nil {
+			continue
+		}
+		lines = append(lines, b.t(user, "subscription.held_entry", severityIcon(event.Severity),
+			event.Timestamp.Format(eventListTimestamp), b.eventTypeName(user, event.Type), shortEventMessage(event.Message)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// heldDelivery runs deliverHeldEvents until it is stopped
+type heldDelivery struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startHeldDelivery starts delivering held notifications
+func (b *Bot) startHeldDelivery() {
+	d := &heldDelivery{stop: make(chan struct{}), done: make(chan struct{})}
+	b.held = d
+
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(heldDeliveryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case now := <-ticker.C:
+				b.deliverHeldEvents(now)
+			}
+		}
+	}()
+}
+
+// stopHeldDelivery stops delivering held notifications; they are kept for
+// the next run
+func (b *Bot) stopHeldDelivery() {
+	if b.held == nil {
+		return
+	}
+	close(b.held.stop)
+	<-b.held.done
+	b.held = nil
+}
+
+// handleSubscribe обрабатывает команду /subscribe: подписывает на события и
+// меняет типы, минимальную важность, тихие часы и часовой пояс
+func (b *Bot) handleSubscribe(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	sub, err := b.eventSubscription(user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	words := strings.Fields(args)
+	for i := 0; i < len(words); i++ {
+		word := strings.ToLower(words[i])
+		if slices.Contains(events.EventTypes, events.EventType(word)) {
+			if !slices.Contains(sub.Types, word) {
+				sub.Types = append(sub.Types, word)
+			}
+			continue
+		}
+
+		if i+1 == len(words) {
+			return b.t(user, "subscription.bad_args", words[i]) + "\n" + b.t(user, "subscription.usage"), false
+		}
+		value := words[i+1]
+		i++
+		switch word {
+		case "severity":
+			if events.SeveritiesFrom(strings.ToLower(value)) == nil {
+				return b.t(user, "subscription.bad_args", value) + "\n" + b.t(user, "subscription.usage"), false
+			}
+			sub.MinSeverity = strings.ToLower(value)
+		case "quiet":
+			if strings.EqualFold(value, "off") {
+				sub.QuietHours = ""
+				break
+			}
+			if _, _, err := config.ParseQuietHours(value); err != nil {
+				return b.t(user, "subscription.bad_args", value) + "\n" + b.t(user, "subscription.usage"), false
+			}
+			sub.QuietHours = value
+		case "tz":
+			if _, err := time.LoadLocation(value); err != nil {
+				return b.t(user, "subscription.bad_zone", value), false
+			}
+			sub.Timezone = value
+		default:
+			return b.t(user, "subscription.bad_args", words[i-1]) + "\n" + b.t(user, "subscription.usage"), false
+		}
+	}
+
+	sub.Enabled = true
+	if err := b.saveSubscription(user, sub); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.t(user, "subscription.updated") + "\n\n" + b.subscriptionText(user, sub), true
+}
+
+// handleUnsubscribe обрабатывает команду /unsubscribe: без аргументов
+// отключает уведомления о событиях, с типами - только о них
+func (b *Bot) handleUnsubscribe(message *tgbotapi.Message, user *database.User, args string) (string, bool) {
+	sub, err := b.eventSubscription(user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	words := strings.Fields(strings.ToLower(args))
+	if len(words) == 0 {
+		sub.Enabled = false
+		if err := b.saveSubscription(user, sub); err != nil {
+			return b.t(user, "error.generic", err), false
+		}
+		return b.t(user, "subscription.unsubscribed"), true
+	}
+
+	for _, word := range words {
+		if !slices.Contains(events.EventTypes, events.EventType(word)) {
+			return b.t(user, "events.unknown_type", word), false
+		}
+		sub.Types = slices.DeleteFunc(sub.Types, func(t string) bool { return t == word })
+	}
+	if err := b.saveSubscription(user, sub); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.t(user, "subscription.updated") + "\n\n" + b.subscriptionText(user, sub), true
+}
+
+// saveSubscription stores a changed subscription in the order of
+// events.EventTypes
+func (b *Bot) saveSubscription(user *database.User, sub *database.EventSubscription) error {
+	types := make([]string, 0, len(sub.Types))
+	for _, eventType := range events.EventTypes {
+		if slices.Contains(sub.Types, string(eventType)) {
+			types = append(types, string(eventType))
+		}
+	}
+	sub.Types = types
+
+	if err := b.db.SaveEventSubscription(sub); err != nil {
+		return err
+	}
+	log.Printf("User %d (%s) changed their event subscription: enabled %t, types %v, severity %s, quiet hours %q %s",
+		user.ID, user.Username, sub.Enabled, sub.Types, sub.MinSeverity, sub.QuietHours, sub.Timezone)
+	return nil
+}
+
+// subscriptionText describes the subscription of a user
+func (b *Bot) subscriptionText(user *database.User, sub *database.EventSubscription) string {
+	status := b.t(user, "subscription.on")
+	if !sub.Enabled {
+		status = b.t(user, "subscription.off")
+	}
+	types := b.t(user, "events.none")
+	if len(sub.Types) > 0 {
+		types = b.eventTypeNames(user, sub.Types)
+	}
+	quiet := b.t(user, "subscription.quiet_off")
+	if sub.QuietHours != "" {
+		zone := sub.Timezone
+		if zone == "" {
+			zone = b.t(user, "subscription.host_zone")
+		}
+		quiet = b.t(user, "subscription.quiet", sub.QuietHours, zone)
+	}
+
+	lines := []string{b.t(user, "subscription.info", status, types, sub.MinSeverity, quiet)}
+
+	var unwatched []string
+	for _, eventType := range sub.Types {
+		if eventType != string(events.EventStartup) && eventType != string(events.EventShutdown) && !b.config.IsEventWatched(eventType) {
+			unwatched = append(unwatched, eventType)
+		}
+	}
+	if len(unwatched) > 0 {
+		lines = append(lines, b.t(user, "subscription.unwatched", b.eventTypeNames(user, unwatched)))
+	}
+	if held, err := b.db.GetHeldEvents(user.ID); err == nil && len(held) > 0 {
+		lines = append(lines, b.t(user, "subscription.held", len(held)))
+	}
+
+	lines = append(lines, "", b.t(user, "subscription.help"))
+	return strings.Join(lines, "\n")
+}
+
+// subscriptionKeyboard toggles the subscription, its types, the minimum
+// severity and the quiet hours
+func subscriptionKeyboard(b *Bot, user *database.User) *tgbotapi.InlineKeyboardMarkup {
+	sub, err := b.eventSubscription(user.ID)
+	if err != nil {
+		log.Printf("Warning: Failed to get the event subscription of user %d: %v", user.ID, err)
+		return menuKeyboard(b, user)
+	}
+
+	toggle := tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.unsubscribe"), subscriptionOffData)
+	if !sub.Enabled {
+		toggle = tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.subscribe"), subscriptionOnData)
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(toggle)}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, eventType := range events.EventTypes {
+		mark := "▫️ "
+		if slices.Contains(sub.Types, string(eventType)) {
+			mark = "✅ "
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(mark+b.eventTypeName(user, eventType), subscriptionTypePrefix+string(eventType)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	var levels []tgbotapi.InlineKeyboardButton
+	for _, severity := range events.Severities {
+		label := severityIcon(severity)
+		if severity == sub.MinSeverity {
+			label = "• " + label + " •"
+		}
+		levels = append(levels, tgbotapi.NewInlineKeyboardButtonData(label, subscriptionLevelPrefix+severity))
+	}
+	rows = append(rows, levels)
+
+	var quiet []tgbotapi.InlineKeyboardButton
+	for _, hours := range quietHoursPresets {
+		label := b.t(user, "button.quiet_hours", hours)
+		if hours == sub.QuietHours {
+			label = "• " + label
+		}
+		quiet = append(quiet, tgbotapi.NewInlineKeyboardButtonData(label, subscriptionQuietPrefix+hours))
+	}
+	quiet = append(quiet, tgbotapi.NewInlineKeyboardButtonData(b.t(user, "button.quiet_off"), subscriptionQuietOffData))
+	rows = append(rows, quiet, b.menuRow(user))
+
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &kb
+}
+
+// handleSubscriptionCallback shows the subscription settings
+func (b *Bot) handleSubscriptionCallback(user *database.User) (string, bool) {
+	sub, err := b.eventSubscription(user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.subscriptionText(user, sub), true
+}
+
+// handleSubscriptionChangeCallback applies a button of the subscription
+// settings and shows them again
+func (b *Bot) handleSubscriptionChangeCallback(callback *tgbotapi.CallbackQuery, user *database.User) (string, bool) {
+	sub, err := b.eventSubscription(user.ID)
+	if err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+
+	data := callback.Data
+	switch {
+	case data == subscriptionOnData:
+		sub.Enabled = true
+	case data == subscriptionOffData:
+		sub.Enabled = false
+	case data == subscriptionQuietOffData:
+		sub.QuietHours = ""
+	case strings.HasPrefix(data, subscriptionTypePrefix):
+		eventType := strings.TrimPrefix(data, subscriptionTypePrefix)
+		if !slices.Contains(events.EventTypes, events.EventType(eventType)) {
+			return b.t(user, "events.unknown_type", eventType), false
+		}
+		if slices.Contains(sub.Types, eventType) {
+			sub.Types = slices.DeleteFunc(sub.Types, func(t string) bool { return t == eventType })
+		} else {
+			sub.Types = append(sub.Types, eventType)
+		}
+	case strings.HasPrefix(data, subscriptionLevelPrefix):
+		severity := strings.TrimPrefix(data, subscriptionLevelPrefix)
+		if events.SeveritiesFrom(severity) == nil {
+			return b.t(user, "error.unknown_action"), false
+		}
+		sub.MinSeverity = severity
+	case strings.HasPrefix(data, subscriptionQuietPrefix):
+		hours := strings.TrimPrefix(data, subscriptionQuietPrefix)
+		if _, _, err := config.ParseQuietHours(hours); err != nil {
+			return b.t(user, "error.unknown_action"), false
+		}
+		sub.QuietHours = hours
+	default:
+		return b.t(user, "error.unknown_action"), false
+	}
+
+	if err := b.saveSubscription(user, sub); err != nil {
+		return b.t(user, "error.generic", err), false
+	}
+	return b.subscriptionText(user, sub), true
+}
@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/events"
+)
+
+func TestEventSubscriptions(t *testing.T) {
+	bot, fake, admin, user := newFakeBot(t)
+	bot.config.Events.NotifyUsers = []int64{admin.ID}
+	bot.config.Events.WatchEvents = []string{"login", "error", "service"}
+	bot.config.Events.Defaults.Types = []string{"login", "error"}
+
+	// Only events.notify_users are subscribed by default
+	login := events.SystemEvent{Type: events.EventLogin, Message: "User logged in", Severity: "info", Timestamp: time.Now()}
+	bot.sendEvent(login)
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].ChatID != admin.ID {
+		t.Fatalf("Expected only the admin notified, got %+v", sent)
+	}
+
+	fake.Reset()
+	bot.handleMessage(commandMessage(user, "/subscribe service severity warning tz UTC"), user)
+	reply := lastSent(t, fake, "Subscription updated")
+	for _, expected := range []string{"Status: subscribed", "Events: Login, System error, Service", "Minimum severity: warning"} {
+		if !containsString(reply.Text, expected) {
+			t.Errorf("Expected %q in the reply, got %s", expected, reply.Text)
+		}
+	}
+	sub, err := bot.db.GetEventSubscription(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sub.Enabled || !slices.Equal(sub.Types, []string{"login", "error", "service"}) || sub.Timezone != "UTC" {
+		t.Errorf("Unexpected subscription %+v", sub)
+	}
+
+	fake.Reset()
+	bot.handleMessage(commandMessage(user, "/subscribe quiet 25:00-07:00"), user)
+	lastSent(t, fake, "Unknown argument: 25:00-07:00")
+
+	// Events below the minimum severity are not sent
+	fake.Reset()
+	bot.sendEvent(login)
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].ChatID != admin.ID {
+		t.Fatalf("Expected the info login sent to the admin only, got %+v", sent)
+	}
+
+	// Quiet hours around now hold non-critical events
+	now := time.Now().UTC()
+	quiet := now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04")
+	fake.Reset()
+	bot.handleMessage(commandMessage(user, "/subscribe quiet "+quiet), user)
+	lastSent(t, fake, "Quiet hours: "+quiet+" (UTC)")
+
+	fake.Reset()
+	bot.sendEvent(events.SystemEvent{Type: events.EventService, Message: "Service nginx changed from running to failed", Severity: "error", Timestamp: now})
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Fatalf("Expected the service event held, got %+v", sent)
+	}
+	bot.sendEvent(events.SystemEvent{Type: events.EventError, Message: "Disk failure", Severity: "critical", Timestamp: now})
+	if sent := fake.Sent(); len(sent) != 2 {
+		t.Fatalf("Expected the critical error sent during quiet hours, got %+v", sent)
+	}
+	if response, _ := bot.handleSubscriptionCallback(user); !containsString(response, "1 notifications are held") {
+		t.Errorf("Expected the held notification counted, got %s", response)
+	}
+
+	fake.Reset()
+	bot.deliverHeldEvents(now)
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Fatalf("Expected nothing delivered during quiet hours, got %+v", sent)
+	}
+	bot.deliverHeldEvents(now.Add(2 * time.Hour))
+	held := lastSent(t, fake, "1 notifications held over quiet hours")
+	if !containsString(held.Text, "<b>Service</b> Service nginx changed from running to failed") {
+		t.Errorf("Expected the held event listed, got %s", held.Text)
+	}
+	if rest, _ := bot.db.GetHeldEvents(user.ID); len(rest) != 0 {
+		t.Errorf("Expected the held events delivered once, got %+v", rest)
+	}
+
+	// The settings keyboard toggles types, the severity and the subscription
+	fake.Reset()
+	pressButton(t, bot, user, reply, "✅ Login")
+	settings := lastSent(t, fake, "Event Subscription")
+	pressButton(t, bot, user, settings, "☀️ No quiet hours")
+	settings = lastSent(t, fake, "Quiet hours: none")
+	pressButton(t, bot, user, settings, "❌")
+	if sub, _ := bot.db.GetEventSubscription(user.ID); slices.Contains(sub.Types, "login") || sub.MinSeverity != "error" || sub.QuietHours != "" {
+		t.Errorf("Unexpected subscription after the buttons %+v", sub)
+	}
+	pressButton(t, bot, user, settings, "🔕 Unsubscribe")
+	lastSent(t, fake, "Status: not subscribed")
+
+	fake.Reset()
+	bot.sendEvent(events.SystemEvent{Type: events.EventError, Message: "Disk failure", Severity: "critical", Timestamp: time.Now()})
+	if sent := fake.Sent(); len(sent) != 1 || sent[0].ChatID != admin.ID {
+		t.Fatalf("Expected the unsubscribed user skipped, got %+v", sent)
+	}
+
+	// A subscription of their own replaces events.notify_users for a user
+	fake.Reset()
+	bot.handleMessage(commandMessage(admin, "/unsubscribe login"), admin)
+	lastSent(t, fake, "Events: System error")
+	fake.Reset()
+	bot.sendEvent(login)
+	if sent := fake.Sent(); len(sent) != 0 {
+		t.Errorf("Expected no login notification, got %+v", sent)
+	}
+	bot.handleMessage(commandMessage(admin, "/unsubscribe"), admin)
+	lastSent(t, fake, "no longer be notified about system events")
+	if recipients := bot.eventRecipients(); len(recipients) != 0 {
+		t.Errorf("Expected no recipients left, got %v", recipients)
+	}
+	if response, _ := bot.handleEventsCallback(admin); !containsString(response, "not subscribed to notifications") {
+		t.Errorf("Unexpected events panel: %s", response)
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	tests := []struct {
+		name     string
+		hours    string
+		timezone string
+		at       time.Time
+		quiet    bool
+	}{
+		{"host zone before", "23:00-07:00", "", time.Date(2026, 10, 16, 17, 59, 0, 0, time.UTC), false}, // 22:59 on the host
+		{"host zone start", "23:00-07:00", "", time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), true},
+		{"host zone past midnight", "23:00-07:00", "", time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC), true},
+		{"host zone end", "23:00-07:00", "", time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC), false},
+		{"host zone daytime", "12:00-14:00", "", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), true},
+		{"host zone not UTC", "12:00-14:00", "", time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC), false},
+		{"named zone before", "23:00-07:00", "Europe/Moscow", time.Date(2026, 10, 16, 19, 30, 0, 0, time.UTC), false}, // 22:30 in Moscow
+		{"named zone start", "23:00-07:00", "Europe/Moscow", time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC), true},
+		{"named zone past midnight", "23:00-07:00", "Europe/Moscow", time.Date(2026, 10, 17, 3, 59, 0, 0, time.UTC), true},
+		{"named zone end", "23:00-07:00", "Europe/Moscow", time.Date(2026, 10, 17, 4, 0, 0, 0, time.UTC), false},
+		{"UTC daytime", "12:00-14:00", "UTC", time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC), true},
+		{"UTC daytime end", "12:00-14:00", "UTC", time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC), false},
+		{"no quiet hours", "", "", time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &database.EventSubscription{QuietHours: tt.hours, Timezone: tt.timezone}
+			if got := inQuietHours(sub, tt.at); got != tt.quiet {
+				t.Errorf("inQuietHours(%q, %q) at %s = %v, expected %v", tt.hours, tt.timezone, tt.at, got, tt.quiet)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/telegram"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// secretTokenHeader carries the webhook secret_token in every update request
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// maxWebhookBody limits the size of an update request
+	maxWebhookBody = 1 << 20
+)
+
+// webhookServer receives updates pushed by Telegram
+type webhookServer struct {
+	config config.WebhookConfig
+	handle func(tgbotapi.Update)
+	server *http.Server
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+func newWebhookServer(cfg config.WebhookConfig, handle func(tgbotapi.Update)) *webhookServer {
+	ws := &webhookServer{
+		config: cfg,
+		handle: handle,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, ws)
+	ws.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return ws
+}
+
+// url returns the public webhook URL registered with Telegram
+func (ws *webhookServer) url() string {
+	return strings.TrimRight(ws.config.URL, "/") + ws.config.Path
+}
+
+// ServeHTTP accepts a single update
+func (ws *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if ws.config.SecretToken != "" {
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(ws.config.SecretToken)) != 1 {
+			log.Printf("Rejected webhook request from %s: invalid secret token", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBody)).Decode(&update); err != nil {
+		http.Error(w, "invalid update", http.StatusBadRequest)
+		return
+	}
+
+	ws.handle(update)
+	w.WriteHeader(http.StatusOK)
+}
+
+// listen binds the listen address, so the webhook is registered only once
+// updates can be accepted
+func (ws *webhookServer) listen() error {
+	listener, err := net.Listen("tcp", ws.config.Listen)
+	if err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	ws.listener = listener
+	ws.mu.Unlock()
+	return nil
+}
+
+// addr returns the bound address, or nil before listen
+func (ws *webhookServer) addr() net.Addr {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.listener == nil {
+		return nil
+	}
+	return ws.listener.Addr()
+}
+
+// serve blocks until the server is shut down
+func (ws *webhookServer) serve() error {
+	ws.mu.Lock()
+	listener := ws.listener
+	ws.mu.Unlock()
+
+	var err error
+	if ws.config.CertFile != "" {
+		err = ws.server.ServeTLS(listener, ws.config.CertFile, ws.config.KeyFile)
+	} else {
+		err = ws.server.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// closeListener releases the address when serve won't be called
+func (ws *webhookServer) closeListener() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.listener != nil {
+		ws.listener.Close()
+	}
+}
+
+// shutdown stops accepting updates and waits for active requests
+func (ws *webhookServer) shutdown(ctx context.Context) error {
+	return ws.server.Shutdown(ctx)
+}
+
+// receiveWebhook listens for updates pushed by Telegram until Stop
+func (b *Bot) receiveWebhook() error {
+	if err := b.webhook.listen(); err != nil {
+		return fmt.Errorf("failed to listen for webhook: %w", err)
+	}
+
+	if err := b.registerWebhook(); err != nil {
+		b.webhook.closeListener()
+		return fmt.Errorf("failed to register webhook: %w", err)
+	}
+
+	log.Printf("Bot started. Waiting for webhook updates on %s...", b.webhook.addr())
+
+	return b.webhook.serve()
+}
+
+// registerWebhook points Telegram at the webhook URL
+func (b *Bot) registerWebhook() error {
+	cfg := b.config.Bot.Webhook
+
+	webhook := telegram.WebhookConfig{
+		URL:                b.webhook.url(),
+		SecretToken:        cfg.SecretToken,
+		MaxConnections:     cfg.MaxConnections,
+		DropPendingUpdates: cfg.DropPendingUpdates,
+	}
+	if cfg.UploadCertificate {
+		webhook.CertificateFile = cfg.CertFile
+	}
+
+	return b.api.SetWebhook(webhook)
+}
+
+// stopWebhook unregisters the webhook and shuts the listener down
+func (b *Bot) stopWebhook() {
+	if err := b.api.DeleteWebhook(); err != nil {
+		log.Printf("Warning: Failed to delete webhook: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.ShutdownTimeout())
+	defer cancel()
+	if err := b.webhook.shutdown(ctx); err != nil {
+		log.Printf("Warning: Failed to shut down webhook listener: %v", err)
+	}
+}
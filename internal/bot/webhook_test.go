@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeBotAPIServer is a local Bot API endpoint recording every request
+type fakeBotAPIServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	calls map[string][]url.Values
+}
+
+func newFakeBotAPIServer(t *testing.T) *fakeBotAPIServer {
+	fake := &fakeBotAPIServer{calls: make(map[string][]url.Values)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+// endpoint returns the api_endpoint format for tgbotapi
+func (f *fakeBotAPIServer) endpoint() string {
+	return f.URL + "/bot%s/%s"
+}
+
+func (f *fakeBotAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	f.mu.Lock()
+	f.calls[method] = append(f.calls[method], r.Form)
+	f.mu.Unlock()
+
+	var result interface{} = true
+	switch method {
+	case "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, UserName: "test_bot"}
+	case "sendMessage":
+		result = tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: 1}}
+	}
+
+	raw, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+// waitFor waits until the method has been called and returns its requests
+func (f *fakeBotAPIServer) waitFor(t *testing.T, method string) []url.Values {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		calls := f.calls[method]
+		f.mu.Unlock()
+		if len(calls) > 0 {
+			return calls
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", method)
+	return nil
+}
+
+func createWebhookTestBot(t *testing.T, endpoint string) *Bot {
+	cfg := createTestConfig()
+	cfg.Bot.APIEndpoint = endpoint
+	cfg.Bot.Mode = config.ModeWebhook
+	cfg.Bot.Workers = 2
+	cfg.Bot.QueueSize = 10
+	cfg.Bot.ShutdownTimeout = 5
+	cfg.Bot.Webhook = config.WebhookConfig{
+		URL:         "https://bot.example.com/",
+		Listen:      "127.0.0.1:0",
+		Path:        "/hook",
+		SecretToken: "s3cret",
+	}
+
+	db := setupTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	bot, err := New(cfg, db)
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	return bot
+}
+
+func postUpdate(t *testing.T, url, secret string, update tgbotapi.Update) int {
+	body, err := json.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Webhook request failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestWebhookLifecycle(t *testing.T) {
+	fake := newFakeBotAPIServer(t)
+	bot := createWebhookTestBot(t, fake.endpoint())
+
+	startErr := make(chan error, 1)
+	go func() { startErr <- bot.Start() }()
+
+	// The webhook is registered with the public URL and the secret
+	params := fake.waitFor(t, "setWebhook")[0]
+	if got := params.Get("url"); got != "https://bot.example.com/hook" {
+		t.Errorf("Expected webhook URL https://bot.example.com/hook, got %q", got)
+	}
+	if got := params.Get("secret_token"); got != "s3cret" {
+		t.Errorf("Expected secret_token s3cret, got %q", got)
+	}
+
+	hookURL := fmt.Sprintf("http://%s/hook", bot.webhook.addr())
+	update := tgbotapi.Update{
+		UpdateID: 1,
+		Message: &tgbotapi.Message{
+			MessageID: 1,
+			From:      &tgbotapi.User{ID: 123456789, UserName: "admin"},
+			Chat:      &tgbotapi.Chat{ID: 123456789},
+			Text:      "/help",
+			Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 5}},
+		},
+	}
+
+	if status := postUpdate(t, hookURL, "wrong", update); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a wrong secret, got %d", status)
+	}
+	if status := postUpdate(t, hookURL, "", update); status != http.StatusForbidden {
+		t.Errorf("Expected 403 without a secret, got %d", status)
+	}
+
+	resp, err := http.Get(hookURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", resp.StatusCode)
+	}
+
+	if status := postUpdate(t, hookURL, "s3cret", update); status != http.StatusOK {
+		t.Fatalf("Expected 200 for a valid update, got %d", status)
+	}
+
+	// The update reaches the handlers, which reply through the Bot API
+	reply := fake.waitFor(t, "sendMessage")[0]
+	if got := reply.Get("chat_id"); got != "123456789" {
+		t.Errorf("Expected reply to chat 123456789, got %q", got)
+	}
+
+	if err := bot.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	fake.waitFor(t, "deleteWebhook")
+
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+}
+
+func TestWebhookRegistrationFailure(t *testing.T) {
+	fake := newFakeBotAPIServer(t)
+
+	// An API endpoint rejecting setWebhook
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/setWebhook") {
+			json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: false, ErrorCode: 400, Description: "Bad Request: bad webhook"})
+			return
+		}
+		fake.serve(w, r)
+	}))
+	defer failing.Close()
+
+	bot := createWebhookTestBot(t, failing.URL+"/bot%s/%s")
+
+	if err := bot.Start(); err == nil {
+		t.Fatal("Expected Start to fail when setWebhook is rejected")
+	}
+
+	// The listener is released
+	if _, err := http.Get(fmt.Sprintf("http://%s/hook", bot.webhook.addr())); err == nil {
+		t.Error("Expected the webhook listener to be closed")
+	}
+}
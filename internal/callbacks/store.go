@@ -0,0 +1,188 @@
+package callbacks
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+)
+
+// DefaultTTL is how long a callback token stays valid
+const DefaultTTL = 24 * time.Hour
+
+// CleanupInterval is how often Start removes expired tokens by default
+const CleanupInterval = time.Hour
+
+var (
+	// ErrNotFound is returned for unknown tokens
+	ErrNotFound = errors.New("callback token not found")
+	// ErrExpired is returned for tokens past their expiry
+	ErrExpired = errors.New("callback token expired")
+	// ErrForeignUser is returned when a token is used by someone other than its owner
+	ErrForeignUser = errors.New("callback token belongs to another user")
+)
+
+// Payload is the data behind a callback token
+type Payload struct {
+	Action    string
+	Path      string
+	Page      int
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+// Store keeps short opaque tokens mapped to callback payloads, so inline
+// buttons stay under Telegram's 64-byte callback_data limit regardless of
+// the payload size
+type Store struct {
+	db  *database.DB
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // set while the cleanup runs
+	wg     sync.WaitGroup
+}
+
+// NewStore creates a token store backed by the database
+func NewStore(db *database.DB, ttl time.Duration) *Store {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{
+		db:  db,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// Issue stores the payload for its owner and returns a token for callback
+// data. Issuing the same payload again returns the same token with a
+// refreshed expiry.
+func (s *Store) Issue(userID int64, action, path string, page int) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := &database.CallbackToken{
+		Token:     token,
+		Action:    action,
+		Path:      path,
+		Page:      page,
+		UserID:    userID,
+		ExpiresAt: s.now().Add(s.ttl).UTC(),
+	}
+	if err := s.db.SaveCallbackToken(record); err != nil {
+		return "", fmt.Errorf("failed to save callback token: %w", err)
+	}
+
+	return record.Token, nil
+}
+
+// Resolve returns the payload of a token if it is still valid and belongs
+// to the user
+func (s *Store) Resolve(token string, userID int64) (*Payload, error) {
+	record, err := s.db.GetCallbackToken(token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load callback token: %w", err)
+	}
+
+	if record.UserID != userID {
+		return nil, ErrForeignUser
+	}
+	if s.now().After(record.ExpiresAt) {
+		return nil, ErrExpired
+	}
+
+	return &Payload{
+		Action:    record.Action,
+		Path:      record.Path,
+		Page:      record.Page,
+		UserID:    record.UserID,
+		ExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// Revoke invalidates a token, e.g. once a single-use button was pressed
+func (s *Store) Revoke(token string) error {
+	return s.db.DeleteCallbackToken(token)
+}
+
+// Cleanup removes expired tokens
+func (s *Store) Cleanup() error {
+	return s.db.DeleteExpiredCallbackTokens(s.now().UTC())
+}
+
+// Start removes expired tokens now and then every interval in the
+// background until Stop. Tokens are issued for every paged or file manager
+// keyboard, so the table would otherwise grow for as long as the bot runs.
+func (s *Store) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = CleanupInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go s.run(ctx, interval)
+}
+
+// Stop stops the background cleanup and waits for it
+func (s *Store) Stop() {
+	s.mu.Lock()
+	if s.cancel == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	s.cancel = nil
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Store) run(ctx context.Context, interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Cleanup(); err != nil {
+			log.Printf("Warning: Failed to clean up callback tokens: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newToken generates a random token of 16 hex characters. Hex keeps the
+// token free of the '_' separator used in callback prefixes.
+func newToken() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate callback token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
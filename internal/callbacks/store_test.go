@@ -0,0 +1,149 @@
+package callbacks
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+)
+
+func setupTestStore(t *testing.T) *Store {
+	tmpFile, err := os.CreateTemp("", "callbacks_test_*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	t.Cleanup(func() { os.Remove(tmpFile.Name()) })
+
+	db, err := database.New(tmpFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewStore(db, time.Hour)
+}
+
+func TestIssueResolve(t *testing.T) {
+	store := setupTestStore(t)
+
+	token, err := store.Issue(1, "dir", "/var/log", 2)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	payload, err := store.Resolve(token, 1)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if payload.Action != "dir" || payload.Path != "/var/log" || payload.Page != 2 {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	// Same payload reuses the token
+	again, err := store.Issue(1, "dir", "/var/log", 2)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if again != token {
+		t.Errorf("Expected token %s to be reused, got %s", token, again)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	store := setupTestStore(t)
+
+	if _, err := store.Resolve("missing", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	token, err := store.Issue(1, "file", "/etc/hosts", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if _, err := store.Resolve(token, 2); !errors.Is(err, ErrForeignUser) {
+		t.Errorf("Expected ErrForeignUser, got %v", err)
+	}
+
+	store.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := store.Resolve(token, 1); !errors.Is(err, ErrExpired) {
+		t.Errorf("Expected ErrExpired, got %v", err)
+	}
+
+	if err := store.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if _, err := store.Resolve(token, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after cleanup, got %v", err)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	store := setupTestStore(t)
+
+	token, err := store.Issue(1, "confirm", "shutdown_now", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if err := store.Revoke(token); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := store.Resolve(token, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Revoke, got %v", err)
+	}
+
+	// The payload can be issued again with a new token
+	again, err := store.Issue(1, "confirm", "shutdown_now", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if again == token {
+		t.Error("Expected a new token after Revoke")
+	}
+}
+
+func TestStartCleansUpPeriodically(t *testing.T) {
+	store := setupTestStore(t)
+
+	expiring, err := store.Issue(1, "dir", "/tmp", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	valid, err := store.Issue(1, "dir", "/var/log", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	store.Start(10 * time.Millisecond)
+	defer store.Stop()
+
+	// Expires after the first cleanup, so a later one has to remove it
+	time.Sleep(50 * time.Millisecond)
+	if err := store.db.SaveCallbackToken(&database.CallbackToken{
+		Token: expiring, Action: "dir", Path: "/tmp", UserID: 1, ExpiresAt: time.Now().Add(-time.Minute).UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := store.db.GetCallbackToken(expiring); errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the expired token removed in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := store.Resolve(valid, 1); err != nil {
+		t.Errorf("Expected the valid token kept, got %v", err)
+	}
+
+	store.Stop()
+	store.Stop()
+}
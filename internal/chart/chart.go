@@ -0,0 +1,257 @@
+// Package chart renders line charts of time series as PNG images, with no
+// dependency beyond the standard library.
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"time"
+)
+
+// ErrNoData is returned for a chart without any point
+var ErrNoData = errors.New("no data to chart")
+
+// Size of the rendered image
+const (
+	Width  = 960
+	Height = 480
+)
+
+const (
+	textScale = 2
+	xTicks    = 6
+	yTicks    = 5
+)
+
+var (
+	background = color.RGBA{255, 255, 255, 255}
+	foreground = color.RGBA{40, 40, 40, 255}
+	gridColor  = color.RGBA{225, 225, 225, 255}
+	palette    = []color.RGBA{
+		{31, 119, 180, 255},
+		{214, 39, 40, 255},
+		{44, 160, 44, 255},
+		{255, 127, 14, 255},
+		{148, 103, 189, 255},
+		{140, 86, 75, 255},
+		{23, 190, 207, 255},
+		{127, 127, 127, 255},
+	}
+)
+
+// Point is a value at a time
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a named line, with points in time order
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart describes a line chart over a time range
+type Chart struct {
+	Title    string
+	Series   []Series
+	From, To time.Time
+	// Fixed range of values when Max > Min, e.g. 0-100 for percents;
+	// otherwise the range fits the data
+	Min, Max float64
+	// Format formats the values on the axis, %g when nil
+	Format func(v float64) string
+	// Points further apart than Gap are not joined, 0 joins every point
+	Gap time.Duration
+	// Location of the time labels, local time when nil
+	Location *time.Location
+}
+
+// Render draws the chart as a PNG image
+func Render(w io.Writer, c *Chart) error {
+	low, high, ok := c.valueRange()
+	if !ok {
+		return ErrNoData
+	}
+	if !c.To.After(c.From) {
+		return fmt.Errorf("invalid time range %s - %s", c.From, c.To)
+	}
+	format := c.Format
+	if format == nil {
+		format = func(v float64) string { return fmt.Sprintf("%g", v) }
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	lineHeight := glyphHeight * textScale
+	labelWidth := 0
+	step := (high - low) / yTicks
+	for i := 0; i <= yTicks; i++ {
+		labelWidth = max(labelWidth, textWidth(format(low+float64(i)*step), textScale))
+	}
+	plot := image.Rect(labelWidth+20, 2*lineHeight+30, Width-30, Height-lineHeight-24)
+
+	// Title and legend
+	drawText(img, plot.Min.X, 12, c.Title, textScale, foreground)
+	x := plot.Max.X
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		name := c.Series[i].Name
+		x -= textWidth(name, textScale)
+		drawText(img, x, 12+lineHeight+8, name, textScale, foreground)
+		x -= lineHeight + 6
+		fill(img, image.Rect(x, 12+lineHeight+8, x+lineHeight, 12+2*lineHeight+8), seriesColor(i))
+		x -= 20
+	}
+
+	// Value axis
+	for i := 0; i <= yTicks; i++ {
+		y := plot.Max.Y - i*plot.Dy()/yTicks
+		fill(img, image.Rect(plot.Min.X, y, plot.Max.X, y+1), gridColor)
+		label := format(low + float64(i)*step)
+		drawText(img, plot.Min.X-10-textWidth(label, textScale), y-lineHeight/2, label, textScale, foreground)
+	}
+
+	// Time axis
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	span := c.To.Sub(c.From)
+	layout := "15:04"
+	switch {
+	case span > 7*24*time.Hour:
+		layout = "01-02"
+	case span > 36*time.Hour:
+		layout = "01-02 15:04"
+	}
+	for i := 0; i <= xTicks; i++ {
+		x := plot.Min.X + i*plot.Dx()/xTicks
+		fill(img, image.Rect(x, plot.Min.Y, x+1, plot.Max.Y), gridColor)
+		label := c.From.Add(span * time.Duration(i) / xTicks).In(loc).Format(layout)
+		labelX := min(max(x-textWidth(label, textScale)/2, 0), Width-textWidth(label, textScale))
+		drawText(img, labelX, plot.Max.Y+10, label, textScale, foreground)
+	}
+	fill(img, image.Rect(plot.Min.X, plot.Max.Y, plot.Max.X+1, plot.Max.Y+1), foreground)
+	fill(img, image.Rect(plot.Min.X, plot.Min.Y, plot.Min.X+1, plot.Max.Y+1), foreground)
+
+	// Lines
+	toX := func(t time.Time) int {
+		return plot.Min.X + int(float64(plot.Dx())*float64(t.Sub(c.From))/float64(span))
+	}
+	toY := func(v float64) int {
+		v = math.Max(low, math.Min(high, v))
+		return plot.Max.Y - int(float64(plot.Dy())*(v-low)/(high-low))
+	}
+	for i, series := range c.Series {
+		col := seriesColor(i)
+		for j, p := range series.Points {
+			if p.Time.Before(c.From) || p.Time.After(c.To) {
+				continue
+			}
+			x, y := toX(p.Time), toY(p.Value)
+			prev := j - 1
+			if prev < 0 || series.Points[prev].Time.Before(c.From) || (c.Gap > 0 && p.Time.Sub(series.Points[prev].Time) > c.Gap) {
+				fill(img, image.Rect(x-1, y-1, x+2, y+2), col) // a lone point
+				continue
+			}
+			px, py := toX(series.Points[prev].Time), toY(series.Points[prev].Value)
+			drawLine(img, px, py, x, y, col)
+			drawLine(img, px, py+1, x, y+1, col)
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// valueRange returns the range of the value axis, rounded to steps of
+// the grid
+func (c *Chart) valueRange() (float64, float64, bool) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, series := range c.Series {
+		for _, p := range series.Points {
+			low, high = math.Min(low, p.Value), math.Max(high, p.Value)
+		}
+	}
+	if math.IsInf(low, 1) {
+		return 0, 0, false
+	}
+	if c.Max > c.Min {
+		return c.Min, c.Max, true
+	}
+
+	if low >= 0 {
+		low = 0 // usage and rates read best from zero
+	}
+	if high <= low {
+		high = low + 1
+	}
+	step := niceStep((high - low) / yTicks)
+	for {
+		start := math.Floor(low/step) * step
+		if start+step*yTicks >= high {
+			return start, start + step*yTicks, true
+		}
+		step = niceStep(step * 1.01) // the next nice step
+	}
+}
+
+// niceStep rounds a step up to 1, 2 or 5 times a power of ten
+func niceStep(step float64) float64 {
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*magnitude >= step {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func seriesColor(i int) color.RGBA {
+	return palette[i%len(palette)]
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// drawLine draws a line with Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
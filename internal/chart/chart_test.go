@@ -0,0 +1,102 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var points []Point
+	for i := 0; i <= 24; i++ {
+		if i == 12 || i == 13 {
+			continue // a gap
+		}
+		points = append(points, Point{Time: from.Add(time.Duration(i) * time.Hour), Value: float64(i * 4)})
+	}
+
+	var buf bytes.Buffer
+	err := Render(&buf, &Chart{
+		Title:    "CPU, 24h",
+		Series:   []Series{{Name: "cpu", Points: points}},
+		From:     from,
+		To:       from.Add(24 * time.Hour),
+		Min:      0,
+		Max:      100,
+		Gap:      90 * time.Minute,
+		Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Expected a PNG image: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != Width || b.Dy() != Height {
+		t.Errorf("Expected %dx%d, got %v", Width, Height, b)
+	}
+
+	colored := 0
+	want := palette[0]
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if uint8(r>>8) == want.R && uint8(g>>8) == want.G && uint8(b>>8) == want.B {
+				colored++
+			}
+		}
+	}
+	if colored < 500 {
+		t.Errorf("Expected the line drawn, got %d pixels of its color", colored)
+	}
+}
+
+func TestRenderWithoutData(t *testing.T) {
+	now := time.Now()
+	err := Render(&bytes.Buffer{}, &Chart{Series: []Series{{Name: "cpu"}}, From: now.Add(-time.Hour), To: now})
+	if !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got %v", err)
+	}
+}
+
+func TestValueRange(t *testing.T) {
+	tests := []struct {
+		values    []float64
+		low, high float64
+	}{
+		{[]float64{3, 7}, 0, 10},
+		{[]float64{120, 870}, 0, 1000},
+		{[]float64{0, 0}, 0, 1},
+		{[]float64{-3, 4}, -4, 6},
+		{[]float64{1234567}, 0, 2500000},
+	}
+	for _, tt := range tests {
+		var points []Point
+		for _, v := range tt.values {
+			points = append(points, Point{Value: v})
+		}
+		c := &Chart{Series: []Series{{Points: points}}}
+		low, high, ok := c.valueRange()
+		if !ok || low != tt.low || high != tt.high {
+			t.Errorf("%v: expected %v-%v, got %v-%v", tt.values, tt.low, tt.high, low, high)
+		}
+	}
+}
+
+func TestGlyphs(t *testing.T) {
+	for r, glyph := range glyphs {
+		for _, row := range glyph {
+			if len(row) != glyphWidth {
+				t.Errorf("Glyph %q has a row of %d pixels", r, len(row))
+			}
+		}
+	}
+	if textWidth("ab", 2) != 22 {
+		t.Errorf("Expected two glyphs 22 pixels wide, got %d", textWidth("ab", 2))
+	}
+}
@@ -2,10 +2,18 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	// Time zones of quiet hours, also on hosts without a zoneinfo database
+	_ "time/tzdata"
 
+	"github.com/cupbot/cupbot/internal/i18n"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -16,11 +24,73 @@ type Config struct {
 	FileManager FileManagerConfig `yaml:"file_manager"`
 	Screenshot  ScreenshotConfig  `yaml:"screenshot"`
 	Events      EventsConfig      `yaml:"events"`
+	Exec        ExecConfig        `yaml:"exec"`
+	Shell       ShellConfig       `yaml:"shell"`
+	Services    ServicesConfig    `yaml:"services"`
+	Alerts      AlertsConfig      `yaml:"alerts"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Prometheus  PrometheusConfig  `yaml:"prometheus"`
+	System      SystemConfig      `yaml:"system"`
 }
 
 type BotConfig struct {
-	Token string `yaml:"token"`
-	Debug bool   `yaml:"debug"`
+	Token           string          `yaml:"token"`
+	Debug           bool            `yaml:"debug"`
+	Mode            string          `yaml:"mode"`             // polling, webhook
+	APIEndpoint     string          `yaml:"api_endpoint"`     // Bot API URL format, e.g. http://localhost:8081/bot%s/%s
+	Workers         int             `yaml:"workers"`          // concurrent update handlers
+	QueueSize       int             `yaml:"queue_size"`       // pending updates per chat
+	ShutdownTimeout int             `yaml:"shutdown_timeout"` // seconds to drain handlers on stop
+	DialogTimeout   int             `yaml:"dialog_timeout"`   // seconds an interactive prompt waits for input
+	Language        string          `yaml:"language"`         // replies for users without a supported Telegram language: ru, en
+	MenuStyle       string          `yaml:"menu_style"`       // panel, log; users may pick their own with /style
+	Confirm         ConfirmConfig   `yaml:"confirm"`
+	Dashboard       DashboardConfig `yaml:"dashboard"`
+	Webhook         WebhookConfig   `yaml:"webhook"`
+}
+
+// ConfirmConfig lists the actions that run only after an explicit
+// Confirm tap. Actions are callback data (e.g. shutdown_now) or command
+// names with a leading slash (e.g. /deleteuser).
+type ConfirmConfig struct {
+	Actions []string `yaml:"actions"`
+	Timeout int      `yaml:"timeout"` // seconds the Confirm button stays valid
+}
+
+// DashboardConfig limits the pinned /dashboard messages
+type DashboardConfig struct {
+	Interval   int `yaml:"interval"`     // seconds between refreshes
+	MaxPerChat int `yaml:"max_per_chat"` // dashboards refreshing at once in one chat
+}
+
+// MinDashboardInterval keeps dashboard edits well under the Telegram
+// limits of about one message per second in a chat
+const MinDashboardInterval = 5
+
+// Bot receive modes
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+// Menu styles. A panel is one message edited in place by its buttons, a log
+// answers every button with a new message.
+const (
+	MenuStylePanel = "panel"
+	MenuStyleLog   = "log"
+)
+
+// WebhookConfig configures the embedded listener used in webhook mode
+type WebhookConfig struct {
+	URL                string `yaml:"url"`                  // public base URL Telegram sends updates to
+	Listen             string `yaml:"listen"`               // local listen address
+	Path               string `yaml:"path"`                 // secret URL path
+	SecretToken        string `yaml:"secret_token"`         // checked against X-Telegram-Bot-Api-Secret-Token
+	CertFile           string `yaml:"cert_file"`            // TLS certificate; plain HTTP when empty
+	KeyFile            string `yaml:"key_file"`             // TLS private key
+	UploadCertificate  bool   `yaml:"upload_certificate"`   // send cert_file to Telegram (self-signed certificates)
+	MaxConnections     int    `yaml:"max_connections"`      // 1-100, Telegram default when 0
+	DropPendingUpdates bool   `yaml:"drop_pending_updates"` // drop updates queued while the bot was down
 }
 
 type DatabaseConfig struct {
@@ -51,9 +121,170 @@ type ScreenshotConfig struct {
 
 type EventsConfig struct {
 	Enabled         bool     `yaml:"enabled"`
-	NotifyUsers     []int64  `yaml:"notify_users"`     // Users to notify about events
+	NotifyUsers     []int64  `yaml:"notify_users"`     // Users subscribed to events until they change their subscription
 	WatchEvents     []string `yaml:"watch_events"`     // login, logout, startup, shutdown, error
 	PollingInterval int      `yaml:"polling_interval"` // seconds
+	// Intervals overrides polling_interval for single sources by name:
+	// sessions, processes, services, journal (Linux), system_log (Windows)
+	Intervals map[string]int `yaml:"intervals"`
+	// Throttle limits events by type; "*" applies to the types not listed.
+	// Critical events are never throttled.
+	Throttle       map[string]EventThrottle `yaml:"throttle"`
+	RecipientLimit int                      `yaml:"recipient_limit"` // notifications a minute per user, negative for no limit
+	// Defaults is the subscription of users who haven't changed their own
+	Defaults EventSubscriptionDefaults `yaml:"defaults"`
+}
+
+// EventSubscriptionDefaults are the event subscription settings users start
+// with
+type EventSubscriptionDefaults struct {
+	Types       []string `yaml:"types"`        // watch_events when empty
+	MinSeverity string   `yaml:"min_severity"` // info when empty
+	QuietHours  string   `yaml:"quiet_hours"`  // e.g. 23:00-07:00; non-critical events are held meanwhile
+	Timezone    string   `yaml:"timezone"`     // IANA name of the quiet hours zone, the host's when empty
+}
+
+// EventSeverities are the severities of events from the least severe
+var EventSeverities = []string{"info", "warning", "error", "critical"}
+
+// EventThrottle limits the events of one type. Zero turns a limit off.
+type EventThrottle struct {
+	DedupWindow    int    `yaml:"dedup_window"`    // seconds identical events are collapsed for
+	RateLimit      int    `yaml:"rate_limit"`      // events a minute passed on at once, the rest are batched
+	Digest         int    `yaml:"digest"`          // seconds low-severity events are batched for
+	DigestSeverity string `yaml:"digest_severity"` // the most severe severity batched, info by default
+}
+
+// DigestSeverities are the severities digests can collect up to
+var DigestSeverities = []string{"info", "warning", "error"}
+
+// ExecConfig configures /exec. Only the listed commands run, directly and
+// without a shell.
+type ExecConfig struct {
+	Enabled    bool              `yaml:"enabled"`
+	Timeout    int               `yaml:"timeout"`     // seconds, for commands without their own
+	MaxOutput  int               `yaml:"max_output"`  // bytes of combined stdout and stderr kept
+	WorkingDir string            `yaml:"working_dir"` // for commands without their own; the bot's when empty
+	ClearEnv   bool              `yaml:"clear_env"`   // start commands with only PATH and env instead of the bot environment
+	Env        map[string]string `yaml:"env"`         // added to the environment of every command
+	Commands   []ExecCommand     `yaml:"commands"`
+}
+
+// ExecCommand is an allowlisted command. Users type its name followed by
+// arguments; every argument must fully match one of AllowArgs.
+type ExecCommand struct {
+	Name       string            `yaml:"name"`
+	Path       string            `yaml:"path"`       // executable, looked up in PATH; defaults to name
+	Args       []string          `yaml:"args"`       // fixed arguments passed before the user ones
+	AllowArgs  []string          `yaml:"allow_args"` // regular expressions; no user arguments when empty
+	MaxArgs    int               `yaml:"max_args"`   // 0 means no limit
+	Timeout    int               `yaml:"timeout"`    // seconds
+	WorkingDir string            `yaml:"working_dir"`
+	Env        map[string]string `yaml:"env"` // added after exec.env
+}
+
+// ShellConfig configures the interactive /shell sessions
+type ShellConfig struct {
+	Enabled     bool     `yaml:"enabled"`
+	Command     string   `yaml:"command"`      // $SHELL or /bin/sh, cmd.exe on Windows when empty
+	Args        []string `yaml:"args"`         // passed to the shell
+	WorkingDir  string   `yaml:"working_dir"`  // the bot's when empty
+	IdleTimeout int      `yaml:"idle_timeout"` // seconds without input or output before the session is killed
+}
+
+// ServicesConfig configures /services. Only services matching a rule are
+// shown, and only the actions of the matching rules can be run on them.
+type ServicesConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Timeout int           `yaml:"timeout"` // seconds to wait for a start, stop or restart
+	Allowed []ServiceRule `yaml:"allowed"`
+}
+
+// ServiceRule allows actions on the services whose name matches Name, a
+// pattern such as "nginx" or "docker*" compared ignoring case
+type ServiceRule struct {
+	Name    string   `yaml:"name"`
+	Actions []string `yaml:"actions"` // start, stop, restart, enable, disable; status is always allowed
+}
+
+// ServiceActions are the service actions a rule can allow
+var ServiceActions = []string{"start", "stop", "restart", "enable", "disable"}
+
+// MetricsConfig configures the history of host metrics charted by /graph.
+// Samples older than raw_hours are averaged into rows of resolution
+// seconds, rows older than retention days are deleted.
+type MetricsConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	Interval   int  `yaml:"interval"`   // seconds between samples
+	RawHours   int  `yaml:"raw_hours"`  // hours samples are kept as recorded
+	Resolution int  `yaml:"resolution"` // seconds averaged into a row after raw_hours
+	Retention  int  `yaml:"retention"`  // days
+}
+
+// PrometheusConfig configures the optional HTTP listener serving /metrics
+// in the Prometheus text format and /healthz
+type PrometheusConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Listen   string `yaml:"listen"`   // bind address
+	Username string `yaml:"username"` // basic auth on both endpoints, off when empty
+	Password string `yaml:"password"`
+}
+
+// SystemConfig configures the background collector behind /status, the
+// dashboard, alerts and metrics
+type SystemConfig struct {
+	SampleInterval int `yaml:"sample_interval"` // seconds between snapshots of the host
+}
+
+// AlertsConfig configures threshold alerts on the metrics of the host. The
+// rules are evaluated every events.polling_interval seconds.
+type AlertsConfig struct {
+	Enabled     bool        `yaml:"enabled"`
+	NotifyUsers []int64     `yaml:"notify_users"` // recipients of rules without their own
+	Rules       []AlertRule `yaml:"rules"`
+}
+
+// AlertRule fires when a metric stays past the threshold for Duration
+// seconds and resolves once it gets back past Clear
+type AlertRule struct {
+	Name        string   `yaml:"name"`
+	Metric      string   `yaml:"metric"`    // one of AlertMetrics
+	Target      string   `yaml:"target"`    // mount point or device of a disk, network interface (all when empty)
+	Operator    string   `yaml:"operator"`  // > or <
+	Threshold   float64  `yaml:"threshold"` // percent, bytes per second for network metrics
+	Clear       *float64 `yaml:"clear"`     // threshold when unset
+	Duration    int      `yaml:"duration"`  // seconds
+	NotifyUsers []int64  `yaml:"notify_users"`
+}
+
+// Metrics an alert rule can watch
+const (
+	AlertMetricCPU      = "cpu"       // average usage of all cores, percent
+	AlertMetricMemory   = "memory"    // used memory, percent
+	AlertMetricDiskUsed = "disk_used" // used space of the target disk, percent
+	AlertMetricDiskFree = "disk_free" // free space of the target disk, percent
+	AlertMetricNetRecv  = "net_recv"  // bytes received per second
+	AlertMetricNetSent  = "net_sent"  // bytes sent per second
+)
+
+// AlertMetrics lists the metrics an alert rule can watch
+var AlertMetrics = []string{AlertMetricCPU, AlertMetricMemory, AlertMetricDiskUsed, AlertMetricDiskFree, AlertMetricNetRecv, AlertMetricNetSent}
+
+// ClearLevel returns the value the metric has to get back past to resolve
+// the alert
+func (r *AlertRule) ClearLevel() float64 {
+	if r.Clear != nil {
+		return *r.Clear
+	}
+	return r.Threshold
+}
+
+// AlertRecipients returns the users notified about a rule
+func (c *Config) AlertRecipients(rule *AlertRule) []int64 {
+	if len(rule.NotifyUsers) > 0 {
+		return rule.NotifyUsers
+	}
+	return c.Alerts.NotifyUsers
 }
 
 func Load(configPath string) (*Config, error) {
@@ -80,6 +311,10 @@ func Load(configPath string) (*Config, error) {
 		config.Bot.Debug = debug == "true"
 	}
 
+	if mode := os.Getenv("BOT_MODE"); mode != "" {
+		config.Bot.Mode = mode
+	}
+
 	if dbPath := os.Getenv("DB_PATH"); dbPath != "" {
 		config.Database.Path = dbPath
 	}
@@ -99,6 +334,48 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	// Устанавливаем значения по умолчанию
+	if config.Bot.Mode == "" {
+		config.Bot.Mode = ModePolling
+	}
+	if config.Bot.Mode == ModeWebhook {
+		if config.Bot.Webhook.Listen == "" {
+			config.Bot.Webhook.Listen = ":8443"
+		}
+		if config.Bot.Webhook.Path == "" {
+			config.Bot.Webhook.Path = "/webhook"
+		}
+	}
+	if config.Bot.Workers <= 0 {
+		config.Bot.Workers = 8
+	}
+	if config.Bot.QueueSize <= 0 {
+		config.Bot.QueueSize = 100
+	}
+	if config.Bot.ShutdownTimeout <= 0 {
+		config.Bot.ShutdownTimeout = 30 // 30 seconds
+	}
+	if config.Bot.DialogTimeout <= 0 {
+		config.Bot.DialogTimeout = 300 // 5 minutes
+	}
+	if config.Bot.Confirm.Actions == nil {
+		config.Bot.Confirm.Actions = []string{"shutdown_now", "force_shutdown", "force_reboot", "/deleteuser"}
+	}
+	if config.Bot.Confirm.Timeout <= 0 {
+		config.Bot.Confirm.Timeout = 60 // 1 minute
+	}
+	if config.Bot.Language == "" {
+		config.Bot.Language = i18n.Default
+	}
+	if config.Bot.MenuStyle == "" {
+		config.Bot.MenuStyle = MenuStylePanel
+	}
+	if config.Bot.Dashboard.Interval <= 0 {
+		config.Bot.Dashboard.Interval = 10 // 10 seconds
+	}
+	if config.Bot.Dashboard.MaxPerChat <= 0 {
+		config.Bot.Dashboard.MaxPerChat = 1
+	}
+
 	if config.Database.Path == "" {
 		config.Database.Path = "cupbot.db"
 	}
@@ -147,6 +424,57 @@ func Load(configPath string) (*Config, error) {
 	if config.Events.NotifyUsers == nil {
 		config.Events.NotifyUsers = make([]int64, 0)
 	}
+	if config.Events.Throttle == nil {
+		config.Events.Throttle = map[string]EventThrottle{
+			"*":       {DedupWindow: 300},
+			"process": {DedupWindow: 300, RateLimit: 10, Digest: 3600},
+		}
+	}
+	if config.Events.RecipientLimit == 0 {
+		config.Events.RecipientLimit = 20
+	}
+
+	// Exec defaults
+	if config.Exec.Timeout <= 0 {
+		config.Exec.Timeout = 30 // 30 seconds
+	}
+	if config.Exec.MaxOutput <= 0 {
+		config.Exec.MaxOutput = 64 * 1024 // 64KB
+	}
+
+	// Shell defaults
+	if config.Shell.IdleTimeout <= 0 {
+		config.Shell.IdleTimeout = 600 // 10 minutes
+	}
+
+	// Services defaults
+	if config.Services.Timeout <= 0 {
+		config.Services.Timeout = 30 // 30 seconds
+	}
+
+	// Metrics defaults
+	if config.Metrics.Interval <= 0 {
+		config.Metrics.Interval = 60 // 1 minute
+	}
+	if config.Metrics.RawHours <= 0 {
+		config.Metrics.RawHours = 24
+	}
+	if config.Metrics.Resolution <= 0 {
+		config.Metrics.Resolution = 600 // 10 minutes
+	}
+	if config.Metrics.Retention <= 0 {
+		config.Metrics.Retention = 30 // 30 days
+	}
+
+	// Prometheus defaults
+	if config.Prometheus.Listen == "" {
+		config.Prometheus.Listen = "127.0.0.1:9877"
+	}
+
+	// System defaults
+	if config.System.SampleInterval <= 0 {
+		config.System.SampleInterval = 5
+	}
 
 	// Ensure slices are never nil
 	if config.Users.AdminUserIDs == nil {
@@ -156,9 +484,218 @@ func Load(configPath string) (*Config, error) {
 		config.Users.AllowedUsers = make([]int64, 0)
 	}
 
+	if err := config.validateBot(); err != nil {
+		return nil, err
+	}
+	if err := config.validateExec(); err != nil {
+		return nil, err
+	}
+	if err := config.validateServices(); err != nil {
+		return nil, err
+	}
+	if err := config.validateAlerts(); err != nil {
+		return nil, err
+	}
+	if err := config.validateEvents(); err != nil {
+		return nil, err
+	}
+	if err := config.validatePrometheus(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
+// validateBot checks the language, menu style, dashboard and receive mode
+// settings
+func (c *Config) validateBot() error {
+	if !i18n.Supported(c.Bot.Language) {
+		return fmt.Errorf("invalid bot.language %q: expected one of %s", c.Bot.Language, strings.Join(i18n.Languages(), ", "))
+	}
+	if !ValidMenuStyle(c.Bot.MenuStyle) {
+		return fmt.Errorf("invalid bot.menu_style %q: expected %s or %s", c.Bot.MenuStyle, MenuStylePanel, MenuStyleLog)
+	}
+	if c.Bot.Dashboard.Interval < MinDashboardInterval {
+		return fmt.Errorf("bot.dashboard.interval must be at least %d seconds", MinDashboardInterval)
+	}
+
+	switch c.Bot.Mode {
+	case ModePolling:
+		return nil
+	case ModeWebhook:
+	default:
+		return fmt.Errorf("invalid bot.mode %q: expected %s or %s", c.Bot.Mode, ModePolling, ModeWebhook)
+	}
+
+	webhook := c.Bot.Webhook
+	if webhook.URL == "" {
+		return fmt.Errorf("bot.webhook.url is required in webhook mode")
+	}
+	if !strings.HasPrefix(webhook.Path, "/") {
+		return fmt.Errorf("bot.webhook.path must start with /")
+	}
+	if (webhook.CertFile == "") != (webhook.KeyFile == "") {
+		return fmt.Errorf("bot.webhook.cert_file and bot.webhook.key_file must be set together")
+	}
+	if webhook.UploadCertificate && webhook.CertFile == "" {
+		return fmt.Errorf("bot.webhook.upload_certificate requires cert_file")
+	}
+	if webhook.MaxConnections < 0 || webhook.MaxConnections > 100 {
+		return fmt.Errorf("bot.webhook.max_connections must be between 1 and 100")
+	}
+	return nil
+}
+
+// validateExec checks that /exec commands have unique names and valid
+// argument patterns
+func (c *Config) validateExec() error {
+	seen := make(map[string]bool)
+	for i, cmd := range c.Exec.Commands {
+		if cmd.Name == "" || strings.ContainsAny(cmd.Name, " \t\n") {
+			return fmt.Errorf("exec.commands[%d]: invalid name %q", i, cmd.Name)
+		}
+		if seen[cmd.Name] {
+			return fmt.Errorf("exec.commands[%d]: duplicate name %q", i, cmd.Name)
+		}
+		seen[cmd.Name] = true
+
+		for _, pattern := range cmd.AllowArgs {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("exec.commands[%d] (%s): invalid allow_args pattern %q: %w", i, cmd.Name, pattern, err)
+			}
+		}
+		if cmd.Timeout < 0 || cmd.MaxArgs < 0 {
+			return fmt.Errorf("exec.commands[%d] (%s): timeout and max_args can't be negative", i, cmd.Name)
+		}
+	}
+	return nil
+}
+
+// validateServices checks the patterns and actions of the service rules
+func (c *Config) validateServices() error {
+	for i, rule := range c.Services.Allowed {
+		if _, err := path.Match(rule.Name, ""); err != nil || rule.Name == "" {
+			return fmt.Errorf("services.allowed[%d]: invalid name pattern %q", i, rule.Name)
+		}
+		for _, action := range rule.Actions {
+			if !slices.Contains(ServiceActions, action) {
+				return fmt.Errorf("services.allowed[%d] (%s): invalid action %q: expected one of %s", i, rule.Name, action, strings.Join(ServiceActions, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// validatePrometheus checks the listen address and that basic auth has
+// both a username and a password
+func (c *Config) validatePrometheus() error {
+	if _, _, err := net.SplitHostPort(c.Prometheus.Listen); err != nil {
+		return fmt.Errorf("invalid prometheus.listen %q: %w", c.Prometheus.Listen, err)
+	}
+	if (c.Prometheus.Username == "") != (c.Prometheus.Password == "") {
+		return fmt.Errorf("prometheus.username and prometheus.password must be set together")
+	}
+	return nil
+}
+
+// validateEvents checks the throttling of event types and the subscription
+// defaults
+func (c *Config) validateEvents() error {
+	defaults := c.Events.Defaults
+	if defaults.MinSeverity != "" && !slices.Contains(EventSeverities, defaults.MinSeverity) {
+		return fmt.Errorf("invalid events.defaults.min_severity %q: expected one of %s", defaults.MinSeverity, strings.Join(EventSeverities, ", "))
+	}
+	if defaults.QuietHours != "" {
+		if _, _, err := ParseQuietHours(defaults.QuietHours); err != nil {
+			return fmt.Errorf("invalid events.defaults.quiet_hours: %w", err)
+		}
+	}
+	if _, err := time.LoadLocation(defaults.Timezone); err != nil {
+		return fmt.Errorf("invalid events.defaults.timezone %q: %w", defaults.Timezone, err)
+	}
+
+	for eventType, throttle := range c.Events.Throttle {
+		if throttle.DedupWindow < 0 || throttle.RateLimit < 0 || throttle.Digest < 0 {
+			return fmt.Errorf("events.throttle.%s: dedup_window, rate_limit and digest can't be negative", eventType)
+		}
+		if throttle.DigestSeverity != "" && !slices.Contains(DigestSeverities, throttle.DigestSeverity) {
+			return fmt.Errorf("events.throttle.%s: invalid digest_severity %q: expected one of %s", eventType, throttle.DigestSeverity, strings.Join(DigestSeverities, ", "))
+		}
+	}
+	return nil
+}
+
+// validateAlerts checks that alert rules have unique names, known metrics
+// and a clear level on the resolving side of the threshold
+func (c *Config) validateAlerts() error {
+	seen := make(map[string]bool)
+	for i, rule := range c.Alerts.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("alerts.rules[%d]: name is required", i)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("alerts.rules[%d]: duplicate name %q", i, rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if !slices.Contains(AlertMetrics, rule.Metric) {
+			return fmt.Errorf("alerts.rules[%d] (%s): invalid metric %q: expected one of %s", i, rule.Name, rule.Metric, strings.Join(AlertMetrics, ", "))
+		}
+		if (rule.Metric == AlertMetricDiskUsed || rule.Metric == AlertMetricDiskFree) && rule.Target == "" {
+			return fmt.Errorf("alerts.rules[%d] (%s): target disk is required", i, rule.Name)
+		}
+		if rule.Duration < 0 {
+			return fmt.Errorf("alerts.rules[%d] (%s): duration can't be negative", i, rule.Name)
+		}
+		switch rule.Operator {
+		case ">":
+			if rule.ClearLevel() > rule.Threshold {
+				return fmt.Errorf("alerts.rules[%d] (%s): clear must not be above the threshold", i, rule.Name)
+			}
+		case "<":
+			if rule.ClearLevel() < rule.Threshold {
+				return fmt.Errorf("alerts.rules[%d] (%s): clear must not be below the threshold", i, rule.Name)
+			}
+		default:
+			return fmt.Errorf("alerts.rules[%d] (%s): invalid operator %q: expected > or <", i, rule.Name, rule.Operator)
+		}
+	}
+	return nil
+}
+
+// ParseQuietHours parses quiet hours such as 23:00-07:00 into minutes of
+// the day. The end may be before the start, for hours past midnight.
+func ParseQuietHours(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q: expected HH:MM-HH:MM", s)
+	}
+	if start, err = parseClock(from); err != nil {
+		return 0, 0, fmt.Errorf("%q: %w", s, err)
+	}
+	if end, err = parseClock(to); err != nil {
+		return 0, 0, fmt.Errorf("%q: %w", s, err)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("%q: start and end are the same", s)
+	}
+	return start, end, nil
+}
+
+// parseClock parses HH:MM into minutes of the day
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidMenuStyle reports whether style is panel or log
+func ValidMenuStyle(style string) bool {
+	return style == MenuStylePanel || style == MenuStyleLog
+}
+
 func parseUserIDs(s string) []int64 {
 	parts := strings.Split(s, ",")
 	ids := make([]int64, 0) // Always return non-nil slice
@@ -173,6 +710,8 @@ func parseUserIDs(s string) []int64 {
 	return ids
 }
 
+// IsAdmin reports whether the user is listed in admin_user_ids.
+// The list only seeds the users table; runtime checks go through auth.Middleware.
 func (c *Config) IsAdmin(userID int64) bool {
 	for _, id := range c.Users.AdminUserIDs {
 		if id == userID {
@@ -182,6 +721,7 @@ func (c *Config) IsAdmin(userID int64) bool {
 	return false
 }
 
+// IsAllowed reports whether a user unknown to the database may register
 func (c *Config) IsAllowed(userID int64) bool {
 	// Администраторы всегда разрешены
 	if c.IsAdmin(userID) {
@@ -221,6 +761,16 @@ func (c *Config) IsActionAllowed(action string) bool {
 	return false
 }
 
+// RequiresConfirmation checks if an action has to be confirmed before it runs
+func (c *Config) RequiresConfirmation(action string) bool {
+	for _, confirmed := range c.Bot.Confirm.Actions {
+		if confirmed == action {
+			return true
+		}
+	}
+	return false
+}
+
 // IsEventWatched checks if an event type is being watched
 func (c *Config) IsEventWatched(eventType string) bool {
 	for _, watchedEvent := range c.Events.WatchEvents {
@@ -231,6 +781,23 @@ func (c *Config) IsEventWatched(eventType string) bool {
 	return false
 }
 
+// EventSourceInterval returns the polling interval of an event source in
+// seconds
+func (c *Config) EventSourceInterval(source string) int {
+	if interval := c.Events.Intervals[source]; interval > 0 {
+		return interval
+	}
+	return c.Events.PollingInterval
+}
+
+// EventThrottle returns the throttling of an event type
+func (c *Config) EventThrottle(eventType string) EventThrottle {
+	if throttle, ok := c.Events.Throttle[eventType]; ok {
+		return throttle
+	}
+	return c.Events.Throttle["*"]
+}
+
 // ShouldNotifyUser checks if a user should be notified about events
 func (c *Config) ShouldNotifyUser(userID int64) bool {
 	for _, notifyUser := range c.Events.NotifyUsers {
@@ -240,3 +807,30 @@ func (c *Config) ShouldNotifyUser(userID int64) bool {
 	}
 	return false
 }
+
+// IsServiceVisible reports whether a service matches one of the rules in
+// services.allowed
+func (c *Config) IsServiceVisible(name string) bool {
+	for _, rule := range c.Services.Allowed {
+		if matchServiceName(rule.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServiceActionAllowed reports whether a rule matching the service allows
+// the action
+func (c *Config) IsServiceActionAllowed(name, action string) bool {
+	for _, rule := range c.Services.Allowed {
+		if matchServiceName(rule.Name, name) && slices.Contains(rule.Actions, action) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchServiceName(pattern, name string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return ok
+}
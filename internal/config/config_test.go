@@ -26,8 +26,23 @@ users:
   allowed_users: [987654321]`,
 			expectedConfig: &Config{
 				Bot: BotConfig{
-					Token: "test_token",
-					Debug: true,
+					Token:           "test_token",
+					Debug:           true,
+					Mode:            "polling",
+					Workers:         8,
+					QueueSize:       100,
+					ShutdownTimeout: 30,
+					DialogTimeout:   300,
+					Language:        "ru",
+					MenuStyle:       "panel",
+					Confirm: ConfirmConfig{
+						Actions: []string{"shutdown_now", "force_shutdown", "force_reboot", "/deleteuser"},
+						Timeout: 60,
+					},
+					Dashboard: DashboardConfig{
+						Interval:   10,
+						MaxPerChat: 1,
+					},
 				},
 				Database: DatabaseConfig{
 					Path: "test.db",
@@ -56,6 +71,33 @@ users:
 					NotifyUsers:     []int64{},
 					WatchEvents:     []string{"login", "logout", "error"},
 					PollingInterval: 30,
+					Throttle: map[string]EventThrottle{
+						"*":       {DedupWindow: 300},
+						"process": {DedupWindow: 300, RateLimit: 10, Digest: 3600},
+					},
+					RecipientLimit: 20,
+				},
+				Exec: ExecConfig{
+					Timeout:   30,
+					MaxOutput: 64 * 1024,
+				},
+				Shell: ShellConfig{
+					IdleTimeout: 600,
+				},
+				Services: ServicesConfig{
+					Timeout: 30,
+				},
+				Metrics: MetricsConfig{
+					Interval:   60,
+					RawHours:   24,
+					Resolution: 600,
+					Retention:  30,
+				},
+				Prometheus: PrometheusConfig{
+					Listen: "127.0.0.1:9877",
+				},
+				System: SystemConfig{
+					SampleInterval: 5,
 				},
 			},
 			expectError: false,
@@ -72,8 +114,23 @@ users:
 			},
 			expectedConfig: &Config{
 				Bot: BotConfig{
-					Token: "env_token",
-					Debug: true,
+					Token:           "env_token",
+					Debug:           true,
+					Mode:            "polling",
+					Workers:         8,
+					QueueSize:       100,
+					ShutdownTimeout: 30,
+					DialogTimeout:   300,
+					Language:        "ru",
+					MenuStyle:       "panel",
+					Confirm: ConfirmConfig{
+						Actions: []string{"shutdown_now", "force_shutdown", "force_reboot", "/deleteuser"},
+						Timeout: 60,
+					},
+					Dashboard: DashboardConfig{
+						Interval:   10,
+						MaxPerChat: 1,
+					},
 				},
 				Database: DatabaseConfig{
 					Path: "env.db",
@@ -102,6 +159,33 @@ users:
 					NotifyUsers:     []int64{},
 					WatchEvents:     []string{"login", "logout", "error"},
 					PollingInterval: 30,
+					Throttle: map[string]EventThrottle{
+						"*":       {DedupWindow: 300},
+						"process": {DedupWindow: 300, RateLimit: 10, Digest: 3600},
+					},
+					RecipientLimit: 20,
+				},
+				Exec: ExecConfig{
+					Timeout:   30,
+					MaxOutput: 64 * 1024,
+				},
+				Shell: ShellConfig{
+					IdleTimeout: 600,
+				},
+				Services: ServicesConfig{
+					Timeout: 30,
+				},
+				Metrics: MetricsConfig{
+					Interval:   60,
+					RawHours:   24,
+					Resolution: 600,
+					Retention:  30,
+				},
+				Prometheus: PrometheusConfig{
+					Listen: "127.0.0.1:9877",
+				},
+				System: SystemConfig{
+					SampleInterval: 5,
 				},
 			},
 			expectError: false,
@@ -119,8 +203,23 @@ database:
 			},
 			expectedConfig: &Config{
 				Bot: BotConfig{
-					Token: "env_override_token",
-					Debug: false,
+					Token:           "env_override_token",
+					Debug:           false,
+					Mode:            "polling",
+					Workers:         8,
+					QueueSize:       100,
+					ShutdownTimeout: 30,
+					DialogTimeout:   300,
+					Language:        "ru",
+					MenuStyle:       "panel",
+					Confirm: ConfirmConfig{
+						Actions: []string{"shutdown_now", "force_shutdown", "force_reboot", "/deleteuser"},
+						Timeout: 60,
+					},
+					Dashboard: DashboardConfig{
+						Interval:   10,
+						MaxPerChat: 1,
+					},
 				},
 				Database: DatabaseConfig{
 					Path: "env_override.db",
@@ -149,6 +248,33 @@ database:
 					NotifyUsers:     []int64{},
 					WatchEvents:     []string{"login", "logout", "error"},
 					PollingInterval: 30,
+					Throttle: map[string]EventThrottle{
+						"*":       {DedupWindow: 300},
+						"process": {DedupWindow: 300, RateLimit: 10, Digest: 3600},
+					},
+					RecipientLimit: 20,
+				},
+				Exec: ExecConfig{
+					Timeout:   30,
+					MaxOutput: 64 * 1024,
+				},
+				Shell: ShellConfig{
+					IdleTimeout: 600,
+				},
+				Services: ServicesConfig{
+					Timeout: 30,
+				},
+				Metrics: MetricsConfig{
+					Interval:   60,
+					RawHours:   24,
+					Resolution: 600,
+					Retention:  30,
+				},
+				Prometheus: PrometheusConfig{
+					Listen: "127.0.0.1:9877",
+				},
+				System: SystemConfig{
+					SampleInterval: 5,
 				},
 			},
 			expectError: false,
@@ -158,8 +284,23 @@ database:
 			configContent: "",
 			expectedConfig: &Config{
 				Bot: BotConfig{
-					Token: "",
-					Debug: false,
+					Token:           "",
+					Debug:           false,
+					Mode:            "polling",
+					Workers:         8,
+					QueueSize:       100,
+					ShutdownTimeout: 30,
+					DialogTimeout:   300,
+					Language:        "ru",
+					MenuStyle:       "panel",
+					Confirm: ConfirmConfig{
+						Actions: []string{"shutdown_now", "force_shutdown", "force_reboot", "/deleteuser"},
+						Timeout: 60,
+					},
+					Dashboard: DashboardConfig{
+						Interval:   10,
+						MaxPerChat: 1,
+					},
 				},
 				Database: DatabaseConfig{
 					Path: "cupbot.db",
@@ -188,6 +329,33 @@ database:
 					NotifyUsers:     []int64{},
 					WatchEvents:     []string{"login", "logout", "error"},
 					PollingInterval: 30,
+					Throttle: map[string]EventThrottle{
+						"*":       {DedupWindow: 300},
+						"process": {DedupWindow: 300, RateLimit: 10, Digest: 3600},
+					},
+					RecipientLimit: 20,
+				},
+				Exec: ExecConfig{
+					Timeout:   30,
+					MaxOutput: 64 * 1024,
+				},
+				Shell: ShellConfig{
+					IdleTimeout: 600,
+				},
+				Services: ServicesConfig{
+					Timeout: 30,
+				},
+				Metrics: MetricsConfig{
+					Interval:   60,
+					RawHours:   24,
+					Resolution: 600,
+					Retention:  30,
+				},
+				Prometheus: PrometheusConfig{
+					Listen: "127.0.0.1:9877",
+				},
+				System: SystemConfig{
+					SampleInterval: 5,
 				},
 			},
 			expectError: false,
@@ -451,3 +619,614 @@ invalid_yaml_syntax: [unclosed_bracket`
 		t.Error("Expected error for invalid YAML, but got none")
 	}
 }
+
+func TestLoadWebhookMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name: "Defaults applied",
+			content: `bot:
+  mode: webhook
+  webhook:
+    url: "https://bot.example.com"`,
+		},
+		{
+			name: "Missing URL",
+			content: `bot:
+  mode: webhook`,
+			expectError: true,
+		},
+		{
+			name: "Unknown mode",
+			content: `bot:
+  mode: push`,
+			expectError: true,
+		},
+		{
+			name: "Certificate without key",
+			content: `bot:
+  mode: webhook
+  webhook:
+    url: "https://bot.example.com"
+    cert_file: "cert.pem"`,
+			expectError: true,
+		},
+		{
+			name: "Path without leading slash",
+			content: `bot:
+  mode: webhook
+  webhook:
+    url: "https://bot.example.com"
+    path: "hook"`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if config.Bot.Webhook.Listen != ":8443" {
+				t.Errorf("Expected default listen address :8443, got %q", config.Bot.Webhook.Listen)
+			}
+			if config.Bot.Webhook.Path != "/webhook" {
+				t.Errorf("Expected default path /webhook, got %q", config.Bot.Webhook.Path)
+			}
+		})
+	}
+}
+
+func TestLoadConfirmActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "Defaults applied",
+			content:  "bot:\n  token: \"test_token\"",
+			expected: []string{"shutdown_now", "force_shutdown", "force_reboot", "/deleteuser"},
+		},
+		{
+			name:     "Explicit list",
+			content:  "bot:\n  confirm:\n    actions: [reboot_now]",
+			expected: []string{"reboot_now"},
+		},
+		{
+			name:     "Disabled",
+			content:  "bot:\n  confirm:\n    actions: []",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(config.Bot.Confirm.Actions, tt.expected) {
+				t.Errorf("Expected actions %v, got %v", tt.expected, config.Bot.Confirm.Actions)
+			}
+			for _, action := range tt.expected {
+				if !config.RequiresConfirmation(action) {
+					t.Errorf("Expected %s to require confirmation", action)
+				}
+			}
+			if config.RequiresConfirmation("status") {
+				t.Error("status should not require confirmation")
+			}
+		})
+	}
+}
+
+func TestLoadLanguage(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Default",
+			content:  "bot:\n  token: \"test_token\"",
+			expected: "ru",
+		},
+		{
+			name:     "English",
+			content:  "bot:\n  language: en",
+			expected: "en",
+		},
+		{
+			name:        "Unsupported",
+			content:     "bot:\n  language: de",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if config.Bot.Language != tt.expected {
+				t.Errorf("Expected language %q, got %q", tt.expected, config.Bot.Language)
+			}
+		})
+	}
+}
+
+func TestLoadDashboardInterval(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString("bot:\n  dashboard:\n    interval: 1"); err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+
+	if _, err := Load(tmpFile.Name()); err == nil {
+		t.Error("Expected an error for a dashboard interval under the minimum")
+	}
+}
+
+func TestLoadExecCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name:    "Valid",
+			content: "exec:\n  commands:\n    - name: ping\n      allow_args: ['^[\\w.-]+$']\n      timeout: 10",
+		},
+		{
+			name:        "Invalid pattern",
+			content:     "exec:\n  commands:\n    - name: ping\n      allow_args: ['[']",
+			expectError: true,
+		},
+		{
+			name:        "Duplicate name",
+			content:     "exec:\n  commands:\n    - name: ping\n    - name: ping",
+			expectError: true,
+		},
+		{
+			name:        "Name with spaces",
+			content:     "exec:\n  commands:\n    - name: systemctl status",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(config.Exec.Commands) != 1 || config.Exec.Commands[0].Timeout != 10 {
+				t.Errorf("Unexpected commands: %+v", config.Exec.Commands)
+			}
+		})
+	}
+}
+
+func TestLoadServices(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name:    "Valid",
+			content: "services:\n  allowed:\n    - name: nginx\n      actions: [start, stop, restart]\n    - name: 'docker*'",
+		},
+		{
+			name:        "Invalid pattern",
+			content:     "services:\n  allowed:\n    - name: '['",
+			expectError: true,
+		},
+		{
+			name:        "Empty name",
+			content:     "services:\n  allowed:\n    - actions: [start]",
+			expectError: true,
+		},
+		{
+			name:        "Unknown action",
+			content:     "services:\n  allowed:\n    - name: nginx\n      actions: [delete]",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !config.IsServiceVisible("NGINX") || !config.IsServiceVisible("docker.socket") || config.IsServiceVisible("sshd") {
+				t.Error("Services should match the rules ignoring case")
+			}
+			if !config.IsServiceActionAllowed("nginx", "restart") || config.IsServiceActionAllowed("nginx", "disable") {
+				t.Error("Only the listed actions should be allowed")
+			}
+			if config.IsServiceActionAllowed("docker", "start") {
+				t.Error("A rule without actions only allows status")
+			}
+		})
+	}
+}
+
+func TestLoadAlerts(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name: "Valid",
+			content: "alerts:\n  enabled: true\n  notify_users: [1]\n  rules:\n" +
+				"    - {name: disk-c, metric: disk_free, target: 'C:', operator: '<', threshold: 10, clear: 15, duration: 300, notify_users: [2, 3]}\n" +
+				"    - {name: memory, metric: memory, operator: '>', threshold: 90}",
+		},
+		{
+			name:        "Duplicate name",
+			content:     "alerts:\n  rules:\n    - {name: a, metric: cpu, operator: '>', threshold: 90}\n    - {name: a, metric: memory, operator: '>', threshold: 90}",
+			expectError: true,
+		},
+		{
+			name:        "Unknown metric",
+			content:     "alerts:\n  rules:\n    - {name: a, metric: swap, operator: '>', threshold: 90}",
+			expectError: true,
+		},
+		{
+			name:        "Disk without target",
+			content:     "alerts:\n  rules:\n    - {name: a, metric: disk_used, operator: '>', threshold: 90}",
+			expectError: true,
+		},
+		{
+			name:        "Clear past the threshold",
+			content:     "alerts:\n  rules:\n    - {name: a, metric: cpu, operator: '>', threshold: 90, clear: 95}",
+			expectError: true,
+		},
+		{
+			name:        "Unknown operator",
+			content:     "alerts:\n  rules:\n    - {name: a, metric: cpu, operator: '>=', threshold: 90}",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			disk, memory := &config.Alerts.Rules[0], &config.Alerts.Rules[1]
+			if disk.ClearLevel() != 15 || memory.ClearLevel() != 90 {
+				t.Errorf("Expected clear levels 15 and 90, got %v and %v", disk.ClearLevel(), memory.ClearLevel())
+			}
+			if got := config.AlertRecipients(disk); len(got) != 2 || got[0] != 2 {
+				t.Errorf("Expected the recipients of the rule, got %v", got)
+			}
+			if got := config.AlertRecipients(memory); len(got) != 1 || got[0] != 1 {
+				t.Errorf("Expected alerts.notify_users, got %v", got)
+			}
+		})
+	}
+}
+
+func TestLoadPrometheus(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{name: "Valid", content: "prometheus:\n  enabled: true\n  listen: ':9100'\n  username: prom\n  password: secret"},
+		{name: "Without auth", content: "prometheus:\n  enabled: true\n  listen: '0.0.0.0:9100'"},
+		{name: "Invalid listen", content: "prometheus:\n  listen: '9100'", expectError: true},
+		{name: "Username without password", content: "prometheus:\n  username: prom", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			_, err = Load(tmpFile.Name())
+			if tt.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadMenuStyle(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Default",
+			content:  "bot:\n  token: \"test_token\"",
+			expected: MenuStylePanel,
+		},
+		{
+			name:     "Log",
+			content:  "bot:\n  menu_style: log",
+			expected: MenuStyleLog,
+		},
+		{
+			name:        "Unknown",
+			content:     "bot:\n  menu_style: popup",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if config.Bot.MenuStyle != tt.expected {
+				t.Errorf("Expected menu style %q, got %q", tt.expected, config.Bot.MenuStyle)
+			}
+		})
+	}
+}
+
+func TestLoadEventThrottle(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{name: "Valid", content: "events:\n  throttle:\n    '*':\n      dedup_window: 60\n    process:\n      rate_limit: 5\n      digest: 3600\n      digest_severity: warning"},
+		{name: "Negative limit", content: "events:\n  throttle:\n    login:\n      rate_limit: -1", expectError: true},
+		{name: "Critical digest", content: "events:\n  throttle:\n    error:\n      digest: 600\n      digest_severity: critical", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := config.EventThrottle("process"); got.RateLimit != 5 || got.DedupWindow != 0 {
+				t.Errorf("Expected the process throttle as configured, got %+v", got)
+			}
+			if got := config.EventThrottle("login"); got.DedupWindow != 60 {
+				t.Errorf("Expected login throttled by the * entry, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestLoadEventSubscriptionDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{name: "Valid", content: "events:\n  defaults:\n    types: [service, error]\n    min_severity: warning\n    quiet_hours: 23:00-07:00\n    timezone: Europe/Berlin"},
+		{name: "Unknown severity", content: "events:\n  defaults:\n    min_severity: urgent", expectError: true},
+		{name: "Bad quiet hours", content: "events:\n  defaults:\n    quiet_hours: 23:00", expectError: true},
+		{name: "Unknown zone", content: "events:\n  defaults:\n    timezone: Mars/Olympus", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config_test_*.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpFile.Name())
+
+			if _, err := tmpFile.WriteString(tt.content); err != nil {
+				t.Fatal(err)
+			}
+			tmpFile.Close()
+
+			config, err := Load(tmpFile.Name())
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			defaults := config.Events.Defaults
+			if !reflect.DeepEqual(defaults.Types, []string{"service", "error"}) || defaults.MinSeverity != "warning" || defaults.QuietHours != "23:00-07:00" {
+				t.Errorf("Unexpected defaults %+v", defaults)
+			}
+		})
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		input      string
+		start, end int
+		expectErr  bool
+	}{
+		{input: "23:00-07:00", start: 23 * 60, end: 7 * 60},
+		{input: "12:30 - 14:15", start: 12*60 + 30, end: 14*60 + 15},
+		{input: "7:00-8:00", start: 7 * 60, end: 8 * 60},
+		{input: "22:00", expectErr: true},
+		{input: "25:00-07:00", expectErr: true},
+		{input: "08:00-08:00", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		start, end, err := ParseQuietHours(tt.input)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("ParseQuietHours(%q): expected an error", tt.input)
+			}
+			continue
+		}
+		if err != nil || start != tt.start || end != tt.end {
+			t.Errorf("ParseQuietHours(%q) = %d, %d, %v; expected %d, %d", tt.input, start, end, err, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package conversation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+)
+
+// DefaultTimeout is how long a dialog waits for the next answer
+const DefaultTimeout = 5 * time.Minute
+
+var (
+	// ErrNotFound is returned when the chat has no pending dialog
+	ErrNotFound = errors.New("no pending dialog")
+	// ErrExpired is returned, together with the state, for dialogs past their timeout
+	ErrExpired = errors.New("dialog expired")
+)
+
+// State is the pending dialog of a chat
+type State struct {
+	ChatID    int64
+	UserID    int64 // the user the dialog waits for
+	Dialog    string
+	Step      int
+	Values    map[string]string // answers to the previous steps by step name
+	ExpiresAt time.Time
+}
+
+// Store keeps the pending dialog of every chat in the database, so a
+// prompt can be answered after the bot restarts
+type Store struct {
+	db      *database.DB
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewStore creates a dialog store backed by the database
+func NewStore(db *database.DB, timeout time.Duration) *Store {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Store{
+		db:      db,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// Start begins a dialog at its first step, replacing any dialog pending in the chat
+func (s *Store) Start(chatID, userID int64, dialog string) (*State, error) {
+	state := &State{
+		ChatID: chatID,
+		UserID: userID,
+		Dialog: dialog,
+		Values: make(map[string]string),
+	}
+	if err := s.Save(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Get returns the pending dialog of a chat
+func (s *Store) Get(chatID int64) (*State, error) {
+	record, err := s.db.GetConversation(chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dialog: %w", err)
+	}
+
+	state := &State{
+		ChatID:    record.ChatID,
+		UserID:    record.UserID,
+		Dialog:    record.Dialog,
+		Step:      record.Step,
+		Values:    make(map[string]string),
+		ExpiresAt: record.ExpiresAt,
+	}
+	if err := json.Unmarshal([]byte(record.Data), &state.Values); err != nil {
+		return nil, fmt.Errorf("failed to decode dialog data: %w", err)
+	}
+
+	if s.now().After(state.ExpiresAt) {
+		return state, ErrExpired
+	}
+	return state, nil
+}
+
+// Save stores the state and gives the user a full timeout for the next answer
+func (s *Store) Save(state *State) error {
+	data, err := json.Marshal(state.Values)
+	if err != nil {
+		return fmt.Errorf("failed to encode dialog data: %w", err)
+	}
+
+	state.ExpiresAt = s.now().Add(s.timeout).UTC()
+	record := &database.Conversation{
+		ChatID:    state.ChatID,
+		UserID:    state.UserID,
+		Dialog:    state.Dialog,
+		Step:      state.Step,
+		Data:      string(data),
+		ExpiresAt: state.ExpiresAt,
+	}
+	if err := s.db.SaveConversation(record); err != nil {
+		return fmt.Errorf("failed to save dialog: %w", err)
+	}
+	return nil
+}
+
+// Delete ends the dialog of a chat
+func (s *Store) Delete(chatID int64) error {
+	return s.db.DeleteConversation(chatID)
+}
+
+// Cleanup removes expired dialogs
+func (s *Store) Cleanup() error {
+	return s.db.DeleteExpiredConversations(s.now().UTC())
+}
@@ -0,0 +1,119 @@
+package conversation
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/database"
+)
+
+func setupTestStore(t *testing.T) (*Store, *database.DB) {
+	tmpFile, err := os.CreateTemp("", "conversation_test_*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	t.Cleanup(func() { os.Remove(tmpFile.Name()) })
+
+	db, err := database.New(tmpFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewStore(db, time.Hour), db
+}
+
+func TestStartGetSave(t *testing.T) {
+	store, _ := setupTestStore(t)
+
+	if _, err := store.Get(100); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	state, err := store.Start(100, 1, "banuser")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	state.Step = 1
+	state.Values["user_id"] = "42"
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := store.Get(100)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if loaded.UserID != 1 || loaded.Dialog != "banuser" || loaded.Step != 1 || loaded.Values["user_id"] != "42" {
+		t.Errorf("Unexpected state: %+v", loaded)
+	}
+
+	// Starting another dialog replaces the pending one
+	if _, err := store.Start(100, 2, "unbanuser"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	loaded, err = store.Get(100)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if loaded.UserID != 2 || loaded.Dialog != "unbanuser" || loaded.Step != 0 || len(loaded.Values) != 0 {
+		t.Errorf("Expected a fresh dialog, got %+v", loaded)
+	}
+
+	if err := store.Delete(100); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after Delete, got %v", err)
+	}
+}
+
+func TestStateSurvivesReopen(t *testing.T) {
+	store, db := setupTestStore(t)
+
+	state, err := store.Start(100, 1, "deleteuser")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	state.Values["user_id"] = "42"
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A new store over the same database sees the dialog
+	loaded, err := NewStore(db, time.Hour).Get(100)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if loaded.Dialog != "deleteuser" || loaded.Values["user_id"] != "42" {
+		t.Errorf("Unexpected state: %+v", loaded)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	store, _ := setupTestStore(t)
+
+	if _, err := store.Start(100, 1, "banuser"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	store.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	state, err := store.Get(100)
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("Expected ErrExpired, got %v", err)
+	}
+	if state == nil || state.UserID != 1 {
+		t.Errorf("Expected the expired state to be returned, got %+v", state)
+	}
+
+	if err := store.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+	if _, err := store.Get(100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after cleanup, got %v", err)
+	}
+}
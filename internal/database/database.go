@@ -1,8 +1,10 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -10,14 +12,17 @@ import (
 
 // User представляет пользователя бота
 type User struct {
-	ID        int64     `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	FirstName string    `json:"first_name" db:"first_name"`
-	LastName  string    `json:"last_name" db:"last_name"`
-	IsAdmin   bool      `json:"is_admin" db:"is_admin"`
-	IsActive  bool      `json:"is_active" db:"is_active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID           int64     `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	FirstName    string    `json:"first_name" db:"first_name"`
+	LastName     string    `json:"last_name" db:"last_name"`
+	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
+	IsActive     bool      `json:"is_active" db:"is_active"`
+	LanguageCode string    `json:"language_code" db:"language_code"` // language of the Telegram client
+	Language     string    `json:"language" db:"language"`           // picked with /language, empty follows LanguageCode
+	MenuStyle    string    `json:"menu_style" db:"menu_style"`       // picked with /style, empty follows bot.menu_style
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // CommandHistory представляет историю выполненных команд
@@ -29,6 +34,9 @@ type CommandHistory struct {
 	Success    bool      `json:"success" db:"success"`
 	Response   string    `json:"response" db:"response"`
 	ExecutedAt time.Time `json:"executed_at" db:"executed_at"`
+	// Set for host commands run with /exec
+	ExitCode *int          `json:"exit_code,omitempty" db:"exit_code"`
+	Duration time.Duration `json:"duration,omitempty" db:"duration_ms"`
 }
 
 // UserSession представляет активную сессию пользователя
@@ -39,6 +47,153 @@ type UserSession struct {
 	IsActive bool      `json:"is_active" db:"is_active"`
 }
 
+// CallbackToken хранит данные inline-кнопки, на которую ссылается короткий токен
+type CallbackToken struct {
+	Token     string    `json:"token" db:"token"`
+	Action    string    `json:"action" db:"action"`
+	Path      string    `json:"path" db:"path"`
+	Page      int       `json:"page" db:"page"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Conversation хранит текущий шаг пошагового диалога в чате
+type Conversation struct {
+	ChatID    int64     `json:"chat_id" db:"chat_id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Dialog    string    `json:"dialog" db:"dialog"`
+	Step      int       `json:"step" db:"step"`
+	Data      string    `json:"data" db:"data"` // values collected so far, JSON
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Streams of a shell transcript
+const (
+	ShellInput  = "in"
+	ShellOutput = "out"
+)
+
+// ShellSession is a /shell session, kept with its transcript for audit
+type ShellSession struct {
+	ID        int64      `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	ChatID    int64      `json:"chat_id" db:"chat_id"`
+	Command   string     `json:"command" db:"command"` // the shell
+	StartedAt time.Time  `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	ExitCode  *int       `json:"exit_code,omitempty" db:"exit_code"`
+	EndReason string     `json:"end_reason" db:"end_reason"` // exit, kill, idle, shutdown or restart
+}
+
+// ShellTranscriptEntry is input typed into a shell session or output it
+// printed
+type ShellTranscriptEntry struct {
+	SessionID int64     `json:"session_id" db:"session_id"`
+	Stream    string    `json:"stream" db:"stream"` // ShellInput or ShellOutput
+	Data      string    `json:"data" db:"data"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Event is a recorded system event
+type Event struct {
+	ID             int64      `json:"id" db:"id"`
+	Type           string     `json:"type" db:"type"`
+	Severity       string     `json:"severity" db:"severity"`
+	Source         string     `json:"source" db:"source"`
+	Message        string     `json:"message" db:"message"`
+	Details        string     `json:"details" db:"details"`
+	OccurredAt     time.Time  `json:"occurred_at" db:"occurred_at"`
+	AcknowledgedBy int64      `json:"acknowledged_by,omitempty" db:"acknowledged_by"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+}
+
+// EventSubscription is what events a user is notified about. Users without
+// one are subscribed as the configuration defaults say.
+type EventSubscription struct {
+	UserID      int64     `json:"user_id" db:"user_id"`
+	Enabled     bool      `json:"enabled" db:"enabled"`
+	Types       []string  `json:"types" db:"event_types"`
+	MinSeverity string    `json:"min_severity" db:"min_severity"`
+	QuietHours  string    `json:"quiet_hours" db:"quiet_hours"` // e.g. 23:00-07:00, empty without quiet hours
+	Timezone    string    `json:"timezone" db:"timezone"`       // IANA name, empty for the host's
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HeldEvent is an event notification held over the quiet hours of a user
+type HeldEvent struct {
+	ID      int64     `json:"id" db:"id"`
+	UserID  int64     `json:"user_id" db:"user_id"`
+	EventID int64     `json:"event_id" db:"event_id"` // the recorded event, 0 if it wasn't recorded
+	Event   string    `json:"event" db:"event"`       // the event as JSON
+	HeldAt  time.Time `json:"held_at" db:"held_at"`
+}
+
+// EventFilter selects recorded events; empty fields match everything
+type EventFilter struct {
+	Types      []string
+	Severities []string
+	Since      time.Time
+	Until      time.Time
+}
+
+// where returns the WHERE clause of the filter and its arguments
+func (f EventFilter) where() (string, []any) {
+	var conditions []string
+	var args []any
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		conditions = append(conditions, column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")")
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	in("type", f.Types)
+	in("severity", f.Severities)
+	if !f.Since.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, f.Until)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// AlertState is the state of an alert rule, kept so a restart doesn't fire
+// an alert again
+type AlertState struct {
+	Rule      string    `json:"rule" db:"rule"`
+	State     string    `json:"state" db:"state"` // ok, pending or firing
+	Value     float64   `json:"value" db:"value"` // the last value of the metric
+	Since     time.Time `json:"since" db:"since"` // when the metric crossed the threshold
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MetricSample is a value of a host metric. Old samples are averaged into
+// rows covering Resolution seconds.
+type MetricSample struct {
+	Metric     string    `json:"metric" db:"metric"`
+	Target     string    `json:"target" db:"target"` // disk or network interface, empty for host-wide metrics
+	Value      float64   `json:"value" db:"value"`
+	Resolution int       `json:"resolution" db:"resolution"` // 0 for a raw sample
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// CommandCount is how many times a command succeeded or failed
+type CommandCount struct {
+	Command string `json:"command" db:"command"`
+	Success bool   `json:"success" db:"success"`
+	Count   int64  `json:"count" db:"count"`
+}
+
 // DB представляет подключение к базе данных
 type DB struct {
 	conn *sql.DB
@@ -75,6 +230,9 @@ func (db *DB) migrate() error {
 			last_name TEXT,
 			is_admin BOOLEAN DEFAULT FALSE,
 			is_active BOOLEAN DEFAULT TRUE,
+			language_code TEXT NOT NULL DEFAULT '',
+			language TEXT NOT NULL DEFAULT '',
+			menu_style TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
@@ -86,6 +244,8 @@ func (db *DB) migrate() error {
 			success BOOLEAN NOT NULL,
 			response TEXT,
 			executed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			exit_code INTEGER,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
 			FOREIGN KEY (user_id) REFERENCES users (id)
 		)`,
 		`CREATE TABLE IF NOT EXISTS user_sessions (
@@ -95,9 +255,101 @@ func (db *DB) migrate() error {
 			is_active BOOLEAN DEFAULT TRUE,
 			FOREIGN KEY (user_id) REFERENCES users (id)
 		)`,
+		`CREATE TABLE IF NOT EXISTS callback_tokens (
+			token TEXT PRIMARY KEY,
+			action TEXT NOT NULL,
+			path TEXT NOT NULL DEFAULT '',
+			page INTEGER NOT NULL DEFAULT 0,
+			user_id INTEGER NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS conversations (
+			chat_id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			dialog TEXT NOT NULL,
+			step INTEGER NOT NULL DEFAULT 0,
+			data TEXT NOT NULL DEFAULT '{}',
+			expires_at DATETIME NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS shell_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			chat_id INTEGER NOT NULL,
+			command TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME,
+			exit_code INTEGER,
+			end_reason TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS shell_transcript (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id INTEGER NOT NULL,
+			stream TEXT NOT NULL,
+			data TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (session_id) REFERENCES shell_sessions (id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS alert_states (
+			rule TEXT PRIMARY KEY,
+			state TEXT NOT NULL,
+			value REAL NOT NULL DEFAULT 0,
+			since DATETIME NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			metric TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			value REAL NOT NULL,
+			resolution INTEGER NOT NULL DEFAULT 0,
+			recorded_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS event_mutes (
+			user_id INTEGER NOT NULL,
+			event_type TEXT NOT NULL,
+			muted_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, event_type)
+		)`,
+		`CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			severity TEXT NOT NULL,
+			source TEXT NOT NULL DEFAULT '',
+			message TEXT NOT NULL,
+			details TEXT NOT NULL DEFAULT '',
+			occurred_at DATETIME NOT NULL,
+			acknowledged_by INTEGER NOT NULL DEFAULT 0,
+			acknowledged_at DATETIME
+		)`,
 		`CREATE INDEX IF NOT EXISTS idx_command_history_user_id ON command_history (user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_command_history_executed_at ON command_history (executed_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_callback_tokens_payload ON callback_tokens (user_id, action, path, page)`,
+		`CREATE INDEX IF NOT EXISTS idx_callback_tokens_expires_at ON callback_tokens (expires_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_conversations_expires_at ON conversations (expires_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_shell_sessions_user_id ON shell_sessions (user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_metrics_metric_recorded_at ON metrics (metric, recorded_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_shell_transcript_session_id ON shell_transcript (session_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_events_occurred_at ON events (occurred_at)`,
+		`CREATE TABLE IF NOT EXISTS event_subscriptions (
+			user_id INTEGER PRIMARY KEY,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			event_types TEXT NOT NULL DEFAULT '',
+			min_severity TEXT NOT NULL DEFAULT '',
+			quiet_hours TEXT NOT NULL DEFAULT '',
+			timezone TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS held_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			event_id INTEGER NOT NULL DEFAULT 0,
+			event TEXT NOT NULL,
+			held_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_held_events_user_id ON held_events (user_id)`,
 	}
 
 	for _, query := range queries {
@@ -106,18 +358,64 @@ func (db *DB) migrate() error {
 		}
 	}
 
+	// Columns added after the table was first released
+	columns := []struct {
+		table, name, definition string
+	}{
+		{"users", "language_code", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "language", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "menu_style", "TEXT NOT NULL DEFAULT ''"},
+		{"command_history", "exit_code", "INTEGER"},
+		{"command_history", "duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		if err := db.addColumn(column.table, column.name, column.definition); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", column.table, column.name, err)
+		}
+	}
+
 	return nil
 }
 
+// addColumn adds a column to a table created by an older version
+func (db *DB) addColumn(table, name, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			column, kind     string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &column, &kind, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if column == name {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition))
+	return err
+}
+
 // CreateOrUpdateUser создает или обновляет пользователя
 func (db *DB) CreateOrUpdateUser(user *User) error {
 	query := `
-		INSERT OR REPLACE INTO users (id, username, first_name, last_name, is_admin, is_active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, COALESCE((SELECT created_at FROM users WHERE id = ?), CURRENT_TIMESTAMP), CURRENT_TIMESTAMP)
+		INSERT OR REPLACE INTO users (id, username, first_name, last_name, is_admin, is_active, language_code, language, menu_style, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE((SELECT created_at FROM users WHERE id = ?), CURRENT_TIMESTAMP), CURRENT_TIMESTAMP)
 	`
 
 	_, err := db.conn.Exec(query, user.ID, user.Username, user.FirstName, user.LastName,
-		user.IsAdmin, user.IsActive, user.ID)
+		user.IsAdmin, user.IsActive, user.LanguageCode, user.Language, user.MenuStyle, user.ID)
 
 	return err
 }
@@ -125,14 +423,14 @@ func (db *DB) CreateOrUpdateUser(user *User) error {
 // GetUser получает пользователя по ID
 func (db *DB) GetUser(userID int64) (*User, error) {
 	query := `
-		SELECT id, username, first_name, last_name, is_admin, is_active, created_at, updated_at
+		SELECT id, username, first_name, last_name, is_admin, is_active, language_code, language, menu_style, created_at, updated_at
 		FROM users WHERE id = ?
 	`
 
 	user := &User{}
 	err := db.conn.QueryRow(query, userID).Scan(
 		&user.ID, &user.Username, &user.FirstName, &user.LastName,
-		&user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsAdmin, &user.IsActive, &user.LanguageCode, &user.Language, &user.MenuStyle, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
@@ -145,7 +443,7 @@ func (db *DB) GetUser(userID int64) (*User, error) {
 // GetAllUsers получает всех пользователей
 func (db *DB) GetAllUsers() ([]*User, error) {
 	query := `
-		SELECT id, username, first_name, last_name, is_admin, is_active, created_at, updated_at
+		SELECT id, username, first_name, last_name, is_admin, is_active, language_code, language, menu_style, created_at, updated_at
 		FROM users ORDER BY created_at DESC
 	`
 
@@ -160,7 +458,7 @@ func (db *DB) GetAllUsers() ([]*User, error) {
 		user := &User{}
 		err := rows.Scan(
 			&user.ID, &user.Username, &user.FirstName, &user.LastName,
-			&user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+			&user.IsAdmin, &user.IsActive, &user.LanguageCode, &user.Language, &user.MenuStyle, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -174,12 +472,12 @@ func (db *DB) GetAllUsers() ([]*User, error) {
 // AddCommandHistory добавляет запись в историю команд
 func (db *DB) AddCommandHistory(history *CommandHistory) error {
 	query := `
-		INSERT INTO command_history (user_id, command, arguments, success, response, executed_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO command_history (user_id, command, arguments, success, response, executed_at, exit_code, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := db.conn.Exec(query, history.UserID, history.Command, history.Arguments,
-		history.Success, history.Response, history.ExecutedAt)
+		history.Success, history.Response, history.ExecutedAt, history.ExitCode, history.Duration.Milliseconds())
 
 	if err != nil {
 		return err
@@ -197,7 +495,7 @@ func (db *DB) AddCommandHistory(history *CommandHistory) error {
 // GetCommandHistory получает историю команд пользователя
 func (db *DB) GetCommandHistory(userID int64, limit int) ([]*CommandHistory, error) {
 	query := `
-		SELECT id, user_id, command, arguments, success, response, executed_at
+		SELECT id, user_id, command, arguments, success, response, executed_at, exit_code, duration_ms
 		FROM command_history 
 		WHERE user_id = ? 
 		ORDER BY executed_at DESC 
@@ -213,13 +511,20 @@ func (db *DB) GetCommandHistory(userID int64, limit int) ([]*CommandHistory, err
 	var history []*CommandHistory
 	for rows.Next() {
 		cmd := &CommandHistory{}
+		var exitCode sql.NullInt64
+		var durationMs int64
 		err := rows.Scan(
 			&cmd.ID, &cmd.UserID, &cmd.Command, &cmd.Arguments,
-			&cmd.Success, &cmd.Response, &cmd.ExecutedAt,
+			&cmd.Success, &cmd.Response, &cmd.ExecutedAt, &exitCode, &durationMs,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			cmd.ExitCode = &code
+		}
+		cmd.Duration = time.Duration(durationMs) * time.Millisecond
 		history = append(history, cmd)
 	}
 
@@ -229,7 +534,7 @@ func (db *DB) GetCommandHistory(userID int64, limit int) ([]*CommandHistory, err
 // GetAllCommandHistory получает всю историю команд (для админов)
 func (db *DB) GetAllCommandHistory(limit int) ([]*CommandHistory, error) {
 	query := `
-		SELECT ch.id, ch.user_id, ch.command, ch.arguments, ch.success, ch.response, ch.executed_at
+		SELECT ch.id, ch.user_id, ch.command, ch.arguments, ch.success, ch.response, ch.executed_at, ch.exit_code, ch.duration_ms
 		FROM command_history ch
 		ORDER BY ch.executed_at DESC 
 		LIMIT ?
@@ -244,13 +549,20 @@ func (db *DB) GetAllCommandHistory(limit int) ([]*CommandHistory, error) {
 	var history []*CommandHistory
 	for rows.Next() {
 		cmd := &CommandHistory{}
+		var exitCode sql.NullInt64
+		var durationMs int64
 		err := rows.Scan(
 			&cmd.ID, &cmd.UserID, &cmd.Command, &cmd.Arguments,
-			&cmd.Success, &cmd.Response, &cmd.ExecutedAt,
+			&cmd.Success, &cmd.Response, &cmd.ExecutedAt, &exitCode, &durationMs,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			cmd.ExitCode = &code
+		}
+		cmd.Duration = time.Duration(durationMs) * time.Millisecond
 		history = append(history, cmd)
 	}
 
@@ -317,15 +629,40 @@ func (db *DB) GetActiveUsers(minutes int) ([]*UserSession, error) {
 	return sessions, nil
 }
 
-// CleanOldHistory удаляет старую историю команд (старше N дней)
+// CleanOldHistory удаляет старую историю команд (старше N дней),
+// завершенные до этого shell-сессии с их записью и старые события
 func (db *DB) CleanOldHistory(days int) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		DELETE FROM command_history 
 		WHERE executed_at < datetime('now', '-' || ? || ' days')
 	`
+	if _, err := tx.Exec(query, days); err != nil {
+		return err
+	}
 
-	_, err := db.conn.Exec(query, days)
-	return err
+	cutoff := time.Now().AddDate(0, 0, -days)
+	query = `
+		DELETE FROM shell_transcript WHERE session_id IN (
+			SELECT id FROM shell_sessions WHERE ended_at < ?
+		)
+	`
+	if _, err := tx.Exec(query, cutoff); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM shell_sessions WHERE ended_at < ?`, cutoff); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM events WHERE occurred_at < ?`, cutoff); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetStats получает статистику использования
@@ -378,6 +715,54 @@ func (db *DB) GetStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// GetCommandCounts counts command_history by command and outcome. Button
+// presses are counted together as "callback" and commands other than the
+// given ones as "unknown", so the names don't grow with what users type.
+func (db *DB) GetCommandCounts(commands []string) ([]*CommandCount, error) {
+	known := "0"
+	args := make([]any, 0, len(commands))
+	if len(commands) > 0 {
+		known = "command IN (?" + strings.Repeat(", ?", len(commands)-1) + ")"
+		for _, c := range commands {
+			args = append(args, c)
+		}
+	}
+	query := `
+		SELECT CASE
+				WHEN command LIKE 'callback:%' THEN 'callback'
+				WHEN ` + known + ` THEN command
+				ELSE 'unknown'
+			END AS name,
+			success, COUNT(*)
+		FROM command_history
+		GROUP BY name, success
+		ORDER BY name, success
+	`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*CommandCount
+	for rows.Next() {
+		c := &CommandCount{}
+		if err := rows.Scan(&c.Command, &c.Success, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// Ping checks that the database answers queries
+func (db *DB) Ping(ctx context.Context) error {
+	var one int
+	return db.conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
 // SetUserAdmin sets or removes admin privileges for a user
 func (db *DB) SetUserAdmin(userID int64, isAdmin bool) error {
 	query := `UPDATE users SET is_admin = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
@@ -412,6 +797,22 @@ func (db *DB) DeleteUser(userID int64) error {
 		return err
 	}
 
+	// Delete muted event types
+	_, err = tx.Exec(`DELETE FROM event_mutes WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+
+	// Delete the event subscription and held events
+	_, err = tx.Exec(`DELETE FROM event_subscriptions WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM held_events WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+
 	// Delete user
 	_, err = tx.Exec(`DELETE FROM users WHERE id = ?`, userID)
 	if err != nil {
@@ -424,7 +825,7 @@ func (db *DB) DeleteUser(userID int64) error {
 // GetUsersByStatus gets users by their active status
 func (db *DB) GetUsersByStatus(isActive bool) ([]*User, error) {
 	query := `
-		SELECT id, username, first_name, last_name, is_admin, is_active, created_at, updated_at
+		SELECT id, username, first_name, last_name, is_admin, is_active, language_code, language, menu_style, created_at, updated_at
 		FROM users WHERE is_active = ? ORDER BY created_at DESC
 	`
 
@@ -439,7 +840,7 @@ func (db *DB) GetUsersByStatus(isActive bool) ([]*User, error) {
 		user := &User{}
 		err := rows.Scan(
 			&user.ID, &user.Username, &user.FirstName, &user.LastName,
-			&user.IsAdmin, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+			&user.IsAdmin, &user.IsActive, &user.LanguageCode, &user.Language, &user.MenuStyle, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -449,3 +850,653 @@ func (db *DB) GetUsersByStatus(isActive bool) ([]*User, error) {
 
 	return users, nil
 }
+
+// EnsureUser creates an empty active user row if none exists yet, leaving
+// existing rows (and their roles) untouched
+func (db *DB) EnsureUser(userID int64, isAdmin bool) error {
+	query := `INSERT OR IGNORE INTO users (id, username, first_name, last_name, is_admin, is_active)
+		VALUES (?, '', '', '', ?, TRUE)`
+	_, err := db.conn.Exec(query, userID, isAdmin)
+	return err
+}
+
+// UpdateUserProfile refreshes the Telegram profile fields of a user without
+// touching the stored admin/active flags
+func (db *DB) UpdateUserProfile(user *User) error {
+	query := `
+		UPDATE users SET username = ?, first_name = ?, last_name = ?, language_code = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	_, err := db.conn.Exec(query, user.Username, user.FirstName, user.LastName, user.LanguageCode, user.ID)
+	return err
+}
+
+// SetUserLanguage stores the language picked by a user; an empty language
+// goes back to the language of the Telegram client
+func (db *DB) SetUserLanguage(userID int64, language string) error {
+	query := `UPDATE users SET language = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := db.conn.Exec(query, language, userID)
+	return err
+}
+
+// SetUserMenuStyle stores the menu style picked by a user; an empty style
+// goes back to bot.menu_style
+func (db *DB) SetUserMenuStyle(userID int64, style string) error {
+	query := `UPDATE users SET menu_style = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := db.conn.Exec(query, style, userID)
+	return err
+}
+
+// SaveCallbackToken stores a callback token. If the same user already has a
+// token for the same payload, that token is kept, its expiry is extended and
+// token.Token is updated to it.
+func (db *DB) SaveCallbackToken(token *CallbackToken) error {
+	query := `
+		INSERT INTO callback_tokens (token, action, path, page, user_id, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, action, path, page) DO UPDATE SET expires_at = excluded.expires_at
+	`
+
+	_, err := db.conn.Exec(query, token.Token, token.Action, token.Path, token.Page,
+		token.UserID, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return db.conn.QueryRow(
+		`SELECT token FROM callback_tokens WHERE user_id = ? AND action = ? AND path = ? AND page = ?`,
+		token.UserID, token.Action, token.Path, token.Page,
+	).Scan(&token.Token)
+}
+
+// GetCallbackToken gets a callback token by its value
+func (db *DB) GetCallbackToken(token string) (*CallbackToken, error) {
+	query := `
+		SELECT token, action, path, page, user_id, expires_at, created_at
+		FROM callback_tokens WHERE token = ?
+	`
+
+	t := &CallbackToken{}
+	err := db.conn.QueryRow(query, token).Scan(
+		&t.Token, &t.Action, &t.Path, &t.Page, &t.UserID, &t.ExpiresAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// DeleteCallbackToken removes a callback token
+func (db *DB) DeleteCallbackToken(token string) error {
+	_, err := db.conn.Exec(`DELETE FROM callback_tokens WHERE token = ?`, token)
+	return err
+}
+
+// DeleteExpiredCallbackTokens removes callback tokens that expired before now
+func (db *DB) DeleteExpiredCallbackTokens(now time.Time) error {
+	_, err := db.conn.Exec(`DELETE FROM callback_tokens WHERE expires_at < ?`, now)
+	return err
+}
+
+// SaveConversation creates or replaces the conversation of a chat
+func (db *DB) SaveConversation(conv *Conversation) error {
+	query := `
+		INSERT OR REPLACE INTO conversations (chat_id, user_id, dialog, step, data, expires_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`
+
+	_, err := db.conn.Exec(query, conv.ChatID, conv.UserID, conv.Dialog, conv.Step, conv.Data, conv.ExpiresAt)
+	return err
+}
+
+// GetConversation gets the conversation of a chat
+func (db *DB) GetConversation(chatID int64) (*Conversation, error) {
+	query := `
+		SELECT chat_id, user_id, dialog, step, data, expires_at, updated_at
+		FROM conversations WHERE chat_id = ?
+	`
+
+	c := &Conversation{}
+	err := db.conn.QueryRow(query, chatID).Scan(
+		&c.ChatID, &c.UserID, &c.Dialog, &c.Step, &c.Data, &c.ExpiresAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// DeleteConversation removes the conversation of a chat
+func (db *DB) DeleteConversation(chatID int64) error {
+	_, err := db.conn.Exec(`DELETE FROM conversations WHERE chat_id = ?`, chatID)
+	return err
+}
+
+// DeleteExpiredConversations removes conversations that expired before now
+func (db *DB) DeleteExpiredConversations(now time.Time) error {
+	_, err := db.conn.Exec(`DELETE FROM conversations WHERE expires_at < ?`, now)
+	return err
+}
+
+// CreateShellSession records the start of a shell session and sets its ID
+func (db *DB) CreateShellSession(session *ShellSession) error {
+	query := `
+		INSERT INTO shell_sessions (user_id, chat_id, command, started_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := db.conn.Exec(query, session.UserID, session.ChatID, session.Command, session.StartedAt)
+	if err != nil {
+		return err
+	}
+	session.ID, err = result.LastInsertId()
+	return err
+}
+
+// AddShellTranscript appends input or output to the transcript of a session
+func (db *DB) AddShellTranscript(sessionID int64, stream, data string) error {
+	query := `
+		INSERT INTO shell_transcript (session_id, stream, data, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+
+	_, err := db.conn.Exec(query, sessionID, stream, data, time.Now())
+	return err
+}
+
+// EndShellSession records how a shell session ended; exitCode is nil for a
+// killed shell
+func (db *DB) EndShellSession(sessionID int64, exitCode *int, reason string) error {
+	query := `
+		UPDATE shell_sessions SET ended_at = ?, exit_code = ?, end_reason = ?
+		WHERE id = ? AND ended_at IS NULL
+	`
+
+	_, err := db.conn.Exec(query, time.Now(), exitCode, reason, sessionID)
+	return err
+}
+
+// EndStaleShellSessions closes the sessions left open by a bot that stopped
+// without ending them
+func (db *DB) EndStaleShellSessions() error {
+	query := `
+		UPDATE shell_sessions SET ended_at = ?, end_reason = 'restart'
+		WHERE ended_at IS NULL
+	`
+
+	_, err := db.conn.Exec(query, time.Now())
+	return err
+}
+
+// GetShellSession gets a shell session
+func (db *DB) GetShellSession(sessionID int64) (*ShellSession, error) {
+	query := `
+		SELECT id, user_id, chat_id, command, started_at, ended_at, exit_code, end_reason
+		FROM shell_sessions WHERE id = ?
+	`
+
+	s := &ShellSession{}
+	var endedAt sql.NullTime
+	var exitCode sql.NullInt64
+	err := db.conn.QueryRow(query, sessionID).Scan(
+		&s.ID, &s.UserID, &s.ChatID, &s.Command, &s.StartedAt, &endedAt, &exitCode, &s.EndReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		s.EndedAt = &endedAt.Time
+	}
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		s.ExitCode = &code
+	}
+
+	return s, nil
+}
+
+// GetShellTranscript gets the transcript of a session in order
+func (db *DB) GetShellTranscript(sessionID int64) ([]*ShellTranscriptEntry, error) {
+	query := `
+		SELECT session_id, stream, data, created_at
+		FROM shell_transcript WHERE session_id = ?
+		ORDER BY id
+	`
+
+	rows, err := db.conn.Query(query, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*ShellTranscriptEntry
+	for rows.Next() {
+		e := &ShellTranscriptEntry{}
+		if err := rows.Scan(&e.SessionID, &e.Stream, &e.Data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// SaveAlertState creates or replaces the state of an alert rule
+func (db *DB) SaveAlertState(state *AlertState) error {
+	query := `
+		INSERT OR REPLACE INTO alert_states (rule, state, value, since, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`
+
+	_, err := db.conn.Exec(query, state.Rule, state.State, state.Value, state.Since)
+	return err
+}
+
+// GetAlertStates gets the states of all alert rules
+func (db *DB) GetAlertStates() ([]*AlertState, error) {
+	query := `SELECT rule, state, value, since, updated_at FROM alert_states ORDER BY rule`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []*AlertState
+	for rows.Next() {
+		s := &AlertState{}
+		if err := rows.Scan(&s.Rule, &s.State, &s.Value, &s.Since, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		states = append(states, s)
+	}
+
+	return states, rows.Err()
+}
+
+// DeleteAlertState removes the state of an alert rule
+func (db *DB) DeleteAlertState(rule string) error {
+	_, err := db.conn.Exec(`DELETE FROM alert_states WHERE rule = ?`, rule)
+	return err
+}
+
+// AddMetricSamples records samples of one sampling run
+func (db *DB) AddMetricSamples(samples []*MetricSample) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO metrics (metric, target, value, resolution, recorded_at)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range samples {
+		if _, err := stmt.Exec(s.Metric, s.Target, s.Value, s.Resolution, s.RecordedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetMetricSamples gets the samples of a metric for every target recorded
+// between from and to, in time order
+func (db *DB) GetMetricSamples(metric string, from, to time.Time) ([]*MetricSample, error) {
+	query := `
+		SELECT metric, target, value, resolution, recorded_at
+		FROM metrics WHERE metric = ? AND recorded_at >= ? AND recorded_at <= ?
+		ORDER BY recorded_at, target
+	`
+
+	rows, err := db.conn.Query(query, metric, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []*MetricSample
+	for rows.Next() {
+		s := &MetricSample{}
+		if err := rows.Scan(&s.Metric, &s.Target, &s.Value, &s.Resolution, &s.RecordedAt); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, rows.Err()
+}
+
+// DownsampleMetrics replaces the raw samples recorded before the cutoff by
+// their averages over periods of step. The cutoff is rounded down to a
+// whole period so that no period is averaged twice.
+func (db *DB) DownsampleMetrics(before time.Time, step time.Duration) error {
+	before = before.Truncate(step)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT metric, target, value, recorded_at
+		FROM metrics WHERE resolution = 0 AND recorded_at < ?
+	`, before)
+	if err != nil {
+		return err
+	}
+
+	type period struct {
+		metric, target string
+		start          time.Time
+	}
+	type sum struct {
+		total float64
+		count int
+	}
+	sums := make(map[period]*sum)
+	var order []period
+	for rows.Next() {
+		var metric, target string
+		var value float64
+		var recordedAt time.Time
+		if err := rows.Scan(&metric, &target, &value, &recordedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		key := period{metric, target, recordedAt.Truncate(step).In(before.Location())}
+		if sums[key] == nil {
+			sums[key] = &sum{}
+			order = append(order, key)
+		}
+		sums[key].total += value
+		sums[key].count++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range order {
+		s := sums[key]
+		if _, err := tx.Exec(`
+			INSERT INTO metrics (metric, target, value, resolution, recorded_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, key.metric, key.target, s.total/float64(s.count), int(step/time.Second), key.start); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM metrics WHERE resolution = 0 AND recorded_at < ?`, before); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteMetricsBefore removes the samples recorded before t
+func (db *DB) DeleteMetricsBefore(t time.Time) error {
+	_, err := db.conn.Exec(`DELETE FROM metrics WHERE recorded_at < ?`, t)
+	return err
+}
+
+// MuteEventType stops event notifications of a type for a user
+func (db *DB) MuteEventType(userID int64, eventType string) error {
+	query := `INSERT OR IGNORE INTO event_mutes (user_id, event_type) VALUES (?, ?)`
+	_, err := db.conn.Exec(query, userID, eventType)
+	return err
+}
+
+// UnmuteEventType resumes event notifications of a type for a user
+func (db *DB) UnmuteEventType(userID int64, eventType string) error {
+	_, err := db.conn.Exec(`DELETE FROM event_mutes WHERE user_id = ? AND event_type = ?`, userID, eventType)
+	return err
+}
+
+// GetMutedEventTypes gets the event types a user muted, sorted by name
+func (db *DB) GetMutedEventTypes(userID int64) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT event_type FROM event_mutes WHERE user_id = ? ORDER BY event_type`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var types []string
+	for rows.Next() {
+		var eventType string
+		if err := rows.Scan(&eventType); err != nil {
+			return nil, err
+		}
+		types = append(types, eventType)
+	}
+
+	return types, rows.Err()
+}
+
+// AddEvent records a system event and sets its ID
+func (db *DB) AddEvent(event *Event) error {
+	query := `
+		INSERT INTO events (type, severity, source, message, details, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.conn.Exec(query, event.Type, event.Severity, event.Source, event.Message, event.Details, event.OccurredAt)
+	if err != nil {
+		return err
+	}
+	event.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetEvents gets a page of the events matching a filter, newest first
+func (db *DB) GetEvents(filter EventFilter, limit, offset int) ([]*Event, error) {
+	where, args := filter.where()
+	query := `
+		SELECT id, type, severity, source, message, details, occurred_at, acknowledged_by, acknowledged_at
+		FROM events` + where + `
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := db.conn.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// CountEvents counts the events matching a filter
+func (db *DB) CountEvents(filter EventFilter) (int, error) {
+	where, args := filter.where()
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM events`+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetEvent gets a recorded event
+func (db *DB) GetEvent(eventID int64) (*Event, error) {
+	query := `
+		SELECT id, type, severity, source, message, details, occurred_at, acknowledged_by, acknowledged_at
+		FROM events WHERE id = ?
+	`
+	return scanEvent(db.conn.QueryRow(query, eventID))
+}
+
+// AcknowledgeEvent marks an event as handled by a user. It reports false
+// when the event was already acknowledged.
+func (db *DB) AcknowledgeEvent(eventID, userID int64) (bool, error) {
+	query := `
+		UPDATE events SET acknowledged_by = ?, acknowledged_at = ?
+		WHERE id = ? AND acknowledged_at IS NULL
+	`
+
+	result, err := db.conn.Exec(query, userID, time.Now(), eventID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func scanEvent(row interface{ Scan(dest ...any) error }) (*Event, error) {
+	event := &Event{}
+	var acknowledgedAt sql.NullTime
+	err := row.Scan(
+		&event.ID, &event.Type, &event.Severity, &event.Source, &event.Message, &event.Details,
+		&event.OccurredAt, &event.AcknowledgedBy, &acknowledgedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if acknowledgedAt.Valid {
+		event.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	return event, nil
+}
+
+// GetEventSubscription gets the event subscription of a user; sql.ErrNoRows
+// means the user has none of their own
+func (db *DB) GetEventSubscription(userID int64) (*EventSubscription, error) {
+	query := `
+		SELECT user_id, enabled, event_types, min_severity, quiet_hours, timezone, updated_at
+		FROM event_subscriptions WHERE user_id = ?
+	`
+	return scanEventSubscription(db.conn.QueryRow(query, userID))
+}
+
+// GetEventSubscriptions gets the event subscriptions of all users
+func (db *DB) GetEventSubscriptions() ([]*EventSubscription, error) {
+	query := `
+		SELECT user_id, enabled, event_types, min_severity, quiet_hours, timezone, updated_at
+		FROM event_subscriptions ORDER BY user_id
+	`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*EventSubscription
+	for rows.Next() {
+		sub, err := scanEventSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// SaveEventSubscription creates or replaces the event subscription of a user
+func (db *DB) SaveEventSubscription(sub *EventSubscription) error {
+	query := `
+		INSERT INTO event_subscriptions (user_id, enabled, event_types, min_severity, quiet_hours, timezone, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			enabled = excluded.enabled,
+			event_types = excluded.event_types,
+			min_severity = excluded.min_severity,
+			quiet_hours = excluded.quiet_hours,
+			timezone = excluded.timezone,
+			updated_at = excluded.updated_at
+	`
+
+	sub.UpdatedAt = time.Now()
+	_, err := db.conn.Exec(query, sub.UserID, sub.Enabled, strings.Join(sub.Types, ","), sub.MinSeverity,
+		sub.QuietHours, sub.Timezone, sub.UpdatedAt)
+	return err
+}
+
+func scanEventSubscription(row interface{ Scan(dest ...any) error }) (*EventSubscription, error) {
+	sub := &EventSubscription{}
+	var types string
+	err := row.Scan(&sub.UserID, &sub.Enabled, &types, &sub.MinSeverity, &sub.QuietHours, &sub.Timezone, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if types != "" {
+		sub.Types = strings.Split(types, ",")
+	}
+	return sub, nil
+}
+
+// HoldEvent keeps an event notification until the quiet hours of a user end
+// and sets its ID
+func (db *DB) HoldEvent(held *HeldEvent) error {
+	query := `INSERT INTO held_events (user_id, event_id, event, held_at) VALUES (?, ?, ?, ?)`
+
+	result, err := db.conn.Exec(query, held.UserID, held.EventID, held.Event, held.HeldAt)
+	if err != nil {
+		return err
+	}
+	held.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetHeldEvents gets the notifications held for a user, oldest first
+func (db *DB) GetHeldEvents(userID int64) ([]*HeldEvent, error) {
+	query := `SELECT id, user_id, event_id, event, held_at FROM held_events WHERE user_id = ? ORDER BY id`
+
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var held []*HeldEvent
+	for rows.Next() {
+		h := &HeldEvent{}
+		if err := rows.Scan(&h.ID, &h.UserID, &h.EventID, &h.Event, &h.HeldAt); err != nil {
+			return nil, err
+		}
+		held = append(held, h)
+	}
+
+	return held, rows.Err()
+}
+
+// GetHeldEventUsers gets the users with held notifications
+func (db *DB) GetHeldEventUsers() ([]int64, error) {
+	rows, err := db.conn.Query(`SELECT DISTINCT user_id FROM held_events ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+
+	return users, rows.Err()
+}
+
+// DeleteHeldEvents removes the notifications held for a user up to and
+// including lastID
+func (db *DB) DeleteHeldEvents(userID, lastID int64) error {
+	_, err := db.conn.Exec(`DELETE FROM held_events WHERE user_id = ? AND id <= ?`, userID, lastID)
+	return err
+}
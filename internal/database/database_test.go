@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -173,6 +176,532 @@ func TestGetAllUsers(t *testing.T) {
 	}
 }
 
+func TestSetUserLanguage(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	user := &User{ID: 123456789, Username: "testuser", LanguageCode: "en-US", IsActive: true}
+	if err := db.CreateOrUpdateUser(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if err := db.SetUserLanguage(user.ID, "ru"); err != nil {
+		t.Fatalf("Failed to set language: %v", err)
+	}
+
+	// A profile refresh must not reset the chosen language
+	user.LanguageCode = "de"
+	if err := db.UpdateUserProfile(user); err != nil {
+		t.Fatalf("Failed to update profile: %v", err)
+	}
+
+	stored, err := db.GetUser(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if stored.Language != "ru" {
+		t.Errorf("Expected language ru, got %q", stored.Language)
+	}
+	if stored.LanguageCode != "de" {
+		t.Errorf("Expected language code de, got %q", stored.LanguageCode)
+	}
+}
+
+func TestSetUserMenuStyle(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	user := &User{ID: 123456789, Username: "testuser", IsActive: true}
+	if err := db.CreateOrUpdateUser(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if err := db.SetUserMenuStyle(user.ID, "log"); err != nil {
+		t.Fatalf("Failed to set menu style: %v", err)
+	}
+
+	stored, err := db.GetUser(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if stored.MenuStyle != "log" {
+		t.Errorf("Expected menu style log, got %q", stored.MenuStyle)
+	}
+
+	// Saving the user back keeps the style
+	if err := db.CreateOrUpdateUser(stored); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	if users, err := db.GetAllUsers(); err != nil || len(users) != 1 || users[0].MenuStyle != "log" {
+		t.Errorf("Expected menu style log in the user list, got %+v (%v)", users, err)
+	}
+}
+
+func TestCommandHistoryExitCode(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	user := &User{ID: 123456789, Username: "testuser", IsActive: true}
+	if err := db.CreateOrUpdateUser(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	exitCode := 2
+	entries := []*CommandHistory{
+		{UserID: user.ID, Command: "status", Success: true, ExecutedAt: time.Now().Add(-time.Minute)},
+		{UserID: user.ID, Command: "exec", Arguments: "ping host", ExitCode: &exitCode, Duration: 1500 * time.Millisecond, ExecutedAt: time.Now()},
+	}
+	for _, entry := range entries {
+		if err := db.AddCommandHistory(entry); err != nil {
+			t.Fatalf("Failed to add history: %v", err)
+		}
+	}
+
+	history, err := db.GetCommandHistory(user.ID, 10)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(history))
+	}
+	if history[0].ExitCode == nil || *history[0].ExitCode != 2 || history[0].Duration != 1500*time.Millisecond {
+		t.Errorf("Expected exit code 2 after 1.5s, got %v after %s", history[0].ExitCode, history[0].Duration)
+	}
+	if history[1].ExitCode != nil || history[1].Duration != 0 {
+		t.Errorf("Bot commands have no exit code or duration, got %v after %s", history[1].ExitCode, history[1].Duration)
+	}
+}
+
+func TestShellSessionTranscript(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	session := &ShellSession{UserID: 123456789, ChatID: 123456789, Command: "/bin/sh", StartedAt: time.Now()}
+	if err := db.CreateShellSession(session); err != nil {
+		t.Fatalf("Failed to create shell session: %v", err)
+	}
+	stale := &ShellSession{UserID: 123456789, ChatID: 123456789, Command: "/bin/sh", StartedAt: time.Now()}
+	if err := db.CreateShellSession(stale); err != nil {
+		t.Fatalf("Failed to create shell session: %v", err)
+	}
+
+	for _, entry := range []struct{ stream, data string }{
+		{ShellOutput, "$ "},
+		{ShellInput, "exit 3"},
+		{ShellOutput, "exit 3\n"},
+	} {
+		if err := db.AddShellTranscript(session.ID, entry.stream, entry.data); err != nil {
+			t.Fatalf("Failed to add transcript: %v", err)
+		}
+	}
+	exitCode := 3
+	if err := db.EndShellSession(session.ID, &exitCode, "exit"); err != nil {
+		t.Fatalf("Failed to end shell session: %v", err)
+	}
+	if err := db.EndStaleShellSessions(); err != nil {
+		t.Fatalf("Failed to end stale sessions: %v", err)
+	}
+
+	got, err := db.GetShellSession(session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get shell session: %v", err)
+	}
+	if got.EndedAt == nil || got.ExitCode == nil || *got.ExitCode != 3 || got.EndReason != "exit" {
+		t.Errorf("Expected a session ended by exit 3, got %+v", got)
+	}
+	if got, err := db.GetShellSession(stale.ID); err != nil || got.EndedAt == nil || got.EndReason != "restart" {
+		t.Errorf("Expected the open session ended by the restart, got %+v (%v)", got, err)
+	}
+
+	transcript, err := db.GetShellTranscript(session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get transcript: %v", err)
+	}
+	if len(transcript) != 3 || transcript[1].Stream != ShellInput || transcript[1].Data != "exit 3" {
+		t.Errorf("Unexpected transcript: %+v", transcript)
+	}
+
+	// Sessions ended before the cutoff go with their transcript
+	if err := db.CleanOldHistory(0); err != nil {
+		t.Fatalf("Failed to clean history: %v", err)
+	}
+	if _, err := db.GetShellSession(session.ID); err == nil {
+		t.Error("Old shell session should be deleted")
+	}
+	if transcript, _ := db.GetShellTranscript(session.ID); len(transcript) != 0 {
+		t.Errorf("Transcript of a deleted session should be deleted, got %d entries", len(transcript))
+	}
+}
+
+func TestAlertStates(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	since := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	if err := db.SaveAlertState(&AlertState{Rule: "disk-c", State: "pending", Value: 12, Since: since}); err != nil {
+		t.Fatalf("Failed to save alert state: %v", err)
+	}
+	if err := db.SaveAlertState(&AlertState{Rule: "disk-c", State: "firing", Value: 8.5, Since: since}); err != nil {
+		t.Fatalf("Failed to replace alert state: %v", err)
+	}
+	if err := db.SaveAlertState(&AlertState{Rule: "cpu", State: "ok", Since: since}); err != nil {
+		t.Fatalf("Failed to save alert state: %v", err)
+	}
+
+	states, err := db.GetAlertStates()
+	if err != nil {
+		t.Fatalf("Failed to get alert states: %v", err)
+	}
+	if len(states) != 2 || states[1].Rule != "disk-c" || states[1].State != "firing" || states[1].Value != 8.5 || !states[1].Since.Equal(since) {
+		t.Errorf("Unexpected alert states: %+v", states)
+	}
+
+	if err := db.DeleteAlertState("cpu"); err != nil {
+		t.Fatalf("Failed to delete alert state: %v", err)
+	}
+	if states, _ := db.GetAlertStates(); len(states) != 1 {
+		t.Errorf("Expected one alert state left, got %d", len(states))
+	}
+}
+
+func TestEvents(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	now := time.Now()
+	for _, e := range []*Event{
+		{Type: "login", Severity: "info", Source: "utmp", Message: "alice logged in", OccurredAt: now.Add(-72 * time.Hour)},
+		{Type: "error", Severity: "error", Message: "disk failure", Details: "sda: I/O error", OccurredAt: now.Add(-2 * time.Hour)},
+		{Type: "service", Severity: "warning", Message: "nginx stopped", OccurredAt: now.Add(-time.Hour)},
+		{Type: "login", Severity: "info", Message: "bob logged in", OccurredAt: now},
+	} {
+		if err := db.AddEvent(e); err != nil {
+			t.Fatalf("Failed to add event: %v", err)
+		}
+		if e.ID == 0 {
+			t.Fatal("Expected the event ID to be set")
+		}
+	}
+
+	all, err := db.GetEvents(EventFilter{}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(all) != 4 || all[0].Message != "bob logged in" || all[3].Message != "alice logged in" {
+		t.Fatalf("Expected the events newest first, got %+v", all)
+	}
+
+	for _, test := range []struct {
+		name     string
+		filter   EventFilter
+		expected []string
+	}{
+		{"type", EventFilter{Types: []string{"login"}}, []string{"bob logged in", "alice logged in"}},
+		{"severity", EventFilter{Severities: []string{"warning", "error", "critical"}}, []string{"nginx stopped", "disk failure"}},
+		{"since", EventFilter{Since: now.Add(-24 * time.Hour)}, []string{"bob logged in", "nginx stopped", "disk failure"}},
+		{"range", EventFilter{Since: now.Add(-3 * time.Hour), Until: now.Add(-90 * time.Minute)}, []string{"disk failure"}},
+		{"combined", EventFilter{Types: []string{"login"}, Since: now.Add(-time.Hour)}, []string{"bob logged in"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := db.GetEvents(test.filter, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := db.CountEvents(test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.expected) || count != len(test.expected) {
+				t.Fatalf("Expected %v, got %d events (count %d)", test.expected, len(got), count)
+			}
+			for i := range got {
+				if got[i].Message != test.expected[i] {
+					t.Errorf("Event %d: expected %q, got %q", i, test.expected[i], got[i].Message)
+				}
+			}
+		})
+	}
+
+	if page, _ := db.GetEvents(EventFilter{}, 2, 2); len(page) != 2 || page[0].Message != "disk failure" {
+		t.Errorf("Unexpected second page: %+v", page)
+	}
+
+	failure := all[2]
+	ok, err := db.AcknowledgeEvent(failure.ID, 42)
+	if err != nil || !ok {
+		t.Fatalf("Expected the event acknowledged, got %v (%v)", ok, err)
+	}
+	if ok, _ := db.AcknowledgeEvent(failure.ID, 43); ok {
+		t.Error("An acknowledged event should not be acknowledged again")
+	}
+	got, err := db.GetEvent(failure.ID)
+	if err != nil {
+		t.Fatalf("Failed to get event: %v", err)
+	}
+	if got.AcknowledgedBy != 42 || got.AcknowledgedAt == nil || got.Details != "sda: I/O error" {
+		t.Errorf("Unexpected acknowledged event: %+v", got)
+	}
+
+	// Cleanup drops events past the retention
+	if err := db.CleanOldHistory(1); err != nil {
+		t.Fatalf("Failed to clean history: %v", err)
+	}
+	if count, _ := db.CountEvents(EventFilter{}); count != 3 {
+		t.Errorf("Expected 3 events left, got %d", count)
+	}
+}
+
+func TestEventMutes(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	for _, eventType := range []string{"process", "login", "process"} {
+		if err := db.MuteEventType(1, eventType); err != nil {
+			t.Fatalf("Failed to mute %s: %v", eventType, err)
+		}
+	}
+	if err := db.MuteEventType(2, "error"); err != nil {
+		t.Fatal(err)
+	}
+
+	muted, err := db.GetMutedEventTypes(1)
+	if err != nil {
+		t.Fatalf("Failed to get muted event types: %v", err)
+	}
+	if len(muted) != 2 || muted[0] != "login" || muted[1] != "process" {
+		t.Errorf("Expected login and process muted, got %v", muted)
+	}
+
+	if err := db.UnmuteEventType(1, "login"); err != nil {
+		t.Fatalf("Failed to unmute: %v", err)
+	}
+	if muted, _ := db.GetMutedEventTypes(1); len(muted) != 1 || muted[0] != "process" {
+		t.Errorf("Expected only process muted, got %v", muted)
+	}
+	if muted, _ := db.GetMutedEventTypes(2); len(muted) != 1 {
+		t.Errorf("Muting should be per user, got %v", muted)
+	}
+}
+
+func TestEventSubscriptions(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	if _, err := db.GetEventSubscription(1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Expected no subscription yet, got %v", err)
+	}
+
+	sub := &EventSubscription{UserID: 1, Enabled: true, Types: []string{"login", "error"}, MinSeverity: "warning", QuietHours: "23:00-07:00", Timezone: "Europe/Moscow"}
+	if err := db.SaveEventSubscription(sub); err != nil {
+		t.Fatalf("Failed to save subscription: %v", err)
+	}
+	sub.Enabled, sub.Types = false, nil
+	if err := db.SaveEventSubscription(sub); err != nil {
+		t.Fatalf("Failed to update subscription: %v", err)
+	}
+	if err := db.SaveEventSubscription(&EventSubscription{UserID: 2, Enabled: true, Types: []string{"service"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetEventSubscription(1)
+	if err != nil {
+		t.Fatalf("Failed to get subscription: %v", err)
+	}
+	if got.Enabled || len(got.Types) != 0 || got.MinSeverity != "warning" || got.QuietHours != "23:00-07:00" || got.Timezone != "Europe/Moscow" {
+		t.Errorf("Unexpected subscription %+v", got)
+	}
+	all, err := db.GetEventSubscriptions()
+	if err != nil || len(all) != 2 || all[1].Types[0] != "service" {
+		t.Errorf("Expected both subscriptions, got %+v, %v", all, err)
+	}
+
+	// Held events are kept per user until delivered
+	at := time.Now()
+	for _, held := range []*HeldEvent{
+		{UserID: 1, EventID: 5, Event: `{"type":"login"}`, HeldAt: at},
+		{UserID: 1, EventID: 6, Event: `{"type":"error"}`, HeldAt: at},
+		{UserID: 2, Event: `{"type":"service"}`, HeldAt: at},
+	} {
+		if err := db.HoldEvent(held); err != nil || held.ID == 0 {
+			t.Fatalf("Failed to hold event: %v", err)
+		}
+	}
+	if users, err := db.GetHeldEventUsers(); err != nil || len(users) != 2 {
+		t.Errorf("Expected two users with held events, got %v, %v", users, err)
+	}
+	held, err := db.GetHeldEvents(1)
+	if err != nil || len(held) != 2 || held[0].EventID != 5 || held[1].Event != `{"type":"error"}` {
+		t.Fatalf("Unexpected held events %+v, %v", held, err)
+	}
+	if err := db.DeleteHeldEvents(1, held[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if held, _ := db.GetHeldEvents(1); len(held) != 1 || held[0].EventID != 6 {
+		t.Errorf("Expected only the second event held, got %+v", held)
+	}
+
+	// Deleting a user removes both
+	if err := db.CreateOrUpdateUser(&User{ID: 1, Username: "u1", IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeleteUser(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetEventSubscription(1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected the subscription deleted with the user, got %v", err)
+	}
+	if held, _ := db.GetHeldEvents(1); len(held) != 0 {
+		t.Errorf("Expected held events deleted with the user, got %+v", held)
+	}
+}
+
+func TestMetricsDownsampling(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	start := time.Now().Truncate(time.Hour).Add(-48 * time.Hour)
+	var samples []*MetricSample
+	for i := 0; i < 30; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		samples = append(samples,
+			&MetricSample{Metric: "cpu", Value: float64(i), RecordedAt: at},
+			&MetricSample{Metric: "disk_used", Target: "/", Value: 50, RecordedAt: at},
+		)
+	}
+	recent := &MetricSample{Metric: "cpu", Value: 99, RecordedAt: time.Now()}
+	if err := db.AddMetricSamples(append(samples, recent)); err != nil {
+		t.Fatalf("Failed to add samples: %v", err)
+	}
+
+	// The cutoff in the middle of a period leaves that period raw
+	if err := db.DownsampleMetrics(start.Add(25*time.Minute), 10*time.Minute); err != nil {
+		t.Fatalf("Failed to downsample: %v", err)
+	}
+	got, err := db.GetMetricSamples("cpu", start, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to get samples: %v", err)
+	}
+	if len(got) != 2+10+1 {
+		t.Fatalf("Expected 2 averages, 10 raw samples and the recent one, got %d", len(got))
+	}
+	if got[0].Resolution != 600 || got[0].Value != 4.5 || !got[0].RecordedAt.Equal(start) {
+		t.Errorf("Expected the average of the first 10 minutes, got %+v", got[0])
+	}
+	if got[1].Value != 14.5 || got[2].Resolution != 0 || got[2].Value != 20 {
+		t.Errorf("Unexpected samples after the first average: %+v, %+v", got[1], got[2])
+	}
+	if disk, _ := db.GetMetricSamples("disk_used", start, time.Now()); len(disk) != 12 || disk[0].Target != "/" || disk[0].Value != 50 {
+		t.Errorf("Expected the disk downsampled on its own, got %d samples", len(disk))
+	}
+
+	if err := db.DeleteMetricsBefore(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to delete old samples: %v", err)
+	}
+	if got, _ := db.GetMetricSamples("cpu", start, time.Now()); len(got) != 1 || got[0].Value != 99 {
+		t.Errorf("Expected only the recent sample left, got %d", len(got))
+	}
+}
+
+func TestGetCommandCounts(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	for _, h := range []struct {
+		command string
+		success bool
+	}{
+		{"status", true}, {"status", true}, {"status", false},
+		{"callback:ps_p_0123", true}, {"callback:menu", true}, {"exec", false},
+		{"nosuchcommand", false}, {"anothertypo", false},
+	} {
+		if err := db.AddCommandHistory(&CommandHistory{UserID: 1, Command: h.command, Success: h.success, ExecutedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, err := db.GetCommandCounts([]string{"status", "exec", "help"})
+	if err != nil {
+		t.Fatalf("Failed to count commands: %v", err)
+	}
+	expected := []CommandCount{
+		{"callback", true, 2},
+		{"exec", false, 1},
+		{"status", false, 1},
+		{"status", true, 2},
+		{"unknown", false, 2},
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %d counts, got %d", len(expected), len(counts))
+	}
+	for i := range expected {
+		if *counts[i] != expected[i] {
+			t.Errorf("Count %d: expected %+v, got %+v", i, expected[i], *counts[i])
+		}
+	}
+
+	if counts, err := db.GetCommandCounts(nil); err != nil || len(counts) != 3 || counts[1].Command != "unknown" {
+		t.Errorf("Expected only callback and unknown without known commands, got %v", err)
+	}
+}
+
+func TestPing(t *testing.T) {
+	db := setupTestDB(t)
+	defer teardownTestDB(t, db)
+
+	if err := db.Ping(context.Background()); err != nil {
+		t.Errorf("Expected the database to answer, got %v", err)
+	}
+}
+
+func TestMigrateAddsUserLanguageColumns(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	// A users table as created before the language columns existed
+	conn, err := sql.Open("sqlite3", tmpFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY,
+		username TEXT,
+		first_name TEXT,
+		last_name TEXT,
+		is_admin BOOLEAN DEFAULT FALSE,
+		is_active BOOLEAN DEFAULT TRUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err == nil {
+		_, err = conn.Exec(`INSERT INTO users (id, username, first_name, last_name) VALUES (1, 'old', 'Old', 'User')`)
+	}
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := New(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to open old database: %v", err)
+	}
+	defer db.Close()
+
+	user, err := db.GetUser(1)
+	if err != nil {
+		t.Fatalf("Failed to get migrated user: %v", err)
+	}
+	if user.Language != "" || user.LanguageCode != "" {
+		t.Errorf("Expected empty language for migrated user, got %q/%q", user.Language, user.LanguageCode)
+	}
+	if err := db.SetUserLanguage(1, "en"); err != nil {
+		t.Errorf("Failed to set language after migration: %v", err)
+	}
+}
+
 // Helper functions
 func setupTestDB(t *testing.T) *DB {
 	tmpFile, err := os.CreateTemp("", "test_*.db")
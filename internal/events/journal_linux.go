@@ -0,0 +1,175 @@
+//go:build linux
+
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalBatch caps the entries read in one poll, so that a burst of errors
+// doesn't turn into a burst of notifications
+const journalBatch = 50
+
+// journalEntry holds the fields of a journalctl JSON entry the source uses
+type journalEntry struct {
+	Cursor     string          `json:"__CURSOR"`
+	Realtime   string          `json:"__REALTIME_TIMESTAMP"`
+	Priority   string          `json:"PRIORITY"`
+	Message    json.RawMessage `json:"MESSAGE"`
+	Identifier string          `json:"SYSLOG_IDENTIFIER"`
+	Command    string          `json:"_COMM"`
+	Unit       string          `json:"_SYSTEMD_UNIT"`
+	PID        string          `json:"_PID"`
+}
+
+// journalSource reports journal entries with error priority or above
+// logged since the previous poll
+type journalSource struct {
+	run    commandRunner
+	cursor string
+	since  time.Time
+}
+
+// newJournalSource returns a source reading the journal with journalctl; a
+// nil run runs it on the host
+func newJournalSource(run commandRunner) *journalSource {
+	if run == nil {
+		run = execCommand
+	}
+	return &journalSource{run: run}
+}
+
+func (s *journalSource) Name() string { return "journal" }
+
+// Init starts after the last entry of the journal, or now when it is empty
+func (s *journalSource) Init(ctx context.Context) error {
+	s.since = time.Now()
+	out, err := s.run(ctx, "journalctl", "--output=json", "--no-pager", "--quiet", "--lines=1")
+	if err != nil {
+		return err
+	}
+	entries, err := parseJournal(out)
+	if err != nil {
+		return err
+	}
+	s.cursor = ""
+	if len(entries) > 0 {
+		s.cursor = entries[len(entries)-1].Cursor
+	}
+	return nil
+}
+
+func (s *journalSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	args := []string{"--priority=err", "--output=json", "--no-pager", "--quiet", "--lines=" + strconv.Itoa(journalBatch)}
+	if s.cursor != "" {
+		args = append(args, "--after-cursor="+s.cursor)
+	} else {
+		args = append(args, "--since=@"+strconv.FormatInt(s.since.Unix(), 10))
+	}
+	out, err := s.run(ctx, "journalctl", args...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseJournal(out)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]SystemEvent, 0, len(entries))
+	for _, e := range entries {
+		// --since has a resolution of seconds; entries at the start
+		// second may have been reported already
+		if s.cursor == "" && e.time().Before(s.since) {
+			continue
+		}
+		events = append(events, e.event())
+	}
+	if len(entries) > 0 {
+		s.cursor = entries[len(entries)-1].Cursor
+	}
+	return events, nil
+}
+
+// parseJournal decodes the lines of journalctl --output=json
+func parseJournal(out []byte) ([]journalEntry, error) {
+	var entries []journalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e journalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("parse journal entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// message returns MESSAGE, which journalctl writes as an array of bytes
+// when it isn't valid UTF-8
+func (e *journalEntry) message() string {
+	var text string
+	if err := json.Unmarshal(e.Message, &text); err == nil {
+		return text
+	}
+	var raw []byte
+	if err := json.Unmarshal(e.Message, &raw); err == nil {
+		return strings.ToValidUTF8(string(raw), "?")
+	}
+	return ""
+}
+
+func (e *journalEntry) time() time.Time {
+	usec, err := strconv.ParseInt(e.Realtime, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMicro(usec)
+}
+
+func (e *journalEntry) event() SystemEvent {
+	name := e.Identifier
+	if name == "" {
+		name = e.Command
+	}
+
+	message := strings.TrimSpace(e.message())
+	if name != "" {
+		message = name + ": " + message
+	}
+
+	var details []string
+	if e.Unit != "" {
+		details = append(details, "unit "+e.Unit)
+	}
+	if e.PID != "" {
+		details = append(details, "PID "+e.PID)
+	}
+	details = append(details, "priority "+e.Priority)
+
+	// Priorities 0-2 are emerg, alert and crit, 3 is err
+	severity := SeverityError
+	if priority, err := strconv.Atoi(e.Priority); err == nil && priority <= 2 {
+		severity = SeverityCritical
+	}
+
+	return SystemEvent{
+		Type:      EventError,
+		Message:   message,
+		Details:   strings.Join(details, ", "),
+		Timestamp: e.time(),
+		Severity:  severity,
+		Source:    "journal",
+	}
+}
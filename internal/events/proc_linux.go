@@ -0,0 +1,143 @@
+//go:build linux
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const procRoot = "/proc"
+
+// procEntry identifies a process. The start time tells a new process apart
+// from an old one whose PID was reused.
+type procEntry struct {
+	name  string
+	start uint64
+}
+
+// procSource reports processes started and exited since the previous poll
+type procSource struct {
+	root  string
+	known map[int]procEntry
+}
+
+func newProcSource(root string) *procSource {
+	return &procSource{root: root}
+}
+
+func (s *procSource) Name() string { return "processes" }
+
+func (s *procSource) Init(ctx context.Context) error {
+	known, err := s.scan()
+	if err != nil {
+		return err
+	}
+	s.known = known
+	return nil
+}
+
+func (s *procSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	current, err := s.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var events []SystemEvent
+	for pid, entry := range current {
+		if old, ok := s.known[pid]; ok && old == entry {
+			continue
+		}
+		// The PID is left out of the message so that restarts of the same
+		// program are duplicates
+		details := fmt.Sprintf("PID %d", pid)
+		if cmdline := s.cmdline(pid); cmdline != "" {
+			details += ": " + cmdline
+		}
+		events = append(events, SystemEvent{
+			Type:      EventProcess,
+			Message:   "New process started: " + entry.name,
+			Details:   details,
+			Timestamp: now,
+			Severity:  SeverityInfo,
+			Source:    "proc",
+		})
+	}
+	for pid, entry := range s.known {
+		if cur, ok := current[pid]; ok && cur == entry {
+			continue
+		}
+		events = append(events, SystemEvent{
+			Type:      EventProcess,
+			Message:   "Process terminated: " + entry.name,
+			Details:   fmt.Sprintf("PID %d", pid),
+			Timestamp: now,
+			Severity:  SeverityInfo,
+			Source:    "proc",
+		})
+	}
+	s.known = current
+	return events, nil
+}
+
+// scan reads the processes running now. Processes exiting during the scan
+// are skipped.
+func (s *procSource) scan() (map[int]procEntry, error) {
+	dirs, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make(map[int]procEntry)
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil || !dir.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.root, dir.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		if entry, ok := parseProcStat(data); ok {
+			processes[pid] = entry
+		}
+	}
+	return processes, nil
+}
+
+// cmdline returns the command line of a process, empty for kernel threads
+// and processes that already exited
+func (s *procSource) cmdline(pid int) string {
+	data, err := os.ReadFile(filepath.Join(s.root, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.ReplaceAll(data, []byte{0}, []byte{' '})))
+}
+
+// parseProcStat reads the name and start time from /proc/<pid>/stat. The
+// name is in parentheses and may contain spaces and parentheses itself.
+func parseProcStat(data []byte) (procEntry, bool) {
+	open, end := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return procEntry{}, false
+	}
+	// Fields after the name start with the third, the state; the start
+	// time is the 22nd
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return procEntry{}, false
+	}
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return procEntry{}, false
+	}
+	return procEntry{name: string(data[open+1 : end]), start: start}, true
+}
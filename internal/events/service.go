@@ -2,10 +2,7 @@ package events
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"os/exec"
-	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +22,11 @@ const (
 	EventService  EventType = "service"
 )
 
+// EventTypes lists every event type the service emits
+var EventTypes = []EventType{
+	EventLogin, EventLogout, EventStartup, EventShutdown, EventError, EventProcess, EventService,
+}
+
 // SystemEvent represents a system event
 type SystemEvent struct {
 	Type      EventType `json:"type"`
@@ -34,38 +36,42 @@ type SystemEvent struct {
 	Severity  string    `json:"severity"` // info, warning, error, critical
 	Source    string    `json:"source"`
 	UserID    string    `json:"user_id,omitempty"`
+	ID        int64     `json:"id,omitempty"`      // recorded event, 0 for digests and unrecorded events
+	Count     int       `json:"count,omitempty"`   // events a digest summarizes
+	Repeats   int       `json:"repeats,omitempty"` // duplicates collapsed since the previous one passed on
 }
 
 // EventHandler is a function that handles system events
 type EventHandler func(event SystemEvent)
 
-// Service provides system event monitoring
+// Service runs the event sources events.watch_events needs, each polled on
+// its own interval, and passes every event to the handlers. Throttling is
+// left to the handlers that notify, see Throttle.
 type Service struct {
 	config   *config.Config
+	registry *Registry
 	handlers []EventHandler
 	ctx      context.Context
 	cancel   context.CancelFunc
 	wg       sync.WaitGroup
+	running  sync.WaitGroup // handlers that have not returned yet
 	mu       sync.RWMutex
-
-	// Event state tracking
-	lastBootTime   time.Time
-	lastLoginCheck time.Time
-	knownProcesses map[string]bool
-	knownServices  map[string]string
 }
 
 // NewService creates a new events service
 func NewService(cfg *config.Config) *Service {
+	return newService(cfg, NewRegistry())
+}
+
+func newService(cfg *config.Config, registry *Registry) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Service{
-		config:         cfg,
-		handlers:       make([]EventHandler, 0),
-		ctx:            ctx,
-		cancel:         cancel,
-		knownProcesses: make(map[string]bool),
-		knownServices:  make(map[string]string),
+		config:   cfg,
+		registry: registry,
+		handlers: make([]EventHandler, 0),
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 }
 
@@ -85,40 +91,44 @@ func (s *Service) Start() error {
 
 	log.Println("Starting system events monitoring...")
 
-	// Initialize tracking data
-	s.initializeTracking()
-
-	// Start monitoring routines
-	s.wg.Add(1)
-	go s.monitorEvents()
+	sources := s.registry.Sources(s.config)
+	if len(sources) == 0 {
+		log.Println("Warning: No event sources for the watched events on this system")
+	}
+	for _, source := range sources {
+		s.wg.Add(1)
+		go s.watch(source, time.Duration(s.config.EventSourceInterval(source.Name()))*time.Second)
+	}
 
 	// Send startup event
 	s.emitEvent(SystemEvent{
 		Type:      EventStartup,
 		Message:   "CupBot system monitoring started",
 		Timestamp: time.Now(),
-		Severity:  "info",
+		Severity:  SeverityInfo,
 		Source:    "cupbot",
 	})
 
 	return nil
 }
 
-// Stop stops the events monitoring
+// Stop stops the events monitoring and returns once the handlers are done
+// with every event, the shutdown event included
 func (s *Service) Stop() {
 	log.Println("Stopping system events monitoring...")
 
+	s.cancel()
+	s.wg.Wait()
+
 	// Send shutdown event
 	s.emitEvent(SystemEvent{
 		Type:      EventShutdown,
 		Message:   "CupBot system monitoring stopped",
 		Timestamp: time.Now(),
-		Severity:  "info",
+		Severity:  SeverityInfo,
 		Source:    "cupbot",
 	})
-
-	s.cancel()
-	s.wg.Wait()
+	s.running.Wait()
 }
 
 // IsEventWatched checks if an event type is being monitored
@@ -126,285 +136,60 @@ func (s *Service) IsEventWatched(eventType EventType) bool {
 	return s.config.IsEventWatched(string(eventType))
 }
 
-// monitorEvents runs the main event monitoring loop
-func (s *Service) monitorEvents() {
+// watch polls a source until the service stops. A source that fails to
+// initialize is retried on the next tick instead of being polled, so that it
+// doesn't report everything it finds as new. Failures are logged when they
+// start and stop rather than on every tick.
+func (s *Service) watch(source EventSource, interval time.Duration) {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(time.Duration(s.config.Events.PollingInterval) * time.Second)
+	if interval <= 0 {
+		interval = time.Duration(s.config.Events.PollingInterval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	var failure string
+	report := func(err error) {
+		switch {
+		case err != nil && err.Error() != failure:
+			log.Printf("Warning: Event source %s failed: %v", source.Name(), err)
+			failure = err.Error()
+		case err == nil && failure != "":
+			log.Printf("Event source %s recovered", source.Name())
+			failure = ""
+		}
+	}
+
+	err := source.Init(s.ctx)
+	ready := err == nil
+	report(err)
+
 	for {
 		select {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
-			s.checkEvents()
 		}
-	}
-}
-
-// checkEvents performs various system checks
-func (s *Service) checkEvents() {
-	// Check for login/logout events
-	if s.IsEventWatched(EventLogin) || s.IsEventWatched(EventLogout) {
-		s.checkLoginEvents()
-	}
 
-	// Check for process events
-	if s.IsEventWatched(EventProcess) {
-		s.checkProcessEvents()
-	}
-
-	// Check for service events
-	if s.IsEventWatched(EventService) {
-		s.checkServiceEvents()
-	}
-
-	// Check for errors in event log
-	if s.IsEventWatched(EventError) {
-		s.checkErrorEvents()
-	}
-}
-
-// initializeTracking initializes the tracking state
-func (s *Service) initializeTracking() {
-	// Get current boot time
-	s.lastBootTime = s.getSystemBootTime()
-	s.lastLoginCheck = time.Now()
-
-	// Initialize known processes
-	processes := s.getCurrentProcesses()
-	for _, proc := range processes {
-		s.knownProcesses[proc] = true
-	}
-
-	// Initialize known services
-	services := s.getCurrentServices()
-	for name, status := range services {
-		s.knownServices[name] = status
-	}
-}
-
-// checkLoginEvents monitors for user login/logout events
-func (s *Service) checkLoginEvents() {
-	// Use PowerShell to check recent logon events
-	cmd := exec.Command("powershell", "-Command",
-		fmt.Sprintf("Get-WinEvent -FilterHashtable @{LogName='Security'; ID=4624,4634; StartTime='%s'} -MaxEvents 10 | Select-Object TimeCreated, Id, Message",
-			s.lastLoginCheck.Format("2006-01-02T15:04:05")))
-
-	output, err := cmd.Output()
-	if err != nil {
-		return // No events or error
-	}
-
-	events := s.parseLogonEvents(string(output))
-	for _, event := range events {
-		s.emitEvent(event)
-	}
-
-	s.lastLoginCheck = time.Now()
-}
-
-// checkProcessEvents monitors for new/terminated processes
-func (s *Service) checkProcessEvents() {
-	currentProcesses := s.getCurrentProcesses()
-	currentMap := make(map[string]bool)
-
-	// Check for new processes
-	for _, proc := range currentProcesses {
-		currentMap[proc] = true
-		if !s.knownProcesses[proc] {
-			s.emitEvent(SystemEvent{
-				Type:      EventProcess,
-				Message:   fmt.Sprintf("New process started: %s", proc),
-				Timestamp: time.Now(),
-				Severity:  "info",
-				Source:    "process_monitor",
-			})
+		if !ready {
+			err := source.Init(s.ctx)
+			ready = err == nil
+			report(err)
+			continue
 		}
-	}
 
-	// Check for terminated processes
-	for proc := range s.knownProcesses {
-		if !currentMap[proc] {
-			s.emitEvent(SystemEvent{
-				Type:      EventProcess,
-				Message:   fmt.Sprintf("Process terminated: %s", proc),
-				Timestamp: time.Now(),
-				Severity:  "info",
-				Source:    "process_monitor",
-			})
+		events, err := source.Poll(s.ctx)
+		if s.ctx.Err() != nil {
+			return
 		}
-	}
-
-	s.knownProcesses = currentMap
-}
-
-// checkServiceEvents monitors for service status changes
-func (s *Service) checkServiceEvents() {
-	currentServices := s.getCurrentServices()
-
-	for name, status := range currentServices {
-		if oldStatus, exists := s.knownServices[name]; exists {
-			if oldStatus != status {
-				s.emitEvent(SystemEvent{
-					Type:      EventService,
-					Message:   fmt.Sprintf("Service %s changed from %s to %s", name, oldStatus, status),
-					Timestamp: time.Now(),
-					Severity:  s.getServiceSeverity(status),
-					Source:    "service_monitor",
-				})
+		report(err)
+		for _, event := range events {
+			if s.IsEventWatched(event.Type) {
+				s.emitEvent(event)
 			}
 		}
 	}
-
-	s.knownServices = currentServices
-}
-
-// checkErrorEvents monitors for system errors
-func (s *Service) checkErrorEvents() {
-	// Check system event log for recent errors
-	cmd := exec.Command("powershell", "-Command",
-		fmt.Sprintf("Get-WinEvent -FilterHashtable @{LogName='System'; Level=1,2; StartTime='%s'} -MaxEvents 5 | Select-Object TimeCreated, LevelDisplayName, Message",
-			time.Now().Add(-time.Duration(s.config.Events.PollingInterval*2)*time.Second).Format("2006-01-02T15:04:05")))
-
-	output, err := cmd.Output()
-	if err != nil {
-		return
-	}
-
-	errorEvents := s.parseErrorEvents(string(output))
-	for _, event := range errorEvents {
-		s.emitEvent(event)
-	}
-}
-
-// Helper methods for system information gathering
-
-func (s *Service) getSystemBootTime() time.Time {
-	cmd := exec.Command("powershell", "-Command", "(Get-CimInstance -ClassName Win32_OperatingSystem).LastBootUpTime")
-	output, err := cmd.Output()
-	if err != nil {
-		return time.Now()
-	}
-
-	bootTimeStr := strings.TrimSpace(string(output))
-	bootTime, err := time.Parse("2006-01-02 15:04:05", bootTimeStr[:19])
-	if err != nil {
-		return time.Now()
-	}
-
-	return bootTime
-}
-
-func (s *Service) getCurrentProcesses() []string {
-	cmd := exec.Command("powershell", "-Command", "Get-Process | Select-Object -ExpandProperty ProcessName")
-	output, err := cmd.Output()
-	if err != nil {
-		return []string{}
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	processes := make([]string, 0, len(lines))
-	for _, line := range lines {
-		if proc := strings.TrimSpace(line); proc != "" {
-			processes = append(processes, proc)
-		}
-	}
-
-	return processes
-}
-
-func (s *Service) getCurrentServices() map[string]string {
-	cmd := exec.Command("powershell", "-Command", "Get-Service | Select-Object Name, Status")
-	output, err := cmd.Output()
-	if err != nil {
-		return map[string]string{}
-	}
-
-	services := make(map[string]string)
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-
-	for _, line := range lines[3:] { // Skip header lines
-		if fields := strings.Fields(line); len(fields) >= 2 {
-			name := fields[0]
-			status := fields[len(fields)-1]
-			services[name] = status
-		}
-	}
-
-	return services
-}
-
-// Event parsing methods
-
-func (s *Service) parseLogonEvents(output string) []SystemEvent {
-	var events []SystemEvent
-	lines := strings.Split(output, "\n")
-
-	for _, line := range lines {
-		if strings.Contains(line, "4624") { // Logon
-			events = append(events, SystemEvent{
-				Type:      EventLogin,
-				Message:   "User logged in",
-				Details:   line,
-				Timestamp: time.Now(),
-				Severity:  "info",
-				Source:    "security_log",
-			})
-		} else if strings.Contains(line, "4634") { // Logoff
-			events = append(events, SystemEvent{
-				Type:      EventLogout,
-				Message:   "User logged out",
-				Details:   line,
-				Timestamp: time.Now(),
-				Severity:  "info",
-				Source:    "security_log",
-			})
-		}
-	}
-
-	return events
-}
-
-func (s *Service) parseErrorEvents(output string) []SystemEvent {
-	var events []SystemEvent
-	lines := strings.Split(output, "\n")
-
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		severity := "warning"
-		if strings.Contains(strings.ToLower(line), "error") || strings.Contains(strings.ToLower(line), "critical") {
-			severity = "error"
-		}
-
-		events = append(events, SystemEvent{
-			Type:      EventError,
-			Message:   "System error detected",
-			Details:   line,
-			Timestamp: time.Now(),
-			Severity:  severity,
-			Source:    "system_log",
-		})
-	}
-
-	return events
-}
-
-// Helper methods
-
-func (s *Service) getServiceSeverity(status string) string {
-	switch strings.ToLower(status) {
-	case "stopped":
-		return "warning"
-	case "running":
-		return "info"
-	default:
-		return "info"
-	}
 }
 
 // emitEvent sends an event to all registered handlers
@@ -415,6 +200,10 @@ func (s *Service) emitEvent(event SystemEvent) {
 	s.mu.RUnlock()
 
 	for _, handler := range handlers {
-		go handler(event)
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			handler(event)
+		}()
 	}
 }
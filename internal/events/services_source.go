@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/services"
+)
+
+// serviceSource reports state changes of the services of the host: systemd
+// units on Linux, the Service Control Manager on Windows
+type serviceSource struct {
+	manager services.Manager
+	timeout time.Duration
+	known   map[string]string
+}
+
+func newServiceSource(cfg *config.Config) EventSource {
+	return &serviceSource{manager: services.New(), timeout: time.Duration(cfg.Services.Timeout) * time.Second}
+}
+
+func (s *serviceSource) Name() string { return "services" }
+
+func (s *serviceSource) Init(ctx context.Context) error {
+	states, err := s.states(ctx)
+	if err != nil {
+		return err
+	}
+	s.known = states
+	return nil
+}
+
+func (s *serviceSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	states, err := s.states(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []SystemEvent
+	for name, state := range states {
+		if old, ok := s.known[name]; ok && old != state {
+			events = append(events, SystemEvent{
+				Type:      EventService,
+				Message:   fmt.Sprintf("Service %s changed from %s to %s", name, old, state),
+				Timestamp: time.Now(),
+				Severity:  serviceSeverity(state),
+				Source:    "service_monitor",
+			})
+		}
+	}
+	s.known = states
+	return events, nil
+}
+
+// states maps every service to its state
+func (s *serviceSource) states(ctx context.Context) (map[string]string, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	list, err := s.manager.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]string, len(list))
+	for _, service := range list {
+		states[service.Name] = service.State
+	}
+	return states, nil
+}
+
+// serviceSeverity rates a service entering a state
+func serviceSeverity(state string) string {
+	switch state {
+	case services.StateStopped:
+		return SeverityWarning
+	case services.StateFailed:
+		return SeverityError
+	default:
+		return SeverityInfo
+	}
+}
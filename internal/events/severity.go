@@ -0,0 +1,24 @@
+package events
+
+import "slices"
+
+// Severities of events
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityError    = "error"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the severities from the least to the most severe
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityError, SeverityCritical}
+
+// SeveritiesFrom returns min and every more severe severity, or nil for an
+// unknown one
+func SeveritiesFrom(min string) []string {
+	i := slices.Index(Severities, min)
+	if i < 0 {
+		return nil
+	}
+	return slices.Clone(Severities[i:])
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+// EventSource watches one part of the system. Init remembers its current
+// state, so that Poll reports only what changed since the previous call.
+type EventSource interface {
+	// Name identifies the source in logs and in events.intervals
+	Name() string
+	Init(ctx context.Context) error
+	Poll(ctx context.Context) ([]SystemEvent, error)
+}
+
+// SourceFactory creates a source for a configuration
+type SourceFactory func(cfg *config.Config) EventSource
+
+// registration is a source the registry can create
+type registration struct {
+	name    string
+	types   []EventType
+	factory SourceFactory
+}
+
+// Registry knows the sources of the system and the event types each of them
+// emits
+type Registry struct {
+	sources []registration
+}
+
+// NewRegistry returns a registry of the sources available on this system
+func NewRegistry() *Registry {
+	r := &Registry{}
+	registerPlatformSources(r)
+	return r
+}
+
+// Register adds a source emitting types. A source registered again under
+// the same name replaces the previous one.
+func (r *Registry) Register(name string, types []EventType, factory SourceFactory) {
+	r.sources = slices.DeleteFunc(r.sources, func(reg registration) bool { return reg.name == name })
+	r.sources = append(r.sources, registration{name: name, types: types, factory: factory})
+}
+
+// Sources creates the sources that emit at least one type in
+// events.watch_events
+func (r *Registry) Sources(cfg *config.Config) []EventSource {
+	var sources []EventSource
+	for _, reg := range r.sources {
+		if slices.ContainsFunc(reg.types, func(t EventType) bool { return cfg.IsEventWatched(string(t)) }) {
+			sources = append(sources, reg.factory(cfg))
+		}
+	}
+	return sources
+}
+
+// commandRunner runs a command and returns its standard output. Tests
+// replace it with a stub.
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// execCommand runs a command on the host, with its error output in the
+// returned error
+func execCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return out, fmt.Errorf("%s: %w", bytes.TrimSpace(exitErr.Stderr), err)
+	}
+	return out, err
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/services"
+)
+
+// stubSource fails to initialize once, then reports the events queued for it
+type stubSource struct {
+	mu      sync.Mutex
+	inits   int
+	pending []SystemEvent
+}
+
+func (s *stubSource) Name() string { return "stub" }
+
+func (s *stubSource) Init(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inits++
+	if s.inits == 1 {
+		return errors.New("not yet")
+	}
+	return nil
+}
+
+func (s *stubSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.pending
+	s.pending = nil
+	return events, nil
+}
+
+func TestRegistrySources(t *testing.T) {
+	r := &Registry{}
+	created := map[string]int{}
+	register := func(name string, types ...EventType) {
+		r.Register(name, types, func(*config.Config) EventSource {
+			created[name]++
+			return &stubSource{}
+		})
+	}
+	register("sessions", EventLogin, EventLogout)
+	register("processes", EventProcess)
+	register("journal", EventError)
+	register("journal", EventError, EventService)
+
+	cfg := &config.Config{}
+	cfg.Events.WatchEvents = []string{"logout", "service"}
+	if sources := r.Sources(cfg); len(sources) != 2 {
+		t.Fatalf("Expected the sessions and journal sources, got %d", len(sources))
+	}
+	if created["sessions"] != 1 || created["journal"] != 1 || created["processes"] != 0 {
+		t.Errorf("Unexpected sources created: %v", created)
+	}
+}
+
+func TestServiceWatch(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Events.Enabled = true
+	cfg.Events.PollingInterval = 3600
+	cfg.Events.WatchEvents = []string{"process"}
+	cfg.Events.Intervals = map[string]int{"stub": 1}
+
+	source := &stubSource{pending: []SystemEvent{
+		{Type: EventProcess, Message: "New process started: worker"},
+		{Type: EventLogin, Message: "User root logged in"},
+	}}
+	r := &Registry{}
+	r.Register("stub", []EventType{EventProcess}, func(*config.Config) EventSource { return source })
+
+	s := newService(cfg, r)
+	received := make(chan SystemEvent, 10)
+	s.AddHandler(func(event SystemEvent) { received <- event })
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	var messages []string
+	timeout := time.After(5 * time.Second)
+	for len(messages) < 2 {
+		select {
+		case event := <-received:
+			messages = append(messages, event.Message)
+		case <-timeout:
+			t.Fatalf("Expected the startup event and the process, got %v", messages)
+		}
+	}
+	select {
+	case event := <-received:
+		t.Errorf("Expected the unwatched login dropped, got %q", event.Message)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if source.inits != 2 {
+		t.Errorf("Expected the failed Init retried once, got %d calls", source.inits)
+	}
+}
+
+func TestServiceStopWaitsForHandlers(t *testing.T) {
+	cfg := &config.Config{}
+	s := newService(cfg, &Registry{})
+
+	var mu sync.Mutex
+	var handled []EventType
+	s.AddHandler(func(event SystemEvent) {
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, event.Type)
+	})
+	s.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 || handled[0] != EventShutdown {
+		t.Errorf("Expected the shutdown event handled before Stop returned, got %v", handled)
+	}
+}
+
+// listManager reports the states of its list; nothing else is used
+type listManager struct {
+	services.Manager
+	list []services.Status
+}
+
+func (m *listManager) List(context.Context) ([]services.Status, error) { return m.list, nil }
+
+func TestServiceSource(t *testing.T) {
+	manager := &listManager{list: []services.Status{
+		{Name: "nginx", State: services.StateRunning},
+		{Name: "cron", State: services.StateRunning},
+	}}
+	source := &serviceSource{manager: manager}
+	ctx := context.Background()
+	if err := source.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	manager.list = []services.Status{
+		{Name: "nginx", State: services.StateFailed},
+		{Name: "cron", State: services.StateRunning},
+		{Name: "sshd", State: services.StateRunning},
+	}
+	events, err := source.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Message != "Service nginx changed from running to failed" || events[0].Severity != SeverityError {
+		t.Errorf("Expected the failed nginx reported, got %+v", events)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package events
+
+import "github.com/cupbot/cupbot/internal/config"
+
+// registerPlatformSources registers the Linux sources: logins from wtmp,
+// processes from /proc, systemd units and error entries of the journal
+func registerPlatformSources(r *Registry) {
+	r.Register("sessions", []EventType{EventLogin, EventLogout}, func(*config.Config) EventSource {
+		return newSessionSource(utmpPath, wtmpPath)
+	})
+	r.Register("processes", []EventType{EventProcess}, func(*config.Config) EventSource {
+		return newProcSource(procRoot)
+	})
+	r.Register("services", []EventType{EventService}, newServiceSource)
+	r.Register("journal", []EventType{EventError}, func(*config.Config) EventSource {
+		return newJournalSource(nil)
+	})
+}
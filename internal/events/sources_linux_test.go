@@ -0,0 +1,186 @@
+//go:build linux
+
+package events
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// utmpBytes encodes a utmp record as glibc writes it
+func utmpBytes(recordType int16, line, user, host string, at time.Time) []byte {
+	b := make([]byte, utmpRecordSize)
+	binary.NativeEndian.PutUint16(b[0:2], uint16(recordType))
+	binary.NativeEndian.PutUint32(b[4:8], 4242)
+	copy(b[8:40], line)
+	copy(b[44:76], user)
+	copy(b[76:332], host)
+	binary.NativeEndian.PutUint32(b[340:344], uint32(at.Unix()))
+	return b
+}
+
+func appendFile(t *testing.T, path string, data ...[]byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, d := range data {
+		if _, err := f.Write(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSessionSource(t *testing.T) {
+	dir := t.TempDir()
+	utmp, wtmp := filepath.Join(dir, "utmp"), filepath.Join(dir, "wtmp")
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local)
+
+	// alice is logged in before the bot starts, her login is old history
+	appendFile(t, utmp, utmpBytes(utmpUserProcess, "pts/0", "alice", "", at))
+	appendFile(t, wtmp, utmpBytes(utmpUserProcess, "pts/0", "alice", "", at))
+
+	source := newSessionSource(utmp, wtmp)
+	ctx := context.Background()
+	if err := source.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if events, err := source.Poll(ctx); err != nil || len(events) != 0 {
+		t.Fatalf("Expected nothing new, got %+v, %v", events, err)
+	}
+
+	appendFile(t, wtmp,
+		utmpBytes(utmpUserProcess, "pts/1", "bob", "10.0.0.5", at.Add(time.Minute)),
+		utmpBytes(utmpDeadProcess, "pts/0", "", "", at.Add(2*time.Minute)),
+		utmpBytes(utmpDeadProcess, "tty9", "", "", at.Add(2*time.Minute)),
+	)
+	events, err := source.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected bob's login and alice's logout, got %+v", events)
+	}
+	if events[0].Type != EventLogin || events[0].Message != "User bob logged in" || events[0].Details != "bob on pts/1 from 10.0.0.5" || !events[0].Timestamp.Equal(at.Add(time.Minute)) {
+		t.Errorf("Unexpected login %+v", events[0])
+	}
+	if events[1].Type != EventLogout || events[1].Message != "User alice logged out" {
+		t.Errorf("Unexpected logout %+v", events[1])
+	}
+
+	// A rotated wtmp is read from the start
+	if err := os.WriteFile(wtmp, utmpBytes(utmpDeadProcess, "pts/1", "", "", at.Add(time.Hour)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, err = source.Poll(ctx)
+	if err != nil || len(events) != 1 || events[0].Message != "User bob logged out" {
+		t.Errorf("Expected bob's logout after rotation, got %+v, %v", events, err)
+	}
+}
+
+func writeProc(t *testing.T, root string, pid int, name string, start int, cmdline string) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stat := fmt.Sprintf("%d (%s) S 1 1 1 0 -1 4194560 100 0 0 0 1 1 0 0 20 0 1 0 %d 1000 100", pid, name, start)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(strings.ReplaceAll(cmdline, " ", "\x00")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcSource(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, 1, "systemd", 1, "/sbin/init")
+	writeProc(t, root, 200, "bash", 500, "bash")
+
+	source := newProcSource(root)
+	ctx := context.Background()
+	if err := source.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// bash exits and its PID goes to a new process
+	writeProc(t, root, 200, "my (odd) name", 900, "python3 worker.py")
+	events, err := source.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := map[string]string{}
+	for _, e := range events {
+		messages[e.Message] = e.Details
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected one start and one exit, got %+v", events)
+	}
+	if details, ok := messages["New process started: my (odd) name"]; !ok || details != "PID 200: python3 worker.py" {
+		t.Errorf("Expected the new process with its command line, got %v", messages)
+	}
+	if details, ok := messages["Process terminated: bash"]; !ok || details != "PID 200" {
+		t.Errorf("Expected bash reported terminated, got %v", messages)
+	}
+
+	if err := os.RemoveAll(filepath.Join(root, "200")); err != nil {
+		t.Fatal(err)
+	}
+	events, err = source.Poll(ctx)
+	if err != nil || len(events) != 1 || events[0].Message != "Process terminated: my (odd) name" {
+		t.Errorf("Expected the exit reported, got %+v, %v", events, err)
+	}
+}
+
+func TestJournalSource(t *testing.T) {
+	var calls [][]string
+	output := `{"__CURSOR":"s=1;i=10","__REALTIME_TIMESTAMP":"1792141800000000","PRIORITY":"6","MESSAGE":"Started"}`
+	run := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		return []byte(output), nil
+	}
+
+	source := newJournalSource(run)
+	ctx := context.Background()
+	if err := source.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	output = `{"__CURSOR":"s=1;i=12","__REALTIME_TIMESTAMP":"1792141860000000","PRIORITY":"3","MESSAGE":"I/O error on sda","SYSLOG_IDENTIFIER":"kernel"}
+{"__CURSOR":"s=1;i=13","__REALTIME_TIMESTAMP":"1792141861000000","PRIORITY":"2","MESSAGE":[102,97,105,108],"_COMM":"smartd","_SYSTEMD_UNIT":"smartd.service","_PID":"77"}
+`
+	events, err := source.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(calls[1], "--after-cursor=s=1;i=10") || !slices.Contains(calls[1], "--priority=err") {
+		t.Errorf("Expected errors after the initial cursor, got %v", calls[1])
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected two entries, got %+v", events)
+	}
+	if events[0].Message != "kernel: I/O error on sda" || events[0].Severity != SeverityError || !events[0].Timestamp.Equal(time.UnixMicro(1792141860000000)) {
+		t.Errorf("Unexpected event %+v", events[0])
+	}
+	if events[1].Message != "smartd: fail" || events[1].Severity != SeverityCritical || events[1].Details != "unit smartd.service, PID 77, priority 2" {
+		t.Errorf("Unexpected event %+v", events[1])
+	}
+
+	output = ""
+	if events, err := source.Poll(ctx); err != nil || len(events) != 0 {
+		t.Errorf("Expected nothing new, got %+v, %v", events, err)
+	}
+	if !slices.Contains(calls[2], "--after-cursor=s=1;i=13") {
+		t.Errorf("Expected the cursor moved to the last entry, got %v", calls[2])
+	}
+}
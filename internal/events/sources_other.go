@@ -0,0 +1,7 @@
+//go:build !linux && !windows
+
+package events
+
+// registerPlatformSources registers nothing: there are no event sources for
+// this system
+func registerPlatformSources(r *Registry) {}
@@ -0,0 +1,202 @@
+//go:build windows
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+// winEventTime is the time format Get-WinEvent filters take
+const winEventTime = "2006-01-02T15:04:05"
+
+// registerPlatformSources registers the Windows sources: logons from the
+// Security log, processes, services and errors from the System log
+func registerPlatformSources(r *Registry) {
+	r.Register("sessions", []EventType{EventLogin, EventLogout}, func(*config.Config) EventSource {
+		return &securityLogSource{run: execCommand}
+	})
+	r.Register("processes", []EventType{EventProcess}, func(*config.Config) EventSource {
+		return &winProcessSource{run: execCommand}
+	})
+	r.Register("services", []EventType{EventService}, newServiceSource)
+	r.Register("system_log", []EventType{EventError}, func(*config.Config) EventSource {
+		return &systemLogSource{run: execCommand}
+	})
+}
+
+// powershell runs a PowerShell command
+func powershell(ctx context.Context, run commandRunner, command string) (string, error) {
+	out, err := run(ctx, "powershell", "-NoProfile", "-Command", command)
+	return string(out), err
+}
+
+// securityLogSource reports logons (4624) and logoffs (4634) from the
+// Security log
+type securityLogSource struct {
+	run       commandRunner
+	lastCheck time.Time
+}
+
+func (s *securityLogSource) Name() string { return "sessions" }
+
+func (s *securityLogSource) Init(ctx context.Context) error {
+	s.lastCheck = time.Now()
+	return nil
+}
+
+func (s *securityLogSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	checked := time.Now()
+	output, err := powershell(ctx, s.run, fmt.Sprintf(
+		"Get-WinEvent -FilterHashtable @{LogName='Security'; ID=4624,4634; StartTime='%s'} -MaxEvents 10 -ErrorAction SilentlyContinue | Select-Object TimeCreated, Id, Message",
+		s.lastCheck.Format(winEventTime)))
+	if err != nil {
+		return nil, err
+	}
+	s.lastCheck = checked
+	return parseLogonEvents(output), nil
+}
+
+// winProcessSource reports process names that appeared or disappeared
+type winProcessSource struct {
+	run   commandRunner
+	known map[string]bool
+}
+
+func (s *winProcessSource) Name() string { return "processes" }
+
+func (s *winProcessSource) Init(ctx context.Context) error {
+	known, err := s.processes(ctx)
+	if err != nil {
+		return err
+	}
+	s.known = known
+	return nil
+}
+
+func (s *winProcessSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	current, err := s.processes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []SystemEvent
+	for proc := range current {
+		if !s.known[proc] {
+			events = append(events, SystemEvent{
+				Type:      EventProcess,
+				Message:   fmt.Sprintf("New process started: %s", proc),
+				Timestamp: time.Now(),
+				Severity:  SeverityInfo,
+				Source:    "process_monitor",
+			})
+		}
+	}
+	for proc := range s.known {
+		if !current[proc] {
+			events = append(events, SystemEvent{
+				Type:      EventProcess,
+				Message:   fmt.Sprintf("Process terminated: %s", proc),
+				Timestamp: time.Now(),
+				Severity:  SeverityInfo,
+				Source:    "process_monitor",
+			})
+		}
+	}
+	s.known = current
+	return events, nil
+}
+
+func (s *winProcessSource) processes(ctx context.Context) (map[string]bool, error) {
+	output, err := powershell(ctx, s.run, "Get-Process | Select-Object -ExpandProperty ProcessName")
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if proc := strings.TrimSpace(line); proc != "" {
+			processes[proc] = true
+		}
+	}
+	return processes, nil
+}
+
+// systemLogSource reports critical and error entries of the System log
+type systemLogSource struct {
+	run       commandRunner
+	lastCheck time.Time
+}
+
+func (s *systemLogSource) Name() string { return "system_log" }
+
+func (s *systemLogSource) Init(ctx context.Context) error {
+	s.lastCheck = time.Now()
+	return nil
+}
+
+func (s *systemLogSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	checked := time.Now()
+	output, err := powershell(ctx, s.run, fmt.Sprintf(
+		"Get-WinEvent -FilterHashtable @{LogName='System'; Level=1,2; StartTime='%s'} -MaxEvents 5 -ErrorAction SilentlyContinue | Select-Object TimeCreated, LevelDisplayName, Message",
+		s.lastCheck.Format(winEventTime)))
+	if err != nil {
+		return nil, err
+	}
+	s.lastCheck = checked
+	return parseErrorEvents(output), nil
+}
+
+func parseLogonEvents(output string) []SystemEvent {
+	var events []SystemEvent
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "4624") { // Logon
+			events = append(events, SystemEvent{
+				Type:      EventLogin,
+				Message:   "User logged in",
+				Details:   line,
+				Timestamp: time.Now(),
+				Severity:  SeverityInfo,
+				Source:    "security_log",
+			})
+		} else if strings.Contains(line, "4634") { // Logoff
+			events = append(events, SystemEvent{
+				Type:      EventLogout,
+				Message:   "User logged out",
+				Details:   line,
+				Timestamp: time.Now(),
+				Severity:  SeverityInfo,
+				Source:    "security_log",
+			})
+		}
+	}
+	return events
+}
+
+func parseErrorEvents(output string) []SystemEvent {
+	var events []SystemEvent
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		severity := SeverityWarning
+		if strings.Contains(strings.ToLower(line), "error") || strings.Contains(strings.ToLower(line), "critical") {
+			severity = SeverityError
+		}
+
+		events = append(events, SystemEvent{
+			Type:      EventError,
+			Message:   "System error detected",
+			Details:   line,
+			Timestamp: time.Now(),
+			Severity:  severity,
+			Source:    "system_log",
+		})
+	}
+	return events
+}
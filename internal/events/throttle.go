@@ -0,0 +1,270 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+const (
+	// rateWindow is the period events.throttle rate limits count in
+	rateWindow = time.Minute
+	// digestLines caps the events a digest lists
+	digestLines = 50
+	// digestSource is the source of digest events
+	digestSource = "digest"
+	// flushInterval is how often due digests are sent
+	flushInterval = 5 * time.Second
+)
+
+// Throttle passes events to a handler through the throttling of
+// events.throttle and sends digests as they become due. It is meant for
+// notifications; events are recorded and counted before it.
+type Throttle struct {
+	handler EventHandler
+
+	mu        sync.Mutex
+	throttler *throttler
+	stop      chan struct{} // nil unless started
+	done      chan struct{}
+}
+
+// NewThrottle creates a throttle in front of handler
+func NewThrottle(cfg *config.Config, handler EventHandler) *Throttle {
+	return &Throttle{handler: handler, throttler: newThrottler(cfg)}
+}
+
+// Start sends due digests in the background until Stop
+func (t *Throttle) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != nil {
+		return
+	}
+	t.stop, t.done = make(chan struct{}), make(chan struct{})
+	go t.run(t.stop, t.done)
+}
+
+// Stop stops sending digests in the background and sends the pending ones
+// right away
+func (t *Throttle) Stop() {
+	t.mu.Lock()
+	stop, done := t.stop, t.done
+	t.stop, t.done = nil, nil
+	t.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	t.flush(true)
+}
+
+// Publish passes an event to the handler unless the throttler holds it back
+func (t *Throttle) Publish(event SystemEvent) {
+	t.mu.Lock()
+	event, ok := t.throttler.Process(event, time.Now())
+	t.mu.Unlock()
+	if ok {
+		t.handler(event)
+	}
+}
+
+func (t *Throttle) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.flush(false)
+		}
+	}
+}
+
+// flush passes on the digests that are due, or all pending ones
+func (t *Throttle) flush(all bool) {
+	t.mu.Lock()
+	digests := t.throttler.Flush(time.Now(), all)
+	t.mu.Unlock()
+
+	for _, digest := range digests {
+		t.handler(digest)
+	}
+}
+
+// throttler collapses duplicates, rate limits event types and batches
+// low-severity events into digests, as events.throttle configures for each
+// type. Critical events pass unchanged.
+type throttler struct {
+	config  *config.Config
+	seen    map[string]*dedupEntry
+	windows map[EventType]*rateCount
+	batches map[EventType]*batch
+}
+
+// dedupEntry counts the duplicates of an event until its window ends
+type dedupEntry struct {
+	until   time.Time
+	repeats int
+}
+
+// rateCount counts the events of a type passed on in the current window
+type rateCount struct {
+	start time.Time
+	count int
+}
+
+// batch collects events for a digest
+type batch struct {
+	due      time.Time
+	count    int
+	severity string
+	events   []SystemEvent
+}
+
+func newThrottler(cfg *config.Config) *throttler {
+	return &throttler{
+		config:  cfg,
+		seen:    make(map[string]*dedupEntry),
+		windows: make(map[EventType]*rateCount),
+		batches: make(map[EventType]*batch),
+	}
+}
+
+// Process returns the event to pass on now, if any. An event passed on
+// after its duplicates were collapsed carries their number in Repeats.
+func (t *throttler) Process(event SystemEvent, now time.Time) (SystemEvent, bool) {
+	if event.Severity == SeverityCritical {
+		return event, true
+	}
+	throttle := t.config.EventThrottle(string(event.Type))
+
+	if throttle.DedupWindow > 0 {
+		key := string(event.Type) + "\x00" + event.Source + "\x00" + event.Message
+		entry, ok := t.seen[key]
+		if ok && now.Before(entry.until) {
+			entry.repeats++
+			return SystemEvent{}, false
+		}
+		if ok {
+			event.Repeats = entry.repeats
+		}
+		t.seen[key] = &dedupEntry{until: now.Add(time.Duration(throttle.DedupWindow) * time.Second)}
+	}
+
+	if throttle.Digest > 0 && severityRank(event.Severity) <= severityRank(digestSeverity(throttle)) {
+		t.add(event, now.Add(time.Duration(throttle.Digest)*time.Second))
+		return SystemEvent{}, false
+	}
+
+	if throttle.RateLimit > 0 {
+		window, ok := t.windows[event.Type]
+		if !ok || now.Sub(window.start) >= rateWindow {
+			window = &rateCount{start: now}
+			t.windows[event.Type] = window
+		}
+		if window.count >= throttle.RateLimit {
+			// The rest of the window goes out as one digest when it ends,
+			// or with the regular digest of the type
+			due := window.start.Add(rateWindow)
+			if throttle.Digest > 0 {
+				due = now.Add(time.Duration(throttle.Digest) * time.Second)
+			}
+			t.add(event, due)
+			return SystemEvent{}, false
+		}
+		window.count++
+	}
+
+	return event, true
+}
+
+// Flush returns the digests that are due, or all of them when all is set.
+// A batch of one event is passed on as that event.
+func (t *throttler) Flush(now time.Time, all bool) []SystemEvent {
+	var digests []SystemEvent
+	for eventType, b := range t.batches {
+		if !all && now.Before(b.due) {
+			continue
+		}
+		delete(t.batches, eventType)
+		if b.count == 1 {
+			digests = append(digests, b.events[0])
+			continue
+		}
+		digests = append(digests, b.digest(eventType, now))
+	}
+	slices.SortFunc(digests, func(a, b SystemEvent) int { return strings.Compare(string(a.Type), string(b.Type)) })
+
+	// Forget duplicates once a window as long as their own has passed
+	// without them
+	for key, entry := range t.seen {
+		if now.Sub(entry.until) > time.Duration(t.config.EventThrottle(strings.SplitN(key, "\x00", 2)[0]).DedupWindow)*time.Second {
+			delete(t.seen, key)
+		}
+	}
+	return digests
+}
+
+// add puts an event into the batch of its type, which is due at due
+// unless it already was due earlier
+func (t *throttler) add(event SystemEvent, due time.Time) {
+	b, ok := t.batches[event.Type]
+	if !ok {
+		b = &batch{due: due, severity: event.Severity}
+		t.batches[event.Type] = b
+	}
+	if due.Before(b.due) {
+		b.due = due
+	}
+	if severityRank(event.Severity) > severityRank(b.severity) {
+		b.severity = event.Severity
+	}
+	b.count++
+	if len(b.events) < digestLines {
+		b.events = append(b.events, event)
+	}
+}
+
+// digest summarizes a batch in one event
+func (b *batch) digest(eventType EventType, now time.Time) SystemEvent {
+	lines := make([]string, 0, len(b.events)+1)
+	for _, e := range b.events {
+		lines = append(lines, e.Timestamp.Format("15:04:05")+" "+e.Message)
+	}
+	if more := b.count - len(b.events); more > 0 {
+		lines = append(lines, fmt.Sprintf("... and %d more", more))
+	}
+
+	return SystemEvent{
+		Type:      eventType,
+		Message:   fmt.Sprintf("Digest of %d %s events", b.count, eventType),
+		Details:   strings.Join(lines, "\n"),
+		Timestamp: now,
+		Severity:  b.severity,
+		Source:    digestSource,
+		Count:     b.count,
+	}
+}
+
+// digestSeverity returns the most severe severity a throttle batches
+func digestSeverity(throttle config.EventThrottle) string {
+	if throttle.DigestSeverity == "" {
+		return SeverityInfo
+	}
+	return throttle.DigestSeverity
+}
+
+// severityRank orders severities; unknown ones rank as info
+func severityRank(severity string) int {
+	return max(slices.Index(Severities, severity), 0)
+}
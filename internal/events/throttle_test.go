@@ -0,0 +1,154 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+func throttleConfig(throttle map[string]config.EventThrottle) *config.Config {
+	cfg := &config.Config{}
+	cfg.Events.Throttle = throttle
+	return cfg
+}
+
+func TestThrottlerDedup(t *testing.T) {
+	th := newThrottler(throttleConfig(map[string]config.EventThrottle{"*": {DedupWindow: 60}}))
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	failed := SystemEvent{Type: EventService, Message: "Service nginx changed from running to failed", Severity: SeverityError, Source: "service_monitor"}
+
+	if _, ok := th.Process(failed, now); !ok {
+		t.Fatal("Expected the first event passed on")
+	}
+	for i := 1; i <= 3; i++ {
+		if _, ok := th.Process(failed, now.Add(time.Duration(i)*time.Second)); ok {
+			t.Fatal("Expected duplicates within the window collapsed")
+		}
+	}
+	// Another message is not a duplicate
+	if _, ok := th.Process(SystemEvent{Type: EventService, Message: "Service cron changed from running to failed"}, now); !ok {
+		t.Error("Expected a different event passed on")
+	}
+
+	event, ok := th.Process(failed, now.Add(2*time.Minute))
+	if !ok || event.Repeats != 3 {
+		t.Errorf("Expected the event after the window with 3 repeats, got %+v, %v", event, ok)
+	}
+
+	// Critical events are never collapsed
+	critical := SystemEvent{Type: EventError, Message: "Disk failure", Severity: SeverityCritical}
+	for range 2 {
+		if _, ok := th.Process(critical, now); !ok {
+			t.Error("Expected critical events passed on")
+		}
+	}
+}
+
+func TestThrottlerRateLimit(t *testing.T) {
+	th := newThrottler(throttleConfig(map[string]config.EventThrottle{"login": {RateLimit: 2}}))
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	passed := 0
+	for i := range 5 {
+		if _, ok := th.Process(SystemEvent{Type: EventLogin, Message: fmt.Sprintf("User u%d logged in", i), Severity: SeverityInfo, Timestamp: now}, now); ok {
+			passed++
+		}
+	}
+	if passed != 2 {
+		t.Errorf("Expected 2 logins passed on, got %d", passed)
+	}
+	if digests := th.Flush(now.Add(30*time.Second), false); len(digests) != 0 {
+		t.Errorf("Expected the overflow held until the window ends, got %+v", digests)
+	}
+
+	digests := th.Flush(now.Add(time.Minute), false)
+	if len(digests) != 1 || digests[0].Count != 3 || digests[0].Source != digestSource {
+		t.Fatalf("Expected a digest of the 3 other logins, got %+v", digests)
+	}
+	if !strings.Contains(digests[0].Details, "09:00:00 User u4 logged in") {
+		t.Errorf("Expected the digest to list the logins, got %q", digests[0].Details)
+	}
+
+	// A new window passes events on again
+	if _, ok := th.Process(SystemEvent{Type: EventLogin, Message: "User u5 logged in"}, now.Add(time.Minute)); !ok {
+		t.Error("Expected a login in the next window passed on")
+	}
+}
+
+func TestThrottlerDigest(t *testing.T) {
+	th := newThrottler(throttleConfig(map[string]config.EventThrottle{
+		"process": {Digest: 3600, DigestSeverity: SeverityWarning},
+	}))
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	for i := range digestLines + 5 {
+		if _, ok := th.Process(SystemEvent{Type: EventProcess, Message: fmt.Sprintf("New process started: p%d", i), Severity: SeverityInfo}, now); ok {
+			t.Fatal("Expected info events batched")
+		}
+	}
+	if _, ok := th.Process(SystemEvent{Type: EventProcess, Message: "Process terminated: db", Severity: SeverityWarning}, now); ok {
+		t.Fatal("Expected warnings batched up to digest_severity")
+	}
+	if _, ok := th.Process(SystemEvent{Type: EventProcess, Message: "Process crashed: db", Severity: SeverityError}, now); !ok {
+		t.Error("Expected errors above digest_severity passed on")
+	}
+	if _, ok := th.Process(SystemEvent{Type: EventProcess, Message: "OOM killer: db", Severity: SeverityCritical}, now); !ok {
+		t.Error("Expected critical events to bypass the digest")
+	}
+
+	if digests := th.Flush(now.Add(59*time.Minute), false); len(digests) != 0 {
+		t.Errorf("Expected no digest before the hour, got %+v", digests)
+	}
+	digests := th.Flush(now.Add(time.Hour), false)
+	if len(digests) != 1 {
+		t.Fatalf("Expected one digest, got %+v", digests)
+	}
+	digest := digests[0]
+	if digest.Count != digestLines+6 || digest.Severity != SeverityWarning || digest.Message != "Digest of 56 process events" {
+		t.Errorf("Unexpected digest %+v", digest)
+	}
+	if !strings.HasSuffix(digest.Details, "... and 6 more") {
+		t.Errorf("Expected the digest list capped, got %q", digest.Details)
+	}
+
+	// Stopping flushes what is pending; a single event goes out as itself
+	th.Process(SystemEvent{Type: EventProcess, Message: "New process started: late", Severity: SeverityInfo}, now)
+	if digests := th.Flush(now, true); len(digests) != 1 || digests[0].Message != "New process started: late" {
+		t.Errorf("Expected the single pending event flushed, got %+v", digests)
+	}
+}
+
+func TestThrottlePublish(t *testing.T) {
+	var mu sync.Mutex
+	var passed []SystemEvent
+	th := NewThrottle(throttleConfig(map[string]config.EventThrottle{
+		"process": {DedupWindow: 300, Digest: 3600},
+	}), func(event SystemEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		passed = append(passed, event)
+	})
+	th.Start()
+
+	// Restarts of the same program are duplicates, other programs are not
+	for _, message := range []string{"New process started: cron", "New process started: cron", "New process started: sshd"} {
+		th.Publish(SystemEvent{Type: EventProcess, Message: message, Severity: SeverityInfo})
+	}
+	th.Publish(SystemEvent{Type: EventProcess, Message: "OOM killer: db", Severity: SeverityCritical, ID: 7})
+
+	mu.Lock()
+	if len(passed) != 1 || passed[0].ID != 7 {
+		t.Errorf("Expected only the critical event passed on at once, got %+v", passed)
+	}
+	mu.Unlock()
+
+	// Stopping sends the pending digest
+	th.Stop()
+	if len(passed) != 2 || passed[1].Count != 2 || passed[1].Source != digestSource {
+		t.Errorf("Expected a digest of cron and sshd on stop, got %+v", passed)
+	}
+}
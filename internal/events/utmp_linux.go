@@ -0,0 +1,161 @@
+//go:build linux
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Session records of the system. utmp holds the current sessions, wtmp
+// every login and logout.
+const (
+	utmpPath = "/var/run/utmp"
+	wtmpPath = "/var/log/wtmp"
+)
+
+// struct utmp of glibc on Linux, which keeps 32-bit times on 64-bit
+// systems for compatibility
+const (
+	utmpRecordSize = 384
+
+	utmpUserProcess = 7
+	utmpDeadProcess = 8
+)
+
+// utmpRecord is the part of a utmp entry sessions need
+type utmpRecord struct {
+	Type int16
+	PID  int32
+	Line string
+	User string
+	Host string
+	Time time.Time
+}
+
+// parseUtmp decodes whole records and ignores a trailing partial one
+func parseUtmp(data []byte) []utmpRecord {
+	records := make([]utmpRecord, 0, len(data)/utmpRecordSize)
+	for ; len(data) >= utmpRecordSize; data = data[utmpRecordSize:] {
+		records = append(records, utmpRecord{
+			Type: int16(binary.NativeEndian.Uint16(data[0:2])),
+			PID:  int32(binary.NativeEndian.Uint32(data[4:8])),
+			Line: cString(data[8:40]),
+			User: cString(data[44:76]),
+			Host: cString(data[76:332]),
+			Time: time.Unix(int64(int32(binary.NativeEndian.Uint32(data[340:344]))),
+				int64(int32(binary.NativeEndian.Uint32(data[344:348])))*int64(time.Microsecond)),
+		})
+	}
+	return records
+}
+
+// cString returns a NUL-padded string
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// sessionSource reports logins and logouts appended to wtmp since the
+// previous poll. A logout record names only the terminal, so the source
+// remembers who logged in on each one, starting from the sessions in utmp.
+type sessionSource struct {
+	utmp   string
+	wtmp   string
+	offset int64
+	users  map[string]string
+}
+
+func newSessionSource(utmp, wtmp string) *sessionSource {
+	return &sessionSource{utmp: utmp, wtmp: wtmp}
+}
+
+func (s *sessionSource) Name() string { return "sessions" }
+
+func (s *sessionSource) Init(ctx context.Context) error {
+	s.users = make(map[string]string)
+	if data, err := os.ReadFile(s.utmp); err == nil {
+		for _, r := range parseUtmp(data) {
+			if r.Type == utmpUserProcess {
+				s.users[r.Line] = r.User
+			}
+		}
+	}
+
+	info, err := os.Stat(s.wtmp)
+	if err != nil {
+		return err
+	}
+	s.offset = info.Size() - info.Size()%utmpRecordSize
+	return nil
+}
+
+func (s *sessionSource) Poll(ctx context.Context) ([]SystemEvent, error) {
+	f, err := os.Open(s.wtmp)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// A rotated wtmp starts over
+	if info.Size() < s.offset {
+		s.offset = 0
+	}
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	records := parseUtmp(data)
+	s.offset += int64(len(records) * utmpRecordSize)
+
+	var events []SystemEvent
+	for _, r := range records {
+		switch r.Type {
+		case utmpUserProcess:
+			s.users[r.Line] = r.User
+			details := fmt.Sprintf("%s on %s", r.User, r.Line)
+			if r.Host != "" {
+				details += " from " + r.Host
+			}
+			events = append(events, SystemEvent{
+				Type:      EventLogin,
+				Message:   fmt.Sprintf("User %s logged in", r.User),
+				Details:   details,
+				Timestamp: r.Time,
+				Severity:  SeverityInfo,
+				Source:    "wtmp",
+				UserID:    r.User,
+			})
+		case utmpDeadProcess:
+			user, ok := s.users[r.Line]
+			if !ok {
+				continue
+			}
+			delete(s.users, r.Line)
+			events = append(events, SystemEvent{
+				Type:      EventLogout,
+				Message:   fmt.Sprintf("User %s logged out", user),
+				Details:   fmt.Sprintf("%s on %s", user, r.Line),
+				Timestamp: r.Time,
+				Severity:  SeverityInfo,
+				Source:    "wtmp",
+				UserID:    user,
+			})
+		}
+	}
+	return events, nil
+}
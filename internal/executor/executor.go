@@ -0,0 +1,263 @@
+// Package executor runs the host commands allowlisted in exec.commands.
+// Commands are started directly, never through a shell, so arguments can't
+// chain or redirect anything.
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+var (
+	// ErrNotAllowed is returned for commands missing from exec.commands
+	ErrNotAllowed = errors.New("command is not allowed")
+	// ErrArgument is returned for arguments no pattern of the command allows
+	ErrArgument = errors.New("argument is not allowed")
+)
+
+// waitDelay bounds the wait for the output of processes left behind by a
+// command killed on timeout
+const waitDelay = 2 * time.Second
+
+// preservedEnv is kept with clear_env so commands can still be found and run
+var preservedEnv = []string{"PATH", "SYSTEMROOT", "WINDIR", "TEMP", "TMP"}
+
+// Result is the outcome of a command that started
+type Result struct {
+	Command   string
+	Args      []string // user arguments
+	Output    string   // combined stdout and stderr, at most exec.max_output bytes
+	Truncated bool     // output was longer than exec.max_output
+	ExitCode  int      // -1 when the command was killed
+	TimedOut  bool
+	Duration  time.Duration
+}
+
+// Command is an allowlisted command with its compiled argument patterns
+type Command struct {
+	config.ExecCommand
+	patterns []*regexp.Regexp
+}
+
+// Usage describes the arguments a command accepts
+func (c *Command) Usage() string {
+	if len(c.AllowArgs) == 0 {
+		return c.Name
+	}
+	return c.Name + " [" + strings.Join(c.AllowArgs, " | ") + "]"
+}
+
+// Service runs allowlisted commands
+type Service struct {
+	config   *config.Config
+	commands []*Command
+	byName   map[string]*Command
+}
+
+// NewService compiles the allowlist. Patterns are validated by config.Load,
+// invalid ones left by hand-built configs match nothing.
+func NewService(cfg *config.Config) *Service {
+	s := &Service{config: cfg, byName: make(map[string]*Command)}
+	for _, ec := range cfg.Exec.Commands {
+		cmd := &Command{ExecCommand: ec}
+		for _, pattern := range ec.AllowArgs {
+			if re, err := regexp.Compile("^(?:" + pattern + ")$"); err == nil {
+				cmd.patterns = append(cmd.patterns, re)
+			}
+		}
+		s.commands = append(s.commands, cmd)
+		s.byName[ec.Name] = cmd
+	}
+	return s
+}
+
+// Enabled reports whether /exec is turned on
+func (s *Service) Enabled() bool {
+	return s.config.Exec.Enabled
+}
+
+// Commands returns the allowlist in config order
+func (s *Service) Commands() []*Command {
+	return s.commands
+}
+
+// Run parses a command line, checks it against the allowlist and runs it.
+// A command that exits with an error still returns a Result; errors are for
+// commands that are not allowed or could not start.
+func (s *Service) Run(ctx context.Context, line string) (*Result, error) {
+	fields, err := SplitArgs(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: empty command", ErrNotAllowed)
+	}
+
+	cmd, ok := s.byName[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotAllowed, fields[0])
+	}
+	args := fields[1:]
+	if err := cmd.check(args); err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(cmd.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = time.Duration(s.config.Exec.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	path := cmd.Path
+	if path == "" {
+		path = cmd.Name
+	}
+	process := exec.CommandContext(ctx, path, append(append([]string(nil), cmd.Args...), args...)...)
+	process.Dir = cmd.WorkingDir
+	if process.Dir == "" {
+		process.Dir = s.config.Exec.WorkingDir
+	}
+	process.Env = s.environment(cmd)
+	process.WaitDelay = waitDelay
+
+	output := &limitedBuffer{limit: s.config.Exec.MaxOutput}
+	process.Stdout = output
+	process.Stderr = output
+
+	start := time.Now()
+	err = process.Run()
+	if process.ProcessState == nil {
+		return nil, fmt.Errorf("failed to start %s: %w", cmd.Name, err)
+	}
+
+	// Exit errors are reported by the exit code
+	result := &Result{
+		Command:  cmd.Name,
+		Args:     args,
+		Duration: time.Since(start),
+		ExitCode: process.ProcessState.ExitCode(),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	result.Output, result.Truncated = output.result()
+	return result, nil
+}
+
+// check validates user arguments against the command patterns
+func (c *Command) check(args []string) error {
+	if c.MaxArgs > 0 && len(args) > c.MaxArgs {
+		return fmt.Errorf("%w: %s takes at most %d arguments", ErrArgument, c.Name, c.MaxArgs)
+	}
+	for _, arg := range args {
+		allowed := false
+		for _, re := range c.patterns {
+			if re.MatchString(arg) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %q for %s", ErrArgument, arg, c.Name)
+		}
+	}
+	return nil
+}
+
+// environment builds the environment of a command: the bot environment or
+// only preservedEnv with clear_env, then exec.env and the command env
+func (s *Service) environment(cmd *Command) []string {
+	var env []string
+	if s.config.Exec.ClearEnv {
+		for _, name := range preservedEnv {
+			if value, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+value)
+			}
+		}
+	} else {
+		env = os.Environ()
+	}
+	for name, value := range s.config.Exec.Env {
+		env = append(env, name+"="+value)
+	}
+	for name, value := range cmd.Env {
+		env = append(env, name+"="+value)
+	}
+	return env
+}
+
+// SplitArgs splits a command line at spaces. Single and double quotes group
+// words; there are no escapes, so Windows paths pass unchanged.
+func SplitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inWord  bool
+		quote   rune
+	)
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it. stdout and
+// stderr are written from separate goroutines.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:max(room, 0)]...)
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	// Keep reading so the command never blocks on a full pipe
+	return len(p), nil
+}
+
+// result returns the output as valid UTF-8; consoles may use other encodings
+func (b *limitedBuffer) result() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.ToValidUTF8(string(b.buf), "�"), b.truncated
+}
@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+func skipOnWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix commands")
+	}
+}
+
+func newTestService(commands ...config.ExecCommand) *Service {
+	return NewService(&config.Config{Exec: config.ExecConfig{
+		Enabled:   true,
+		Timeout:   5,
+		MaxOutput: 1024,
+		Commands:  commands,
+	}})
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"echo hello world", []string{"echo", "hello", "world"}},
+		{"  echo   spaced  ", []string{"echo", "spaced"}},
+		{`echo "two words" 'single quoted'`, []string{"echo", "two words", "single quoted"}},
+		{`dir C:\Users\Public`, []string{"dir", `C:\Users\Public`}},
+		{`echo ""`, []string{"echo", ""}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		args, err := SplitArgs(tt.line)
+		if err != nil {
+			t.Errorf("SplitArgs(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, tt.expected) {
+			t.Errorf("SplitArgs(%q): expected %q, got %q", tt.line, tt.expected, args)
+		}
+	}
+
+	if _, err := SplitArgs(`echo "open`); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+}
+
+func TestRunChecksAllowlist(t *testing.T) {
+	s := newTestService(
+		config.ExecCommand{Name: "echo", AllowArgs: []string{`[a-z]+`}, MaxArgs: 2},
+		config.ExecCommand{Name: "uptime"},
+	)
+
+	tests := []struct {
+		line string
+		err  error
+	}{
+		{"rm -rf /", ErrNotAllowed},
+		{"echo hello; rm", ErrArgument},
+		{"echo Hello", ErrArgument},
+		{"echo a b c", ErrArgument},
+		{"uptime -p", ErrArgument},
+	}
+	for _, tt := range tests {
+		if _, err := s.Run(context.Background(), tt.line); !errors.Is(err, tt.err) {
+			t.Errorf("Run(%q): expected %v, got %v", tt.line, tt.err, err)
+		}
+	}
+}
+
+func TestRunCapturesOutput(t *testing.T) {
+	skipOnWindows(t)
+	s := newTestService(config.ExecCommand{
+		Name:      "say",
+		Path:      "sh",
+		Args:      []string{"-c", `echo "out $1"; echo "err $1" >&2; exit 3`, "say"},
+		AllowArgs: []string{`\w+`},
+	})
+
+	result, err := s.Run(context.Background(), "say hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("Expected exit code 3, got %d", result.ExitCode)
+	}
+	if !strings.Contains(result.Output, "out hello") || !strings.Contains(result.Output, "err hello") {
+		t.Errorf("Expected stdout and stderr, got %q", result.Output)
+	}
+	if result.Truncated || result.TimedOut {
+		t.Errorf("Unexpected result flags: %+v", result)
+	}
+}
+
+func TestRunTruncatesOutput(t *testing.T) {
+	skipOnWindows(t)
+	s := newTestService(config.ExecCommand{Name: "yes", Path: "sh", Args: []string{"-c", "head -c 5000 /dev/zero | tr '\\0' x"}})
+
+	result, err := s.Run(context.Background(), "yes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Truncated || len(result.Output) != 1024 {
+		t.Errorf("Expected 1024 bytes of truncated output, got %d (truncated %v)", len(result.Output), result.Truncated)
+	}
+	if result.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", result.ExitCode)
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	skipOnWindows(t)
+	s := newTestService(config.ExecCommand{Name: "sleep", AllowArgs: []string{`\d+`}, Timeout: 1})
+
+	result, err := s.Run(context.Background(), "sleep 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimedOut || result.ExitCode != -1 {
+		t.Errorf("Expected a killed command, got %+v", result)
+	}
+	if result.Duration.Seconds() > 5 {
+		t.Errorf("Command ran for %s despite the timeout", result.Duration)
+	}
+}
+
+func TestRunEnvironmentAndWorkingDir(t *testing.T) {
+	skipOnWindows(t)
+	t.Setenv("CUPBOT_SECRET", "leaked")
+	dir := t.TempDir()
+
+	s := newTestService(config.ExecCommand{
+		Name:       "env",
+		Path:       "sh",
+		Args:       []string{"-c", `echo "$CUPBOT_SECRET|$GLOBAL|$LOCAL|$(pwd)"`},
+		WorkingDir: dir,
+		Env:        map[string]string{"LOCAL": "local"},
+	})
+	s.config.Exec.ClearEnv = true
+	s.config.Exec.Env = map[string]string{"GLOBAL": "global"}
+
+	result, err := s.Run(context.Background(), "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pwd prints the resolved path where the temp directory is a symlink
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output := strings.TrimSpace(result.Output); output != "|global|local|"+resolved {
+		t.Errorf("Unexpected environment or directory: %q", output)
+	}
+}
+
+func TestRunMissingExecutable(t *testing.T) {
+	s := newTestService(config.ExecCommand{Name: "missing", Path: "cupbot-no-such-command"})
+
+	if _, err := s.Run(context.Background(), "missing"); err == nil {
+		t.Error("Expected an error for a missing executable")
+	}
+}
@@ -0,0 +1,297 @@
+// Package exporter serves metrics in the Prometheus text exposition format
+// on /metrics and a health check on /healthz, without a client library.
+package exporter
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+// checkTimeout limits every health check
+const checkTimeout = 5 * time.Second
+
+// Collector adds metrics to a scrape
+type Collector func(w *Writer)
+
+// Check reports whether a dependency works
+type Check func(ctx context.Context) error
+
+// Server is the HTTP listener of /metrics and /healthz
+type Server struct {
+	config     config.PrometheusConfig
+	server     *http.Server
+	collectors []Collector
+	checkNames []string
+	checks     map[string]Check
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+// NewServer creates the listener; it serves nothing until Start
+func NewServer(cfg config.PrometheusConfig) *Server {
+	s := &Server{
+		config: cfg,
+		checks: make(map[string]Check),
+	}
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// AddCollector adds metrics to every scrape
+func (s *Server) AddCollector(c Collector) {
+	s.collectors = append(s.collectors, c)
+}
+
+// AddCheck adds a dependency to /healthz
+func (s *Server) AddCheck(name string, c Check) {
+	s.checkNames = append(s.checkNames, name)
+	s.checks[name] = c
+}
+
+// Handler returns the endpoints behind basic auth when it is configured
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	mux.HandleFunc("/healthz", s.serveHealth)
+	if s.config.Username == "" {
+		return mux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(s.config.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(s.config.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="cupbot"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Start binds the listen address and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.config.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	log.Printf("Serving metrics on http://%s/metrics", listener.Addr())
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Metrics listener failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Addr returns the bound address, or nil before Start
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Stop shuts the listener down and waits for active requests
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	writer := NewWriter()
+	for _, collect := range s.collectors {
+		collect(writer)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writer.WriteTo(w)
+}
+
+// serveHealth runs every check and answers 503 when one fails
+func (s *Server) serveHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	status := http.StatusOK
+	result := struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}{Status: "ok", Checks: make(map[string]string)}
+	for _, name := range s.checkNames {
+		if err := s.checks[name](ctx); err != nil {
+			status = http.StatusServiceUnavailable
+			result.Status = "fail"
+			result.Checks[name] = err.Error()
+			continue
+		}
+		result.Checks[name] = "ok"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// Writer collects the metric families of one scrape
+type Writer struct {
+	families map[string]*family
+	order    []string
+}
+
+type family struct {
+	help, kind string
+	lines      []string
+}
+
+// NewWriter creates an empty scrape
+func NewWriter() *Writer {
+	return &Writer{families: make(map[string]*family)}
+}
+
+// Gauge adds a sample of a gauge; labels are name, value pairs
+func (w *Writer) Gauge(name, help string, value float64, labels ...string) {
+	w.add(name, help, "gauge", name, value, labels)
+}
+
+// Counter adds a sample of a counter; labels are name, value pairs
+func (w *Writer) Counter(name, help string, value float64, labels ...string) {
+	w.add(name, help, "counter", name, value, labels)
+}
+
+// Histogram adds the buckets, sum and count of a histogram
+func (w *Writer) Histogram(name, help string, h *Histogram, labels ...string) {
+	buckets, counts, sum, count := h.snapshot()
+	for i, bound := range buckets {
+		w.add(name, help, "histogram", name+"_bucket", float64(counts[i]), withLabel(labels, "le", formatValue(bound)))
+	}
+	w.add(name, help, "histogram", name+"_bucket", float64(count), withLabel(labels, "le", "+Inf"))
+	w.add(name, help, "histogram", name+"_sum", sum, labels)
+	w.add(name, help, "histogram", name+"_count", float64(count), labels)
+}
+
+func (w *Writer) add(name, help, kind, sample string, value float64, labels []string) {
+	f := w.families[name]
+	if f == nil {
+		f = &family{help: help, kind: kind}
+		w.families[name] = f
+		w.order = append(w.order, name)
+	}
+	f.lines = append(f.lines, sample+formatLabels(labels)+" "+formatValue(value))
+}
+
+// WriteTo writes the scrape in the text exposition format
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, name := range w.order {
+		f := w.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(f.help), name, f.kind)
+		for _, line := range f.lines {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	n, err := io.WriteString(out, b.String())
+	return int64(n), err
+}
+
+// withLabel returns a copy of labels with one more pair
+func withLabel(labels []string, name, value string) []string {
+	return append(append(make([]string, 0, len(labels)+2), labels...), name, value)
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+escapeLabel(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// Histogram counts observations in cumulative buckets. It is safe for
+// concurrent use.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // upper bounds, ascending
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram creates a histogram with the given upper bounds
+func NewHistogram(buckets ...float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{buckets: sorted, counts: make([]uint64, len(sorted))}
+}
+
+// Observe records a value
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *Histogram) snapshot() ([]float64, []uint64, float64, uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.buckets, append([]uint64(nil), h.counts...), h.sum, h.count
+}
@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+func TestWriter(t *testing.T) {
+	w := NewWriter()
+	w.Counter("cupbot_updates_total", "Updates handled.", 3, "type", "message")
+	w.Gauge("cupbot_host_memory_used_percent", "Used memory.", 41.5)
+	w.Counter("cupbot_updates_total", "Updates handled.", 1, "type", "callback")
+	w.Gauge("cupbot_host_disk_used_percent", "Used disk space.", 12, "mountpoint", `C:\`, "label", "say \"hi\"\n")
+
+	h := NewHistogram(0.5, 0.1)
+	for _, v := range []float64{0.05, 0.2, 2} {
+		h.Observe(v)
+	}
+	w.Histogram("cupbot_update_duration_seconds", "Handler latency.", h)
+
+	var out strings.Builder
+	w.WriteTo(&out)
+	expected := `# HELP cupbot_updates_total Updates handled.
+# TYPE cupbot_updates_total counter
+cupbot_updates_total{type="message"} 3
+cupbot_updates_total{type="callback"} 1
+# HELP cupbot_host_memory_used_percent Used memory.
+# TYPE cupbot_host_memory_used_percent gauge
+cupbot_host_memory_used_percent 41.5
+# HELP cupbot_host_disk_used_percent Used disk space.
+# TYPE cupbot_host_disk_used_percent gauge
+cupbot_host_disk_used_percent{mountpoint="C:\\",label="say \"hi\"\n"} 12
+# HELP cupbot_update_duration_seconds Handler latency.
+# TYPE cupbot_update_duration_seconds histogram
+cupbot_update_duration_seconds_bucket{le="0.1"} 1
+cupbot_update_duration_seconds_bucket{le="0.5"} 2
+cupbot_update_duration_seconds_bucket{le="+Inf"} 3
+cupbot_update_duration_seconds_sum 2.25
+cupbot_update_duration_seconds_count 3
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(config.PrometheusConfig{Listen: "127.0.0.1:0", Username: "prom", Password: "secret"})
+	s.AddCollector(func(w *Writer) { w.Gauge("cupbot_up", "The bot runs.", 1) })
+	dbErr := error(nil)
+	s.AddCheck("telegram", func(ctx context.Context) error { return nil })
+	s.AddCheck("database", func(ctx context.Context) error { return dbErr })
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop(context.Background())
+
+	get := func(path, username, password string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "http://"+s.Addr().String()+path, nil)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	if resp, _ := get("/metrics", "", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", resp.StatusCode)
+	}
+	if resp, _ := get("/metrics", "prom", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a wrong password, got %d", resp.StatusCode)
+	}
+	resp, body := get("/metrics", "prom", "secret")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "cupbot_up 1") || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected the metrics, got %d: %s", resp.StatusCode, body)
+	}
+
+	resp, body = get("/healthz", "prom", "secret")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"status":"ok"`) {
+		t.Errorf("Expected a healthy bot, got %d: %s", resp.StatusCode, body)
+	}
+
+	dbErr = errors.New("database is locked")
+	resp, body = get("/healthz", "prom", "secret")
+	var health struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	json.Unmarshal([]byte(body), &health)
+	if resp.StatusCode != http.StatusServiceUnavailable || health.Status != "fail" || health.Checks["database"] != "database is locked" || health.Checks["telegram"] != "ok" {
+		t.Errorf("Expected the failing database reported, got %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestServerWithoutAuth(t *testing.T) {
+	s := NewServer(config.PrometheusConfig{Listen: "127.0.0.1:0"})
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz open without auth, got %d", rec.Code)
+	}
+}
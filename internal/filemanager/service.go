@@ -1,7 +1,6 @@
 package filemanager
 
 import (
-	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
@@ -10,7 +9,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cupbot/cupbot/internal/callbacks"
 	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/i18n"
+	"github.com/cupbot/cupbot/internal/telegram/render"
+)
+
+// Callback actions of the interactive file manager. Each button carries
+// "fm_<action>_<token>", the token resolving to the path and page.
+const (
+	ActionDir        = "dir"
+	ActionFile       = "file"
+	ActionParent     = "parent"
+	ActionDownload   = "download"
+	ActionPage       = "page"
+	ActionBreadcrumb = "breadcrumb"
 )
 
 // FileInfo represents information about a file or directory
@@ -25,7 +38,8 @@ type FileInfo struct {
 
 // Service provides file management operations
 type Service struct {
-	config *config.Config
+	config    *config.Config
+	callbacks *callbacks.Store
 }
 
 // NewService creates a new file manager service
@@ -513,27 +527,43 @@ func (s *Service) copyFile(src, dst string) error {
 
 // Callback data encoding/decoding utilities
 
-// EncodePathForCallback encodes a file path for use in callback data
-func (s *Service) EncodePathForCallback(path string) string {
-	return base64.URLEncoding.EncodeToString([]byte(path))
+// SetCallbackStore sets the token store used for callback data
+func (s *Service) SetCallbackStore(store *callbacks.Store) {
+	s.callbacks = store
+}
+
+// EncodePathForCallback stores a navigation payload for the user and returns
+// a short token for use in callback data
+func (s *Service) EncodePathForCallback(userID int64, action, path string, page int) (string, error) {
+	if s.callbacks == nil {
+		return "", fmt.Errorf("callback store not configured")
+	}
+	return s.callbacks.Issue(userID, action, filepath.Clean(path), page)
 }
 
-// DecodePathFromCallback decodes a file path from callback data
-func (s *Service) DecodePathFromCallback(encoded string) (string, error) {
-	decoded, err := base64.URLEncoding.DecodeString(encoded)
+// DecodePathFromCallback resolves a token issued to the user for the given
+// action and validates the stored path
+func (s *Service) DecodePathFromCallback(userID int64, action, token string) (*callbacks.Payload, error) {
+	if s.callbacks == nil {
+		return nil, fmt.Errorf("callback store not configured")
+	}
+
+	payload, err := s.callbacks.Resolve(token, userID)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode callback path: %w", err)
+		return nil, err
 	}
-	
-	path := string(decoded)
-	cleanPath := filepath.Clean(path)
-	
+	if payload.Action != action {
+		return nil, fmt.Errorf("callback token issued for %s, not %s", payload.Action, action)
+	}
+
+	payload.Path = filepath.Clean(payload.Path)
+
 	// Validate path security
-	if !s.isDriveAllowed(cleanPath) {
-		return "", fmt.Errorf("access to drive not allowed")
+	if !s.isDriveAllowed(payload.Path) {
+		return nil, fmt.Errorf("access to drive not allowed")
 	}
-	
-	return cleanPath, nil
+
+	return payload, nil
 }
 
 // ValidateCallbackPath validates if a decoded path is safe and accessible
@@ -553,24 +583,24 @@ func (s *Service) ValidateCallbackPath(path string) error {
 	return nil
 }
 
-// GetDriveSelectionResponse returns navigation response for drive selection
-func (s *Service) GetDriveSelectionResponse() *NavigationResponse {
+// GetDriveSelectionResponse returns navigation response for drive selection in lang
+func (s *Service) GetDriveSelectionResponse(lang string) *NavigationResponse {
 	drives := s.GetAvailableDrives()
 	
-	content := "📁 *File Manager - Drive Selection*\n\n"
+	content := i18n.T(lang, "files.drive_selection") + "\n\n"
 	if len(drives) == 0 {
-		content += "❌ No drives available in configuration"
+		content += i18n.T(lang, "files.no_drives")
 		return &NavigationResponse{
 			Content:        content,
 			RequiresUpdate: false,
 		}
 	}
 	
-	content += "💾 **Available Drives:**\n\n"
+	content += i18n.T(lang, "files.available_drives") + "\n\n"
 	for _, drive := range drives {
-		content += fmt.Sprintf("• %s\\\n", drive)
+		content += "• " + render.Escape(drive) + "\n"
 	}
-	content += "\n💡 *Click on a drive below to start browsing:*"
+	content += "\n" + i18n.T(lang, "files.pick_drive")
 	
 	return &NavigationResponse{
 		Content:        content,
@@ -578,8 +608,8 @@ func (s *Service) GetDriveSelectionResponse() *NavigationResponse {
 	}
 }
 
-// GetDirectoryNavigationResponse returns navigation response for directory browsing
-func (s *Service) GetDirectoryNavigationResponse(path string, page int) (*NavigationResponse, error) {
+// GetDirectoryNavigationResponse returns navigation response for directory browsing in lang
+func (s *Service) GetDirectoryNavigationResponse(lang, path string, page int) (*NavigationResponse, error) {
 	if !s.IsValidPath(path) {
 		return nil, fmt.Errorf("invalid or inaccessible path: %s", path)
 	}
@@ -591,7 +621,7 @@ func (s *Service) GetDirectoryNavigationResponse(path string, page int) (*Naviga
 	}
 	
 	context := s.GetNavigationContextWithPagination(path, page, result)
-	content := s.generateDirectoryContent(context, result)
+	content := s.generateDirectoryContent(lang, context, result)
 	
 	return &NavigationResponse{
 		Content:        content,
@@ -600,14 +630,14 @@ func (s *Service) GetDirectoryNavigationResponse(path string, page int) (*Naviga
 	}, nil
 }
 
-// GetFileDetailsResponse returns navigation response for file details
-func (s *Service) GetFileDetailsResponse(filePath string) (*NavigationResponse, error) {
+// GetFileDetailsResponse returns navigation response for file details in lang
+func (s *Service) GetFileDetailsResponse(lang, filePath string) (*NavigationResponse, error) {
 	fileInfo, err := s.GetFileInfo(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 	
-	content := s.generateFileDetailsContent(fileInfo)
+	content := s.generateFileDetailsContent(lang, fileInfo)
 	
 	return &NavigationResponse{
 		Content:        content,
@@ -616,47 +646,44 @@ func (s *Service) GetFileDetailsResponse(filePath string) (*NavigationResponse,
 }
 
 // generateDirectoryContent creates the text content for directory listing
-func (s *Service) generateDirectoryContent(context *NavigationContext, result *PaginatedDirectoryResult) string {
-	content := fmt.Sprintf("📁 *Current Directory*\n`%s`\n\n", context.CurrentPath)
+func (s *Service) generateDirectoryContent(lang string, context *NavigationContext, result *PaginatedDirectoryResult) string {
+	content := i18n.T(lang, "files.current_directory", context.CurrentPath) + "\n\n"
 	
 	// Add breadcrumb path
 	if len(context.Breadcrumbs) > 0 {
-		breadcrumb := "📍 **Path:** "
+		names := make([]string, len(context.Breadcrumbs))
 		for i, item := range context.Breadcrumbs {
-			if i > 0 {
-				breadcrumb += " > "
-			}
-			breadcrumb += item.Name
+			names[i] = item.Name
 		}
-		content += breadcrumb + "\n\n"
+		content += i18n.T(lang, "files.path_label") + " " + render.Escape(strings.Join(names, " > ")) + "\n\n"
 	}
 	
 	// Add directory statistics
-	content += fmt.Sprintf("📊 **Contents:** %d folders, %d files", context.TotalDirectories, context.TotalFiles)
+	content += i18n.T(lang, "files.contents", context.TotalDirectories, context.TotalFiles)
 	
 	// Add pagination info if needed
 	if result.TotalPages > 1 {
-		content += fmt.Sprintf(" (Page %d/%d)", result.CurrentPage, result.TotalPages)
+		content += " " + i18n.T(lang, "files.page", result.CurrentPage, result.TotalPages)
 	}
 	content += "\n\n"
 	
 	if len(result.Files) == 0 {
-		content += "📭 *This directory is empty*\n\n"
+		content += i18n.T(lang, "files.empty_directory") + "\n\n"
 	} else {
-		content += "💡 *Click on any item below to navigate:*\n"
+		content += i18n.T(lang, "files.pick_item") + "\n"
 	}
 	
 	return content
 }
 
 // generateFileDetailsContent creates content for file details view
-func (s *Service) generateFileDetailsContent(fileInfo *FileInfo) string {
-	content := "📄 *File Details*\n\n"
-	content += fmt.Sprintf("📛 **Name:** %s\n", fileInfo.Name)
-	content += fmt.Sprintf("📏 **Size:** %s\n", FormatSize(fileInfo.Size))
-	content += fmt.Sprintf("📅 **Modified:** %s\n", fileInfo.ModTime.Format("2006-01-02 15:04:05"))
-	content += fmt.Sprintf("🔒 **Permissions:** %s\n", fileInfo.Mode)
-	content += fmt.Sprintf("📍 **Path:** `%s`\n\n", fileInfo.Path)
+func (s *Service) generateFileDetailsContent(lang string, fileInfo *FileInfo) string {
+	content := i18n.T(lang, "files.details") + "\n\n"
+	content += i18n.T(lang, "files.details_name", fileInfo.Name) + "\n"
+	content += i18n.T(lang, "files.details_size", FormatSize(fileInfo.Size)) + "\n"
+	content += i18n.T(lang, "files.details_modified", fileInfo.ModTime.Format("2006-01-02 15:04:05")) + "\n"
+	content += i18n.T(lang, "files.details_permissions", fileInfo.Mode) + "\n"
+	content += i18n.T(lang, "files.details_path", fileInfo.Path) + "\n\n"
 	
 	return content
 }
@@ -1,7 +1,7 @@
 package filemanager
 
 import (
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,7 +9,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/cupbot/cupbot/internal/callbacks"
 	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/i18n"
 )
 
 func TestGetParentDirectory(t *testing.T) {
@@ -309,72 +312,108 @@ func TestGetAvailableDrives(t *testing.T) {
 
 // Interactive Navigation Tests
 
-func TestEncodeDecodePathForCallback(t *testing.T) {
+// newCallbackTestService creates a service with a callback store backed by a temp database
+func newCallbackTestService(t *testing.T) (*Service, *callbacks.Store) {
+	tmpFile, err := os.CreateTemp("", "filemanager_test_*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpFile.Close()
+	t.Cleanup(func() { os.Remove(tmpFile.Name()) })
+
+	db, err := database.New(tmpFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
 	service := NewService(&config.Config{
 		FileManager: config.FileManagerConfig{
 			AllowedDrives: []string{"C:", "D:"},
 		},
 	})
+	store := callbacks.NewStore(db, callbacks.DefaultTTL)
+	service.SetCallbackStore(store)
+	return service, store
+}
+
+func TestEncodeDecodePathForCallback(t *testing.T) {
+	service, _ := newCallbackTestService(t)
+	userID := int64(123456789)
 	
 	testCases := []struct {
 		name string
 		path string
+		page int
 	}{
-		{"Simple path", "C:\\Users"},
-		{"Path with spaces", "C:\\Program Files"},
-		{"Deep path", "C:\\Users\\Documents\\Projects"},
-		{"Root path", "C:\\"},
+		{"Simple path", "C:\\Users", 0},
+		{"Path with spaces", "C:\\Program Files", 0},
+		{"Deep path", "C:\\Users\\Documents\\Projects", 0},
+		{"Root path", "C:\\", 0},
+		{"Long path", "C:\\" + strings.Repeat("very_long_directory_name\\", 10), 3},
 	}
 	
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Encode path
-			encoded := service.EncodePathForCallback(tc.path)
-			if encoded == "" {
-				t.Error("Encoded path should not be empty")
+			token, err := service.EncodePathForCallback(userID, ActionPage, tc.path, tc.page)
+			if err != nil {
+				t.Fatalf("Failed to encode path: %v", err)
+			}
+			if token == "" {
+				t.Error("Token should not be empty")
 			}
 			
-			// Verify it's valid base64
-			_, err := base64.URLEncoding.DecodeString(encoded)
-			if err != nil {
-				t.Errorf("Encoded path is not valid base64: %v", err)
+			// Callback data must fit into Telegram's 64-byte limit
+			data := "fm_" + ActionPage + "_" + token
+			if len(data) > 64 {
+				t.Errorf("Callback data too long: %d bytes", len(data))
 			}
 			
-			// Decode path
-			decoded, err := service.DecodePathFromCallback(encoded)
+			payload, err := service.DecodePathFromCallback(userID, ActionPage, token)
 			if err != nil {
 				t.Fatalf("Failed to decode path: %v", err)
 			}
 			
-			// Clean paths for comparison
 			expected := filepath.Clean(tc.path)
-			if decoded != expected {
-				t.Errorf("Decoded path mismatch. Expected: %s, Got: %s", expected, decoded)
+			if payload.Path != expected {
+				t.Errorf("Decoded path mismatch. Expected: %s, Got: %s", expected, payload.Path)
+			}
+			if payload.Page != tc.page {
+				t.Errorf("Decoded page mismatch. Expected: %d, Got: %d", tc.page, payload.Page)
 			}
 		})
 	}
 }
 
 func TestDecodePathFromCallback_Security(t *testing.T) {
-	service := NewService(&config.Config{
-		FileManager: config.FileManagerConfig{
-			AllowedDrives: []string{"C:", "D:"},
-		},
-	})
+	service, _ := newCallbackTestService(t)
+	userID := int64(123456789)
+	
+	issue := func(path string) string {
+		token, err := service.EncodePathForCallback(userID, ActionDir, path, 0)
+		if err != nil {
+			t.Fatalf("Failed to encode path: %v", err)
+		}
+		return token
+	}
 	
 	testCases := []struct {
-		name        string
-		encodedPath string
-		shouldFail  bool
+		name       string
+		userID     int64
+		action     string
+		token      string
+		shouldFail bool
 	}{
-		{"Invalid base64", "invalid-base64!", true},
-		{"Restricted drive", service.EncodePathForCallback("E:\\restricted"), true},
-		{"Valid path", service.EncodePathForCallback("C:\\Windows"), false},
+		{"Unknown token", userID, ActionDir, "invalid-token!", true},
+		{"Restricted drive", userID, ActionDir, issue("E:\\restricted"), true},
+		{"Foreign user", 987654321, ActionDir, issue("C:\\Windows"), true},
+		{"Action mismatch", userID, ActionDownload, issue("C:\\Windows"), true},
+		{"Valid path", userID, ActionDir, issue("C:\\Windows"), false},
 	}
 	
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := service.DecodePathFromCallback(tc.encodedPath)
+			_, err := service.DecodePathFromCallback(tc.userID, tc.action, tc.token)
 			if tc.shouldFail && err == nil {
 				t.Error("Expected decoding to fail, but it succeeded")
 			} else if !tc.shouldFail && err != nil {
@@ -382,6 +421,11 @@ func TestDecodePathFromCallback_Security(t *testing.T) {
 			}
 		})
 	}
+	
+	_, err := service.DecodePathFromCallback(987654321, ActionDir, issue("C:\\Users"))
+	if !errors.Is(err, callbacks.ErrForeignUser) {
+		t.Errorf("Expected ErrForeignUser, got %v", err)
+	}
 }
 
 func TestGetNavigationContext(t *testing.T) {
@@ -604,7 +648,7 @@ func TestGetDriveSelectionResponse(t *testing.T) {
 		},
 	})
 	
-	response := service.GetDriveSelectionResponse()
+	response := service.GetDriveSelectionResponse(i18n.English)
 	
 	if response == nil {
 		t.Fatal("GetDriveSelectionResponse returned nil")
@@ -629,7 +673,7 @@ func TestGetDriveSelectionResponse_NoDrives(t *testing.T) {
 		},
 	})
 	
-	response := service.GetDriveSelectionResponse()
+	response := service.GetDriveSelectionResponse(i18n.English)
 	
 	if response == nil {
 		t.Fatal("GetDriveSelectionResponse returned nil")
@@ -665,7 +709,7 @@ func TestGetDirectoryNavigationResponse(t *testing.T) {
 		FileManager: config.FileManagerConfig{},
 	})
 	
-	response, err := service.GetDirectoryNavigationResponse(tempDir, 1)
+	response, err := service.GetDirectoryNavigationResponse(i18n.English, tempDir, 1)
 	if err != nil {
 		t.Fatalf("GetDirectoryNavigationResponse failed: %v", err)
 	}
@@ -714,7 +758,7 @@ func TestGetFileDetailsResponse(t *testing.T) {
 		FileManager: config.FileManagerConfig{},
 	})
 	
-	response, err := service.GetFileDetailsResponse(tempFile.Name())
+	response, err := service.GetFileDetailsResponse(i18n.English, tempFile.Name())
 	if err != nil {
 		t.Fatalf("GetFileDetailsResponse failed: %v", err)
 	}
@@ -740,6 +784,37 @@ func TestGetFileDetailsResponse(t *testing.T) {
 	}
 }
 
+func TestResponsesEscapeFileNames(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "cupbot_<escape>_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "a_b&<c>.txt")
+	if err := os.WriteFile(filePath, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	service := NewService(&config.Config{})
+
+	listing, err := service.GetDirectoryNavigationResponse(i18n.English, tempDir, 1)
+	if err != nil {
+		t.Fatalf("GetDirectoryNavigationResponse failed: %v", err)
+	}
+	if strings.Contains(listing.Content, "<escape>") || !strings.Contains(listing.Content, "&lt;escape&gt;") {
+		t.Errorf("Directory names should be escaped, got: %s", listing.Content)
+	}
+
+	details, err := service.GetFileDetailsResponse(i18n.English, filePath)
+	if err != nil {
+		t.Fatalf("GetFileDetailsResponse failed: %v", err)
+	}
+	if !strings.Contains(details.Content, "a_b&amp;&lt;c&gt;.txt") {
+		t.Errorf("File names should be escaped, got: %s", details.Content)
+	}
+}
+
 func TestGetNavigationContextWithPagination(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "cupbot_context_test")
@@ -0,0 +1,492 @@
+package i18n
+
+var en = Catalog{
+	"language.name": "English",
+
+	// Errors shared by many replies
+	"error.generic":          "❌ Error: %v",
+	"error.admin_required":   "❌ Access denied: Admin privileges required",
+	"error.unauthorized":     "❌ You are not allowed to use this bot.",
+	"error.unknown_command":  "Unknown command: %s\nUse /help to see the available commands",
+	"error.unknown_action":   "❌ Unknown action",
+	"error.update_interface": "❌ Error updating interface",
+	"action.cancelled":       "❌ Cancelled",
+
+	// Command descriptions in /help and the Telegram menu
+	"cmd.start":         "Start working with the bot",
+	"cmd.help":          "Show this help",
+	"cmd.status":        "Full system status",
+	"cmd.dashboard":     "Pinned status that refreshes itself",
+	"cmd.graph":         "Chart a metric over a period",
+	"cmd.events":        "Browse recorded system events",
+	"cmd.subscribe":     "Subscribe to system events and set up notifications",
+	"cmd.unsubscribe":   "Stop notifications about system events",
+	"cmd.uptime":        "System uptime",
+	"cmd.history":       "Command history (10 by default)",
+	"cmd.cancel":        "Cancel the current action",
+	"cmd.files":         "File manager",
+	"cmd.screenshot":    "Take a desktop screenshot",
+	"cmd.language":      "Change the language of the bot",
+	"cmd.style":         "Edit menus in place or send new messages",
+	"cmd.users":         "List all users",
+	"cmd.stats":         "Bot usage statistics",
+	"cmd.cleanup":       "Delete history and events older than N days (30 by default)",
+	"cmd.exec":          "Run an allowlisted command on the host",
+	"cmd.shell":         "Open an interactive shell on the host",
+	"cmd.shell_kill":    "Kill your shell session",
+	"cmd.ps":            "List processes, sorted by CPU or memory",
+	"cmd.services":      "List the services you may manage",
+	"cmd.service":       "Show or change a service",
+	"cmd.addadmin":      "Make a user an administrator",
+	"cmd.removeadmin":   "Revoke administrator rights",
+	"cmd.banuser":       "Ban a user",
+	"cmd.unbanuser":     "Unban a user",
+	"cmd.deleteuser":    "Delete a user",
+	"usage.path":        "[path]",
+	"usage.days":        "[days]",
+	"usage.exec":        "[command]",
+	"usage.ps":          "[cpu|mem] [filter]",
+	"usage.services":    "[filter]",
+	"usage.graph":       "[cpu|memory|disk|net] [24h] [disk or interface]",
+	"usage.events":      "[type] [severity] [24h|date[..date]]",
+	"usage.subscribe":   "[types] [severity S] [quiet HH:MM-HH:MM|off] [tz Zone]",
+	"usage.unsubscribe": "[types]",
+	"usage.service":     "[name] [status|start|stop|restart|enable|disable]",
+
+	"start.welcome": "🤖 <b>Welcome to CupBot!</b>\n\nHello, %s! This bot lets you manage a computer remotely.\n\n📊 <b>Features:</b>\n• System status\n• Uptime monitoring\n• Command history",
+	"start.admin":   "🔑 <b>You are an administrator!</b>\n• User management\n• Usage statistics\n• Data cleanup",
+	"start.footer":  "📱 <b>Use the buttons below:</b>",
+
+	"help.title":          "📖 <b>Command Help</b>",
+	"help.user_commands":  "<b>Basic commands:</b>",
+	"help.admin_commands": "<b>Administrator commands:</b>",
+	"help.info":           "<b>Information:</b>\n• Every command is saved to the history\n• Only authorized users can use the bot\n• Administrators have extended access",
+
+	"status.error":            "❌ Error getting system information: %v",
+	"status.title":            "💻 <b>System Status</b>",
+	"status.host":             "🖥️ <b>Host:</b> %s",
+	"status.os":               "🔧 <b>OS:</b> %s %s",
+	"status.uptime":           "⏰ <b>Uptime:</b> %s",
+	"status.processes":        "🔄 <b>Processes:</b> %d",
+	"status.cpu":              "🧠 <b>CPU:</b>",
+	"status.cpu_model":        "   • Model: %s",
+	"status.cpu_cores":        "   • Cores: %d",
+	"status.cpu_usage":        "   • Usage: %.1f%%",
+	"status.cpu_temperature":  "   • Temperature: %.1f°C",
+	"status.memory":           "🧮 <b>Memory:</b>",
+	"status.memory_total":     "   • Total: %s",
+	"status.memory_used":      "   • Used: %s (%.1f%%)",
+	"status.memory_available": "   • Available: %s",
+	"status.disks":            "💾 <b>Disks:</b>",
+	"status.disk_space":       "     Total: %s | Free: %s (%.1f%%)",
+	"status.network":          "🌐 <b>Network (active interfaces):</b>",
+	"status.network_traffic":  "     Sent: %s | Received: %s",
+	"status.network_rate":     "     Now: ↑ %s/s | ↓ %s/s",
+	"status.cpu_load":         "   • Load average: %.2f, %.2f, %.2f",
+	"status.collected":        "🕒 Data as of %s (%ds ago)",
+	"uptime.error":            "❌ Error getting uptime: %v",
+	"uptime.response":         "⏰ <b>System uptime:</b> %s",
+	"duration.days":           "%dd %dh %dm",
+	"duration.hours":          "%dh %dm",
+	"duration.minutes":        "%dm",
+	"duration.seconds":        "%ds",
+
+	"history.error": "❌ Error getting history: %v",
+	"history.empty": "📝 Command history is empty",
+	"history.title": "📝 <b>Command History</b> (last %d):",
+	"history.entry": "%d. %s <code>/%s %s</code>\n   <i>Time: %s</i>",
+	"history.exit":  "   <i>Exit code: %d · %v</i>",
+
+	"users.error":      "❌ Error getting the user list: %v",
+	"users.empty":      "👥 The user list is empty",
+	"users.title":      "👥 <b>Users:</b>",
+	"users.role_user":  "User",
+	"users.role_admin": "Administrator",
+	"users.entry":      "%d. %s <b>%s %s</b> (@%s)\n   ID: %d | %s\n   Created: %s",
+
+	"stats.error":               "❌ Error getting statistics: %v",
+	"stats.title":               "📊 <b>Usage Statistics:</b>",
+	"stats.total_users":         "👥 Total users: %v",
+	"stats.active_users":        "🟢 Active users: %v",
+	"stats.total_commands":      "📝 Total commands: %v",
+	"stats.successful_commands": "✅ Successful commands: %v",
+	"stats.recent_commands":     "🕐 Commands in 24 hours: %v",
+	"stats.success_rate":        "📈 Success rate: %.1f%%",
+
+	"cleanup.error": "❌ Error cleaning up data: %v",
+	"cleanup.done":  "🧹 Cleanup finished. Records older than %d days were deleted.",
+
+	"admin.user_id_required":   "❌ A user ID is required. Example: /%s 123456789",
+	"admin.invalid_user_id":    "❌ Invalid user ID",
+	"admin.promoted":           "✅ User %d is now an administrator",
+	"admin.demoted":            "✅ Administrator rights of user %d revoked",
+	"admin.cannot_demote_self": "❌ You can't revoke your own administrator rights",
+	"admin.banned":             "✅ User %d banned",
+	"admin.cannot_ban_self":    "❌ You can't ban yourself",
+	"admin.unbanned":           "✅ User %d unbanned",
+	"admin.deleted":            "✅ User %d deleted",
+	"admin.cannot_delete_self": "❌ You can't delete yourself",
+
+	// Keyboard buttons
+	"button.status":             "💻 System Status",
+	"button.uptime":             "⏰ Uptime",
+	"button.history":            "📝 Command History",
+	"button.files":              "📁 File Manager",
+	"button.screenshot":         "📸 Screenshot",
+	"button.events":             "🔔 Events",
+	"button.users":              "👥 Users",
+	"button.stats":              "📊 Statistics",
+	"button.admin_menu":         "🔑 Admin Menu",
+	"button.menu":               "📜 Menu",
+	"button.main_menu":          "🏠 Main Menu",
+	"button.power_menu":         "🔌 Power Management",
+	"button.user_menu":          "👥 User Management",
+	"button.file_manager_admin": "📁 File Manager+",
+	"button.screenshot_admin":   "📸 Screenshot+",
+	"button.monitoring":         "💻 System Monitoring",
+	"button.system_tools":       "🔧 System Tools",
+	"button.system_events":      "🔔 System Events",
+	"button.event_mute":         "🔕 Mute %s",
+	"button.event_unmute":       "🔔 Unmute %s",
+	"button.event_details":      "📄 Details",
+	"button.event_log":          "📜 Event Log",
+	"button.subscription":       "⚙️ Subscription",
+	"button.subscribe":          "🔔 Subscribe",
+	"button.unsubscribe":        "🔕 Unsubscribe",
+	"button.quiet_hours":        "🌙 %s",
+	"button.quiet_off":          "☀️ No quiet hours",
+	"button.event_acknowledge":  "✅ Acknowledge",
+	"button.back_events":        "🔙 Back to Events",
+	"button.back_admin":         "🔙 Admin Menu",
+	"button.back_menu":          "🔙 Back to Menu",
+	"button.shutdown_now":       "🔴 Shutdown Now",
+	"button.reboot_now":         "🔄 Reboot Now",
+	"button.shutdown_1min":      "⏱️ Shutdown in 1min",
+	"button.reboot_1min":        "⏱️ Reboot in 1min",
+	"button.shutdown_5min":      "⏰ Shutdown in 5min",
+	"button.reboot_5min":        "⏰ Reboot in 5min",
+	"button.shutdown_10min":     "🕒 Shutdown in 10min",
+	"button.reboot_10min":       "🕒 Reboot in 10min",
+	"button.shutdown_30min":     "🕥 Shutdown in 30min",
+	"button.reboot_30min":       "🕥 Reboot in 30min",
+	"button.force_shutdown":     "⚠️ Force Shutdown",
+	"button.force_reboot":       "⚠️ Force Reboot",
+	"button.cancel_power":       "❌ Cancel Operation",
+	"button.power_status":       "ℹ️ Power Status",
+	"button.list_users":         "👥 List All Users",
+	"button.user_stats":         "📊 User Statistics",
+	"button.add_admin":          "➕ Add Administrator",
+	"button.remove_admin":       "➖ Remove Administrator",
+	"button.ban_user":           "🚫 Ban User",
+	"button.unban_user":         "✅ Unban User",
+	"button.delete_user":        "🗑️ Delete User",
+	"button.browse_files":       "📁 Browse Files",
+	"button.prev":               "◀️ Prev",
+	"button.next":               "Next ▶️",
+	"button.page":               "Page %d/%d",
+	"button.up":                 "⬆️ Up",
+	"button.drives":             "🏠 Drives",
+	"button.refresh":            "🔄 Refresh",
+	"button.download":           "⬇️ Download",
+	"button.properties":         "ℹ️ Properties",
+	"button.back_directory":     "🔙 Back to Directory",
+	"button.confirm":            "✅ Confirm",
+	"button.cancel":             "❌ Cancel",
+	"button.language_auto":      "🔄 Telegram language",
+	"button.pause":              "⏸ Pause",
+	"button.resume":             "▶️ Resume",
+	"button.stop":               "⏹ Stop",
+	"button.ctrl_c":             "⛔ Ctrl-C",
+	"button.kill":               "✖️ Kill",
+	"button.terminate":          "⏹ Terminate %d",
+	"button.kill_process":       "💀 Kill %d",
+	"button.back_processes":     "🔙 Back to Processes",
+	"button.sort_cpu":           "⚡ Sort by CPU",
+	"button.sort_mem":           "💾 Sort by Memory",
+	"button.services":           "⚙️ Services",
+	"button.back_services":      "🔙 Back to Services",
+	"button.service_start":      "▶️ Start",
+	"button.service_stop":       "⏹ Stop",
+	"button.service_restart":    "🔄 Restart",
+	"button.service_enable":     "✅ Enable at boot",
+	"button.service_disable":    "🚫 Disable",
+
+	"menu.main":               "🏠 <b>Main Menu</b>\n\nHello, %s! Choose an action:",
+	"menu.menu":               "📜 <b>Menu</b>\n\nHello, %s! Choose an action:",
+	"menu.admin":              "🔑 <b>Admin Menu</b>\n\nSelect an action:",
+	"menu.users":              "👥 <b>User Management</b>\n\nSelect a user management action:",
+	"menu.file_manager_admin": "📁 <b>Enhanced File Manager</b>\n\nAdmin file management features:\n\n• Browse all accessible drives\n• Upload and download files\n• View file details and permissions\n\nUse the buttons below or <code>/files</code> command to start browsing.",
+	"menu.system_tools":       "🛠️ <b>System Tools</b>\n\nCollection of system monitoring and diagnostic tools:",
+
+	"events.info":                 "🔔 <b>System Events Monitor</b>\n\nStatus: %s\nWatched events: %s",
+	"events.running":              "running",
+	"events.disabled":             "disabled",
+	"events.none":                 "none",
+	"events.recipient":            "You receive notifications about them.",
+	"events.not_recipient":        "You are not subscribed to notifications, see /subscribe.",
+	"events.muted_list":           "🔕 Muted: %s",
+	"events.digest":               "%s <b>%s</b> · digest of %d events\n🕒 %s\n\n<pre>%s</pre>",
+	"events.repeats":              "🔁 Repeated %d more times",
+	"events.skipped":              "⏸ %d notifications were skipped over the limit",
+	"events.notification":         "%s <b>%s</b>\n%s\n🕒 %s · %s",
+	"events.details":              "📄 <b>%s</b>\n%s\n🕒 %s · %s\n\n<pre>%s</pre>",
+	"events.no_details":           "📄 <b>%s</b>\n%s\n🕒 %s · %s\n\nNo further details were recorded.",
+	"events.muted":                "🔕 You will no longer be notified about %s events.",
+	"events.unmuted":              "🔔 Notifications about %s events are back on.",
+	"events.unknown_type":         "❌ Unknown event type %s",
+	"events.usage":                "Usage: /events [type] [severity] [period or date]\nTypes: login, logout, startup, shutdown, error, process, service\nSeverity: info, warning, error+, critical — that level and above\nPeriod: 30m, 24h, 7d; date: 2026-10-01 or 2026-10-01..2026-10-07",
+	"events.bad_filter":           "❌ Unknown filter: %s",
+	"events.log_title":            "📜 <b>Event Log</b> · %d total · page %d/%d",
+	"events.log_filter":           "🔎 Filter: %s",
+	"events.log_none":             "No events recorded.",
+	"events.log_entry":            "%s #%d %s <b>%s</b> %s",
+	"events.log_expired":          "❌ This event list is no longer available, run /events again.",
+	"events.not_found":            "❌ Event #%d not found",
+	"events.entry_title":          "%s <b>%s</b> #%d",
+	"events.entry_message":        "%s",
+	"events.entry_severity":       "Severity: %s",
+	"events.entry_time":           "🕒 %s",
+	"events.entry_source":         "Source: %s",
+	"events.entry_acknowledged":   "✅ Acknowledged by %s at %s",
+	"events.entry_open":           "Not acknowledged",
+	"events.entry_details":        "<pre>%s</pre>",
+	"events.acknowledged":         "✅ Event #%d acknowledged.",
+	"events.already_acknowledged": "Event #%d was already acknowledged.",
+	"events.type.login":           "Login",
+	"events.type.logout":          "Logout",
+	"events.type.startup":         "Startup",
+	"events.type.shutdown":        "Shutdown",
+	"events.type.error":           "System error",
+	"events.type.process":         "Process",
+	"events.type.service":         "Service",
+
+	"subscription.info":         "⚙️ <b>Event Subscription</b>\n\nStatus: %s\nEvents: %s\nMinimum severity: %s\nQuiet hours: %s",
+	"subscription.on":           "subscribed",
+	"subscription.off":          "not subscribed",
+	"subscription.quiet":        "%s (%s)",
+	"subscription.quiet_off":    "none",
+	"subscription.host_zone":    "host time",
+	"subscription.unwatched":    "⚠️ Not watched on this host: %s",
+	"subscription.held":         "🌙 %d notifications are held until the quiet hours end.",
+	"subscription.help":         "Non-critical events arriving during quiet hours are sent in one message when they end. Change the subscription with the buttons below or /subscribe.",
+	"subscription.updated":      "✅ Subscription updated.",
+	"subscription.unsubscribed": "🔕 You will no longer be notified about system events. /subscribe turns notifications back on.",
+	"subscription.bad_args":     "❌ Unknown argument: %s",
+	"subscription.bad_zone":     "❌ Unknown time zone %s, expected a name such as Europe/Moscow",
+	"subscription.usage":        "Usage: /subscribe [types] [severity S] [quiet HH:MM-HH:MM|off] [tz Zone]\nTypes: login, logout, startup, shutdown, error, process, service\nSeverity: info, warning, error, critical — that level and above\nExample: /subscribe service error severity warning quiet 23:00-07:00 tz Europe/Berlin",
+	"subscription.held_title":   "🌙 <b>%d notifications held over quiet hours</b>",
+	"subscription.held_entry":   "%s %s <b>%s</b> %s",
+	"subscription.held_more":    "… and %d more, see /events",
+
+	"screenshot.error":          "❌ Error taking screenshot: %v",
+	"screenshot.send_error":     "❌ Error sending screenshot: %v",
+	"screenshot.caption":        "📸 Desktop Screenshot\nTaken at: %s",
+	"screenshot.sent":           "📸 Screenshot taken and sent!",
+	"screenshot.info":           "📸 <b>Screenshot Service</b>\n\nUse <code>/screenshot</code> command to take a desktop screenshot.",
+	"screenshot.admin_title":    "📸 <b>Enhanced Screenshot Service</b>",
+	"screenshot.service_mode":   "⚠️ <b>Service Mode Detected</b>\n\nScreenshots are not available when running as a Windows Service.\n\n📝 <b>Alternative:</b> Run CupBot in interactive mode to enable screenshots.\n\n🔧 <b>How to run interactively:</b>\n1. Stop the Windows service\n2. Run <code>cupbot.exe</code> directly from command line\n3. Screenshot functionality will be available",
+	"screenshot.test_error":     "❌ Error testing screenshot: %v",
+	"screenshot.available":      "✅ Screenshot functionality is available",
+	"screenshot.admin_features": "Admin screenshot features:\n\n• Capture full desktop\n• Configurable quality and format\n• Automatic timestamping\n\nUse <code>/screenshot</code> command to capture the desktop.",
+
+	"power.title":                    "🔌 <b>Power Management</b>",
+	"power.status_title":             "ℹ️ <b>Power Management Status</b>",
+	"power.choose":                   "Choose a power operation:",
+	"power.active_operation":         "⚠️ <b>Active Operation:</b> %s",
+	"power.time_remaining":           "⏱️ <b>Time remaining:</b> %v",
+	"power.initiated_by":             "👤 <b>Initiated by:</b> User %d",
+	"power.scheduled_for":            "⏰ <b>Scheduled for:</b> %s",
+	"power.no_operations":            "✅ No active power operations",
+	"power.platform_limited":         "⚠️ Platform: Non-Windows (limited support)",
+	"power.platform_full":            "🟢 Platform: Windows (full support)",
+	"power.shutdown_error":           "❌ Error initiating shutdown: %v",
+	"power.reboot_error":             "❌ Error initiating reboot: %v",
+	"power.schedule_shutdown_error":  "❌ Error scheduling shutdown: %v",
+	"power.schedule_reboot_error":    "❌ Error scheduling reboot: %v",
+	"power.cancel_error":             "❌ Error canceling operation: %v",
+	"power.shutdown_now":             "🔴 <b>Immediate shutdown initiated</b>\n\nThe system will shut down now.",
+	"power.reboot_now":               "🔄 <b>Immediate reboot initiated</b>\n\nThe system will restart now.",
+	"power.force_shutdown_now":       "⚠️ <b>Force shutdown initiated</b>\n\nThe system will shut down immediately, closing all applications.",
+	"power.force_reboot_now":         "⚠️ <b>Force reboot initiated</b>\n\nThe system will restart immediately, closing all applications.",
+	"power.shutdown_scheduled":       "⏰ <b>Shutdown scheduled</b>\n\nThe system will shut down in %v.",
+	"power.force_shutdown_scheduled": "⏰ <b>Force shutdown scheduled</b>\n\nThe system will shut down in %v.",
+	"power.reboot_scheduled":         "⏰ <b>Reboot scheduled</b>\n\nThe system will restart in %v.",
+	"power.force_reboot_scheduled":   "⏰ <b>Force reboot scheduled</b>\n\nThe system will restart in %v.",
+	"power.canceled":                 "✅ <b>Power operation canceled</b>\n\nAny scheduled shutdown or reboot has been canceled.",
+
+	// File manager
+	"files.title":               "📁 <b>File Manager</b>\n\nAvailable drives:",
+	"files.drive_selection":     "📁 <b>File Manager - Drive Selection</b>",
+	"files.available_drives":    "💾 <b>Available Drives:</b>",
+	"files.no_drives":           "❌ No drives available in configuration",
+	"files.pick_drive":          "💡 <b>Click on a drive below to start browsing:</b>",
+	"files.current_directory":   "📁 <b>Current Directory</b>\n<code>%s</code>",
+	"files.path_label":          "📍 <b>Path:</b>",
+	"files.contents":            "📊 <b>Contents:</b> %d folders, %d files",
+	"files.page":                "(Page %d/%d)",
+	"files.empty_directory":     "📭 <b>This directory is empty</b>",
+	"files.pick_item":           "💡 <b>Click on any item below to navigate:</b>",
+	"files.details":             "📄 <b>File Details</b>",
+	"files.details_name":        "📛 <b>Name:</b> %s",
+	"files.details_size":        "📏 <b>Size:</b> %s",
+	"files.details_modified":    "📅 <b>Modified:</b> %s",
+	"files.details_permissions": "🔒 <b>Permissions:</b> %s",
+	"files.details_path":        "📍 <b>Path:</b> <code>%s</code>",
+	"files.list_error":          "❌ Error listing directory: %v",
+	"files.download_error":      "❌ Download failed: %v",
+	"files.send_error":          "❌ Failed to send file: %v",
+	"files.sent":                "✅ File downloaded and sent!",
+	"files.expired":             "⌛ This button has expired. Use /files to start browsing again.",
+	"files.foreign":             "❌ This button belongs to another user",
+	"files.invalid_path":        "❌ Invalid path: %v",
+	"files.unknown":             "❌ Unknown file manager command",
+
+	// Interactive prompts
+	"dialog.addadmin":        "➕ <b>Add Administrator</b>\n\nSend the ID of the user to promote or pick one below:",
+	"dialog.removeadmin":     "➖ <b>Remove Administrator</b>\n\nSend the ID of the administrator to demote or pick one below:",
+	"dialog.banuser":         "🚫 <b>Ban User</b>\n\nSend the ID of the user to ban or pick one below:",
+	"dialog.unbanuser":       "✅ <b>Unban User</b>\n\nSend the ID of the user to unban or pick one below:",
+	"dialog.deleteuser":      "🗑️ <b>Delete User</b>\n\nSend the ID of the user to delete or pick one below.\n\n⚠️ <b>Warning:</b> This action cannot be undone!",
+	"dialog.invalid_user_id": "Invalid user ID, send a number like 123456789",
+	"dialog.expired":         "⌛ This prompt has expired. Open the menu to start again.",
+	"dialog.foreign":         "❌ This prompt belongs to another user",
+	"dialog.unknown":         "❌ Unknown prompt",
+	"dialog.unavailable":     "❌ This prompt is no longer available",
+	"cancel.nothing":         "ℹ️ Nothing to cancel",
+	"cancel.done":            "❌ Action cancelled",
+
+	"confirm.prompt":  "⚠️ <b>Confirm action</b>\n\n%s\n\nPress Confirm within %s to proceed.",
+	"confirm.expired": "⌛ This confirmation has expired. Nothing was done.",
+	"confirm.foreign": "❌ This confirmation belongs to another user",
+
+	"language.prompt":  "🌐 <b>Language</b>\n\nCurrent language: %s\n\nPick a language below or send <code>/language ru</code>, <code>/language en</code> or <code>/language auto</code>.",
+	"language.auto":    "%s (Telegram language)",
+	"language.changed": "✅ Language: %s",
+	"language.unknown": "❌ Unknown language %q. Available: %s, auto",
+
+	"style.prompt":  "🪟 <b>Menu style</b>\n\nCurrent style: %s\n\n<b>Panel</b> keeps one message and updates it as you press buttons, <b>chat log</b> answers every button with a new message. Pick a style below or send <code>/style panel</code> or <code>/style log</code>.",
+	"style.changed": "✅ Menu style: %s",
+	"style.unknown": "❌ Unknown menu style %q. Available: panel, log",
+	"style.panel":   "🪟 Panel",
+	"style.log":     "📜 Chat log",
+
+	"dashboard.title":             "📊 <b>Dashboard</b> · %s",
+	"dashboard.cpu":               "🧠 <b>CPU:</b> %.1f%%",
+	"dashboard.memory":            "🧮 <b>Memory:</b> %s / %s (%.1f%%)",
+	"dashboard.disk":              "💾 <b>%s:</b> used %.1f%%, free %s",
+	"dashboard.network":           "🌐 <b>Network:</b> %s/s",
+	"dashboard.network_measuring": "🌐 <b>Network:</b> measuring…",
+	"dashboard.power":             "⚠️ <b>Scheduled %s</b> in %v",
+	"dashboard.no_power":          "🔌 No scheduled power operations",
+	"dashboard.updated":           "🕒 Updated at %s, every %v",
+	"dashboard.paused":            "⏸ Paused",
+	"dashboard.stopped":           "⏹ Dashboard stopped",
+	"dashboard.shutdown":          "⏹ Dashboard stopped: the bot is shutting down",
+	"dashboard.limit":             "❌ This chat already has %d running dashboard(s). Stop one with its ⏹ button first.",
+	"dashboard.not_running":       "ℹ️ This dashboard is no longer running. Send /dashboard to start a new one.",
+	"dashboard.foreign":           "❌ Only the user who started this dashboard or an admin can control it",
+
+	"exec.disabled":    "❌ Running host commands is disabled. Set <code>exec.enabled</code> in the config to turn it on.",
+	"exec.no_commands": "ℹ️ No commands are allowed. Add them to <code>exec.commands</code> in the config.",
+	"exec.usage":       "🖥 <b>Allowed commands</b>\n\nSend <code>/exec command [arguments]</code>:",
+	"exec.rejected":    "❌ %v",
+	"exec.result":      "🖥 %s\n<b>Exit code:</b> %d · <b>Time:</b> %v",
+	"exec.timed_out":   "⌛ Killed after the timeout",
+	"exec.truncated":   "✂️ Output cut to the first %s",
+	"exec.no_output":   "<i>No output</i>",
+
+	"shell.disabled":        "❌ Shell sessions are disabled. Set <code>shell.enabled</code> in the config to turn them on.",
+	"shell.running":         "❌ You already have a shell session. Kill it with /shell_kill first.",
+	"shell.start_error":     "❌ Failed to start the shell: %v",
+	"shell.audit_error":     "❌ Failed to record the shell session, the shell was not opened: %v",
+	"shell.started":         "🖥 Shell %s started. Every message you send is typed into it.",
+	"shell.not_running":     "ℹ️ You have no running shell session. Open one with /shell.",
+	"shell.write_error":     "❌ Failed to send input to the shell: %v",
+	"shell.interrupt_error": "❌ Failed to send Ctrl-C: %v",
+	"shell.kill_sent":       "⏹ Killing the shell session…",
+	"shell.exited":          "⏹ Shell exited with code %d",
+	"shell.killed":          "⏹ Shell session killed",
+	"shell.idle":            "⌛ Shell session closed after %v without input",
+	"shell.shutdown":        "⏹ Shell session closed: the bot is shutting down",
+
+	"ps.title":                  "📋 <b>Processes</b> sorted by %s · %d total · page %d/%d",
+	"ps.sort_cpu":               "CPU",
+	"ps.sort_mem":               "memory",
+	"ps.filter":                 "🔍 Name contains: <code>%s</code>",
+	"ps.none":                   "ℹ️ No processes match.",
+	"ps.column_name":            "NAME",
+	"ps.expired":                "⌛ This process list has expired. Run /ps again.",
+	"ps.gone":                   "ℹ️ The process has exited.",
+	"ps.own":                    "❌ The bot will not signal its own process.",
+	"ps.terminated":             "⏹ Asked %s (PID %d) to terminate",
+	"ps.killed":                 "💀 Killed %s (PID %d)",
+	"ps.details_title":          "⚙️ <b>%s</b> · PID %d",
+	"ps.user":                   "<b>User:</b> %s",
+	"ps.parent":                 "<b>Parent PID:</b> %d",
+	"ps.status":                 "<b>Status:</b> %s",
+	"ps.started":                "<b>Started:</b> %s",
+	"ps.memory":                 "<b>Memory:</b> %s (%.1f%%)",
+	"ps.threads":                "<b>Threads:</b> %d",
+	"ps.exe":                    "<b>Executable:</b> <code>%s</code>",
+	"ps.cmdline":                "<b>Command line:</b> <code>%s</code>",
+	"ps.open_files":             "📂 <b>Open files:</b> %d",
+	"ps.open_files_unavailable": "📂 <b>Open files:</b> unavailable",
+	"ps.children":               "👶 <b>Children:</b> %d",
+	"ps.more":                   "… and %d more",
+
+	"services.disabled":           "❌ Service management is disabled. Set <code>services.enabled</code> in the config to turn it on.",
+	"services.no_rules":           "ℹ️ No services are allowed. Add them to <code>services.allowed</code> in the config.",
+	"services.usage":              "ℹ️ Send <code>/service name [status|start|stop|restart|enable|disable]</code>",
+	"services.not_allowed":        "❌ Service %s is not in services.allowed.",
+	"services.unknown_action":     "❌ Unknown service action %s",
+	"services.action_not_allowed": "❌ Action %s is not allowed for %s.",
+	"services.done":               "✅ %s <b>%s</b> (%v)",
+	"services.action_start":       "Started",
+	"services.action_stop":        "Stopped",
+	"services.action_restart":     "Restarted",
+	"services.action_enable":      "Enabled at boot:",
+	"services.action_disable":     "Disabled:",
+	"services.title":              "⚙️ <b>Services</b> · %d total · page %d/%d",
+	"services.filter":             "🔍 Name contains: <code>%s</code>",
+	"services.none":               "ℹ️ No allowed services match.",
+	"services.line":               "%s <b>%s</b> · %s · %s",
+	"services.details_title":      "%s <b>%s</b>",
+	"services.description":        "<b>Description:</b> %s",
+	"services.state":              "<b>State:</b> %s (%s)",
+	"services.boot":               "<b>At boot:</b> %s (%s)",
+	"services.pid":                "<b>PID:</b> %d",
+	"services.read_only":          "ℹ️ services.allowed permits no actions on this service.",
+	"services.expired":            "⌛ This service list has expired. Run /services again.",
+	"services.not_found":          "❌ Service %s not found",
+	"services.unsupported":        "❌ Service management is not supported on this system.",
+	"services.error":              "❌ Service manager error: %v",
+	"services.state_running":      "running",
+	"services.state_stopped":      "stopped",
+	"services.state_starting":     "starting",
+	"services.state_stopping":     "stopping",
+	"services.state_failed":       "failed",
+	"services.state_unknown":      "unknown",
+	"services.enabled":            "starts at boot",
+	"services.not_enabled":        "manual",
+
+	"graph.usage":            "📈 Usage: <code>/graph cpu|memory|disk|net [period] [disk or interface]</code>\nPeriods: <code>30m</code>, <code>24h</code>, <code>7d</code>, <code>2w</code>. Example: <code>/graph cpu 24h</code>",
+	"graph.unknown_metric":   "❌ Unknown metric %s. Use cpu, memory, disk or net",
+	"graph.disabled":         "❌ Metrics history is disabled (metrics.enabled)",
+	"graph.no_data":          "📭 No data for the last %s yet",
+	"graph.error":            "❌ Failed to draw the chart: %v",
+	"graph.caption":          "📈 %s, last %s",
+	"graph.metric.cpu":       "CPU usage",
+	"graph.metric.memory":    "Memory usage",
+	"graph.metric.disk_used": "Disk usage",
+	"graph.metric.net_recv":  "Network traffic",
+
+	"alerts.fired":            "🚨 <b>Alert %s</b>\n%s is %s (%s %s) for %s",
+	"alerts.resolved":         "✅ <b>Alert %s resolved</b>\n%s is %s after %s",
+	"alerts.all_interfaces":   "all interfaces",
+	"alerts.metric.cpu":       "CPU usage",
+	"alerts.metric.memory":    "Memory usage",
+	"alerts.metric.disk_used": "Used space on %s",
+	"alerts.metric.disk_free": "Free space on %s",
+	"alerts.metric.net_recv":  "Traffic received on %s",
+	"alerts.metric.net_sent":  "Traffic sent on %s",
+}
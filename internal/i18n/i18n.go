@@ -0,0 +1,76 @@
+// Package i18n holds the message catalogs of the bot. Messages are looked
+// up by key and formatted with fmt verbs, so every catalog has to use the
+// same verbs in the same order for a key. Messages are Telegram HTML.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cupbot/cupbot/internal/telegram/render"
+)
+
+// Supported languages
+const (
+	Russian = "ru"
+	English = "en"
+
+	// Default is used when neither the user nor the configuration pick a
+	// supported language
+	Default = Russian
+)
+
+// Catalog maps message keys to format strings
+type Catalog map[string]string
+
+var catalogs = map[string]Catalog{
+	Russian: ru,
+	English: en,
+}
+
+// Languages lists the supported languages in the order they are offered
+func Languages() []string {
+	return []string{Russian, English}
+}
+
+// Supported reports whether there is a catalog for the language
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Match maps a Telegram language_code such as "en" or "en-US" to a
+// supported language. It returns "" when there is no catalog for it.
+func Match(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if Supported(code) {
+		return code
+	}
+	return ""
+}
+
+// T formats the message key in lang. A message missing from lang falls back
+// to the Default catalog; an unknown key is escaped and returned as is, so
+// plain text can be passed where a key is expected. Text arguments are
+// escaped unless they are render.HTML.
+func T(lang, key string, args ...any) string {
+	format, ok := catalogs[lang][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		format = render.Escape(key)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, render.EscapeArgs(args)...)
+}
+
+// Name returns the name of a language in that language
+func Name(lang string) string {
+	return T(lang, "language.name")
+}
@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/cupbot/cupbot/internal/telegram/render"
+)
+
+// verbPattern matches fmt verbs, skipping the %% escape
+var verbPattern = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for _, lang := range Languages() {
+		for _, other := range Languages() {
+			for key := range catalogs[lang] {
+				if _, ok := catalogs[other][key]; !ok {
+					t.Errorf("%s: key %q is missing, present in %s", other, key, lang)
+				}
+			}
+		}
+	}
+}
+
+func TestCatalogsHaveSameVerbs(t *testing.T) {
+	for key, format := range catalogs[Default] {
+		expected := verbPattern.FindAllString(format, -1)
+		for _, lang := range Languages() {
+			other, ok := catalogs[lang][key]
+			if !ok {
+				continue
+			}
+			if verbs := verbPattern.FindAllString(other, -1); !slices.Equal(verbs, expected) {
+				t.Errorf("%s: key %q uses verbs %v, %s uses %v", lang, key, verbs, Default, expected)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"ru", Russian},
+		{"en", English},
+		{"en-US", English},
+		{"EN_gb", English},
+		{"de", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.code); got != tt.expected {
+			t.Errorf("Match(%q): expected %q, got %q", tt.code, tt.expected, got)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(English, "admin.banned", 42); got != "✅ User 42 banned" {
+		t.Errorf("Unexpected English message: %q", got)
+	}
+	if got := T(Russian, "admin.banned", 42); got != "✅ Пользователь 42 заблокирован" {
+		t.Errorf("Unexpected Russian message: %q", got)
+	}
+	if got := T("de", "admin.banned", 42); got != T(Default, "admin.banned", 42) {
+		t.Errorf("Unsupported language should fall back to %s, got %q", Default, got)
+	}
+	if got := T(English, "plain text"); got != "plain text" {
+		t.Errorf("Unknown key should be returned as is, got %q", got)
+	}
+}
+
+func TestTEscapesArguments(t *testing.T) {
+	if got := T(English, "files.details_name", "a<b>_c"); got != "📛 <b>Name:</b> a&lt;b&gt;_c" {
+		t.Errorf("Text arguments should be escaped, got %q", got)
+	}
+	if got := T(English, "language.changed", render.HTML("<b>x</b>")); got != "✅ Language: <b>x</b>" {
+		t.Errorf("HTML arguments should be kept, got %q", got)
+	}
+	if got := T(English, "a < b"); got != "a &lt; b" {
+		t.Errorf("Plain text should be escaped, got %q", got)
+	}
+}
@@ -0,0 +1,492 @@
+package i18n
+
+var ru = Catalog{
+	"language.name": "Русский",
+
+	// Errors shared by many replies
+	"error.generic":          "❌ Ошибка: %v",
+	"error.admin_required":   "❌ Доступ запрещен. Требуются права администратора.",
+	"error.unauthorized":     "❌ У вас нет прав для использования этого бота.",
+	"error.unknown_command":  "Неизвестная команда: %s\nИспользуйте /help для просмотра доступных команд",
+	"error.unknown_action":   "❌ Неизвестное действие",
+	"error.update_interface": "❌ Ошибка обновления интерфейса",
+	"action.cancelled":       "❌ Отменено",
+
+	// Command descriptions in /help and the Telegram menu
+	"cmd.start":         "Начать работу с ботом",
+	"cmd.help":          "Показать эту справку",
+	"cmd.status":        "Полный статус системы",
+	"cmd.dashboard":     "Закрепленный статус с автообновлением",
+	"cmd.graph":         "График метрики за период",
+	"cmd.events":        "Журнал системных событий",
+	"cmd.subscribe":     "Подписка на системные события и ее настройки",
+	"cmd.unsubscribe":   "Отключить уведомления о системных событиях",
+	"cmd.uptime":        "Время работы системы",
+	"cmd.history":       "История команд (по умолчанию 10)",
+	"cmd.cancel":        "Отменить текущее действие",
+	"cmd.files":         "Файловый менеджер",
+	"cmd.screenshot":    "Создать скриншот рабочего стола",
+	"cmd.language":      "Сменить язык бота",
+	"cmd.style":         "Обновлять меню на месте или присылать новые сообщения",
+	"cmd.users":         "Список всех пользователей",
+	"cmd.stats":         "Статистика использования бота",
+	"cmd.cleanup":       "Очистка истории и событий старше N дней (по умолчанию 30)",
+	"cmd.exec":          "Выполнить разрешенную команду на хосте",
+	"cmd.shell":         "Открыть интерактивную оболочку на хосте",
+	"cmd.shell_kill":    "Завершить вашу shell-сессию",
+	"cmd.ps":            "Список процессов по загрузке CPU или памяти",
+	"cmd.services":      "Список служб, которыми можно управлять",
+	"cmd.service":       "Показать или изменить службу",
+	"cmd.addadmin":      "Назначить администратора",
+	"cmd.removeadmin":   "Убрать права администратора",
+	"cmd.banuser":       "Заблокировать пользователя",
+	"cmd.unbanuser":     "Разблокировать пользователя",
+	"cmd.deleteuser":    "Удалить пользователя",
+	"usage.path":        "[путь]",
+	"usage.days":        "[дни]",
+	"usage.exec":        "[команда]",
+	"usage.ps":          "[cpu|mem] [фильтр]",
+	"usage.services":    "[фильтр]",
+	"usage.graph":       "[cpu|memory|disk|net] [24h] [диск или интерфейс]",
+	"usage.events":      "[тип] [важность] [24h|дата[..дата]]",
+	"usage.subscribe":   "[типы] [severity S] [quiet ЧЧ:ММ-ЧЧ:ММ|off] [tz Пояс]",
+	"usage.unsubscribe": "[типы]",
+	"usage.service":     "[имя] [status|start|stop|restart|enable|disable]",
+
+	"start.welcome": "🤖 <b>Добро пожаловать в CupBot!</b>\n\nПривет, %s! Этот бот позволяет удаленно управлять компьютером.\n\n📊 <b>Основные возможности:</b>\n• Просмотр статуса системы\n• Мониторинг времени работы\n• Просмотр истории команд",
+	"start.admin":   "🔑 <b>Вы — администратор!</b>\n• Управление пользователями\n• Просмотр статистики\n• Очистка данных",
+	"start.footer":  "📱 <b>Используйте кнопки ниже для управления:</b>",
+
+	"help.title":          "📖 <b>Справка по командам</b>",
+	"help.user_commands":  "<b>Основные команды:</b>",
+	"help.admin_commands": "<b>Команды администратора:</b>",
+	"help.info":           "<b>Информация:</b>\n• Все команды записываются в историю\n• Только авторизованные пользователи могут использовать бота\n• Администраторы имеют расширенный доступ",
+
+	"status.error":            "❌ Ошибка получения информации о системе: %v",
+	"status.title":            "💻 <b>Статус системы</b>",
+	"status.host":             "🖥️ <b>Хост:</b> %s",
+	"status.os":               "🔧 <b>ОС:</b> %s %s",
+	"status.uptime":           "⏰ <b>Время работы:</b> %s",
+	"status.processes":        "🔄 <b>Процессов:</b> %d",
+	"status.cpu":              "🧠 <b>Процессор:</b>",
+	"status.cpu_model":        "   • Модель: %s",
+	"status.cpu_cores":        "   • Ядер: %d",
+	"status.cpu_usage":        "   • Загрузка: %.1f%%",
+	"status.cpu_temperature":  "   • Температура: %.1f°C",
+	"status.memory":           "🧮 <b>Память:</b>",
+	"status.memory_total":     "   • Всего: %s",
+	"status.memory_used":      "   • Используется: %s (%.1f%%)",
+	"status.memory_available": "   • Доступно: %s",
+	"status.disks":            "💾 <b>Диски:</b>",
+	"status.disk_space":       "     Всего: %s | Свободно: %s (%.1f%%)",
+	"status.network":          "🌐 <b>Сеть (активные интерфейсы):</b>",
+	"status.network_traffic":  "     Отправлено: %s | Получено: %s",
+	"status.network_rate":     "     Сейчас: ↑ %s/s | ↓ %s/s",
+	"status.cpu_load":         "   • Средняя нагрузка: %.2f, %.2f, %.2f",
+	"status.collected":        "🕒 Данные на %s (%d с назад)",
+	"uptime.error":            "❌ Ошибка получения времени работы: %v",
+	"uptime.response":         "⏰ <b>Время работы системы:</b> %s",
+	"duration.days":           "%d дн. %d ч. %d мин.",
+	"duration.hours":          "%d ч. %d мин.",
+	"duration.minutes":        "%d мин.",
+	"duration.seconds":        "%d сек.",
+
+	"history.error": "❌ Ошибка получения истории: %v",
+	"history.empty": "📝 История команд пуста",
+	"history.title": "📝 <b>История команд</b> (последние %d):",
+	"history.entry": "%d. %s <code>/%s %s</code>\n   <i>Время: %s</i>",
+	"history.exit":  "   <i>Код выхода: %d · %v</i>",
+
+	"users.error":      "❌ Ошибка получения списка пользователей: %v",
+	"users.empty":      "👥 Список пользователей пуст",
+	"users.title":      "👥 <b>Список пользователей:</b>",
+	"users.role_user":  "Пользователь",
+	"users.role_admin": "Администратор",
+	"users.entry":      "%d. %s <b>%s %s</b> (@%s)\n   ID: %d | %s\n   Создан: %s",
+
+	"stats.error":               "❌ Ошибка получения статистики: %v",
+	"stats.title":               "📊 <b>Статистика использования:</b>",
+	"stats.total_users":         "👥 Всего пользователей: %v",
+	"stats.active_users":        "🟢 Активных пользователей: %v",
+	"stats.total_commands":      "📝 Всего команд: %v",
+	"stats.successful_commands": "✅ Успешных команд: %v",
+	"stats.recent_commands":     "🕐 Команд за 24 часа: %v",
+	"stats.success_rate":        "📈 Процент успешности: %.1f%%",
+
+	"cleanup.error": "❌ Ошибка очистки данных: %v",
+	"cleanup.done":  "🧹 Очистка завершена. Удалены записи старше %d дней.",
+
+	"admin.user_id_required":   "❌ Необходимо указать ID пользователя. Пример: /%s 123456789",
+	"admin.invalid_user_id":    "❌ Неверный ID пользователя",
+	"admin.promoted":           "✅ Пользователь %d назначен администратором",
+	"admin.demoted":            "✅ Права администратора у пользователя %d убраны",
+	"admin.cannot_demote_self": "❌ Нельзя убрать права администратора у себя",
+	"admin.banned":             "✅ Пользователь %d заблокирован",
+	"admin.cannot_ban_self":    "❌ Нельзя заблокировать себя",
+	"admin.unbanned":           "✅ Пользователь %d разблокирован",
+	"admin.deleted":            "✅ Пользователь %d удален",
+	"admin.cannot_delete_self": "❌ Нельзя удалить себя",
+
+	// Keyboard buttons
+	"button.status":             "💻 Статус системы",
+	"button.uptime":             "⏰ Время работы",
+	"button.history":            "📝 История команд",
+	"button.files":              "📁 Файлы",
+	"button.screenshot":         "📸 Скриншот",
+	"button.events":             "🔔 События",
+	"button.users":              "👥 Пользователи",
+	"button.stats":              "📊 Статистика",
+	"button.admin_menu":         "🔑 Администрирование",
+	"button.menu":               "📜 Меню",
+	"button.main_menu":          "🏠 Главное меню",
+	"button.power_menu":         "🔌 Питание",
+	"button.user_menu":          "👥 Пользователи",
+	"button.file_manager_admin": "📁 Файлы+",
+	"button.screenshot_admin":   "📸 Скриншот+",
+	"button.monitoring":         "💻 Мониторинг",
+	"button.system_tools":       "🔧 Инструменты",
+	"button.system_events":      "🔔 Системные события",
+	"button.event_mute":         "🔕 Не присылать «%s»",
+	"button.event_unmute":       "🔔 Присылать «%s»",
+	"button.event_details":      "📄 Подробнее",
+	"button.event_log":          "📜 Журнал событий",
+	"button.subscription":       "⚙️ Подписка",
+	"button.subscribe":          "🔔 Подписаться",
+	"button.unsubscribe":        "🔕 Отписаться",
+	"button.quiet_hours":        "🌙 %s",
+	"button.quiet_off":          "☀️ Без тихих часов",
+	"button.event_acknowledge":  "✅ Подтвердить",
+	"button.back_events":        "🔙 К событиям",
+	"button.back_admin":         "🔙 Администрирование",
+	"button.back_menu":          "🔙 В меню",
+	"button.shutdown_now":       "🔴 Выключить сейчас",
+	"button.reboot_now":         "🔄 Перезагрузить сейчас",
+	"button.shutdown_1min":      "⏱️ Выключить через 1 мин",
+	"button.reboot_1min":        "⏱️ Перезагрузить через 1 мин",
+	"button.shutdown_5min":      "⏰ Выключить через 5 мин",
+	"button.reboot_5min":        "⏰ Перезагрузить через 5 мин",
+	"button.shutdown_10min":     "🕒 Выключить через 10 мин",
+	"button.reboot_10min":       "🕒 Перезагрузить через 10 мин",
+	"button.shutdown_30min":     "🕥 Выключить через 30 мин",
+	"button.reboot_30min":       "🕥 Перезагрузить через 30 мин",
+	"button.force_shutdown":     "⚠️ Принудительно выключить",
+	"button.force_reboot":       "⚠️ Принудительно перезагрузить",
+	"button.cancel_power":       "❌ Отменить операцию",
+	"button.power_status":       "ℹ️ Состояние питания",
+	"button.list_users":         "👥 Все пользователи",
+	"button.user_stats":         "📊 Статистика",
+	"button.add_admin":          "➕ Назначить администратора",
+	"button.remove_admin":       "➖ Убрать администратора",
+	"button.ban_user":           "🚫 Заблокировать",
+	"button.unban_user":         "✅ Разблокировать",
+	"button.delete_user":        "🗑️ Удалить пользователя",
+	"button.browse_files":       "📁 Обзор файлов",
+	"button.prev":               "◀️ Назад",
+	"button.next":               "Вперед ▶️",
+	"button.page":               "Стр. %d/%d",
+	"button.up":                 "⬆️ Вверх",
+	"button.drives":             "🏠 Диски",
+	"button.refresh":            "🔄 Обновить",
+	"button.download":           "⬇️ Скачать",
+	"button.properties":         "ℹ️ Свойства",
+	"button.back_directory":     "🔙 К папке",
+	"button.confirm":            "✅ Подтвердить",
+	"button.cancel":             "❌ Отмена",
+	"button.language_auto":      "🔄 Язык Telegram",
+	"button.pause":              "⏸ Пауза",
+	"button.resume":             "▶️ Продолжить",
+	"button.stop":               "⏹ Остановить",
+	"button.ctrl_c":             "⛔ Ctrl-C",
+	"button.kill":               "✖️ Завершить",
+	"button.terminate":          "⏹ Завершить %d",
+	"button.kill_process":       "💀 Убить %d",
+	"button.back_processes":     "🔙 К процессам",
+	"button.sort_cpu":           "⚡ По CPU",
+	"button.sort_mem":           "💾 По памяти",
+	"button.services":           "⚙️ Службы",
+	"button.back_services":      "🔙 К службам",
+	"button.service_start":      "▶️ Запустить",
+	"button.service_stop":       "⏹ Остановить",
+	"button.service_restart":    "🔄 Перезапустить",
+	"button.service_enable":     "✅ Автозапуск",
+	"button.service_disable":    "🚫 Отключить",
+
+	"menu.main":               "🏠 <b>Главное меню</b>\n\nПривет, %s! Выберите действие:",
+	"menu.menu":               "📜 <b>Меню</b>\n\nПривет, %s! Выберите действие:",
+	"menu.admin":              "🔑 <b>Администрирование</b>\n\nВыберите действие:",
+	"menu.users":              "👥 <b>Управление пользователями</b>\n\nВыберите действие:",
+	"menu.file_manager_admin": "📁 <b>Расширенный файловый менеджер</b>\n\nВозможности администратора:\n\n• Просмотр всех доступных дисков\n• Загрузка и скачивание файлов\n• Просмотр свойств и прав файлов\n\nИспользуйте кнопки ниже или команду <code>/files</code>.",
+	"menu.system_tools":       "🛠️ <b>Инструменты</b>\n\nСредства мониторинга и диагностики системы:",
+
+	"events.info":                 "🔔 <b>Монитор системных событий</b>\n\nСостояние: %s\nОтслеживаются: %s",
+	"events.running":              "работает",
+	"events.disabled":             "выключен",
+	"events.none":                 "ничего",
+	"events.recipient":            "Вы получаете уведомления о них.",
+	"events.not_recipient":        "Вы не подписаны на уведомления, см. /subscribe.",
+	"events.muted_list":           "🔕 Отключены: %s",
+	"events.digest":               "%s <b>%s</b> · сводка из %d событий\n🕒 %s\n\n<pre>%s</pre>",
+	"events.repeats":              "🔁 Повторилось еще %d раз",
+	"events.skipped":              "⏸ Пропущено уведомлений сверх лимита: %d",
+	"events.notification":         "%s <b>%s</b>\n%s\n🕒 %s · %s",
+	"events.details":              "📄 <b>%s</b>\n%s\n🕒 %s · %s\n\n<pre>%s</pre>",
+	"events.no_details":           "📄 <b>%s</b>\n%s\n🕒 %s · %s\n\nПодробностей нет.",
+	"events.muted":                "🔕 Уведомления о событиях «%s» отключены.",
+	"events.unmuted":              "🔔 Уведомления о событиях «%s» снова включены.",
+	"events.unknown_type":         "❌ Неизвестный тип событий %s",
+	"events.usage":                "Использование: /events [тип] [важность] [период или дата]\nТипы: login, logout, startup, shutdown, error, process, service\nВажность: info, warning, error+, critical — этот уровень и выше\nПериод: 30m, 24h, 7d; дата: 2026-10-01 или 2026-10-01..2026-10-07",
+	"events.bad_filter":           "❌ Неизвестный фильтр: %s",
+	"events.log_title":            "📜 <b>Журнал событий</b> · всего %d · страница %d/%d",
+	"events.log_filter":           "🔎 Фильтр: %s",
+	"events.log_none":             "Событий не найдено.",
+	"events.log_entry":            "%s #%d %s <b>%s</b> %s",
+	"events.log_expired":          "❌ Этот список событий устарел, выполните /events снова.",
+	"events.not_found":            "❌ Событие #%d не найдено",
+	"events.entry_title":          "%s <b>%s</b> #%d",
+	"events.entry_message":        "%s",
+	"events.entry_severity":       "Важность: %s",
+	"events.entry_time":           "🕒 %s",
+	"events.entry_source":         "Источник: %s",
+	"events.entry_acknowledged":   "✅ Подтверждено: %s, %s",
+	"events.entry_open":           "Не подтверждено",
+	"events.entry_details":        "<pre>%s</pre>",
+	"events.acknowledged":         "✅ Событие #%d подтверждено.",
+	"events.already_acknowledged": "Событие #%d уже было подтверждено.",
+	"events.type.login":           "Вход",
+	"events.type.logout":          "Выход",
+	"events.type.startup":         "Запуск",
+	"events.type.shutdown":        "Остановка",
+	"events.type.error":           "Системная ошибка",
+	"events.type.process":         "Процесс",
+	"events.type.service":         "Служба",
+
+	"subscription.info":         "⚙️ <b>Подписка на события</b>\n\nСтатус: %s\nСобытия: %s\nМинимальная важность: %s\nТихие часы: %s",
+	"subscription.on":           "подписаны",
+	"subscription.off":          "не подписаны",
+	"subscription.quiet":        "%s (%s)",
+	"subscription.quiet_off":    "нет",
+	"subscription.host_zone":    "время сервера",
+	"subscription.unwatched":    "⚠️ Не отслеживаются на этом компьютере: %s",
+	"subscription.held":         "🌙 Уведомлений отложено до конца тихих часов: %d",
+	"subscription.help":         "Некритичные события, пришедшие в тихие часы, отправляются одним сообщением после их окончания. Подписку можно изменить кнопками ниже или командой /subscribe.",
+	"subscription.updated":      "✅ Подписка обновлена.",
+	"subscription.unsubscribed": "🔕 Уведомления о системных событиях отключены. /subscribe включит их снова.",
+	"subscription.bad_args":     "❌ Неизвестный аргумент: %s",
+	"subscription.bad_zone":     "❌ Неизвестный часовой пояс %s, ожидается название вроде Europe/Moscow",
+	"subscription.usage":        "Использование: /subscribe [типы] [severity S] [quiet ЧЧ:ММ-ЧЧ:ММ|off] [tz Пояс]\nТипы: login, logout, startup, shutdown, error, process, service\nВажность: info, warning, error, critical — этот уровень и выше\nПример: /subscribe service error severity warning quiet 23:00-07:00 tz Europe/Moscow",
+	"subscription.held_title":   "🌙 <b>Отложено за тихие часы: %d</b>",
+	"subscription.held_entry":   "%s %s <b>%s</b> %s",
+	"subscription.held_more":    "… и еще %d, см. /events",
+
+	"screenshot.error":          "❌ Ошибка создания скриншота: %v",
+	"screenshot.send_error":     "❌ Ошибка отправки скриншота: %v",
+	"screenshot.caption":        "📸 Скриншот рабочего стола\nСнят: %s",
+	"screenshot.sent":           "📸 Скриншот создан и отправлен!",
+	"screenshot.info":           "📸 <b>Скриншоты</b>\n\nИспользуйте команду <code>/screenshot</code>, чтобы сделать скриншот рабочего стола.",
+	"screenshot.admin_title":    "📸 <b>Расширенные скриншоты</b>",
+	"screenshot.service_mode":   "⚠️ <b>Работа в режиме службы</b>\n\nСкриншоты недоступны, когда бот запущен как служба Windows.\n\n📝 <b>Альтернатива:</b> запустите CupBot в интерактивном режиме.\n\n🔧 <b>Как запустить интерактивно:</b>\n1. Остановите службу Windows\n2. Запустите <code>cupbot.exe</code> из командной строки\n3. Скриншоты станут доступны",
+	"screenshot.test_error":     "❌ Ошибка проверки скриншота: %v",
+	"screenshot.available":      "✅ Скриншоты доступны",
+	"screenshot.admin_features": "Возможности администратора:\n\n• Снимок всего рабочего стола\n• Настраиваемые качество и формат\n• Автоматическая отметка времени\n\nИспользуйте команду <code>/screenshot</code>, чтобы сделать скриншот.",
+
+	"power.title":                    "🔌 <b>Управление питанием</b>",
+	"power.status_title":             "ℹ️ <b>Состояние питания</b>",
+	"power.choose":                   "Выберите операцию:",
+	"power.active_operation":         "⚠️ <b>Активная операция:</b> %s",
+	"power.time_remaining":           "⏱️ <b>Осталось:</b> %v",
+	"power.initiated_by":             "👤 <b>Запустил:</b> пользователь %d",
+	"power.scheduled_for":            "⏰ <b>Запланировано на:</b> %s",
+	"power.no_operations":            "✅ Нет активных операций",
+	"power.platform_limited":         "⚠️ Платформа: не Windows (ограниченная поддержка)",
+	"power.platform_full":            "🟢 Платформа: Windows (полная поддержка)",
+	"power.shutdown_error":           "❌ Ошибка выключения: %v",
+	"power.reboot_error":             "❌ Ошибка перезагрузки: %v",
+	"power.schedule_shutdown_error":  "❌ Ошибка планирования выключения: %v",
+	"power.schedule_reboot_error":    "❌ Ошибка планирования перезагрузки: %v",
+	"power.cancel_error":             "❌ Ошибка отмены операции: %v",
+	"power.shutdown_now":             "🔴 <b>Выключение запущено</b>\n\nСистема сейчас выключится.",
+	"power.reboot_now":               "🔄 <b>Перезагрузка запущена</b>\n\nСистема сейчас перезагрузится.",
+	"power.force_shutdown_now":       "⚠️ <b>Принудительное выключение запущено</b>\n\nСистема выключится немедленно, закрыв все приложения.",
+	"power.force_reboot_now":         "⚠️ <b>Принудительная перезагрузка запущена</b>\n\nСистема перезагрузится немедленно, закрыв все приложения.",
+	"power.shutdown_scheduled":       "⏰ <b>Выключение запланировано</b>\n\nСистема выключится через %v.",
+	"power.force_shutdown_scheduled": "⏰ <b>Принудительное выключение запланировано</b>\n\nСистема выключится через %v.",
+	"power.reboot_scheduled":         "⏰ <b>Перезагрузка запланирована</b>\n\nСистема перезагрузится через %v.",
+	"power.force_reboot_scheduled":   "⏰ <b>Принудительная перезагрузка запланирована</b>\n\nСистема перезагрузится через %v.",
+	"power.canceled":                 "✅ <b>Операция отменена</b>\n\nЗапланированное выключение или перезагрузка отменены.",
+
+	// File manager
+	"files.title":               "📁 <b>Файловый менеджер</b>\n\nДоступные диски:",
+	"files.drive_selection":     "📁 <b>Файловый менеджер — выбор диска</b>",
+	"files.available_drives":    "💾 <b>Доступные диски:</b>",
+	"files.no_drives":           "❌ В конфигурации нет доступных дисков",
+	"files.pick_drive":          "💡 <b>Выберите диск ниже, чтобы начать:</b>",
+	"files.current_directory":   "📁 <b>Текущая папка</b>\n<code>%s</code>",
+	"files.path_label":          "📍 <b>Путь:</b>",
+	"files.contents":            "📊 <b>Содержимое:</b> папок: %d, файлов: %d",
+	"files.page":                "(стр. %d/%d)",
+	"files.empty_directory":     "📭 <b>Папка пуста</b>",
+	"files.pick_item":           "💡 <b>Выберите элемент ниже для перехода:</b>",
+	"files.details":             "📄 <b>Свойства файла</b>",
+	"files.details_name":        "📛 <b>Имя:</b> %s",
+	"files.details_size":        "📏 <b>Размер:</b> %s",
+	"files.details_modified":    "📅 <b>Изменен:</b> %s",
+	"files.details_permissions": "🔒 <b>Права:</b> %s",
+	"files.details_path":        "📍 <b>Путь:</b> <code>%s</code>",
+	"files.list_error":          "❌ Ошибка чтения папки: %v",
+	"files.download_error":      "❌ Ошибка скачивания: %v",
+	"files.send_error":          "❌ Не удалось отправить файл: %v",
+	"files.sent":                "✅ Файл скачан и отправлен!",
+	"files.expired":             "⌛ Кнопка устарела. Используйте /files, чтобы начать заново.",
+	"files.foreign":             "❌ Эта кнопка принадлежит другому пользователю",
+	"files.invalid_path":        "❌ Неверный путь: %v",
+	"files.unknown":             "❌ Неизвестная команда файлового менеджера",
+
+	// Interactive prompts
+	"dialog.addadmin":        "➕ <b>Назначить администратора</b>\n\nОтправьте ID пользователя или выберите его ниже:",
+	"dialog.removeadmin":     "➖ <b>Убрать администратора</b>\n\nОтправьте ID администратора или выберите его ниже:",
+	"dialog.banuser":         "🚫 <b>Заблокировать пользователя</b>\n\nОтправьте ID пользователя или выберите его ниже:",
+	"dialog.unbanuser":       "✅ <b>Разблокировать пользователя</b>\n\nОтправьте ID пользователя или выберите его ниже:",
+	"dialog.deleteuser":      "🗑️ <b>Удалить пользователя</b>\n\nОтправьте ID пользователя или выберите его ниже.\n\n⚠️ <b>Внимание:</b> действие нельзя отменить!",
+	"dialog.invalid_user_id": "Неверный ID пользователя, отправьте число, например 123456789",
+	"dialog.expired":         "⌛ Запрос устарел. Откройте меню, чтобы начать заново.",
+	"dialog.foreign":         "❌ Этот запрос принадлежит другому пользователю",
+	"dialog.unknown":         "❌ Неизвестный запрос",
+	"dialog.unavailable":     "❌ Этот запрос больше недоступен",
+	"cancel.nothing":         "ℹ️ Нет активного действия для отмены",
+	"cancel.done":            "❌ Действие отменено",
+
+	"confirm.prompt":  "⚠️ <b>Подтвердите действие</b>\n\n%s\n\nНажмите «Подтвердить» в течение %s.",
+	"confirm.expired": "⌛ Подтверждение устарело. Ничего не сделано.",
+	"confirm.foreign": "❌ Это подтверждение принадлежит другому пользователю",
+
+	"language.prompt":  "🌐 <b>Язык</b>\n\nТекущий язык: %s\n\nВыберите язык ниже или отправьте <code>/language ru</code>, <code>/language en</code> или <code>/language auto</code>.",
+	"language.auto":    "%s (язык Telegram)",
+	"language.changed": "✅ Язык: %s",
+	"language.unknown": "❌ Неизвестный язык %q. Доступны: %s, auto",
+
+	"style.prompt":  "🪟 <b>Стиль меню</b>\n\nТекущий стиль: %s\n\n<b>Панель</b> — одно сообщение, которое обновляется при нажатии кнопок, <b>лента</b> — каждая кнопка отвечает новым сообщением. Выберите стиль ниже или отправьте <code>/style panel</code> или <code>/style log</code>.",
+	"style.changed": "✅ Стиль меню: %s",
+	"style.unknown": "❌ Неизвестный стиль меню %q. Доступны: panel, log",
+	"style.panel":   "🪟 Панель",
+	"style.log":     "📜 Лента",
+
+	"dashboard.title":             "📊 <b>Панель мониторинга</b> · %s",
+	"dashboard.cpu":               "🧠 <b>CPU:</b> %.1f%%",
+	"dashboard.memory":            "🧮 <b>Память:</b> %s / %s (%.1f%%)",
+	"dashboard.disk":              "💾 <b>%s:</b> занято %.1f%%, свободно %s",
+	"dashboard.network":           "🌐 <b>Сеть:</b> %s/с",
+	"dashboard.network_measuring": "🌐 <b>Сеть:</b> измеряется…",
+	"dashboard.power":             "⚠️ <b>Запланировано: %s</b> через %v",
+	"dashboard.no_power":          "🔌 Нет запланированных операций питания",
+	"dashboard.updated":           "🕒 Обновлено в %s, каждые %v",
+	"dashboard.paused":            "⏸ На паузе",
+	"dashboard.stopped":           "⏹ Панель остановлена",
+	"dashboard.shutdown":          "⏹ Панель остановлена: бот завершает работу",
+	"dashboard.limit":             "❌ В этом чате уже работает панелей: %d. Сначала остановите одну кнопкой ⏹.",
+	"dashboard.not_running":       "ℹ️ Эта панель больше не обновляется. Отправьте /dashboard, чтобы запустить новую.",
+	"dashboard.foreign":           "❌ Управлять панелью может только запустивший ее пользователь или администратор",
+
+	"exec.disabled":    "❌ Выполнение команд на хосте выключено. Включите <code>exec.enabled</code> в конфигурации.",
+	"exec.no_commands": "ℹ️ Нет разрешенных команд. Добавьте их в <code>exec.commands</code> в конфигурации.",
+	"exec.usage":       "🖥 <b>Разрешенные команды</b>\n\nОтправьте <code>/exec команда [аргументы]</code>:",
+	"exec.rejected":    "❌ %v",
+	"exec.result":      "🖥 %s\n<b>Код выхода:</b> %d · <b>Время:</b> %v",
+	"exec.timed_out":   "⌛ Остановлена по таймауту",
+	"exec.truncated":   "✂️ Вывод обрезан до первых %s",
+	"exec.no_output":   "<i>Нет вывода</i>",
+
+	"shell.disabled":        "❌ Shell-сессии выключены. Включите <code>shell.enabled</code> в конфигурации.",
+	"shell.running":         "❌ У вас уже есть shell-сессия. Сначала завершите ее командой /shell_kill.",
+	"shell.start_error":     "❌ Не удалось запустить оболочку: %v",
+	"shell.audit_error":     "❌ Не удалось записать shell-сессию, оболочка не открыта: %v",
+	"shell.started":         "🖥 Оболочка %s запущена. Каждое ваше сообщение вводится в нее.",
+	"shell.not_running":     "ℹ️ У вас нет запущенной shell-сессии. Откройте ее командой /shell.",
+	"shell.write_error":     "❌ Не удалось передать ввод оболочке: %v",
+	"shell.interrupt_error": "❌ Не удалось отправить Ctrl-C: %v",
+	"shell.kill_sent":       "⏹ Завершаю shell-сессию…",
+	"shell.exited":          "⏹ Оболочка завершилась с кодом %d",
+	"shell.killed":          "⏹ Shell-сессия завершена",
+	"shell.idle":            "⌛ Shell-сессия закрыта после %v без ввода",
+	"shell.shutdown":        "⏹ Shell-сессия закрыта: бот останавливается",
+
+	"ps.title":                  "📋 <b>Процессы</b> по %s · всего %d · страница %d/%d",
+	"ps.sort_cpu":               "CPU",
+	"ps.sort_mem":               "памяти",
+	"ps.filter":                 "🔍 Имя содержит: <code>%s</code>",
+	"ps.none":                   "ℹ️ Нет подходящих процессов.",
+	"ps.column_name":            "ИМЯ",
+	"ps.expired":                "⌛ Этот список процессов устарел. Выполните /ps снова.",
+	"ps.gone":                   "ℹ️ Процесс уже завершился.",
+	"ps.own":                    "❌ Бот не отправляет сигналы своему процессу.",
+	"ps.terminated":             "⏹ Процессу %s (PID %d) отправлен запрос на завершение",
+	"ps.killed":                 "💀 Процесс %s (PID %d) убит",
+	"ps.details_title":          "⚙️ <b>%s</b> · PID %d",
+	"ps.user":                   "<b>Пользователь:</b> %s",
+	"ps.parent":                 "<b>Родительский PID:</b> %d",
+	"ps.status":                 "<b>Состояние:</b> %s",
+	"ps.started":                "<b>Запущен:</b> %s",
+	"ps.memory":                 "<b>Память:</b> %s (%.1f%%)",
+	"ps.threads":                "<b>Потоки:</b> %d",
+	"ps.exe":                    "<b>Исполняемый файл:</b> <code>%s</code>",
+	"ps.cmdline":                "<b>Командная строка:</b> <code>%s</code>",
+	"ps.open_files":             "📂 <b>Открытые файлы:</b> %d",
+	"ps.open_files_unavailable": "📂 <b>Открытые файлы:</b> недоступны",
+	"ps.children":               "👶 <b>Дочерние процессы:</b> %d",
+	"ps.more":                   "… и еще %d",
+
+	"services.disabled":           "❌ Управление службами выключено. Включите <code>services.enabled</code> в конфигурации.",
+	"services.no_rules":           "ℹ️ Нет разрешенных служб. Добавьте их в <code>services.allowed</code> в конфигурации.",
+	"services.usage":              "ℹ️ Отправьте <code>/service имя [status|start|stop|restart|enable|disable]</code>",
+	"services.not_allowed":        "❌ Службы %s нет в services.allowed.",
+	"services.unknown_action":     "❌ Неизвестное действие со службой: %s",
+	"services.action_not_allowed": "❌ Действие %s запрещено для %s.",
+	"services.done":               "✅ %s <b>%s</b> (%v)",
+	"services.action_start":       "Запущена служба",
+	"services.action_stop":        "Остановлена служба",
+	"services.action_restart":     "Перезапущена служба",
+	"services.action_enable":      "Автозапуск включен:",
+	"services.action_disable":     "Отключена служба",
+	"services.title":              "⚙️ <b>Службы</b> · всего %d · страница %d/%d",
+	"services.filter":             "🔍 Имя содержит: <code>%s</code>",
+	"services.none":               "ℹ️ Нет подходящих разрешенных служб.",
+	"services.line":               "%s <b>%s</b> · %s · %s",
+	"services.details_title":      "%s <b>%s</b>",
+	"services.description":        "<b>Описание:</b> %s",
+	"services.state":              "<b>Состояние:</b> %s (%s)",
+	"services.boot":               "<b>При загрузке:</b> %s (%s)",
+	"services.pid":                "<b>PID:</b> %d",
+	"services.read_only":          "ℹ️ services.allowed не разрешает действий с этой службой.",
+	"services.expired":            "⌛ Этот список служб устарел. Выполните /services снова.",
+	"services.not_found":          "❌ Служба %s не найдена",
+	"services.unsupported":        "❌ Управление службами не поддерживается в этой системе.",
+	"services.error":              "❌ Ошибка менеджера служб: %v",
+	"services.state_running":      "работает",
+	"services.state_stopped":      "остановлена",
+	"services.state_starting":     "запускается",
+	"services.state_stopping":     "останавливается",
+	"services.state_failed":       "сбой",
+	"services.state_unknown":      "неизвестно",
+	"services.enabled":            "автозапуск",
+	"services.not_enabled":        "вручную",
+
+	"graph.usage":            "📈 Использование: <code>/graph cpu|memory|disk|net [период] [диск или интерфейс]</code>\nПериоды: <code>30m</code>, <code>24h</code>, <code>7d</code>, <code>2w</code>. Пример: <code>/graph cpu 24h</code>",
+	"graph.unknown_metric":   "❌ Неизвестная метрика %s. Доступны cpu, memory, disk и net",
+	"graph.disabled":         "❌ История метрик отключена (metrics.enabled)",
+	"graph.no_data":          "📭 За последние %s данных пока нет",
+	"graph.error":            "❌ Не удалось построить график: %v",
+	"graph.caption":          "📈 %s за последние %s",
+	"graph.metric.cpu":       "Загрузка CPU",
+	"graph.metric.memory":    "Использование памяти",
+	"graph.metric.disk_used": "Использование дисков",
+	"graph.metric.net_recv":  "Сетевой трафик",
+
+	"alerts.fired":            "🚨 <b>Оповещение %s</b>\n%s: %s (%s %s) уже %s",
+	"alerts.resolved":         "✅ <b>Оповещение %s снято</b>\n%s: %s спустя %s",
+	"alerts.all_interfaces":   "все интерфейсы",
+	"alerts.metric.cpu":       "Загрузка CPU",
+	"alerts.metric.memory":    "Использование памяти",
+	"alerts.metric.disk_used": "Занято на %s",
+	"alerts.metric.disk_free": "Свободно на %s",
+	"alerts.metric.net_recv":  "Входящий трафик на %s",
+	"alerts.metric.net_sent":  "Исходящий трафик на %s",
+}
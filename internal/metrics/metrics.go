@@ -0,0 +1,252 @@
+// Package metrics records samples of the host metrics into the database,
+// keeps the history small by averaging old samples, and reads it back as
+// chart series for /graph.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/chart"
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/system"
+)
+
+// Metrics in the history
+const (
+	CPU      = "cpu"       // average usage of all cores, percent
+	Memory   = "memory"    // used memory, percent
+	DiskUsed = "disk_used" // used space by mount point, percent
+	NetRecv  = "net_recv"  // bytes received per second by interface
+	NetSent  = "net_sent"  // bytes sent per second by interface
+)
+
+// maintenanceInterval is how often old samples are averaged and deleted
+const maintenanceInterval = time.Hour
+
+// Sampler returns the current metrics of the host
+type Sampler func() (*system.SystemInfo, error)
+
+// Recorder samples the host every metrics.interval seconds
+type Recorder struct {
+	config *config.Config
+	db     *database.DB
+	sample Sampler
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRecorder creates a recorder of the metrics history
+func NewRecorder(cfg *config.Config, db *database.DB, sample Sampler) *Recorder {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Recorder{
+		config: cfg,
+		db:     db,
+		sample: sample,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start starts sampling
+func (r *Recorder) Start() error {
+	if !r.config.Metrics.Enabled {
+		log.Println("Metrics history is disabled")
+		return nil
+	}
+
+	log.Printf("Recording metrics every %d seconds...", r.config.Metrics.Interval)
+	r.wg.Add(1)
+	go r.run()
+	return nil
+}
+
+// Stop stops sampling and waits for the current sample
+func (r *Recorder) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Recorder) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(r.config.Metrics.Interval) * time.Second)
+	defer ticker.Stop()
+
+	var maintainedAt time.Time
+	for {
+		now := time.Now()
+		if now.Sub(maintainedAt) >= maintenanceInterval {
+			if err := r.maintain(now); err != nil {
+				log.Printf("Warning: Failed to compact the metrics history: %v", err)
+			}
+			maintainedAt = now
+		}
+
+		if info, err := r.sample(); err != nil {
+			log.Printf("Warning: Failed to sample metrics: %v", err)
+		} else if err := r.record(time.Now(), info); err != nil {
+			log.Printf("Warning: Failed to record metrics: %v", err)
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// record stores a sample of the host
+func (r *Recorder) record(now time.Time, info *system.SystemInfo) error {
+	var samples []*database.MetricSample
+	add := func(metric, target string, value float64) {
+		samples = append(samples, &database.MetricSample{Metric: metric, Target: target, Value: value, RecordedAt: now})
+	}
+
+	if len(info.CPUInfo.Usage) > 0 {
+		var total float64
+		for _, usage := range info.CPUInfo.Usage {
+			total += usage
+		}
+		add(CPU, "", total/float64(len(info.CPUInfo.Usage)))
+	}
+	add(Memory, "", info.MemoryInfo.UsedPercent)
+	for _, disk := range info.DiskInfo {
+		add(DiskUsed, disk.Mountpoint, disk.UsedPercent)
+	}
+
+	// The first snapshot has no rates
+	for _, iface := range info.NetworkInfo {
+		if info.RateInterval <= 0 || isLoopback(iface.Name) {
+			continue
+		}
+		add(NetRecv, iface.Name, iface.RecvRate)
+		add(NetSent, iface.Name, iface.SentRate)
+	}
+
+	return r.db.AddMetricSamples(samples)
+}
+
+// maintain averages the samples older than metrics.raw_hours and deletes
+// the ones older than metrics.retention days
+func (r *Recorder) maintain(now time.Time) error {
+	cfg := r.config.Metrics
+	resolution := time.Duration(cfg.Resolution) * time.Second
+	if err := r.db.DownsampleMetrics(now.Add(-time.Duration(cfg.RawHours)*time.Hour), resolution); err != nil {
+		return err
+	}
+	return r.db.DeleteMetricsBefore(now.AddDate(0, 0, -cfg.Retention))
+}
+
+func isLoopback(name string) bool {
+	return name == "lo" || strings.HasPrefix(name, "Loopback")
+}
+
+// History reads the series of a chart from from to to. CPU and memory have
+// one series, disks one per mount point and the network one per direction,
+// summed over the interfaces. A non-empty target keeps only that disk or
+// interface.
+func History(db *database.DB, metric, target string, from, to time.Time) ([]chart.Series, error) {
+	switch metric {
+	case CPU, Memory:
+		samples, err := db.GetMetricSamples(metric, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return []chart.Series{{Name: metric, Points: points(samples, "")}}, nil
+	case DiskUsed:
+		samples, err := db.GetMetricSamples(metric, from, to)
+		if err != nil {
+			return nil, err
+		}
+		targets := make(map[string]bool)
+		for _, s := range samples {
+			if target == "" || strings.EqualFold(s.Target, target) {
+				targets[s.Target] = true
+			}
+		}
+		names := make([]string, 0, len(targets))
+		for name := range targets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		series := make([]chart.Series, 0, len(names))
+		for _, name := range names {
+			series = append(series, chart.Series{Name: name, Points: points(samples, name)})
+		}
+		return series, nil
+	case NetRecv, NetSent:
+		var series []chart.Series
+		for _, m := range []struct{ metric, name string }{{NetRecv, "received"}, {NetSent, "sent"}} {
+			samples, err := db.GetMetricSamples(m.metric, from, to)
+			if err != nil {
+				return nil, err
+			}
+			series = append(series, chart.Series{Name: m.name, Points: sumByTime(samples, target)})
+		}
+		return series, nil
+	}
+	return nil, fmt.Errorf("unknown metric %q", metric)
+}
+
+// points returns the values of one target in time order
+func points(samples []*database.MetricSample, target string) []chart.Point {
+	var list []chart.Point
+	for _, s := range samples {
+		if s.Target == target {
+			list = append(list, chart.Point{Time: s.RecordedAt, Value: s.Value})
+		}
+	}
+	return list
+}
+
+// sumByTime adds up the values of the interfaces recorded together, or
+// keeps the target one
+func sumByTime(samples []*database.MetricSample, target string) []chart.Point {
+	var list []chart.Point
+	for _, s := range samples {
+		if target != "" && s.Target != target {
+			continue
+		}
+		if n := len(list); n > 0 && list[n-1].Time.Equal(s.RecordedAt) {
+			list[n-1].Value += s.Value
+			continue
+		}
+		list = append(list, chart.Point{Time: s.RecordedAt, Value: s.Value})
+	}
+	return list
+}
+
+// ParsePeriod parses periods such as 30m, 24h, 7d or 2w
+func ParsePeriod(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+
+	unit := map[byte]time.Duration{
+		'm': time.Minute,
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}[s[len(s)-1]]
+	if unit == 0 {
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+	return time.Duration(n) * unit, nil
+}
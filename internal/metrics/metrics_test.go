@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+	"github.com/cupbot/cupbot/internal/database"
+	"github.com/cupbot/cupbot/internal/system"
+)
+
+func setupTestRecorder(t *testing.T) *Recorder {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{Metrics: config.MetricsConfig{Enabled: true, Interval: 60, RawHours: 24, Resolution: 600, Retention: 30}}
+	return NewRecorder(cfg, db, nil)
+}
+
+// hostSample is a snapshot with network rates of recv and sent bytes per
+// second on eth0 and half of recv on wlan0
+func hostSample(cpu, recv, sent float64) *system.SystemInfo {
+	return &system.SystemInfo{
+		RateInterval: time.Minute,
+		CPUInfo:      system.CPUInfo{Usage: []float64{cpu, cpu + 10}},
+		MemoryInfo:   system.MemoryInfo{UsedPercent: 40},
+		DiskInfo: []system.DiskInfo{
+			{Mountpoint: "/", UsedPercent: 70},
+			{Mountpoint: "/data", UsedPercent: 20},
+		},
+		NetworkInfo: []system.NetworkInfo{
+			{Name: "lo", RecvRate: recv * 100, SentRate: sent * 100},
+			{Name: "eth0", RecvRate: recv, SentRate: sent},
+			{Name: "wlan0", RecvRate: recv / 2},
+		},
+	}
+}
+
+func TestRecordAndHistory(t *testing.T) {
+	r := setupTestRecorder(t)
+	start := time.Now().Add(-time.Hour)
+
+	first := hostSample(10, 0, 0)
+	first.RateInterval = 0
+	for i, sample := range []*system.SystemInfo{first, hostSample(30, 1000, 100), hostSample(50, 1000, 0)} {
+		if err := r.record(start.Add(time.Duration(i)*time.Minute), sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cpu, err := History(r.db, CPU, "", start, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cpu) != 1 || len(cpu[0].Points) != 3 || cpu[0].Points[2].Value != 55 {
+		t.Errorf("Expected 3 CPU points ending at 55%%, got %+v", cpu)
+	}
+
+	disks, _ := History(r.db, DiskUsed, "", start, time.Now())
+	if len(disks) != 2 || disks[0].Name != "/" || disks[1].Name != "/data" || len(disks[1].Points) != 3 {
+		t.Errorf("Expected a series per disk, got %+v", disks)
+	}
+	if disks, _ := History(r.db, DiskUsed, "/DATA", start, time.Now()); len(disks) != 1 || disks[0].Name != "/data" {
+		t.Errorf("Expected only the target disk, got %+v", disks)
+	}
+
+	// Rates start with the second sample; the loopback is left out
+	network, _ := History(r.db, NetRecv, "", start, time.Now())
+	if len(network) != 2 || network[0].Name != "received" || network[1].Name != "sent" {
+		t.Fatalf("Expected received and sent series, got %+v", network)
+	}
+	if points := network[0].Points; len(points) != 2 || points[0].Value != 1500 || points[1].Value != 1500 {
+		t.Errorf("Expected 1000 + 500 B/s received, got %+v", points)
+	}
+	if points := network[1].Points; len(points) != 2 || points[0].Value != 100 || points[1].Value != 0 {
+		t.Errorf("Expected 100 then 0 B/s sent, got %+v", points)
+	}
+	if eth0, _ := History(r.db, NetRecv, "eth0", start, time.Now()); eth0[0].Points[0].Value != 1000 {
+		t.Errorf("Expected only eth0, got %+v", eth0[0].Points)
+	}
+}
+
+func TestMaintain(t *testing.T) {
+	r := setupTestRecorder(t)
+	now := time.Now()
+
+	old := now.AddDate(0, 0, -40)
+	yesterday := now.Add(-30 * time.Hour).Truncate(time.Hour)
+	for _, at := range []time.Time{old, yesterday, yesterday.Add(time.Minute), now} {
+		if err := r.record(at, hostSample(20, 0, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.maintain(now); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := r.db.GetMetricSamples(CPU, old.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Resolution != 600 || samples[1].Resolution != 0 {
+		t.Errorf("Expected yesterday averaged, today raw and older samples deleted, got %+v", samples)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	valid := map[string]time.Duration{"30m": 30 * time.Minute, "24h": 24 * time.Hour, "7D": 7 * 24 * time.Hour, "2w": 14 * 24 * time.Hour}
+	for s, expected := range valid {
+		if got, err := ParsePeriod(s); err != nil || got != expected {
+			t.Errorf("%s: expected %v, got %v (%v)", s, expected, got, err)
+		}
+	}
+	for _, s := range []string{"", "h", "0h", "-1h", "24", "1y"} {
+		if _, err := ParsePeriod(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
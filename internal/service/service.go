@@ -54,6 +54,8 @@ loop:
 				changes <- c.CurrentStatus
 			case svc.Stop, svc.Shutdown:
 				elog.Info(1, "CupBot service stopping...")
+				// Tell the SCM how long draining in-flight updates may take
+				changes <- svc.Status{State: svc.StopPending, WaitHint: uint32(m.bot.ShutdownTimeout().Milliseconds())}
 				m.stop()
 				break loop
 			case svc.Pause:
@@ -129,7 +131,9 @@ func (m *cupBotService) stop() {
 		m.cancel()
 	}
 	if m.bot != nil {
-		m.bot.Stop()
+		if err := m.bot.Stop(); err != nil {
+			elog.Warning(1, fmt.Sprintf("CupBot stopped with error: %v", err))
+		}
 	}
 	if m.db != nil {
 		m.db.Close()
@@ -222,7 +226,10 @@ func RunInteractive() error {
 	log.Println("Shutting down...")
 
 	// Stop bot gracefully
-	cupBot.Stop()
+	if err := cupBot.Stop(); err != nil {
+		log.Printf("Bot stopped with error: %v", err)
+		return nil
+	}
 	log.Println("Bot stopped successfully")
 
 	return nil
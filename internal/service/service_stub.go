@@ -84,6 +84,8 @@ func RunInteractive() error {
 	<-ctx.Done()
 	log.Println("Shutting down...")
 
-	cupBot.Stop()
+	if err := cupBot.Stop(); err != nil {
+		log.Printf("Bot stopped with error: %v", err)
+	}
 	return nil
 }
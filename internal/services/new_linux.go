@@ -0,0 +1,8 @@
+//go:build linux
+
+package services
+
+// New returns the manager of the host: systemd on Linux
+func New() Manager {
+	return NewSystemd(nil)
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+
+package services
+
+// New returns the manager of the host; there is none on this system
+func New() Manager {
+	return unsupported{}
+}
@@ -0,0 +1,231 @@
+//go:build windows
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// scmPollInterval is how often a pending start or stop is checked
+const scmPollInterval = 250 * time.Millisecond
+
+// scm manages services through the Windows Service Control Manager
+type scm struct{}
+
+// New returns the manager of the host: the Service Control Manager on
+// Windows
+func New() Manager {
+	return scm{}
+}
+
+// List returns every service, sorted by name
+func (scm) List(ctx context.Context) ([]Status, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	names, err := m.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	list := make([]Status, 0, len(names))
+	for _, name := range names {
+		status, err := queryService(m, name)
+		if err != nil {
+			continue // removed meanwhile or not readable
+		}
+		list = append(list, *status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Status returns the state of one service
+func (scm) Status(ctx context.Context, name string) (*Status, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+	return queryService(m, name)
+}
+
+// Start starts a service and waits until it runs
+func (scm) Start(ctx context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return startService(ctx, s)
+	})
+}
+
+// Stop stops a service and waits until it stopped
+func (scm) Stop(ctx context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return stopService(ctx, s)
+	})
+}
+
+// Restart stops a running service and starts it again
+func (scm) Restart(ctx context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		if err := stopService(ctx, s); err != nil {
+			return err
+		}
+		return startService(ctx, s)
+	})
+}
+
+// Enable makes a service start at boot
+func (scm) Enable(ctx context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return setStartType(s, mgr.StartAutomatic)
+	})
+}
+
+// Disable keeps a service from starting at all
+func (scm) Disable(ctx context.Context, name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		return setStartType(s, mgr.StartDisabled)
+	})
+}
+
+// withService opens a service for fn
+func withService(name string, fn func(s *mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := openService(m, name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return fn(s)
+}
+
+func openService(m *mgr.Mgr, name string) (*mgr.Service, error) {
+	s, err := m.OpenService(name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open service %s: %w", name, err)
+	}
+	return s, nil
+}
+
+func queryService(m *mgr.Mgr, name string) (*Status, error) {
+	s, err := openService(m, name)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	state, err := s.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service %s: %w", name, err)
+	}
+	status := &Status{Name: name, PID: int(state.ProcessId)}
+	status.State, status.Detail = scmState(state.State)
+	if cfg, err := s.Config(); err == nil {
+		status.Description = cfg.DisplayName
+		status.StartType = startTypeName(cfg.StartType)
+		status.Enabled = cfg.StartType == mgr.StartAutomatic || cfg.StartType == windows.SERVICE_BOOT_START || cfg.StartType == windows.SERVICE_SYSTEM_START
+	}
+	return status, nil
+}
+
+func startService(ctx context.Context, s *mgr.Service) error {
+	if err := s.Start(); err != nil && !errors.Is(err, windows.ERROR_SERVICE_ALREADY_RUNNING) {
+		return fmt.Errorf("failed to start service %s: %w", s.Name, err)
+	}
+	return waitForState(ctx, s, svc.Running)
+}
+
+func stopService(ctx context.Context, s *mgr.Service) error {
+	if _, err := s.Control(svc.Stop); err != nil && !errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+		return fmt.Errorf("failed to stop service %s: %w", s.Name, err)
+	}
+	return waitForState(ctx, s, svc.Stopped)
+}
+
+// waitForState polls a service until it reaches state or ctx is done
+func waitForState(ctx context.Context, s *mgr.Service, state svc.State) error {
+	ticker := time.NewTicker(scmPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return fmt.Errorf("failed to query service %s: %w", s.Name, err)
+		}
+		if status.State == state {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			current, _ := scmState(status.State)
+			return fmt.Errorf("service %s is still %s: %w", s.Name, current, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func setStartType(s *mgr.Service, startType uint32) error {
+	cfg, err := s.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read the configuration of service %s: %w", s.Name, err)
+	}
+	cfg.StartType = startType
+	if err := s.UpdateConfig(cfg); err != nil {
+		return fmt.Errorf("failed to update service %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// scmState maps the state of a service
+func scmState(state svc.State) (string, string) {
+	switch state {
+	case svc.Running:
+		return StateRunning, "running"
+	case svc.Stopped:
+		return StateStopped, "stopped"
+	case svc.StartPending:
+		return StateStarting, "start pending"
+	case svc.StopPending:
+		return StateStopping, "stop pending"
+	case svc.Paused:
+		return StateStopped, "paused"
+	case svc.PausePending:
+		return StateStopping, "pause pending"
+	case svc.ContinuePending:
+		return StateStarting, "continue pending"
+	}
+	return StateUnknown, fmt.Sprint(state)
+}
+
+func startTypeName(startType uint32) string {
+	switch startType {
+	case windows.SERVICE_BOOT_START:
+		return "boot"
+	case windows.SERVICE_SYSTEM_START:
+		return "system"
+	case mgr.StartAutomatic:
+		return "auto"
+	case mgr.StartManual:
+		return "manual"
+	case mgr.StartDisabled:
+		return "disabled"
+	}
+	return fmt.Sprint(startType)
+}
@@ -0,0 +1,91 @@
+// Package services lists and controls the services of the host: systemd
+// units on Linux and the Service Control Manager on Windows.
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound is returned for services the host doesn't know
+	ErrNotFound = errors.New("service not found")
+	// ErrUnsupported is returned on systems without a backend
+	ErrUnsupported = errors.New("service management is not supported on this system")
+	// ErrInvalidName is returned for names that could be mistaken for options
+	ErrInvalidName = errors.New("invalid service name")
+)
+
+// Actions that change a service, as named in services.allowed
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionRestart = "restart"
+	ActionEnable  = "enable"
+	ActionDisable = "disable"
+)
+
+// Actions lists every action that changes a service
+var Actions = []string{ActionStart, ActionStop, ActionRestart, ActionEnable, ActionDisable}
+
+// States of a service, shared by the backends
+const (
+	StateRunning  = "running"
+	StateStopped  = "stopped"
+	StateStarting = "starting"
+	StateStopping = "stopping"
+	StateFailed   = "failed"
+	StateUnknown  = "unknown"
+)
+
+// Status describes a service
+type Status struct {
+	Name        string `json:"name"`
+	Description string `json:"description"` // the display name on Windows
+	State       string `json:"state"`
+	Detail      string `json:"detail"`     // the state as the backend reports it, e.g. the systemd sub-state
+	Enabled     bool   `json:"enabled"`    // started at boot
+	StartType   string `json:"start_type"` // as the backend reports it, e.g. "enabled" or "manual"
+	PID         int    `json:"pid"`        // 0 when not running
+}
+
+// Manager controls the services of the host. Actions return once the
+// service manager has carried them out or ctx is done.
+type Manager interface {
+	List(ctx context.Context) ([]Status, error)
+	Status(ctx context.Context, name string) (*Status, error)
+	Start(ctx context.Context, name string) error
+	Stop(ctx context.Context, name string) error
+	Restart(ctx context.Context, name string) error
+	Enable(ctx context.Context, name string) error
+	Disable(ctx context.Context, name string) error
+}
+
+// Do runs one of the actions on a service
+func Do(ctx context.Context, m Manager, name, action string) error {
+	switch action {
+	case ActionStart:
+		return m.Start(ctx, name)
+	case ActionStop:
+		return m.Stop(ctx, name)
+	case ActionRestart:
+		return m.Restart(ctx, name)
+	case ActionEnable:
+		return m.Enable(ctx, name)
+	case ActionDisable:
+		return m.Disable(ctx, name)
+	}
+	return fmt.Errorf("unknown service action %q", action)
+}
+
+// unsupported is the manager of systems without a backend
+type unsupported struct{}
+
+func (unsupported) List(context.Context) ([]Status, error)          { return nil, ErrUnsupported }
+func (unsupported) Status(context.Context, string) (*Status, error) { return nil, ErrUnsupported }
+func (unsupported) Start(context.Context, string) error             { return ErrUnsupported }
+func (unsupported) Stop(context.Context, string) error              { return ErrUnsupported }
+func (unsupported) Restart(context.Context, string) error           { return ErrUnsupported }
+func (unsupported) Enable(context.Context, string) error            { return ErrUnsupported }
+func (unsupported) Disable(context.Context, string) error           { return ErrUnsupported }
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Runner runs a command and returns its standard output. Tests replace it
+// with a stub that answers for systemctl.
+type Runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// execRunner runs commands on the host, with their error output in the
+// returned error
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+		return out, fmt.Errorf("%s: %w", bytes.TrimSpace(exitErr.Stderr), err)
+	}
+	return out, err
+}
+
+// showProperties are the unit properties a status needs
+const showProperties = "Id,Description,LoadState,ActiveState,SubState,UnitFileState,MainPID"
+
+// systemd manages service units through systemctl
+type systemd struct {
+	run Runner
+}
+
+// NewSystemd returns a manager of systemd service units; a nil run runs
+// systemctl on the host
+func NewSystemd(run Runner) Manager {
+	if run == nil {
+		run = execRunner
+	}
+	return &systemd{run: run}
+}
+
+func (s *systemd) systemctl(ctx context.Context, args ...string) ([]byte, error) {
+	return s.run(ctx, "systemctl", append(args, "--no-pager")...)
+}
+
+// List returns the installed and loaded service units, sorted by name
+func (s *systemd) List(ctx context.Context) ([]Status, error) {
+	byName := make(map[string]*Status)
+
+	files, err := s.systemctl(ctx, "list-unit-files", "--type=service", "--no-legend")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unit files: %w", err)
+	}
+	for _, fields := range unitLines(files) {
+		name, ok := serviceName(fields[0])
+		if !ok || strings.HasSuffix(name, "@") || len(fields) < 2 {
+			continue // templates can't run themselves
+		}
+		byName[name] = &Status{Name: name, State: StateStopped, Detail: "dead", StartType: fields[1], Enabled: unitEnabled(fields[1])}
+	}
+
+	units, err := s.systemctl(ctx, "list-units", "--type=service", "--all", "--plain", "--no-legend")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list units: %w", err)
+	}
+	for _, fields := range unitLines(units) {
+		// UNIT LOAD ACTIVE SUB DESCRIPTION...
+		name, ok := serviceName(fields[0])
+		if !ok || len(fields) < 4 || fields[1] == "not-found" {
+			continue
+		}
+		status := byName[name]
+		if status == nil {
+			status = &Status{Name: name}
+			byName[name] = status
+		}
+		status.State = unitState(fields[2])
+		status.Detail = fields[3]
+		status.Description = strings.Join(fields[4:], " ")
+	}
+
+	list := make([]Status, 0, len(byName))
+	for _, status := range byName {
+		list = append(list, *status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Status returns the state of one service
+func (s *systemd) Status(ctx context.Context, name string) (*Status, error) {
+	unit, err := unitName(name)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.systemctl(ctx, "show", "--property="+showProperties, unit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", unit, err)
+	}
+
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			props[key] = value
+		}
+	}
+	if props["LoadState"] == "not-found" || props["LoadState"] == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	status := &Status{
+		Name:        strings.TrimSuffix(unit, ".service"),
+		Description: props["Description"],
+		State:       unitState(props["ActiveState"]),
+		Detail:      props["SubState"],
+		StartType:   props["UnitFileState"],
+		Enabled:     unitEnabled(props["UnitFileState"]),
+	}
+	status.PID, _ = strconv.Atoi(props["MainPID"])
+	return status, nil
+}
+
+func (s *systemd) Start(ctx context.Context, name string) error   { return s.do(ctx, "start", name) }
+func (s *systemd) Stop(ctx context.Context, name string) error    { return s.do(ctx, "stop", name) }
+func (s *systemd) Restart(ctx context.Context, name string) error { return s.do(ctx, "restart", name) }
+func (s *systemd) Enable(ctx context.Context, name string) error  { return s.do(ctx, "enable", name) }
+func (s *systemd) Disable(ctx context.Context, name string) error { return s.do(ctx, "disable", name) }
+
+// do runs a systemctl verb on a service that exists
+func (s *systemd) do(ctx context.Context, verb, name string) error {
+	if _, err := s.Status(ctx, name); err != nil {
+		return err
+	}
+	unit, _ := unitName(name)
+	if _, err := s.systemctl(ctx, verb, unit); err != nil {
+		return fmt.Errorf("systemctl %s %s: %w", verb, unit, err)
+	}
+	return nil
+}
+
+// unitName turns a service name into its unit, refusing names systemctl
+// could read as options or as other unit types
+func unitName(name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\n/") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	name = strings.TrimSuffix(name, ".service")
+	if name == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return name + ".service", nil
+}
+
+// serviceName strips the suffix of a service unit
+func serviceName(unit string) (string, bool) {
+	unit = strings.TrimPrefix(unit, "●")
+	name, ok := strings.CutSuffix(unit, ".service")
+	return name, ok && name != ""
+}
+
+// unitLines splits systemctl output into the fields of each line
+func unitLines(out []byte) [][]string {
+	var lines [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+	return lines
+}
+
+// unitState maps the active state of a unit
+func unitState(active string) string {
+	switch active {
+	case "active", "reloading":
+		return StateRunning
+	case "inactive":
+		return StateStopped
+	case "activating":
+		return StateStarting
+	case "deactivating":
+		return StateStopping
+	case "failed":
+		return StateFailed
+	}
+	return StateUnknown
+}
+
+// unitEnabled reports whether a unit file state starts the unit at boot
+func unitEnabled(state string) bool {
+	return state == "enabled" || state == "enabled-runtime"
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeSystemctl answers systemctl commands from canned output and records
+// the commands that change something
+type fakeSystemctl struct {
+	output map[string]string // by arguments joined with spaces
+	fail   map[string]error
+	calls  []string
+}
+
+func (f *fakeSystemctl) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if name != "systemctl" || len(args) == 0 || args[len(args)-1] != "--no-pager" {
+		return nil, fmt.Errorf("unexpected command %s %v", name, args)
+	}
+	key := strings.Join(args[:len(args)-1], " ")
+	f.calls = append(f.calls, key)
+	if err := f.fail[key]; err != nil {
+		return nil, err
+	}
+	return []byte(f.output[key]), nil
+}
+
+func newFakeSystemctl() *fakeSystemctl {
+	return &fakeSystemctl{
+		output: map[string]string{
+			"list-unit-files --type=service --no-legend": "" +
+				"cron.service                enabled  enabled\n" +
+				"getty@.service              enabled  enabled\n" +
+				"nginx.service               disabled enabled\n" +
+				"rsync.service               disabled enabled\n",
+			"list-units --type=service --all --plain --no-legend": "" +
+				"cron.service      loaded    active   running Regular background program processing daemon\n" +
+				"getty@tty1.service loaded   active   running Getty on tty1\n" +
+				"nginx.service     loaded    failed   failed  A high performance web server\n" +
+				"gone.service      not-found inactive dead    gone.service\n",
+			"show --property=" + showProperties + " nginx.service": "" +
+				"Id=nginx.service\nDescription=A high performance web server\nLoadState=loaded\n" +
+				"ActiveState=active\nSubState=running\nUnitFileState=enabled\nMainPID=4242\n",
+			"show --property=" + showProperties + " missing.service": "" +
+				"Id=missing.service\nDescription=missing.service\nLoadState=not-found\nActiveState=inactive\n",
+		},
+		fail: make(map[string]error),
+	}
+}
+
+func TestSystemdList(t *testing.T) {
+	fake := newFakeSystemctl()
+	list, err := NewSystemd(fake.run).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Status{
+		{Name: "cron", Description: "Regular background program processing daemon", State: StateRunning, Detail: "running", StartType: "enabled", Enabled: true},
+		{Name: "getty@tty1", Description: "Getty on tty1", State: StateRunning, Detail: "running"},
+		{Name: "nginx", Description: "A high performance web server", State: StateFailed, Detail: "failed", StartType: "disabled"},
+		{Name: "rsync", State: StateStopped, Detail: "dead", StartType: "disabled"},
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("Expected %d services, got %+v", len(expected), list)
+	}
+	for i := range expected {
+		if list[i] != expected[i] {
+			t.Errorf("Service %d: expected %+v, got %+v", i, expected[i], list[i])
+		}
+	}
+}
+
+func TestSystemdStatus(t *testing.T) {
+	m := NewSystemd(newFakeSystemctl().run)
+
+	status, err := m.Status(context.Background(), "nginx.service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Name != "nginx" || status.State != StateRunning || !status.Enabled || status.PID != 4242 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+
+	if _, err := m.Status(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSystemdActions(t *testing.T) {
+	fake := newFakeSystemctl()
+	m := NewSystemd(fake.run)
+	ctx := context.Background()
+
+	for _, action := range []string{ActionStart, ActionStop, ActionRestart, ActionEnable, ActionDisable} {
+		fake.calls = nil
+		if err := Do(ctx, m, "nginx", action); err != nil {
+			t.Fatalf("%s: %v", action, err)
+		}
+		if last := fake.calls[len(fake.calls)-1]; last != action+" nginx.service" {
+			t.Errorf("%s: expected systemctl %s nginx.service, got %q", action, action, last)
+		}
+	}
+
+	fake.fail["restart nginx.service"] = errors.New("Job for nginx.service failed")
+	if err := m.Restart(ctx, "nginx"); err == nil || !strings.Contains(err.Error(), "Job for nginx.service failed") {
+		t.Errorf("Expected the systemctl error, got %v", err)
+	}
+
+	fake.calls = nil
+	if err := m.Stop(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	for _, call := range fake.calls {
+		if strings.HasPrefix(call, "stop") {
+			t.Error("A missing service should not be stopped")
+		}
+	}
+}
+
+func TestSystemdRejectsOptions(t *testing.T) {
+	fake := newFakeSystemctl()
+	m := NewSystemd(fake.run)
+
+	for _, name := range []string{"", "--all", "-H", "a b", "../nginx"} {
+		if err := m.Start(context.Background(), name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q: expected ErrInvalidName, got %v", name, err)
+		}
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("Invalid names should not reach systemctl, got %v", fake.calls)
+	}
+}
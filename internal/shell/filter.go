@@ -0,0 +1,75 @@
+package shell
+
+import "unicode/utf8"
+
+// filter turns terminal output into plain text: escape sequences and
+// control characters are dropped, backspaces erase. Sequences may be split
+// between reads, so the state carries over.
+type filter struct {
+	state filterState
+}
+
+type filterState int
+
+const (
+	stateText         filterState = iota
+	stateEscape                   // after ESC
+	stateCSI                      // ESC [ parameters, until a final byte
+	stateString                   // ESC ] and other strings, until BEL or ESC \
+	stateStringEscape             // ESC inside a string
+)
+
+// append filters p and appends the text to dst
+func (f *filter) append(dst, p []byte) []byte {
+	for _, c := range p {
+		switch f.state {
+		case stateEscape:
+			switch c {
+			case '[':
+				f.state = stateCSI
+			case ']', 'P', '_', '^', 'X':
+				f.state = stateString
+			default:
+				// Two-byte sequences such as ESC = or ESC 7
+				f.state = stateText
+			}
+		case stateCSI:
+			if c >= 0x40 && c <= 0x7e {
+				f.state = stateText
+			}
+		case stateString:
+			switch c {
+			case 0x07:
+				f.state = stateText
+			case 0x1b:
+				f.state = stateStringEscape
+			}
+		case stateStringEscape:
+			if c == '\\' {
+				f.state = stateText
+			} else {
+				f.state = stateString
+			}
+		default:
+			switch {
+			case c == 0x1b:
+				f.state = stateEscape
+			case c == '\b':
+				dst = eraseRune(dst)
+			case c == '\n' || c == '\t' || c >= 0x20 && c != 0x7f:
+				dst = append(dst, c)
+			}
+			// Carriage returns and other control characters are dropped
+		}
+	}
+	return dst
+}
+
+// eraseRune removes the last character of b, but not a line break
+func eraseRune(b []byte) []byte {
+	if len(b) == 0 || b[len(b)-1] == '\n' {
+		return b
+	}
+	_, size := utf8.DecodeLastRune(b)
+	return b[:len(b)-size]
+}
@@ -0,0 +1,61 @@
+//go:build !linux
+
+package shell
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// pipes connect a shell without a pseudo-terminal. Programs see no terminal,
+// so most print no prompt and buffer their output.
+type pipes struct {
+	stdin  io.WriteCloser
+	output *io.PipeReader
+	writer *io.PipeWriter
+	cmd    *exec.Cmd
+}
+
+// startTerminal starts cmd with stdin and combined output on pipes
+func startTerminal(cmd *exec.Cmd) (terminal, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	output, writer := io.Pipe()
+	cmd.Stdout, cmd.Stderr = writer, writer
+	// Don't wait for background commands holding the output open
+	cmd.WaitDelay = closeDelay
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &pipes{stdin: stdin, output: output, writer: writer, cmd: cmd}, nil
+}
+
+func (p *pipes) Read(b []byte) (int, error) {
+	return p.output.Read(b)
+}
+
+func (p *pipes) Write(b []byte) (int, error) {
+	return p.stdin.Write(b)
+}
+
+func (p *pipes) Close() error {
+	p.stdin.Close()
+	return p.output.Close()
+}
+
+// interrupt signals the shell itself; Windows has no such signal
+func (p *pipes) interrupt() error {
+	return p.cmd.Process.Signal(os.Interrupt)
+}
+
+func (p *pipes) kill() error {
+	return p.cmd.Process.Kill()
+}
+
+// exited ends the output once the shell is gone
+func (p *pipes) exited() {
+	p.writer.Close()
+}
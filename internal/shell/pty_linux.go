@@ -0,0 +1,94 @@
+//go:build linux
+
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Terminal size reported to programs; narrow enough for a phone screen
+const (
+	terminalRows = 40
+	terminalCols = 80
+)
+
+// pty is the master side of a pseudo-terminal whose slave is the shell's
+// controlling terminal
+type pty struct {
+	*os.File
+	cmd *exec.Cmd
+}
+
+// startTerminal starts cmd in a new session on a fresh pseudo-terminal
+func startTerminal(cmd *exec.Cmd) (terminal, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	slave, err := openSlave(master)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+	defer slave.Close()
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return &pty{File: master, cmd: cmd}, nil
+}
+
+// openSlave unlocks the slave of a pseudo-terminal master and opens it. The
+// ioctls go through the raw descriptor: Fd would make the master blocking,
+// and Close could no longer interrupt a pending Read.
+func openSlave(master *os.File) (*os.File, error) {
+	conn, err := master.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var n int
+	var ioctlErr error
+	err = conn.Control(func(fd uintptr) {
+		if ioctlErr = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); ioctlErr != nil {
+			ioctlErr = fmt.Errorf("unlockpt: %w", ioctlErr)
+			return
+		}
+		if n, ioctlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPTN); ioctlErr != nil {
+			ioctlErr = fmt.Errorf("ptsname: %w", ioctlErr)
+			return
+		}
+		ws := &unix.Winsize{Row: terminalRows, Col: terminalCols}
+		if ioctlErr = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, ws); ioctlErr != nil {
+			ioctlErr = fmt.Errorf("set window size: %w", ioctlErr)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ioctlErr != nil {
+		return nil, ioctlErr
+	}
+	return os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+}
+
+// interrupt types Ctrl-C; the terminal signals the foreground process group
+func (p *pty) interrupt() error {
+	_, err := p.Write([]byte{0x03})
+	return err
+}
+
+// kill kills every process of the session, the shell leads its group
+func (p *pty) kill() error {
+	return unix.Kill(-p.cmd.Process.Pid, unix.SIGKILL)
+}
+
+func (p *pty) exited() {}
@@ -0,0 +1,247 @@
+// Package shell runs interactive shell sessions for /shell. On Linux the
+// shell gets a pseudo-terminal, elsewhere it reads and writes plain pipes.
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+// ErrClosed is returned for input to a session whose shell has exited
+var ErrClosed = errors.New("shell session is closed")
+
+// maxPending bounds the output kept between two reads; a command flooding
+// the terminal loses its oldest output instead of growing the buffer
+const maxPending = 256 * 1024
+
+// droppedMarker replaces output lost to maxPending
+const droppedMarker = "\n[…]\n"
+
+// closeDelay bounds the wait for output after the shell exits; commands left
+// in the background may keep the terminal open
+const closeDelay = time.Second
+
+// terminal is the shell side of a session: a pseudo-terminal or pipes
+type terminal interface {
+	io.ReadWriteCloser
+	// interrupt delivers Ctrl-C to the foreground command
+	interrupt() error
+	// kill kills the shell and, where possible, the commands it started
+	kill() error
+	// exited is called once the shell has exited
+	exited()
+}
+
+// Session is a running shell. Output is collected in the background and
+// taken with ReadOutput; Changed signals that there is some.
+type Session struct {
+	command string
+	cmd     *exec.Cmd
+	term    terminal
+
+	changed chan struct{}
+	done    chan struct{}
+
+	mu       sync.Mutex
+	pending  []byte
+	filter   filter
+	dropped  bool
+	closed   bool
+	exitCode int
+}
+
+// Start starts the configured shell
+func Start(cfg config.ShellConfig) (*Session, error) {
+	command := cfg.Command
+	if command == "" {
+		command = defaultShell()
+	}
+
+	cmd := exec.Command(command, cfg.Args...)
+	cmd.Dir = cfg.WorkingDir
+	// Ask programs for plain output, there is no terminal to draw on
+	cmd.Env = append(os.Environ(), "TERM=dumb", "PAGER=cat", "SYSTEMD_PAGER=cat")
+
+	term, err := startTerminal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+
+	s := &Session{
+		command:  command,
+		cmd:      cmd,
+		term:     term,
+		changed:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		exitCode: -1,
+	}
+	go s.wait()
+	return s, nil
+}
+
+// wait waits for the shell to exit and for the rest of its output
+func (s *Session) wait() {
+	read := make(chan struct{})
+	go func() {
+		s.read()
+		close(read)
+	}()
+
+	s.cmd.Wait()
+	s.term.exited()
+	select {
+	case <-read:
+	case <-time.After(closeDelay):
+	}
+	s.term.Close()
+	<-read
+
+	s.mu.Lock()
+	s.closed = true
+	if s.cmd.ProcessState != nil {
+		s.exitCode = s.cmd.ProcessState.ExitCode()
+	}
+	s.mu.Unlock()
+	close(s.done)
+}
+
+// read collects output until the terminal is closed
+func (s *Session) read() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.term.Read(buf)
+		if n > 0 {
+			s.mu.Lock()
+			s.pending = s.filter.append(s.pending, buf[:n])
+			if len(s.pending) > maxPending {
+				s.pending = append(s.pending[:0], s.pending[len(s.pending)-maxPending:]...)
+				s.dropped = true
+			}
+			s.mu.Unlock()
+			s.notify()
+		}
+		if err != nil {
+			// A pseudo-terminal reports EIO once the shell is gone
+			return
+		}
+	}
+}
+
+func (s *Session) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Command returns the shell the session runs
+func (s *Session) Command() string {
+	return s.command
+}
+
+// Changed receives a value after new output arrives
+func (s *Session) Changed() <-chan struct{} {
+	return s.changed
+}
+
+// Done is closed once the shell has exited and its output is collected
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// ExitCode returns the exit code of the shell, -1 while it runs or when it
+// was killed
+func (s *Session) ExitCode() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exitCode
+}
+
+// ReadOutput takes the output collected since the last call. A multi-byte
+// character cut between two reads stays for the next call.
+func (s *Session) ReadOutput() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.pending)
+	if !s.closed {
+		n = completeRunes(s.pending)
+	}
+	output := strings.ToValidUTF8(string(s.pending[:n]), "�")
+	s.pending = append(s.pending[:0], s.pending[n:]...)
+	if s.dropped {
+		output = droppedMarker + output
+		s.dropped = false
+	}
+	return output
+}
+
+// Write sends a line of input to the shell
+func (s *Session) Write(line string) error {
+	select {
+	case <-s.done:
+		return ErrClosed
+	default:
+	}
+	_, err := io.WriteString(s.term, line+"\n")
+	return err
+}
+
+// Interrupt sends Ctrl-C to the command running in the shell
+func (s *Session) Interrupt() error {
+	select {
+	case <-s.done:
+		return ErrClosed
+	default:
+	}
+	return s.term.interrupt()
+}
+
+// Kill kills the shell. On Linux the commands it started die with it,
+// elsewhere background commands may outlive it.
+func (s *Session) Kill() error {
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+	return s.term.kill()
+}
+
+// defaultShell is the shell of the bot user, cmd.exe on Windows
+func defaultShell() string {
+	if runtime.GOOS == "windows" {
+		if comspec := os.Getenv("COMSPEC"); comspec != "" {
+			return comspec
+		}
+		return "cmd.exe"
+	}
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+	return "/bin/sh"
+}
+
+// completeRunes returns the length of b without a trailing incomplete UTF-8
+// sequence
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
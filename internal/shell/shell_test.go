@@ -0,0 +1,141 @@
+package shell
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cupbot/cupbot/internal/config"
+)
+
+func startTestShell(t *testing.T) *Session {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	s, err := Start(config.ShellConfig{Command: "/bin/sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Kill()
+		<-s.Done()
+	})
+	return s
+}
+
+// waitForOutput collects output until it contains text
+func waitForOutput(t *testing.T, s *Session, text string) string {
+	t.Helper()
+	var output strings.Builder
+	deadline := time.After(5 * time.Second)
+	for !strings.Contains(output.String(), text) {
+		select {
+		case <-s.Changed():
+			output.WriteString(s.ReadOutput())
+		case <-s.Done():
+			output.WriteString(s.ReadOutput())
+			if !strings.Contains(output.String(), text) {
+				t.Fatalf("Shell exited without printing %q, got %q", text, output.String())
+			}
+		case <-deadline:
+			t.Fatalf("Shell did not print %q, got %q", text, output.String())
+		}
+	}
+	return output.String()
+}
+
+func TestSessionRunsCommands(t *testing.T) {
+	s := startTestShell(t)
+
+	if err := s.Write("echo hello-$((6 * 7))"); err != nil {
+		t.Fatal(err)
+	}
+	waitForOutput(t, s, "hello-42")
+
+	if err := s.Write("exit 5"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shell did not exit")
+	}
+	if code := s.ExitCode(); code != 5 {
+		t.Errorf("Expected exit code 5, got %d", code)
+	}
+	if err := s.Write("echo late"); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after exit, got %v", err)
+	}
+}
+
+func TestSessionInterrupt(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("needs a pseudo-terminal")
+	}
+	s := startTestShell(t)
+
+	s.Write("sleep 30; echo finished")
+	time.Sleep(200 * time.Millisecond)
+	if err := s.Interrupt(); err != nil {
+		t.Fatal(err)
+	}
+	s.Write("echo after-$((1 + 1))")
+	// The terminal echoes the input, the command itself never prints
+	if output := waitForOutput(t, s, "after-2"); strings.Count(output, "finished") != 1 {
+		t.Errorf("Interrupted command should not finish, got %q", output)
+	}
+}
+
+func TestSessionKill(t *testing.T) {
+	s := startTestShell(t)
+
+	s.Write("sleep 30 &")
+	if err := s.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-s.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Killed shell did not stop")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected string
+	}{
+		{"Plain", []string{"a\tb\r\nc"}, "a\tb\nc"},
+		{"Colors", []string{"\x1b[1;31mred\x1b[0m"}, "red"},
+		{"Split sequence", []string{"x\x1b[", "?2004", "hy"}, "xy"},
+		{"Title", []string{"\x1b]0;title\x07$ ", "\x1b]0;t\x1b\\ok"}, "$ ok"},
+		{"Backspace", []string{"abc\b\bd"}, "ad"},
+		{"Backspace multibyte", []string{"дa\b\b!"}, "!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f filter
+			var out []byte
+			for _, chunk := range tt.chunks {
+				out = f.append(out, []byte(chunk))
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestCompleteRunes(t *testing.T) {
+	b := []byte("ok д")
+	if n := completeRunes(b[:len(b)-1]); n != 3 {
+		t.Errorf("Expected the cut character left out, got %d", n)
+	}
+	if n := completeRunes(b); n != len(b) {
+		t.Errorf("Expected the whole input, got %d", n)
+	}
+}
@@ -0,0 +1,173 @@
+package system
+
+import (
+	"context"
+	"log"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// maxCPUWindow is the longest span CPU usage is averaged over; an older
+// reading is replaced by a fresh one-second measurement
+const maxCPUWindow = time.Minute
+
+// DefaultInterval is used by Start when no interval is given
+const DefaultInterval = 5 * time.Second
+
+// Start takes a snapshot every interval in the background until Stop
+func (s *Service) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	s.snapMu.Lock()
+	defer s.snapMu.Unlock()
+	if s.running {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running, s.cancel = true, cancel
+	s.wg.Add(1)
+	go s.run(ctx, interval)
+}
+
+// Stop stops the background collector and waits for it
+func (s *Service) Stop() {
+	s.snapMu.Lock()
+	if !s.running {
+		s.snapMu.Unlock()
+		return
+	}
+	s.running = false
+	s.cancel()
+	s.snapMu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Service) run(ctx context.Context, interval time.Duration) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.refresh(); err != nil {
+			log.Printf("Failed to collect system info: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh takes a snapshot and keeps it for GetSystemInfo. A failed
+// snapshot leaves the previous one, which grows stale.
+func (s *Service) refresh() (*SystemInfo, error) {
+	s.collectMu.Lock()
+	defer s.collectMu.Unlock()
+
+	info, err := s.collect()
+	if err != nil {
+		return nil, err
+	}
+
+	s.snapMu.Lock()
+	s.snapshot = info
+	s.snapMu.Unlock()
+	return info.clone(), nil
+}
+
+// cpuUsage returns the usage of every core since the previous snapshot.
+// Without a recent one it measures for a second.
+func (s *Service) cpuUsage() ([]float64, error) {
+	if s.cpuTimes == nil || time.Since(s.cpuAt) > maxCPUWindow {
+		times, err := cpu.Times(true)
+		if err != nil {
+			return nil, err
+		}
+		s.cpuTimes, s.cpuAt = times, time.Now()
+		time.Sleep(time.Second)
+	}
+
+	times, err := cpu.Times(true)
+	if err != nil {
+		return nil, err
+	}
+	usage := coreUsage(s.cpuTimes, times)
+	s.cpuTimes, s.cpuAt = times, time.Now()
+	return usage, nil
+}
+
+// coreUsage computes the busy percentage of every core between two readings
+func coreUsage(prev, cur []cpu.TimesStat) []float64 {
+	usage := make([]float64, len(cur))
+	for i := range cur {
+		if i >= len(prev) {
+			continue
+		}
+		prevTotal, prevBusy := busyTimes(prev[i])
+		total, busy := busyTimes(cur[i])
+		if total <= prevTotal {
+			continue
+		}
+		usage[i] = math.Max(0, math.Min(100, (busy-prevBusy)/(total-prevTotal)*100))
+	}
+	return usage
+}
+
+// busyTimes returns the total and the busy time of a core
+func busyTimes(t cpu.TimesStat) (float64, float64) {
+	total := t.Total()
+	if runtime.GOOS == "linux" {
+		// Guest time is already counted in user time
+		total -= t.Guest + t.GuestNice
+	}
+	return total, total - t.Idle - t.Iowait
+}
+
+// networkRates fills the rates of info from the counters of the previous
+// snapshot; the caller holds collectMu
+func (s *Service) networkRates(info *SystemInfo) {
+	prev := s.snapshot
+	if prev == nil {
+		return
+	}
+	elapsed := info.CollectedAt.Sub(prev.CollectedAt)
+	if elapsed <= 0 {
+		return
+	}
+
+	counters := make(map[string]NetworkInfo, len(prev.NetworkInfo))
+	for _, n := range prev.NetworkInfo {
+		counters[n.Name] = n
+	}
+	info.RateInterval = elapsed
+	for i := range info.NetworkInfo {
+		n := &info.NetworkInfo[i]
+		last, ok := counters[n.Name]
+		// A counter that went back was reset
+		if !ok || n.BytesSent < last.BytesSent || n.BytesRecv < last.BytesRecv {
+			continue
+		}
+		n.SentRate = float64(n.BytesSent-last.BytesSent) / elapsed.Seconds()
+		n.RecvRate = float64(n.BytesRecv-last.BytesRecv) / elapsed.Seconds()
+	}
+}
+
+// clone copies a snapshot so that callers cannot change the kept one
+func (i *SystemInfo) clone() *SystemInfo {
+	c := *i
+	c.CPUInfo.Usage = append([]float64(nil), i.CPUInfo.Usage...)
+	c.DiskInfo = append([]DiskInfo(nil), i.DiskInfo...)
+	c.NetworkInfo = append([]NetworkInfo(nil), i.NetworkInfo...)
+	c.Load = append([]float64(nil), i.Load...)
+	return &c
+}
@@ -0,0 +1,101 @@
+package system
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+func TestCoreUsage(t *testing.T) {
+	prev := []cpu.TimesStat{
+		{User: 10, System: 5, Idle: 85},
+		{User: 50, Idle: 50},
+	}
+	cur := []cpu.TimesStat{
+		{User: 40, System: 15, Idle: 145}, // 40 busy of 100
+		{User: 50, Idle: 50},              // no time passed
+		{User: 1, Idle: 1},                // a core the previous reading lacks
+	}
+
+	usage := coreUsage(prev, cur)
+	expected := []float64{40, 0, 0}
+	if len(usage) != len(expected) {
+		t.Fatalf("Expected %d cores, got %v", len(expected), usage)
+	}
+	for i := range expected {
+		if usage[i] != expected[i] {
+			t.Errorf("Core %d: expected %.1f%%, got %.1f%%", i, expected[i], usage[i])
+		}
+	}
+}
+
+func TestNetworkRates(t *testing.T) {
+	at := time.Now()
+	s := NewService()
+	s.snapshot = &SystemInfo{
+		CollectedAt: at.Add(-2 * time.Second),
+		NetworkInfo: []NetworkInfo{
+			{Name: "eth0", BytesSent: 1000, BytesRecv: 5000},
+			{Name: "wlan0", BytesSent: 900, BytesRecv: 900},
+		},
+	}
+
+	info := &SystemInfo{
+		CollectedAt: at,
+		NetworkInfo: []NetworkInfo{
+			{Name: "eth0", BytesSent: 3000, BytesRecv: 9000},
+			{Name: "wlan0", BytesSent: 100, BytesRecv: 100}, // reset
+			{Name: "tun0", BytesSent: 10, BytesRecv: 10},    // new
+		},
+	}
+	s.networkRates(info)
+
+	if info.RateInterval != 2*time.Second {
+		t.Errorf("Expected rates over 2s, got %v", info.RateInterval)
+	}
+	if n := info.NetworkInfo[0]; n.SentRate != 1000 || n.RecvRate != 2000 {
+		t.Errorf("Expected 1000 B/s sent and 2000 B/s received, got %+v", n)
+	}
+	for _, n := range info.NetworkInfo[1:] {
+		if n.SentRate != 0 || n.RecvRate != 0 {
+			t.Errorf("Expected no rate for %s, got %+v", n.Name, n)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	s := NewService()
+	s.Start(time.Hour)
+	defer s.Stop()
+
+	first, err := s.GetSystemInfo()
+	if err != nil {
+		t.Fatalf("Failed to get system info: %v", err)
+	}
+	if first.CollectedAt.IsZero() {
+		t.Error("Expected the snapshot to record when it was taken")
+	}
+
+	// Later requests read the snapshot instead of measuring again
+	start := time.Now()
+	second, err := s.GetSystemInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > 100*time.Millisecond {
+		t.Errorf("Expected the cached snapshot, took %v", took)
+	}
+	if !second.CollectedAt.Equal(first.CollectedAt) {
+		t.Errorf("Expected the same snapshot, got %v and %v", first.CollectedAt, second.CollectedAt)
+	}
+
+	// Callers get copies
+	second.NetworkInfo = nil
+	if third, _ := s.GetSystemInfo(); len(third.NetworkInfo) != len(first.NetworkInfo) {
+		t.Error("Changing a returned snapshot should not change the kept one")
+	}
+
+	s.Stop()
+	s.Stop()
+}
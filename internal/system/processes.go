@@ -0,0 +1,211 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Orders of the process list
+const (
+	SortByCPU    = "cpu"
+	SortByMemory = "mem"
+)
+
+var (
+	// ErrProcessNotFound is returned for a process that exited, including
+	// one whose PID was reused by a newer process
+	ErrProcessNotFound = errors.New("process not found")
+	// ErrOwnProcess is returned for signals to the bot itself
+	ErrOwnProcess = errors.New("refusing to signal the bot's own process")
+)
+
+const (
+	// cpuSampleInterval is measured before a listing when the previous
+	// sample is missing or older than maxCPUSampleAge
+	cpuSampleInterval = 500 * time.Millisecond
+	maxCPUSampleAge   = 10 * time.Second
+)
+
+// ProcessInfo описывает процесс в списке
+type ProcessInfo struct {
+	PID        int32   `json:"pid"`
+	Name       string  `json:"name"`
+	CPUPercent float64 `json:"cpu_percent"` // since the previous listing, of all cores together
+	RSS        uint64  `json:"rss"`
+	CreateTime int64   `json:"create_time"` // ms since the epoch, tells processes with the same PID apart
+}
+
+// ProcessDetails описывает один процесс подробно
+type ProcessDetails struct {
+	ProcessInfo
+	PPID          int32         `json:"ppid"`
+	Username      string        `json:"username"`
+	Cmdline       string        `json:"cmdline"`
+	Exe           string        `json:"exe"`
+	Status        string        `json:"status"`
+	Threads       int32         `json:"threads"`
+	MemoryPercent float32       `json:"memory_percent"`
+	StartedAt     time.Time     `json:"started_at"`
+	OpenFiles     []string      `json:"open_files"`
+	OpenFilesErr  error         `json:"-"` // open files are often hidden from other users
+	Children      []ProcessInfo `json:"children"`
+}
+
+// sampledProcess keeps a process between listings; gopsutil measures CPU
+// usage since the previous call on the same value
+type sampledProcess struct {
+	proc       *process.Process
+	createTime int64
+}
+
+// ListProcesses returns the processes whose name contains filter, ignoring
+// case, sorted by CPU usage or resident memory
+func (s *Service) ListProcesses(sortBy, filter string) ([]ProcessInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	sampled := make(map[int32]*sampledProcess, len(procs))
+	for _, p := range procs {
+		createTime, err := p.CreateTime()
+		if err != nil {
+			continue // exited meanwhile
+		}
+		if previous, ok := s.processes[p.Pid]; ok && previous.createTime == createTime {
+			sampled[p.Pid] = previous
+		} else {
+			sampled[p.Pid] = &sampledProcess{proc: p, createTime: createTime}
+		}
+	}
+
+	// Without a recent sample every usage would read as zero
+	if time.Since(s.sampledAt) > maxCPUSampleAge {
+		for _, sp := range sampled {
+			sp.proc.Percent(0)
+		}
+		time.Sleep(cpuSampleInterval)
+	}
+
+	filter = strings.ToLower(filter)
+	list := make([]ProcessInfo, 0, len(sampled))
+	for pid, sp := range sampled {
+		name, err := sp.proc.Name()
+		if err != nil {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(name), filter) {
+			continue
+		}
+		info := ProcessInfo{PID: pid, Name: name, CreateTime: sp.createTime}
+		info.CPUPercent, _ = sp.proc.Percent(0)
+		if mem, err := sp.proc.MemoryInfo(); err == nil {
+			info.RSS = mem.RSS
+		}
+		list = append(list, info)
+	}
+	s.processes, s.sampledAt = sampled, time.Now()
+
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch {
+		case sortBy == SortByMemory && a.RSS != b.RSS:
+			return a.RSS > b.RSS
+		case sortBy != SortByMemory && a.CPUPercent != b.CPUPercent:
+			return a.CPUPercent > b.CPUPercent
+		}
+		return a.PID < b.PID
+	})
+	return list, nil
+}
+
+// GetProcess returns the details of a process. A non-zero createTime must
+// match, so a PID reused since the listing is not mistaken for the process.
+func (s *Service) GetProcess(pid int32, createTime int64) (*ProcessDetails, error) {
+	p, err := findProcess(pid, createTime)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &ProcessDetails{}
+	d.PID = pid
+	d.Name, _ = p.Name()
+	d.CreateTime, _ = p.CreateTime()
+	d.StartedAt = time.UnixMilli(d.CreateTime)
+	if mem, err := p.MemoryInfo(); err == nil {
+		d.RSS = mem.RSS
+	}
+	d.MemoryPercent, _ = p.MemoryPercent()
+	d.PPID, _ = p.Ppid()
+	d.Username, _ = p.Username()
+	d.Cmdline, _ = p.Cmdline()
+	d.Exe, _ = p.Exe()
+	d.Threads, _ = p.NumThreads()
+	if status, err := p.Status(); err == nil {
+		d.Status = strings.Join(status, ", ")
+	}
+
+	files, err := p.OpenFiles()
+	if err != nil {
+		d.OpenFilesErr = err
+	}
+	for _, f := range files {
+		d.OpenFiles = append(d.OpenFiles, f.Path)
+	}
+
+	// Children are found by parent PID; gopsutil would need pgrep on Linux
+	procs, err := process.Processes()
+	if err == nil {
+		for _, child := range procs {
+			if ppid, err := child.Ppid(); err != nil || ppid != pid || child.Pid == pid {
+				continue
+			}
+			info := ProcessInfo{PID: child.Pid}
+			info.Name, _ = child.Name()
+			info.CreateTime, _ = child.CreateTime()
+			d.Children = append(d.Children, info)
+		}
+	}
+	sort.Slice(d.Children, func(i, j int) bool { return d.Children[i].PID < d.Children[j].PID })
+
+	return d, nil
+}
+
+// SignalProcess terminates a process, or kills it when kill is set. On
+// Windows both end the process at once.
+func (s *Service) SignalProcess(pid int32, createTime int64, kill bool) error {
+	if int(pid) == os.Getpid() {
+		return ErrOwnProcess
+	}
+	p, err := findProcess(pid, createTime)
+	if err != nil {
+		return err
+	}
+	if kill {
+		return p.Kill()
+	}
+	return p.Terminate()
+}
+
+// findProcess opens a running process, checking its start time
+func findProcess(pid int32, createTime int64) (*process.Process, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, ErrProcessNotFound
+	}
+	if createTime != 0 {
+		if started, err := p.CreateTime(); err != nil || started != createTime {
+			return nil, ErrProcessNotFound
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,120 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// startSleep starts a child process that lives until the test ends
+func startSleep(t *testing.T) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd
+}
+
+func TestListProcesses(t *testing.T) {
+	s := NewService()
+	child := startSleep(t)
+
+	for _, sortBy := range []string{SortByCPU, SortByMemory} {
+		list, err := s.ListProcesses(sortBy, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for i, p := range list {
+			if p.PID == int32(child.Process.Pid) {
+				found = p.Name == "sleep" && p.CreateTime > 0
+			}
+			if i == 0 {
+				continue
+			}
+			prev := list[i-1]
+			if sortBy == SortByCPU && prev.CPUPercent < p.CPUPercent || sortBy == SortByMemory && prev.RSS < p.RSS {
+				t.Fatalf("Processes are not sorted by %s at %d", sortBy, i)
+			}
+		}
+		if !found {
+			t.Errorf("Sorted by %s: child process %d missing or incomplete", sortBy, child.Process.Pid)
+		}
+	}
+
+	list, err := s.ListProcesses(SortByCPU, "SLEE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 {
+		t.Fatal("Filter should match the child process ignoring case")
+	}
+	for _, p := range list {
+		if p.Name != "sleep" {
+			t.Errorf("Filter matched %q", p.Name)
+		}
+	}
+}
+
+func TestGetProcess(t *testing.T) {
+	s := NewService()
+	child := startSleep(t)
+
+	self, err := s.GetProcess(int32(os.Getpid()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.RSS == 0 || self.CreateTime == 0 || self.Cmdline == "" {
+		t.Errorf("Expected memory, start time and command line, got %+v", self)
+	}
+	found := false
+	for _, c := range self.Children {
+		found = found || c.PID == int32(child.Process.Pid)
+	}
+	if !found {
+		t.Errorf("Child %d missing from %v", child.Process.Pid, self.Children)
+	}
+
+	if _, err := s.GetProcess(int32(os.Getpid()), self.CreateTime+1); !errors.Is(err, ErrProcessNotFound) {
+		t.Errorf("A different start time should not match, got %v", err)
+	}
+}
+
+func TestSignalProcess(t *testing.T) {
+	s := NewService()
+	child := startSleep(t)
+
+	if err := s.SignalProcess(int32(os.Getpid()), 0, true); !errors.Is(err, ErrOwnProcess) {
+		t.Errorf("Expected the bot to refuse signalling itself, got %v", err)
+	}
+
+	info, err := s.GetProcess(int32(child.Process.Pid), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SignalProcess(info.PID, info.CreateTime+1, false); !errors.Is(err, ErrProcessNotFound) {
+		t.Errorf("A different start time should not match, got %v", err)
+	}
+	if err := s.SignalProcess(info.PID, info.CreateTime, false); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- child.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Terminated process is still running")
+	}
+}
@@ -1,8 +1,10 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
@@ -25,6 +27,11 @@ type SystemInfo struct {
 	DiskInfo     []DiskInfo    `json:"disk_info"`
 	NetworkInfo  []NetworkInfo `json:"network_info"`
 	ProcessCount uint64        `json:"process_count"`
+	Load         []float64     `json:"load,omitempty"` // 1, 5 и 15 минут; nil, если недоступно
+	CollectedAt  time.Time     `json:"collected_at"`
+	// RateInterval is the span the network rates were measured over, zero
+	// in the first snapshot
+	RateInterval time.Duration `json:"rate_interval"`
 }
 
 type CPUInfo struct {
@@ -52,22 +59,55 @@ type DiskInfo struct {
 }
 
 type NetworkInfo struct {
-	Name      string `json:"name"`
-	BytesSent uint64 `json:"bytes_sent"`
-	BytesRecv uint64 `json:"bytes_recv"`
+	Name      string  `json:"name"`
+	BytesSent uint64  `json:"bytes_sent"`
+	BytesRecv uint64  `json:"bytes_recv"`
+	SentRate  float64 `json:"sent_rate"` // bytes per second
+	RecvRate  float64 `json:"recv_rate"`
 }
 
-// Service предоставляет методы для получения системной информации
-type Service struct{}
+// Service предоставляет методы для получения системной информации. После
+// Start снимок системы обновляется в фоне, и GetSystemInfo отвечает сразу.
+type Service struct {
+	// Processes of the last listing, for CPU usage between listings
+	mu        sync.Mutex
+	processes map[int32]*sampledProcess
+	sampledAt time.Time
+
+	// collectMu serializes snapshots; the fields below it belong to the
+	// snapshot in progress
+	collectMu sync.Mutex
+	cpuModel  string
+	cpuTimes  []cpu.TimesStat
+	cpuAt     time.Time
+
+	snapMu   sync.RWMutex
+	snapshot *SystemInfo
+	running  bool
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
 
 // NewService создает новый экземпляр сервиса
 func NewService() *Service {
 	return &Service{}
 }
 
-// GetSystemInfo получает полную информацию о системе
+// GetSystemInfo возвращает последний снимок системы. Пока сборщик не
+// запущен, снимок снимается при каждом вызове.
 func (s *Service) GetSystemInfo() (*SystemInfo, error) {
-	info := &SystemInfo{}
+	s.snapMu.RLock()
+	snapshot, running := s.snapshot, s.running
+	s.snapMu.RUnlock()
+	if running && snapshot != nil {
+		return snapshot.clone(), nil
+	}
+	return s.refresh()
+}
+
+// collect takes a snapshot of the system; the caller holds collectMu
+func (s *Service) collect() (*SystemInfo, error) {
+	info := &SystemInfo{CollectedAt: time.Now()}
 
 	// Информация о хосте
 	hostInfo, err := host.Info()
@@ -109,28 +149,35 @@ func (s *Service) GetSystemInfo() (*SystemInfo, error) {
 		return nil, fmt.Errorf("failed to get network info: %w", err)
 	}
 	info.NetworkInfo = netInfo
+	s.networkRates(info)
+
+	if loads, err := s.GetLoadAverage(); err == nil {
+		info.Load = loads
+	}
 
 	return info, nil
 }
 
 func (s *Service) getCPUInfo() (*CPUInfo, error) {
-	cpuInfos, err := cpu.Info()
-	if err != nil {
-		return nil, err
+	// The model does not change, and cpu.Info is slow on Windows
+	if s.cpuModel == "" {
+		cpuInfos, err := cpu.Info()
+		if err != nil {
+			return nil, err
+		}
+		if len(cpuInfos) > 0 {
+			s.cpuModel = cpuInfos[0].ModelName
+		}
 	}
 
 	info := &CPUInfo{
-		Cores: runtime.NumCPU(),
-	}
-
-	if len(cpuInfos) > 0 {
-		info.ModelName = cpuInfos[0].ModelName
+		ModelName: s.cpuModel,
+		Cores:     runtime.NumCPU(),
 	}
 
 	// Получение загрузки CPU
-	percentages, err := cpu.Percent(time.Second, true)
-	if err == nil {
-		info.Usage = percentages
+	if usage, err := s.cpuUsage(); err == nil {
+		info.Usage = usage
 	}
 
 	// Попытка получить температуру (может не работать на Windows)
@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Client is the part of the Bot API used by the bot
+type Client interface {
+	// Self returns the bot account
+	Self() tgbotapi.User
+	// GetMe asks the Bot API for the bot account, checking the connection
+	GetMe() (tgbotapi.User, error)
+
+	SendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error)
+	EditMessageText(edit tgbotapi.EditMessageTextConfig) (tgbotapi.Message, error)
+	AnswerCallback(answer tgbotapi.CallbackConfig) error
+	SendPhoto(photo tgbotapi.PhotoConfig) (tgbotapi.Message, error)
+	SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error)
+	PinChatMessage(pin tgbotapi.PinChatMessageConfig) error
+	UnpinChatMessage(unpin tgbotapi.UnpinChatMessageConfig) error
+
+	// GetUpdatesChan starts long polling until StopReceivingUpdates
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+
+	SetCommands(config tgbotapi.SetMyCommandsConfig) error
+	DeleteCommands(config tgbotapi.DeleteMyCommandsConfig) error
+
+	SetWebhook(config WebhookConfig) error
+	DeleteWebhook() error
+}
+
+// WebhookConfig holds setWebhook parameters. tgbotapi.WebhookConfig has no
+// secret_token, so setWebhook is sent by hand.
+type WebhookConfig struct {
+	URL                string
+	SecretToken        string
+	CertificateFile    string // uploaded when set, for self-signed certificates
+	MaxConnections     int
+	DropPendingUpdates bool
+}
+
+// BotAPIClient implements Client on top of tgbotapi.BotAPI
+type BotAPIClient struct {
+	api *tgbotapi.BotAPI
+}
+
+// NewClient connects to the Bot API at endpoint, in tgbotapi.APIEndpoint
+// format. An empty endpoint means the public Bot API.
+func NewClient(token, endpoint string, debug bool) (*BotAPIClient, error) {
+	if endpoint == "" {
+		endpoint = tgbotapi.APIEndpoint
+	}
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(token, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	api.Debug = debug
+
+	return &BotAPIClient{api: api}, nil
+}
+
+func (c *BotAPIClient) Self() tgbotapi.User {
+	return c.api.Self
+}
+
+func (c *BotAPIClient) GetMe() (tgbotapi.User, error) {
+	return c.api.GetMe()
+}
+
+func (c *BotAPIClient) SendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	return c.api.Send(msg)
+}
+
+func (c *BotAPIClient) EditMessageText(edit tgbotapi.EditMessageTextConfig) (tgbotapi.Message, error) {
+	return c.api.Send(edit)
+}
+
+func (c *BotAPIClient) AnswerCallback(answer tgbotapi.CallbackConfig) error {
+	_, err := c.api.Request(answer)
+	return err
+}
+
+func (c *BotAPIClient) SendPhoto(photo tgbotapi.PhotoConfig) (tgbotapi.Message, error) {
+	return c.api.Send(photo)
+}
+
+func (c *BotAPIClient) SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	return c.api.Send(doc)
+}
+
+func (c *BotAPIClient) PinChatMessage(pin tgbotapi.PinChatMessageConfig) error {
+	_, err := c.api.Request(pin)
+	return err
+}
+
+func (c *BotAPIClient) UnpinChatMessage(unpin tgbotapi.UnpinChatMessageConfig) error {
+	_, err := c.api.Request(unpin)
+	return err
+}
+
+func (c *BotAPIClient) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return c.api.GetUpdatesChan(config)
+}
+
+func (c *BotAPIClient) StopReceivingUpdates() {
+	c.api.StopReceivingUpdates()
+}
+
+func (c *BotAPIClient) SetCommands(config tgbotapi.SetMyCommandsConfig) error {
+	_, err := c.api.Request(config)
+	return err
+}
+
+func (c *BotAPIClient) DeleteCommands(config tgbotapi.DeleteMyCommandsConfig) error {
+	_, err := c.api.Request(config)
+	return err
+}
+
+func (c *BotAPIClient) SetWebhook(config WebhookConfig) error {
+	params := make(tgbotapi.Params)
+	params["url"] = config.URL
+	params.AddNonEmpty("secret_token", config.SecretToken)
+	params.AddNonZero("max_connections", config.MaxConnections)
+	params.AddBool("drop_pending_updates", config.DropPendingUpdates)
+
+	var err error
+	if config.CertificateFile != "" {
+		files := []tgbotapi.RequestFile{{Name: "certificate", Data: tgbotapi.FilePath(config.CertificateFile)}}
+		_, err = c.api.UploadFiles("setWebhook", params, files)
+	} else {
+		_, err = c.api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return fmt.Errorf("setWebhook failed: %w", err)
+	}
+	return nil
+}
+
+func (c *BotAPIClient) DeleteWebhook() error {
+	_, err := c.api.Request(tgbotapi.DeleteWebhookConfig{})
+	return err
+}
@@ -0,0 +1,269 @@
+// Package render prepares text for Telegram HTML messages: escaping of
+// user and filesystem content, splitting of replies longer than a message
+// and a plain text form for documents and parse failures.
+package render
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Telegram limits, counted in UTF-16 code units
+const (
+	MaxMessageLength = 4096
+	MaxCaptionLength = 1024
+)
+
+// HTML is text that is already safe to send with ParseMode HTML
+type HTML string
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Escape makes arbitrary text safe to embed in an HTML message
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Code wraps text in a <code> entity
+func Code(s string) HTML {
+	return HTML("<code>" + Escape(s) + "</code>")
+}
+
+// EscapeArgs escapes the text arguments of a format string. HTML arguments
+// are passed through, numbers and durations are left for fmt to format.
+func EscapeArgs(args []any) []any {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case HTML:
+			escaped[i] = string(v)
+		case string:
+			escaped[i] = Escape(v)
+		case error:
+			escaped[i] = Escape(v.Error())
+		case fmt.Stringer:
+			escaped[i] = Escape(v.String())
+		default:
+			escaped[i] = arg
+		}
+	}
+	return escaped
+}
+
+// Plain strips the tags of an HTML message and decodes its entities
+func Plain(text string) string {
+	var b strings.Builder
+	for _, tok := range tokenize(text) {
+		if tok.tag == "" {
+			b.WriteString(tok.text)
+		}
+	}
+	return html.UnescapeString(b.String())
+}
+
+// Length returns the length of text as Telegram counts it
+func Length(text string) int {
+	n := 0
+	for _, r := range text {
+		n += runeLength(r)
+	}
+	return n
+}
+
+// runeLength counts invalid runes as one code unit, like U+FFFD
+func runeLength(r rune) int {
+	if l := utf16.RuneLen(r); l > 0 {
+		return l
+	}
+	return 1
+}
+
+// Truncate shortens plain text to at most limit UTF-16 code units, marking
+// the cut with an ellipsis
+func Truncate(text string, limit int) string {
+	if Length(text) <= limit {
+		return text
+	}
+	n := 0
+	for i, r := range text {
+		if n+runeLength(r)+1 > limit {
+			return text[:i] + "…"
+		}
+		n += runeLength(r)
+	}
+	return text
+}
+
+// Split cuts an HTML message into parts of at most limit UTF-16 code units.
+// Parts end at line breaks where possible, and tags open at a cut are
+// closed at the end of the part and reopened at the start of the next one.
+func Split(text string, limit int) []string {
+	if Length(text) <= limit {
+		return []string{text}
+	}
+
+	s := splitter{limit: limit}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		tokens := tokenize(line)
+		// Keep the line whole when it fits in a fresh part
+		if s.size > s.reopened && s.size+Length(line)+closingLength(s.openAfter(tokens)) > limit {
+			s.flush()
+		}
+		for _, tok := range tokens {
+			s.write(tok)
+		}
+	}
+	if s.size > s.reopened {
+		s.flush()
+	}
+	return s.parts
+}
+
+// splitter accumulates the parts of a message
+type splitter struct {
+	limit    int
+	parts    []string
+	current  strings.Builder
+	size     int
+	reopened int      // size of the tags reopened at the start of the part
+	open     []*token // tags open at the end of the current part
+}
+
+// write appends a token, starting a new part when it does not fit
+func (s *splitter) write(tok *token) {
+	for _, piece := range s.pieces(tok) {
+		if s.size > s.reopened && s.size+Length(piece.text)+closingLength(s.openAfter([]*token{piece})) > s.limit {
+			s.flush()
+		}
+		s.current.WriteString(piece.text)
+		s.size += Length(piece.text)
+		s.open = s.openAfter([]*token{piece})
+	}
+}
+
+// pieces cuts text longer than a part into several tokens
+func (s *splitter) pieces(tok *token) []*token {
+	if tok.tag != "" || Length(tok.text) <= s.limit/2 {
+		return []*token{tok}
+	}
+	var result []*token
+	text := tok.text
+	for text != "" {
+		cut := len(text)
+		n := 0
+		for i, r := range text {
+			if n+runeLength(r) > s.limit/2 {
+				cut = i
+				break
+			}
+			n += runeLength(r)
+		}
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+		result = append(result, &token{text: text[:cut]})
+		text = text[cut:]
+	}
+	return result
+}
+
+// flush finishes the current part and reopens its open tags in the next one
+func (s *splitter) flush() {
+	for i := len(s.open) - 1; i >= 0; i-- {
+		s.current.WriteString("</" + s.open[i].tag + ">")
+	}
+	s.parts = append(s.parts, s.current.String())
+
+	s.current.Reset()
+	for _, tag := range s.open {
+		s.current.WriteString(tag.text)
+	}
+	s.size = Length(s.current.String())
+	s.reopened = s.size
+}
+
+// openAfter returns the tags that are open after writing tokens
+func (s *splitter) openAfter(tokens []*token) []*token {
+	open := append([]*token(nil), s.open...)
+	for _, tok := range tokens {
+		switch {
+		case tok.tag == "":
+		case tok.closing:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i].tag == tok.tag {
+					open = open[:i]
+					break
+				}
+			}
+		default:
+			open = append(open, tok)
+		}
+	}
+	return open
+}
+
+// closingLength is the length of the closing tags for open
+func closingLength(open []*token) int {
+	n := 0
+	for _, tag := range open {
+		n += len(tag.tag) + 3
+	}
+	return n
+}
+
+// token is a tag, an entity or a run of text of an HTML message
+type token struct {
+	text    string
+	tag     string // tag name, empty for text
+	closing bool
+}
+
+// tokenize cuts HTML into tags and text. Entities are kept whole and text
+// is cut after spaces, so parts never end in the middle of either.
+func tokenize(s string) []*token {
+	var tokens []*token
+	for s != "" {
+		switch s[0] {
+		case '<':
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				tokens = append(tokens, &token{text: s})
+				return tokens
+			}
+			tokens = append(tokens, tagToken(s[:end+1]))
+			s = s[end+1:]
+		case '&':
+			end := strings.IndexByte(s, ';')
+			if end < 0 || end > 10 {
+				end = 0
+			}
+			tokens = append(tokens, &token{text: s[:end+1]})
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, " <&")
+			switch {
+			case end < 0:
+				end = len(s)
+			case s[end] == ' ':
+				end++
+			}
+			tokens = append(tokens, &token{text: s[:end]})
+			s = s[end:]
+		}
+	}
+	return tokens
+}
+
+// tagToken parses "<b>", "</b>" or `<a href="...">`
+func tagToken(text string) *token {
+	name := strings.TrimSuffix(strings.TrimPrefix(text, "<"), ">")
+	closing := strings.HasPrefix(name, "/")
+	name = strings.TrimPrefix(name, "/")
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name = name[:i]
+	}
+	return &token{text: text, tag: strings.ToLower(name), closing: closing}
+}
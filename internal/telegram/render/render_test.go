@@ -0,0 +1,119 @@
+package render
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscape(t *testing.T) {
+	if got := Escape(`<b>john_doe & *co*</b>`); got != "&lt;b&gt;john_doe &amp; *co*&lt;/b&gt;" {
+		t.Errorf("Unexpected escaping: %q", got)
+	}
+	if got := Code("a<b"); got != "<code>a&lt;b</code>" {
+		t.Errorf("Unexpected code entity: %q", got)
+	}
+}
+
+func TestEscapeArgs(t *testing.T) {
+	args := EscapeArgs([]any{"<x>", errors.New("a & b"), HTML("<b>ok</b>"), 42, time.Minute})
+	expected := []any{"&lt;x&gt;", "a &amp; b", "<b>ok</b>", 42, "1m0s"}
+	for i := range expected {
+		if args[i] != expected[i] {
+			t.Errorf("Argument %d: expected %v, got %v", i, expected[i], args[i])
+		}
+	}
+}
+
+func TestPlain(t *testing.T) {
+	if got := Plain("<b>Name:</b> <code>a &lt;b&gt; &amp; c</code>"); got != "Name: a <b> & c" {
+		t.Errorf("Unexpected plain text: %q", got)
+	}
+}
+
+func TestLength(t *testing.T) {
+	// Emoji outside the BMP take two UTF-16 code units
+	if got := Length("ab📁ж"); got != 5 {
+		t.Errorf("Expected length 5, got %d", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("short", 10); got != "short" {
+		t.Errorf("Short text should be kept, got %q", got)
+	}
+	if got := Truncate("0123456789", 5); got != "0123…" {
+		t.Errorf("Unexpected truncation: %q", got)
+	}
+}
+
+func TestSplitShortMessage(t *testing.T) {
+	parts := Split("<b>hello</b>", 100)
+	if len(parts) != 1 || parts[0] != "<b>hello</b>" {
+		t.Errorf("Short message should be kept whole, got %q", parts)
+	}
+}
+
+func TestSplitAtLines(t *testing.T) {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, "line with some text")
+	}
+	text := strings.Join(lines, "\n")
+
+	parts := Split(text, 100)
+	if len(parts) < 2 {
+		t.Fatalf("Expected several parts, got %d", len(parts))
+	}
+	for _, part := range parts {
+		if Length(part) > 100 {
+			t.Errorf("Part exceeds the limit: %d", Length(part))
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(part, "\n"), "\n") {
+			if line != "line with some text" {
+				t.Errorf("Line was cut: %q", line)
+			}
+		}
+	}
+	if strings.Join(parts, "") != text {
+		t.Error("Parts should add up to the message")
+	}
+}
+
+func TestSplitReopensTags(t *testing.T) {
+	text := "<pre>" + strings.Repeat("output line\n", 40) + "</pre>"
+
+	parts := Split(text, 120)
+	if len(parts) < 2 {
+		t.Fatalf("Expected several parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if Length(part) > 120 {
+			t.Errorf("Part %d exceeds the limit: %d", i, Length(part))
+		}
+		if !strings.HasPrefix(part, "<pre>") || !strings.HasSuffix(part, "</pre>") {
+			t.Errorf("Part %d should be a whole <pre> entity: %q", i, part)
+		}
+	}
+}
+
+func TestSplitLongLine(t *testing.T) {
+	text := "<b>" + strings.Repeat("word&amp;", 100) + "</b>"
+
+	parts := Split(text, 64)
+	for i, part := range parts {
+		if Length(part) > 64 {
+			t.Errorf("Part %d exceeds the limit: %d", i, Length(part))
+		}
+		if strings.Count(part, "<b>") != strings.Count(part, "</b>") {
+			t.Errorf("Part %d has unbalanced tags: %q", i, part)
+		}
+		if strings.Contains(strings.ReplaceAll(part, "&amp;", ""), "&") {
+			t.Errorf("Part %d cuts an entity: %q", i, part)
+		}
+	}
+	if got := Plain(strings.Join(parts, "")); got != Plain(text) {
+		t.Error("Parts should keep the text of the message")
+	}
+}
@@ -0,0 +1,496 @@
+// Package telegramtest provides an in-process fake of telegram.Client for tests
+package telegramtest
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/cupbot/cupbot/internal/telegram"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Bot API methods recorded by Fake
+const (
+	MethodSendMessage    = "sendMessage"
+	MethodEditMessage    = "editMessageText"
+	MethodAnswerCallback = "answerCallbackQuery"
+	MethodSendPhoto      = "sendPhoto"
+	MethodSendDocument   = "sendDocument"
+	MethodPinMessage     = "pinChatMessage"
+	MethodUnpinMessage   = "unpinChatMessage"
+)
+
+// Sent is an outgoing request recorded by Fake
+type Sent struct {
+	Method          string
+	ChatID          int64
+	MessageID       int // the created or edited message
+	Text            string
+	ParseMode       string
+	Keyboard        *tgbotapi.InlineKeyboardMarkup
+	File            tgbotapi.RequestFileData
+	CallbackQueryID string
+}
+
+// Button returns the callback data of the first button whose text contains label
+func (s Sent) Button(label string) (string, bool) {
+	if s.Keyboard == nil {
+		return "", false
+	}
+	for _, row := range s.Keyboard.InlineKeyboard {
+		for _, button := range row {
+			if strings.Contains(button.Text, label) && button.CallbackData != nil {
+				return *button.CallbackData, true
+			}
+		}
+	}
+	return "", false
+}
+
+// message is the current content of a message the bot sent
+type message struct {
+	text     string
+	keyboard *tgbotapi.InlineKeyboardMarkup
+	deleted  bool
+}
+
+// messageKey identifies a message; IDs are unique per chat
+type messageKey struct {
+	chatID    int64
+	messageID int
+}
+
+// Fake records everything the bot sends and feeds it injected updates.
+// Like the Bot API it rejects broken HTML, text over the length limits,
+// edits of deleted messages and edits that change nothing.
+// It is safe for concurrent use.
+type Fake struct {
+	mu            sync.Mutex
+	self          tgbotapi.User
+	sent          []Sent
+	messages      map[messageKey]*message
+	commands      map[int64][]tgbotapi.BotCommand
+	webhook       *telegram.WebhookConfig
+	nextMessageID int
+	nextUpdateID  int
+	err           error
+
+	// updatesMu is separate from mu so a full updates channel can't block
+	// the handlers recording their replies
+	updatesMu sync.Mutex
+	updates   chan tgbotapi.Update
+	stopped   bool
+}
+
+var _ telegram.Client = (*Fake)(nil)
+
+// NewFake creates a fake client for a bot named test_bot
+func NewFake() *Fake {
+	return &Fake{
+		self:     tgbotapi.User{ID: 1, IsBot: true, UserName: "test_bot"},
+		messages: make(map[messageKey]*message),
+		commands: make(map[int64][]tgbotapi.BotCommand),
+		updates:  make(chan tgbotapi.Update, 100),
+	}
+}
+
+// FailWith makes every following request fail with err; nil restores success
+func (f *Fake) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *Fake) Self() tgbotapi.User {
+	return f.self
+}
+
+// GetMe returns the bot account, or the error set with FailWith. It is not
+// recorded.
+func (f *Fake) GetMe() (tgbotapi.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return tgbotapi.User{}, f.err
+	}
+	return f.self, nil
+}
+
+func (f *Fake) SendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	return f.record(Sent{
+		Method:    MethodSendMessage,
+		ChatID:    msg.ChatID,
+		Text:      msg.Text,
+		ParseMode: msg.ParseMode,
+		Keyboard:  inlineKeyboard(msg.ReplyMarkup),
+	})
+}
+
+func (f *Fake) EditMessageText(edit tgbotapi.EditMessageTextConfig) (tgbotapi.Message, error) {
+	return f.record(Sent{
+		Method:    MethodEditMessage,
+		ChatID:    edit.ChatID,
+		MessageID: edit.MessageID,
+		Text:      edit.Text,
+		ParseMode: edit.ParseMode,
+		Keyboard:  edit.ReplyMarkup,
+	})
+}
+
+func (f *Fake) AnswerCallback(answer tgbotapi.CallbackConfig) error {
+	_, err := f.record(Sent{
+		Method:          MethodAnswerCallback,
+		Text:            answer.Text,
+		CallbackQueryID: answer.CallbackQueryID,
+	})
+	return err
+}
+
+func (f *Fake) SendPhoto(photo tgbotapi.PhotoConfig) (tgbotapi.Message, error) {
+	return f.record(Sent{
+		Method:    MethodSendPhoto,
+		ChatID:    photo.ChatID,
+		Text:      photo.Caption,
+		ParseMode: photo.ParseMode,
+		Keyboard:  inlineKeyboard(photo.ReplyMarkup),
+		File:      photo.File,
+	})
+}
+
+func (f *Fake) SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	return f.record(Sent{
+		Method:    MethodSendDocument,
+		ChatID:    doc.ChatID,
+		Text:      doc.Caption,
+		ParseMode: doc.ParseMode,
+		Keyboard:  inlineKeyboard(doc.ReplyMarkup),
+		File:      doc.File,
+	})
+}
+
+func (f *Fake) PinChatMessage(pin tgbotapi.PinChatMessageConfig) error {
+	_, err := f.record(Sent{Method: MethodPinMessage, ChatID: pin.ChatID, MessageID: pin.MessageID})
+	return err
+}
+
+func (f *Fake) UnpinChatMessage(unpin tgbotapi.UnpinChatMessageConfig) error {
+	_, err := f.record(Sent{Method: MethodUnpinMessage, ChatID: unpin.ChatID, MessageID: unpin.MessageID})
+	return err
+}
+
+func (f *Fake) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return f.updates
+}
+
+func (f *Fake) StopReceivingUpdates() {
+	f.updatesMu.Lock()
+	defer f.updatesMu.Unlock()
+	if !f.stopped {
+		f.stopped = true
+		close(f.updates)
+	}
+}
+
+func (f *Fake) SetCommands(config tgbotapi.SetMyCommandsConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.commands[scopeChatID(config.Scope)] = config.Commands
+	return nil
+}
+
+func (f *Fake) DeleteCommands(config tgbotapi.DeleteMyCommandsConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.commands, scopeChatID(config.Scope))
+	return nil
+}
+
+func (f *Fake) SetWebhook(config telegram.WebhookConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.webhook = &config
+	return nil
+}
+
+func (f *Fake) DeleteWebhook() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.webhook = nil
+	return nil
+}
+
+// DeleteMessage removes a message from the chat as if the user deleted it;
+// later edits of it fail like they do in Telegram
+func (f *Fake) DeleteMessage(chatID int64, messageID int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages[messageKey{chatID, messageID}] = &message{deleted: true}
+}
+
+// Inject delivers an update to the bot as if received from Telegram
+func (f *Fake) Inject(update tgbotapi.Update) tgbotapi.Update {
+	if update.UpdateID == 0 {
+		f.mu.Lock()
+		f.nextUpdateID++
+		update.UpdateID = f.nextUpdateID
+		f.mu.Unlock()
+	}
+
+	f.updatesMu.Lock()
+	defer f.updatesMu.Unlock()
+	if !f.stopped {
+		f.updates <- update
+	}
+	return update
+}
+
+// InjectMessage delivers a private text message. Text starting with "/"
+// is marked as a command.
+func (f *Fake) InjectMessage(from tgbotapi.User, text string) tgbotapi.Update {
+	message := &tgbotapi.Message{
+		MessageID: f.newMessageID(),
+		From:      &from,
+		Chat:      &tgbotapi.Chat{ID: from.ID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		length := len(text)
+		if i := strings.Index(text, " "); i > 0 {
+			length = i
+		}
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	}
+
+	return f.Inject(tgbotapi.Update{Message: message})
+}
+
+// InjectCallback delivers a button press on a message sent by the bot
+func (f *Fake) InjectCallback(from tgbotapi.User, on Sent, data string) tgbotapi.Update {
+	return f.Inject(tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:   "cb" + data,
+			From: &from,
+			Message: &tgbotapi.Message{
+				MessageID: on.MessageID,
+				Chat:      &tgbotapi.Chat{ID: on.ChatID, Type: "private"},
+				Text:      on.Text,
+			},
+			Data: data,
+		},
+	})
+}
+
+// Sent returns every recorded request
+func (f *Fake) Sent() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Sent(nil), f.sent...)
+}
+
+// Reset forgets recorded requests
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = nil
+}
+
+// WaitFor waits until a recorded request matches and returns it
+func (f *Fake) WaitFor(timeout time.Duration, match func(Sent) bool) (Sent, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, sent := range f.Sent() {
+			if match(sent) {
+				return sent, true
+			}
+		}
+		if time.Now().After(deadline) {
+			return Sent{}, false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Commands returns the command list of a chat scope; chat 0 is the default scope
+func (f *Fake) Commands(chatID int64) ([]tgbotapi.BotCommand, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	commands, ok := f.commands[chatID]
+	return commands, ok
+}
+
+// Webhook returns the registered webhook, or nil
+func (f *Fake) Webhook() *telegram.WebhookConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.webhook
+}
+
+func (f *Fake) record(sent Sent) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return tgbotapi.Message{}, f.err
+	}
+	if err := checkText(sent); err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	switch sent.Method {
+	case MethodAnswerCallback, MethodPinMessage, MethodUnpinMessage:
+	case MethodEditMessage:
+		// Messages the fake never sent, like the ones tests make up, accept edits
+		if current, ok := f.messages[messageKey{sent.ChatID, sent.MessageID}]; ok {
+			if current.deleted {
+				return tgbotapi.Message{}, badRequest("message to edit not found")
+			}
+			if current.text == sent.Text && reflect.DeepEqual(current.keyboard, sent.Keyboard) {
+				return tgbotapi.Message{}, badRequest("message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+			}
+		}
+		f.messages[messageKey{sent.ChatID, sent.MessageID}] = &message{text: sent.Text, keyboard: sent.Keyboard}
+	default:
+		f.nextMessageID++
+		sent.MessageID = f.nextMessageID
+		f.messages[messageKey{sent.ChatID, sent.MessageID}] = &message{text: sent.Text, keyboard: sent.Keyboard}
+	}
+	f.sent = append(f.sent, sent)
+
+	return tgbotapi.Message{
+		MessageID: sent.MessageID,
+		Chat:      &tgbotapi.Chat{ID: sent.ChatID},
+		Text:      sent.Text,
+	}, nil
+}
+
+func (f *Fake) newMessageID() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextMessageID++
+	return f.nextMessageID
+}
+
+// inlineKeyboard extracts an inline keyboard from a ReplyMarkup field
+func inlineKeyboard(markup interface{}) *tgbotapi.InlineKeyboardMarkup {
+	switch kb := markup.(type) {
+	case tgbotapi.InlineKeyboardMarkup:
+		return &kb
+	case *tgbotapi.InlineKeyboardMarkup:
+		return kb
+	default:
+		return nil
+	}
+}
+
+// htmlTags are the tags the Bot API accepts with ParseMode HTML
+var htmlTags = map[string]bool{
+	"b": true, "strong": true, "i": true, "em": true, "u": true, "ins": true,
+	"s": true, "strike": true, "del": true, "span": true, "tg-spoiler": true,
+	"a": true, "code": true, "pre": true, "blockquote": true, "tg-emoji": true,
+}
+
+var htmlEntity = regexp.MustCompile(`^&(lt|gt|amp|quot|#[0-9]+|#x[0-9a-fA-F]+);`)
+
+// checkText rejects text the Bot API would reject: broken HTML markup and
+// text over the length limits
+func checkText(sent Sent) error {
+	limit := 4096
+	switch sent.Method {
+	case MethodSendMessage, MethodEditMessage:
+	case MethodSendPhoto, MethodSendDocument:
+		limit = 1024
+	default:
+		return nil
+	}
+
+	text := sent.Text
+	if sent.ParseMode == tgbotapi.ModeHTML {
+		var err error
+		if text, err = parseHTML(text); err != nil {
+			return badRequest("can't parse entities: " + err.Error())
+		}
+	}
+
+	if len(utf16.Encode([]rune(text))) > limit {
+		return badRequest("message is too long")
+	}
+	if limit == 4096 && strings.TrimSpace(text) == "" {
+		return badRequest("message text is empty")
+	}
+	return nil
+}
+
+// parseHTML checks the markup of an HTML message and returns its text
+func parseHTML(s string) (string, error) {
+	var text strings.Builder
+	var open []string
+	for s != "" {
+		switch s[0] {
+		case '<':
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				return "", fmt.Errorf("unclosed start tag")
+			}
+			name := strings.Fields(s[1:end] + " ")[0]
+			s = s[end+1:]
+			if closing := strings.TrimPrefix(name, "/"); closing != name {
+				if len(open) == 0 || open[len(open)-1] != closing {
+					return "", fmt.Errorf("unmatched end tag %q", closing)
+				}
+				open = open[:len(open)-1]
+				continue
+			}
+			if !htmlTags[name] {
+				return "", fmt.Errorf("unsupported start tag %q", name)
+			}
+			open = append(open, name)
+		case '&':
+			entity := htmlEntity.FindString(s)
+			if entity == "" {
+				return "", fmt.Errorf("unescaped &")
+			}
+			text.WriteString(html.UnescapeString(entity))
+			s = s[len(entity):]
+		case '>':
+			return "", fmt.Errorf("unescaped >")
+		default:
+			text.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("can't find end tag corresponding to start tag %q", open[len(open)-1])
+	}
+	return text.String(), nil
+}
+
+func badRequest(description string) error {
+	return &tgbotapi.Error{Code: 400, Message: "Bad Request: " + description}
+}
+
+// scopeChatID returns the chat of a command scope, 0 for other scopes
+func scopeChatID(scope *tgbotapi.BotCommandScope) int64 {
+	if scope == nil {
+		return 0
+	}
+	return scope.ChatID
+}